@@ -28,6 +28,7 @@ const (
 	_    // used to be Control
 	PING // node send ip/pki to register with leader
 	ShardState
+	DoubleSignEvidence
 )
 
 // BlockchainSyncMessage is a struct for blockchain sync message.
@@ -173,6 +174,15 @@ func ConstructEpochShardStateMessage(epochShardState shard.EpochShardState) []by
 	return byteBuffer.Bytes()
 }
 
+// ConstructDoubleSignEvidenceMessage constructs double sign evidence message
+// from the rlp encoded evidence list
+func ConstructDoubleSignEvidenceMessage(evidences []byte) []byte {
+	byteBuffer := bytes.NewBuffer([]byte{byte(proto.Node)})
+	byteBuffer.WriteByte(byte(DoubleSignEvidence))
+	byteBuffer.Write(evidences)
+	return byteBuffer.Bytes()
+}
+
 // DeserializeEpochShardStateFromMessage deserializes the shard state Message from bytes payload
 func DeserializeEpochShardStateFromMessage(payload []byte) (*shard.EpochShardState, error) {
 	epochShardState := new(shard.EpochShardState)
@@ -0,0 +1,130 @@
+package explorer
+
+import (
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/internal/ctxerror"
+	"github.com/harmony-one/harmony/internal/utils"
+	"github.com/harmony-one/harmony/shard"
+)
+
+// reindexBatchSize is the number of blocks whose transactions the workers of
+// a reindex extract before they are stored.
+const reindexBatchSize = 256
+
+// Chain is the chain a reindex reads, such as core.BlockChain.
+type Chain interface {
+	CurrentBlock() *types.Block
+	GetBlockByNumber(number uint64) *types.Block
+	ReadShardState(epoch *big.Int) (shard.State, error)
+}
+
+// reindexedBlock is a block with its explorer transactions, or nil if it is
+// already in the storage.
+type reindexedBlock struct {
+	block *types.Block
+	txs   []*Transaction
+}
+
+// Reindex stores the blocks of the given chain from the given height up to
+// its head, with the committee of every epoch, and returns the number of
+// blocks it stored.  Blocks already in the storage are skipped.  The given
+// number of workers extract the transactions of each batch of blocks
+// concurrently, then the blocks are stored in order, each with the
+// checkpoint ReadCheckpoint returns, so that an interrupted reindex can
+// resume after the last block stored.
+func (storage *Storage) Reindex(chain Chain, from uint64, workers int) (int, error) {
+	if workers < 1 {
+		workers = 1
+	}
+	head := chain.CurrentBlock().NumberU64()
+	utils.Logger().Info().
+		Uint64("from", from).
+		Uint64("head", head).
+		Int("workers", workers).
+		Msg("[Explorer] Reindexing blocks")
+
+	stored := 0
+	var epoch *big.Int
+	start := time.Now()
+	for first := from; first <= head; first += reindexBatchSize {
+		last := first + reindexBatchSize - 1
+		if last > head {
+			last = head
+		}
+		blocks := make([]*types.Block, last-first+1)
+		entries := make([]*reindexedBlock, len(blocks))
+		heights := make(chan uint64)
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for height := range heights {
+					block := chain.GetBlockByNumber(height)
+					blocks[height-first] = block
+					if block != nil && !storage.HasBlock(block.Hash().Hex()) {
+						entries[height-first] = &reindexedBlock{block, GetTransactions(block)}
+					}
+				}
+			}()
+		}
+		for height := first; height <= last; height++ {
+			heights <- height
+		}
+		close(heights)
+		wg.Wait()
+
+		for i, block := range blocks {
+			height := first + uint64(i)
+			if block == nil {
+				return stored, ctxerror.New("cannot find block", "height", height)
+			}
+			if epoch == nil || block.Epoch().Cmp(epoch) != 0 {
+				if err := storage.reindexCommittee(chain, block); err != nil {
+					return stored, err
+				}
+				epoch = block.Epoch()
+			}
+			if entry := entries[i]; entry != nil {
+				if err := storage.writeBlock(entry.block, height, entry.txs, true); err != nil {
+					return stored, ctxerror.New("cannot store block", "height", height).WithCause(err)
+				}
+				stored++
+			}
+		}
+		utils.Logger().Info().
+			Uint64("height", last).
+			Int("stored", stored).
+			Str("elapsed", time.Since(start).String()).
+			Msg("[Explorer] Reindexed blocks")
+	}
+	return stored, nil
+}
+
+// reindexCommittee stores the committee of the shard of the given block for
+// its epoch.
+func (storage *Storage) reindexCommittee(chain Chain, block *types.Block) error {
+	state, err := chain.ReadShardState(block.Epoch())
+	if err != nil {
+		return ctxerror.New("cannot read shard state",
+			"epoch", block.Epoch(),
+		).WithCause(err)
+	}
+	committee := state.FindCommitteeByID(block.ShardID())
+	if committee == nil {
+		return ctxerror.New("cannot find shard in the shard state",
+			"epoch", block.Epoch(),
+			"shardID", block.ShardID(),
+		)
+	}
+	if err := storage.DumpCommittee(block.ShardID(), block.Epoch().Uint64(), *committee); err != nil {
+		return ctxerror.New("cannot store committee",
+			"epoch", block.Epoch(),
+		).WithCause(err)
+	}
+	return nil
+}
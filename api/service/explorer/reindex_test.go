@@ -0,0 +1,82 @@
+package explorer
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/assert"
+
+	blockfactory "github.com/harmony-one/harmony/block/factory"
+	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/shard"
+)
+
+type testChain struct {
+	blocks []*types.Block
+	state  shard.State
+}
+
+func (c *testChain) CurrentBlock() *types.Block {
+	return c.blocks[len(c.blocks)-1]
+}
+
+func (c *testChain) GetBlockByNumber(number uint64) *types.Block {
+	if number >= uint64(len(c.blocks)) {
+		return nil
+	}
+	return c.blocks[number]
+}
+
+func (c *testChain) ReadShardState(epoch *big.Int) (shard.State, error) {
+	return c.state, nil
+}
+
+func TestReindex(t *testing.T) {
+	to := common.BytesToAddress([]byte{0x11})
+	chain := &testChain{
+		state: shard.State{{
+			ShardID:  0,
+			NodeList: []shard.NodeID{{EcdsaAddress: to}},
+		}},
+	}
+	for i := 0; i < 5; i++ {
+		tx := types.NewTransaction(uint64(i), to, 0, big.NewInt(int64(i)), 1111, big.NewInt(11111), nil)
+		header := blockfactory.NewTestHeader().With().
+			Number(big.NewInt(int64(i))).
+			Epoch(big.NewInt(int64(i / 3))).
+			Header()
+		chain.blocks = append(chain.blocks, types.NewBlock(header, []*types.Transaction{tx}, nil, nil, nil))
+	}
+	ins := &Storage{}
+	ins.Init("1.1.1.1", "3335", true)
+	defer ins.GetDB().Close()
+	ins.Dump(chain.blocks[1], 1)
+
+	stored, err := ins.Reindex(chain, 0, 3)
+	assert.Nil(t, err, "should be nil")
+	assert.Equal(t, 4, stored, "should skip the block already dumped")
+	checkpoint, ok := ins.ReadCheckpoint()
+	assert.True(t, ok, "should have a checkpoint")
+	assert.Equal(t, uint64(4), checkpoint, "should be the head")
+	for i, block := range chain.blocks {
+		assert.True(t, ins.HasBlock(block.Hash().Hex()), "should have block %d", i)
+		data, err := ins.GetDB().Get([]byte(GetTXKey(block.Transactions()[0].Hash().Hex())))
+		assert.Nil(t, err, "should have the tx of block %d", i)
+		tx := new(Transaction)
+		assert.Nil(t, rlp.DecodeBytes(data, tx), "should be nil")
+	}
+	height, err := ins.GetDB().Get([]byte(BlockHeightKey))
+	assert.Nil(t, err, "should be nil")
+	assert.Equal(t, "4", string(height), "should be the head")
+	for _, epoch := range []uint64{0, 1} {
+		has, err := ins.GetDB().Has([]byte(GetCommitteeKey(0, epoch)))
+		assert.Nil(t, err, "should be nil")
+		assert.True(t, has, "should have the committee of epoch %d", epoch)
+	}
+
+	stored, err = ins.Reindex(chain, checkpoint+1, 3)
+	assert.Nil(t, err, "should be nil")
+	assert.Equal(t, 0, stored, "should have nothing to resume")
+}
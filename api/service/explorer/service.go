@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"math/big"
 	"net"
 	"net/http"
@@ -33,8 +34,11 @@ import (
 const (
 	explorerPortDifference = 4000
 	paginationOffset       = 10
+	maxPaginationLimit     = 1000
 	txViewNone             = "NONE"
 	txViewAll              = "ALL"
+	txTypeCX               = "CX"
+	txTypeContract         = "CONTRACT"
 )
 
 // HTTPError is an HTTP error.
@@ -50,18 +54,25 @@ type Service struct {
 	Port              string
 	GetNodeIDs        func() []libp2p_peer.ID
 	ShardID           uint32
+	GenesisHash       common.Hash
 	storage           *Storage
 	server            *http.Server
 	messageChan       chan *msg_pb.Message
 	GetAccountBalance func(common.Address) (*big.Int, error)
+
+	// Feeds of the events pushed to the subscribers of PublicExplorerAPI.
+	blockFeed     subscriptionFeed
+	txFeed        subscriptionFeed
+	committeeFeed subscriptionFeed
 }
 
 // New returns explorer service.
-func New(selfPeer *p2p.Peer, shardID uint32, GetNodeIDs func() []libp2p_peer.ID, GetAccountBalance func(common.Address) (*big.Int, error)) *Service {
+func New(selfPeer *p2p.Peer, shardID uint32, genesisHash common.Hash, GetNodeIDs func() []libp2p_peer.ID, GetAccountBalance func(common.Address) (*big.Int, error)) *Service {
 	return &Service{
 		IP:                selfPeer.IP,
 		Port:              selfPeer.Port,
 		ShardID:           shardID,
+		GenesisHash:       genesisHash,
 		GetNodeIDs:        GetNodeIDs,
 		GetAccountBalance: GetAccountBalance,
 	}
@@ -70,7 +81,10 @@ func New(selfPeer *p2p.Peer, shardID uint32, GetNodeIDs func() []libp2p_peer.ID,
 // StartService starts explorer service.
 func (s *Service) StartService() {
 	utils.Logger().Info().Msg("Starting explorer service.")
-	s.Init(true)
+	s.Init(false)
+	if err := s.storage.ResetForGenesis(s.IP, s.Port, s.GenesisHash.Hex()); err != nil {
+		utils.Logger().Error().Err(err).Msg("Failed to reset explorer storage")
+	}
 	s.server = s.Run()
 }
 
@@ -105,16 +119,26 @@ func (s *Service) Run() *http.Server {
 
 	s.router = mux.NewRouter()
 	// Set up router for blocks.
-	// Blocks are divided into pages in consequent groups of offset size.
+	// Blocks are divided into pages in consequent groups of offset size, or
+	// into pages of limit size by cursor.
 	s.router.Path("/blocks").Queries("from", "{[0-9]*?}", "to", "{[0-9]*?}", "page", "{[0-9]*?}", "offset", "{[0-9]*?}").HandlerFunc(s.GetExplorerBlocks).Methods("GET")
 	s.router.Path("/blocks").HandlerFunc(s.GetExplorerBlocks)
 
+	// Set up router for block by hash.
+	s.router.Path("/block").Queries("id", "{[0-9A-Fa-fx]*?}").HandlerFunc(s.GetExplorerBlock).Methods("GET")
+	s.router.Path("/block").HandlerFunc(s.GetExplorerBlock)
+
 	// Set up router for tx.
 	s.router.Path("/tx").Queries("id", "{[0-9A-Fa-fx]*?}").HandlerFunc(s.GetExplorerTransaction).Methods("GET")
 	s.router.Path("/tx").HandlerFunc(s.GetExplorerTransaction)
 
+	// Set up router for txs.
+	// Transactions are divided into pages of limit size by cursor.
+	s.router.Path("/txs").HandlerFunc(s.GetExplorerTransactions).Methods("GET")
+
 	// Set up router for address.
-	// Address transactions are divided into pages in consequent groups of offset size.
+	// Address transactions are divided into pages in consequent groups of offset size,
+	// or into pages of limit size by cursor.
 	s.router.Path("/address").Queries("id", fmt.Sprintf("{([0-9A-Fa-fx]*?)|(t?one1[%s]{38})}", bech32.Charset), "tx_view", "{[A-Z]*?}", "page", "{[0-9]*?}", "offset", "{[0-9]*?}").HandlerFunc(s.GetExplorerAddress).Methods("GET")
 	s.router.Path("/address").HandlerFunc(s.GetExplorerAddress)
 
@@ -165,9 +189,38 @@ func (s *Service) ReadBlocksFromDB(from, to int) []*types.Block {
 	return blocks
 }
 
+// isCursorPaginated tells whether the request asks for a page by cursor,
+// rather than by page number.
+func isCursorPaginated(r *http.Request) bool {
+	return r.FormValue("cursor") != "" || r.FormValue("limit") != ""
+}
+
+// readLimit returns the page size of a request paginated by cursor.
+func readLimit(r *http.Request) (int, error) {
+	limitParam := r.FormValue("limit")
+	if limitParam == "" {
+		return paginationOffset, nil
+	}
+	limit, err := strconv.Atoi(limitParam)
+	if err != nil {
+		return 0, err
+	}
+	if limit < 1 || limit > maxPaginationLimit {
+		return 0, ctxerror.New("limit out of range",
+			"limit", limit,
+			"max", maxPaginationLimit,
+		)
+	}
+	return limit, nil
+}
+
 // GetExplorerBlocks serves end-point /blocks
 func (s *Service) GetExplorerBlocks(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
+	if isCursorPaginated(r) {
+		s.getExplorerBlockPage(w, r)
+		return
+	}
 	from := r.FormValue("from")
 	to := r.FormValue("to")
 	pageParam := r.FormValue("page")
@@ -233,14 +286,109 @@ func (s *Service) GetExplorerBlocks(w http.ResponseWriter, r *http.Request) {
 		page = 0
 	}
 
-	accountBlocks := s.ReadBlocksFromDB(fromInt, toInt)
+	data.Blocks = s.readExplorerBlocks(fromInt, toInt)
+
+	paginatedBlocks := make([]*Block, 0)
+	for i := 0; i < offset && i+offset*page < len(data.Blocks); i++ {
+		paginatedBlocks = append(paginatedBlocks, data.Blocks[i+offset*page])
+	}
+	data.Blocks = paginatedBlocks
+}
+
+// getExplorerBlockPage serves a page of /blocks by cursor, which is the
+// height of the first block of the page, the from parameter by default.
+func (s *Service) getExplorerBlockPage(w http.ResponseWriter, r *http.Request) {
+	page := &BlockPage{Blocks: []*Block{}}
+	defer func() {
+		if err := json.NewEncoder(w).Encode(page); err != nil {
+			utils.Logger().Warn().Err(err).Msg("cannot JSON-encode blocks")
+		}
+	}()
+
+	limit, err := readLimit(r)
+	if err != nil {
+		utils.Logger().Warn().Err(err).Msg("invalid limit parameter")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	cursor := r.FormValue("cursor")
+	if cursor == "" {
+		cursor = r.FormValue("from")
+	}
+	first := 0
+	if cursor != "" {
+		if first, err = strconv.Atoi(cursor); err != nil || first < 0 {
+			utils.Logger().Warn().Str("cursor", cursor).Msg("invalid cursor parameter")
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+	bytes, err := s.storage.GetDB().Get([]byte(BlockHeightKey))
+	if err != nil {
+		// No block yet.
+		return
+	}
+	height, err := strconv.Atoi(string(bytes))
+	if err != nil {
+		utils.Logger().Warn().Err(err).Msg("cannot decode block height from DB")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if first > height {
+		return
+	}
+	last := first + limit - 1
+	if last < height {
+		page.Next = strconv.Itoa(last + 1)
+	} else {
+		last = height
+	}
+	page.Blocks = s.readExplorerBlocks(first, last)
+}
+
+// GetExplorerBlock serves /block end-point, the block of the given hash.
+func (s *Service) GetExplorerBlock(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id := r.FormValue("id")
+	var block *Block
+	defer func() {
+		if err := json.NewEncoder(w).Encode(block); err != nil {
+			utils.Logger().Warn().Err(err).Msg("cannot JSON-encode block")
+		}
+	}()
+	if id == "" {
+		utils.Logger().Warn().Msg("invalid id parameter")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	height, err := s.storage.ReadBlockHeightByHash(id)
+	if err != nil {
+		utils.Logger().Warn().Err(err).Str("id", id).Msg("cannot read block height")
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	blocks := s.readExplorerBlocks(height, height)
+	if len(blocks) == 0 {
+		utils.Logger().Warn().Str("id", id).Int("height", height).Msg("cannot read block")
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	block = blocks[0]
+}
+
+// readExplorerBlocks returns the explorer blocks of the given range of
+// heights, with their signers and neighbours.
+func (s *Service) readExplorerBlocks(from, to int) []*Block {
+	db := s.storage.GetDB()
+	blocks := []*Block{}
+	accountBlocks := s.ReadBlocksFromDB(from, to)
 	curEpoch := int64(-1)
 	committee := &shard.Committee{}
 	for id, accountBlock := range accountBlocks {
 		if id == 0 || id == len(accountBlocks)-1 || accountBlock == nil {
 			continue
 		}
-		block := NewBlock(accountBlock, id+fromInt-1)
+		block := NewBlock(accountBlock, id+from-1)
 		if int64(block.Epoch) > curEpoch {
 			if bytes, err := db.Get([]byte(GetCommitteeKey(uint32(s.ShardID), block.Epoch))); err == nil {
 				committee = &shard.Committee{}
@@ -284,10 +432,7 @@ func (s *Service) GetExplorerBlocks(w http.ResponseWriter, r *http.Request) {
 		}
 		// Populate transactions
 		for _, tx := range accountBlock.Transactions() {
-			transaction := GetTransaction(tx, accountBlock)
-			if transaction != nil {
-				block.TXs = append(block.TXs, transaction)
-			}
+			block.TXs = append(block.TXs, GetTransaction(tx, accountBlock))
 		}
 		if accountBlocks[id-1] == nil {
 			block.BlockTime = int64(0)
@@ -299,7 +444,7 @@ func (s *Service) GetExplorerBlocks(w http.ResponseWriter, r *http.Request) {
 			block.BlockTime = accountBlock.Time().Int64() - accountBlocks[id-1].Time().Int64()
 			block.PrevBlock = RefBlock{
 				ID:     accountBlocks[id-1].Hash().Hex(),
-				Height: strconv.Itoa(id + fromInt - 2),
+				Height: strconv.Itoa(id + from - 2),
 			}
 		}
 		if accountBlocks[id+1] == nil {
@@ -310,17 +455,12 @@ func (s *Service) GetExplorerBlocks(w http.ResponseWriter, r *http.Request) {
 		} else {
 			block.NextBlock = RefBlock{
 				ID:     accountBlocks[id+1].Hash().Hex(),
-				Height: strconv.Itoa(id + fromInt),
+				Height: strconv.Itoa(id + from),
 			}
 		}
-		data.Blocks = append(data.Blocks, block)
-	}
-
-	paginatedBlocks := make([]*Block, 0)
-	for i := 0; i < offset && i+offset*page < len(data.Blocks); i++ {
-		paginatedBlocks = append(paginatedBlocks, data.Blocks[i+offset*page])
+		blocks = append(blocks, block)
 	}
-	data.Blocks = paginatedBlocks
+	return blocks
 }
 
 // GetExplorerTransaction servers /tx end-point.
@@ -355,6 +495,69 @@ func (s *Service) GetExplorerTransaction(w http.ResponseWriter, r *http.Request)
 	data.TX = *tx
 }
 
+// GetExplorerTransactions serves /txs end-point, the transactions of the
+// blocks whose time in milliseconds is in [from_time, to_time), in pages by
+// cursor.  The type parameter selects all of them (ALL, the default), the
+// cross-shard ones to the shard to_shard (CX) or the contract creations
+// (CONTRACT).
+func (s *Service) GetExplorerTransactions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	page := &TXPage{TXs: []*Transaction{}}
+	defer func() {
+		if err := json.NewEncoder(w).Encode(page); err != nil {
+			utils.Logger().Warn().Err(err).Msg("cannot JSON-encode TXs")
+		}
+	}()
+
+	limit, err := readLimit(r)
+	if err != nil {
+		utils.Logger().Warn().Err(err).Msg("invalid limit parameter")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	fromTime, toTime := int64(0), int64(math.MaxInt64)
+	if fromParam := r.FormValue("from_time"); fromParam != "" {
+		if fromTime, err = strconv.ParseInt(fromParam, 10, 64); err != nil || fromTime < 0 {
+			utils.Logger().Warn().Str("from_time", fromParam).Msg("invalid from_time parameter")
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+	if toParam := r.FormValue("to_time"); toParam != "" {
+		if toTime, err = strconv.ParseInt(toParam, 10, 64); err != nil || toTime < 0 {
+			utils.Logger().Warn().Str("to_time", toParam).Msg("invalid to_time parameter")
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+	cursor := r.FormValue("cursor")
+	var result *TXPage
+	switch txType := r.FormValue("type"); txType {
+	case "", txViewAll:
+		result, err = s.storage.ReadTXs(fromTime, toTime, cursor, limit)
+	case txTypeCX:
+		var toShardID uint64
+		if toShardID, err = strconv.ParseUint(r.FormValue("to_shard"), 10, 32); err != nil {
+			utils.Logger().Warn().Err(err).Msg("invalid to_shard parameter")
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		result, err = s.storage.ReadCXTXs(uint32(toShardID), fromTime, toTime, cursor, limit)
+	case txTypeContract:
+		result, err = s.storage.ReadContractCreations(fromTime, toTime, cursor, limit)
+	default:
+		utils.Logger().Warn().Str("type", txType).Msg("invalid type parameter")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		utils.Logger().Warn().Err(err).Msg("cannot read TXs")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	page = result
+}
+
 // GetExplorerCommittee servers /comittee end-point.
 func (s *Service) GetExplorerCommittee(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -443,14 +646,23 @@ func (s *Service) GetExplorerAddress(w http.ResponseWriter, r *http.Request) {
 	txViewParam := r.FormValue("tx_view")
 	pageParam := r.FormValue("page")
 	offsetParam := r.FormValue("offset")
+	paginated := isCursorPaginated(r)
 	txView := txViewNone
+	if paginated {
+		txView = txViewAll
+	}
 	if txViewParam != "" {
 		txView = txViewParam
 	}
 	utils.Logger().Info().Str("Address", id).Msg("Querying address")
 	data := &Data{}
+	next := ""
 	defer func() {
-		if err := json.NewEncoder(w).Encode(data.Address); err != nil {
+		var result interface{} = data.Address
+		if paginated {
+			result = &AddressPage{Address: data.Address, Next: next}
+		}
+		if err := json.NewEncoder(w).Encode(result); err != nil {
 			ctxerror.Warn(utils.WithCallerSkip(utils.GetLogInstance(), 1), err,
 				"cannot JSON-encode Address")
 		}
@@ -475,7 +687,7 @@ func (s *Service) GetExplorerAddress(w http.ResponseWriter, r *http.Request) {
 	var page int
 	if pageParam != "" {
 		page, err = strconv.Atoi(pageParam)
-		if err != nil {
+		if err != nil || page < 0 {
 			utils.Logger().Warn().Err(err).Msg("invalid page parameter")
 			w.WriteHeader(http.StatusBadRequest)
 			return
@@ -483,6 +695,12 @@ func (s *Service) GetExplorerAddress(w http.ResponseWriter, r *http.Request) {
 	} else {
 		page = 0
 	}
+	limit, err := readLimit(r)
+	if err != nil {
+		utils.Logger().Warn().Err(err).Msg("invalid limit parameter")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
 
 	data.Address.ID = id
 	// Try to populate the banace by directly calling get balance.
@@ -511,31 +729,35 @@ func (s *Service) GetExplorerAddress(w http.ResponseWriter, r *http.Request) {
 	if balanceAddr.Cmp(big.NewInt(0)) != 0 {
 		data.Address.Balance = balanceAddr
 	}
-	switch txView {
-	case txViewNone:
-		data.Address.TXs = nil
-	case Received:
-		receivedTXs := make([]*Transaction, 0)
-		for _, tx := range data.Address.TXs {
-			if tx.Type == Received {
-				receivedTXs = append(receivedTXs, tx)
-			}
-		}
-		data.Address.TXs = receivedTXs
-	case Sent:
-		sentTXs := make([]*Transaction, 0)
-		for _, tx := range data.Address.TXs {
-			if tx.Type == Sent {
-				sentTXs = append(sentTXs, tx)
-			}
+	if txView == txViewNone {
+		data.Address.TXs = []*Transaction{}
+		return
+	}
+	txType := ""
+	if txView == Received || txView == Sent {
+		txType = txView
+	}
+	if paginated {
+		txs, err := s.storage.ReadAddressTXs(id, txType, r.FormValue("cursor"), limit)
+		if err != nil {
+			utils.Logger().Warn().Err(err).Str("id", id).Msg("cannot read address TXs")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
 		}
-		data.Address.TXs = sentTXs
+		data.Address.TXs, next = txs.TXs, txs.Next
+		return
 	}
-	paginatedTXs := make([]*Transaction, 0)
-	for i := 0; i < offset && i+offset*page < len(data.Address.TXs); i++ {
-		paginatedTXs = append(paginatedTXs, data.Address.TXs[i+offset*page])
+	// Pages by number are read from the first transaction of the address.
+	txs, err := s.storage.ReadAddressTXs(id, txType, "", offset*(page+1))
+	if err != nil {
+		utils.Logger().Warn().Err(err).Str("id", id).Msg("cannot read address TXs")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	data.Address.TXs = []*Transaction{}
+	if offset*page < len(txs.TXs) {
+		data.Address.TXs = txs.TXs[offset*page:]
 	}
-	data.Address.TXs = paginatedTXs
 }
 
 // GetExplorerNodeCount serves /nodes end-point.
@@ -562,9 +784,19 @@ func (s *Service) GetExplorerShard(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// NotifyService notify service
+// NotifyService pushes the committed block or the committee change in the
+// given parameters, keyed NotifyBlock or NotifyCommittee, to the subscribers.
 func (s *Service) NotifyService(params map[string]interface{}) {
-	return
+	if block, ok := params[NotifyBlock].(*types.Block); ok && block != nil {
+		event := newBlockEvent(block)
+		s.blockFeed.send(event)
+		for _, tx := range event.txs {
+			s.txFeed.send(tx)
+		}
+	}
+	if change, ok := params[NotifyCommittee].(*CommitteeChange); ok && change != nil {
+		s.committeeFeed.send(change)
+	}
 }
 
 // SetMessageChan sets up message channel to service.
@@ -574,5 +806,12 @@ func (s *Service) SetMessageChan(messageChan chan *msg_pb.Message) {
 
 // APIs for the services.
 func (s *Service) APIs() []rpc.API {
-	return nil
+	return []rpc.API{
+		{
+			Namespace: "explorer",
+			Version:   "1.0",
+			Service:   NewPublicExplorerAPI(s),
+			Public:    true,
+		},
+	}
 }
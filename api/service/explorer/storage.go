@@ -8,6 +8,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/syndtr/goleveldb/leveldb/util"
 
 	"github.com/harmony-one/harmony/core/types"
 	"github.com/harmony-one/harmony/internal/ctxerror"
@@ -18,13 +19,25 @@ import (
 // Constants for storage.
 const (
 	BlockHeightKey  = "bh"
+	GenesisKey      = "gh"
+	CheckpointKey   = "rc"
 	BlockInfoPrefix = "bi"
 	BlockPrefix     = "b"
+	BlockHashPrefix = "bx"
 	TXPrefix        = "tx"
+	TXTimePrefix    = "tt"
+	CXTXPrefix      = "tc"
+	ContractPrefix  = "tk"
 	AddressPrefix   = "ad"
+	AddressTXPrefix = "at"
 	CommitteePrefix = "cp"
 )
 
+// The index keys below pad their numbers with zeros, so that the key order of
+// LevelDB is the numeric order, and end with a unique suffix, so that entries
+// of equal numbers do not overwrite each other.  The part of an index key
+// after the prefix is the cursor of the entry in paginated reads.
+
 // GetBlockInfoKey ...
 func GetBlockInfoKey(id int) string {
 	return fmt.Sprintf("%s_%d", BlockInfoPrefix, id)
@@ -45,6 +58,37 @@ func GetTXKey(hash string) string {
 	return fmt.Sprintf("%s_%s", TXPrefix, hash)
 }
 
+// GetBlockHashKey returns the key of the height of the block of the given
+// hash.
+func GetBlockHashKey(hash string) string {
+	return fmt.Sprintf("%s_%s", BlockHashPrefix, hash)
+}
+
+// GetTXTimeKey returns the key of a transaction in the index of all the
+// transactions by time, in milliseconds.
+func GetTXTimeKey(timestamp int64, hash string) string {
+	return fmt.Sprintf("%s_%020d_%s", TXTimePrefix, timestamp, hash)
+}
+
+// GetCXTXKey returns the key of a transaction in the index of the cross-shard
+// transactions by destination shard and time.
+func GetCXTXKey(toShardID uint32, timestamp int64, hash string) string {
+	return fmt.Sprintf("%s_%010d_%020d_%s", CXTXPrefix, toShardID, timestamp, hash)
+}
+
+// GetContractKey returns the key of a transaction in the index of the
+// contract creations by time.
+func GetContractKey(timestamp int64, hash string) string {
+	return fmt.Sprintf("%s_%020d_%s", ContractPrefix, timestamp, hash)
+}
+
+// GetAddressTXKey returns the key of a transaction in the index of the
+// transactions of an address, which are in chain order.  A transaction from
+// an address to itself has an entry of each type.
+func GetAddressTXKey(address string, height uint64, index int, txType string) string {
+	return fmt.Sprintf("%s_%s_%020d_%06d_%s", AddressTXPrefix, address, height, index, txType)
+}
+
 // GetCommitteeKey ...
 func GetCommitteeKey(shardID uint32, epoch uint64) string {
 	return fmt.Sprintf("%s_%d_%d", CommitteePrefix, shardID, epoch)
@@ -82,22 +126,55 @@ func (storage *Storage) Init(ip, port string, remove bool) {
 	}
 }
 
+// ResetForGenesis clears the storage, so that it indexes the chain of the
+// given genesis block, unless it already does.  A storage is kept across
+// restarts, but not across network resets.
+func (storage *Storage) ResetForGenesis(ip, port string, genesisHash string) error {
+	data, err := storage.db.Get([]byte(GenesisKey))
+	if err == nil && string(data) == genesisHash {
+		return nil
+	}
+	utils.Logger().Info().
+		Str("genesis", genesisHash).
+		Str("previousGenesis", string(data)).
+		Msg("[Explorer] Clearing storage of another chain")
+	storage.db.Close()
+	storage.Init(ip, port, true)
+	return storage.db.Put([]byte(GenesisKey), []byte(genesisHash))
+}
+
 // GetDB returns the LDBDatabase of the storage.
 func (storage *Storage) GetDB() *ethdb.LDBDatabase {
 	return storage.db
 }
 
 // Dump extracts information from block and index them into lvdb for explorer.
+// A block already in the storage is skipped.
 func (storage *Storage) Dump(block *types.Block, height uint64) {
 	utils.Logger().Info().Uint64("block height", height).Msg("Dumping block")
-	if block == nil {
+	if block == nil || storage.HasBlock(block.Hash().Hex()) {
 		return
 	}
+	if err := storage.writeBlock(block, height, GetTransactions(block), false); err != nil {
+		ctxerror.Warn(utils.GetLogger(), err, "cannot write batch")
+	}
+}
 
+// HasBlock returns whether the block of the given hash is in the storage.
+func (storage *Storage) HasBlock(hash string) bool {
+	has, err := storage.db.Has([]byte(GetBlockHashKey(hash)))
+	return err == nil && has
+}
+
+// writeBlock stores the given block with its explorer transactions in one
+// batch, with the reindex checkpoint at its height if checkpoint is set.
+func (storage *Storage) writeBlock(block *types.Block, height uint64, txs []*Transaction, checkpoint bool) error {
 	batch := storage.db.NewBatch()
-	// Update block height.
-	if err := batch.Put([]byte(BlockHeightKey), []byte(strconv.Itoa(int(height)))); err != nil {
-		utils.Logger().Warn().Err(err).Msg("cannot batch block height")
+	// Update block height, which older blocks stored later do not lower.
+	if data, err := storage.db.Get([]byte(BlockHeightKey)); err != nil || readHeight(data) < height {
+		if err := batch.Put([]byte(BlockHeightKey), []byte(strconv.Itoa(int(height)))); err != nil {
+			utils.Logger().Warn().Err(err).Msg("cannot batch block height")
+		}
 	}
 
 	// Store block.
@@ -109,20 +186,39 @@ func (storage *Storage) Dump(block *types.Block, height uint64) {
 	} else {
 		utils.Logger().Error().Err(err).Msg("Failed to serialize block")
 	}
+	if err := batch.Put([]byte(GetBlockHashKey(block.Hash().Hex())), []byte(strconv.FormatUint(height, 10))); err != nil {
+		utils.Logger().Warn().Err(err).Msg("cannot batch block hash")
+	}
 
 	// Store txs
-	for _, tx := range block.Transactions() {
-		if tx.To() == nil {
-			continue
-		}
-
-		explorerTransaction := GetTransaction(tx, block)
+	for index, tx := range block.Transactions() {
+		explorerTransaction := txs[index]
 		storage.UpdateTXStorage(batch, explorerTransaction, tx)
-		storage.UpdateAddress(batch, explorerTransaction, tx)
+		storage.UpdateTXIndexes(batch, explorerTransaction, tx, block.Time().Int64()*1000)
+		storage.UpdateAddress(batch, explorerTransaction, tx, height, index)
 	}
-	if err := batch.Write(); err != nil {
-		ctxerror.Warn(utils.GetLogger(), err, "cannot write batch")
+	if checkpoint {
+		if err := batch.Put([]byte(CheckpointKey), []byte(strconv.FormatUint(height, 10))); err != nil {
+			return ctxerror.New("cannot batch reindex checkpoint").WithCause(err)
+		}
 	}
+	return batch.Write()
+}
+
+// readHeight decodes a stored block height, 0 if invalid.
+func readHeight(data []byte) uint64 {
+	height, _ := strconv.ParseUint(string(data), 10, 64)
+	return height
+}
+
+// ReadCheckpoint returns the height of the last block a reindex stored, and
+// whether there is one.
+func (storage *Storage) ReadCheckpoint() (uint64, bool) {
+	data, err := storage.db.Get([]byte(CheckpointKey))
+	if err != nil {
+		return 0, false
+	}
+	return readHeight(data), true
 }
 
 // DumpCommittee commits validators for shardNum and epoch.
@@ -154,16 +250,53 @@ func (storage *Storage) UpdateTXStorage(batch ethdb.Batch, explorerTransaction *
 	}
 }
 
-// UpdateAddress ...
-func (storage *Storage) UpdateAddress(batch ethdb.Batch, explorerTransaction *Transaction, tx *types.Transaction) {
+// UpdateTXIndexes adds the given transaction, of a block of the given time in
+// milliseconds, to the index of the transactions by time, and to those of the
+// cross-shard transactions and of the contract creations if it is one.  The
+// entries of these indexes are the hashes of the transactions.
+func (storage *Storage) UpdateTXIndexes(batch ethdb.Batch, explorerTransaction *Transaction, tx *types.Transaction, timestamp int64) {
+	hash := []byte(explorerTransaction.ID)
+	if err := batch.Put([]byte(GetTXTimeKey(timestamp, explorerTransaction.ID)), hash); err != nil {
+		utils.Logger().Warn().Err(err).Msg("cannot batch TX time index")
+	}
+	if tx.ShardID() != tx.ToShardID() {
+		if err := batch.Put([]byte(GetCXTXKey(tx.ToShardID(), timestamp, explorerTransaction.ID)), hash); err != nil {
+			utils.Logger().Warn().Err(err).Msg("cannot batch cross-shard TX index")
+		}
+	}
+	if explorerTransaction.Contract != "" {
+		if err := batch.Put([]byte(GetContractKey(timestamp, explorerTransaction.ID)), hash); err != nil {
+			utils.Logger().Warn().Err(err).Msg("cannot batch contract creation index")
+		}
+	}
+}
+
+// UpdateAddress updates the addresses of the sender and the recipient, or the
+// created contract, of the given transaction, the one of the given index in
+// the block of the given height.
+func (storage *Storage) UpdateAddress(batch ethdb.Batch, explorerTransaction *Transaction, tx *types.Transaction, height uint64, index int) {
+	recipient := explorerTransaction.To
+	if recipient == "" {
+		recipient = explorerTransaction.Contract
+	}
 	explorerTransaction.Type = Received
-	storage.UpdateAddressStorage(batch, explorerTransaction.To, explorerTransaction, tx)
+	storage.UpdateAddressStorage(batch, recipient, explorerTransaction, tx, height, index)
 	explorerTransaction.Type = Sent
-	storage.UpdateAddressStorage(batch, explorerTransaction.From, explorerTransaction, tx)
+	storage.UpdateAddressStorage(batch, explorerTransaction.From, explorerTransaction, tx, height, index)
 }
 
-// UpdateAddressStorage updates specific addr Address.
-func (storage *Storage) UpdateAddressStorage(batch ethdb.Batch, addr string, explorerTransaction *Transaction, tx *types.Transaction) {
+// UpdateAddressStorage updates specific addr Address, and adds the given
+// transaction to the index of its transactions.
+func (storage *Storage) UpdateAddressStorage(batch ethdb.Batch, addr string, explorerTransaction *Transaction, tx *types.Transaction, height uint64, index int) {
+	if data, err := rlp.EncodeToBytes(explorerTransaction); err == nil {
+		key := GetAddressTXKey(addr, height, index, explorerTransaction.Type)
+		if err := batch.Put([]byte(key), data); err != nil {
+			utils.Logger().Warn().Err(err).Msg("cannot batch address TX")
+		}
+	} else {
+		utils.Logger().Error().Err(err).Msg("cannot encode address TX")
+	}
+
 	key := GetAddressKey(addr)
 
 	var address Address
@@ -178,7 +311,9 @@ func (storage *Storage) UpdateAddressStorage(batch ethdb.Batch, addr string, exp
 		address.Balance = tx.Value()
 	}
 	address.ID = addr
-	address.TXs = append(address.TXs, explorerTransaction)
+	// The transactions of the address are in its index only, so that the
+	// cost of an update does not grow with its history.
+	address.TXs = nil
 	encoded, err := rlp.EncodeToBytes(address)
 	if err == nil {
 		if err := batch.Put([]byte(key), encoded); err != nil {
@@ -188,3 +323,108 @@ func (storage *Storage) UpdateAddressStorage(batch ethdb.Batch, addr string, exp
 		utils.Logger().Error().Err(err).Msg("cannot encode address")
 	}
 }
+
+// ReadBlockHeightByHash returns the height of the block of the given hash.
+func (storage *Storage) ReadBlockHeightByHash(hash string) (int, error) {
+	data, err := storage.db.Get([]byte(GetBlockHashKey(hash)))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(string(data))
+}
+
+// ReadTXs returns a page of up to limit transactions of the blocks whose time
+// in milliseconds is in [fromTime, toTime), in time order, starting after the
+// given cursor if not empty.
+func (storage *Storage) ReadTXs(fromTime, toTime int64, cursor string, limit int) (*TXPage, error) {
+	return storage.readTXPage(TXTimePrefix,
+		fmt.Sprintf("%020d", fromTime), fmt.Sprintf("%020d", toTime),
+		cursor, limit, storage.readIndexedTX, nil)
+}
+
+// ReadCXTXs returns a page of the cross-shard transactions to the given shard,
+// as ReadTXs does for all the transactions.
+func (storage *Storage) ReadCXTXs(toShardID uint32, fromTime, toTime int64, cursor string, limit int) (*TXPage, error) {
+	return storage.readTXPage(CXTXPrefix,
+		fmt.Sprintf("%010d_%020d", toShardID, fromTime), fmt.Sprintf("%010d_%020d", toShardID, toTime),
+		cursor, limit, storage.readIndexedTX, nil)
+}
+
+// ReadContractCreations returns a page of the transactions creating contracts,
+// as ReadTXs does for all the transactions.
+func (storage *Storage) ReadContractCreations(fromTime, toTime int64, cursor string, limit int) (*TXPage, error) {
+	return storage.readTXPage(ContractPrefix,
+		fmt.Sprintf("%020d", fromTime), fmt.Sprintf("%020d", toTime),
+		cursor, limit, storage.readIndexedTX, nil)
+}
+
+// ReadAddressTXs returns a page of up to limit transactions of the given
+// address, of the given type or of both if txType is empty, in chain order,
+// starting after the given cursor if not empty.
+func (storage *Storage) ReadAddressTXs(address string, txType string, cursor string, limit int) (*TXPage, error) {
+	var accept func(tx *Transaction) bool
+	if txType != "" {
+		accept = func(tx *Transaction) bool { return tx.Type == txType }
+	}
+	decode := func(data []byte) (*Transaction, error) {
+		tx := new(Transaction)
+		return tx, rlp.DecodeBytes(data, tx)
+	}
+	return storage.readTXPage(AddressTXPrefix+"_"+address, "", "", cursor, limit, decode, accept)
+}
+
+// readIndexedTX returns the transaction of the hash in an index entry.
+func (storage *Storage) readIndexedTX(hash []byte) (*Transaction, error) {
+	data, err := storage.db.Get([]byte(GetTXKey(string(hash))))
+	if err != nil {
+		return nil, err
+	}
+	tx := new(Transaction)
+	return tx, rlp.DecodeBytes(data, tx)
+}
+
+// readTXPage returns a page of up to limit transactions, decoded from the
+// entries of the index of the given prefix, in key order, whose cursors are in
+// [from, to) and after the given cursor if not empty.  Transactions accept
+// rejects, if not nil, are skipped.  An empty bound means none.
+func (storage *Storage) readTXPage(
+	prefix, from, to, cursor string, limit int,
+	decode func(data []byte) (*Transaction, error), accept func(tx *Transaction) bool,
+) (*TXPage, error) {
+	indexPrefix := prefix + "_"
+	rng := util.BytesPrefix([]byte(indexPrefix))
+	if cursor != "" && cursor >= from {
+		// The first key after the cursor.
+		rng.Start = []byte(indexPrefix + cursor + "\x00")
+	} else if from != "" {
+		rng.Start = []byte(indexPrefix + from)
+	}
+	if to != "" {
+		rng.Limit = []byte(indexPrefix + to)
+	}
+	it := storage.db.LDB().NewIterator(rng, nil)
+	defer it.Release()
+
+	page := &TXPage{TXs: []*Transaction{}}
+	last := ""
+	for it.Next() {
+		tx, err := decode(it.Value())
+		if err != nil {
+			return nil, ctxerror.New("cannot decode indexed transaction",
+				"key", string(it.Key())).WithCause(err)
+		}
+		if accept != nil && !accept(tx) {
+			continue
+		}
+		if len(page.TXs) == limit {
+			page.Next = last
+			break
+		}
+		page.TXs = append(page.TXs, tx)
+		last = string(it.Key()[len(indexPrefix):])
+	}
+	if err := it.Error(); err != nil {
+		return nil, ctxerror.New("cannot iterate over index", "prefix", prefix).WithCause(err)
+	}
+	return page, nil
+}
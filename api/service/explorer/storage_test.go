@@ -2,6 +2,7 @@ package explorer
 
 import (
 	"bytes"
+	"math"
 	"math/big"
 	"strconv"
 	"testing"
@@ -12,6 +13,7 @@ import (
 
 	blockfactory "github.com/harmony-one/harmony/block/factory"
 	"github.com/harmony-one/harmony/core/types"
+	common2 "github.com/harmony-one/harmony/internal/common"
 	"github.com/harmony-one/harmony/shard"
 
 	"github.com/stretchr/testify/assert"
@@ -42,6 +44,15 @@ func TestGetCommitteeKey(t *testing.T) {
 	assert.Equal(t, GetCommitteeKey(uint32(0), uint64(0)), "cp_0_0", "error")
 }
 
+// Test for index keys
+func TestGetIndexKeys(t *testing.T) {
+	assert.Equal(t, GetBlockHashKey("0xab"), "bx_0xab", "error")
+	assert.Equal(t, GetTXTimeKey(12, "0xab"), "tt_00000000000000000012_0xab", "error")
+	assert.Equal(t, GetCXTXKey(3, 12, "0xab"), "tc_0000000003_00000000000000000012_0xab", "error")
+	assert.Equal(t, GetContractKey(12, "0xab"), "tk_00000000000000000012_0xab", "error")
+	assert.Equal(t, GetAddressTXKey("one1", 7, 2, Sent), "at_one1_00000000000000000007_000002_SENT", "error")
+}
+
 func TestInit(t *testing.T) {
 	ins := GetStorageInstance("1.1.1.1", "3333", true)
 	if err := ins.GetDB().Put([]byte{1}, []byte{2}); err != nil {
@@ -136,3 +147,67 @@ func TestUpdateAddressStorage(t *testing.T) {
 	assert.Nil(t, err, "should be nil")
 	assert.Equal(t, bytes.Compare(data, blockData), 0, "should be equal")
 }
+
+func txIDs(page *TXPage) []string {
+	ids := []string{}
+	for _, tx := range page.TXs {
+		ids = append(ids, tx.ID)
+	}
+	return ids
+}
+
+func TestIndexes(t *testing.T) {
+	to := common.BytesToAddress([]byte{0x11})
+	tx1 := types.NewTransaction(1, to, 0, big.NewInt(111), 1111, big.NewInt(11111), nil)
+	tx2 := types.NewCrossShardTransaction(2, &to, 0, 1, big.NewInt(222), 2222, big.NewInt(22222), nil)
+	tx3 := types.NewContractCreation(3, 0, big.NewInt(333), 3333, big.NewInt(33333), []byte{0x33})
+	block1 := types.NewBlock(blockfactory.NewTestHeader().With().Number(big.NewInt(1)).Time(big.NewInt(10)).Header(),
+		[]*types.Transaction{tx1, tx2}, nil, nil, nil)
+	block2 := types.NewBlock(blockfactory.NewTestHeader().With().Number(big.NewInt(2)).Time(big.NewInt(20)).Header(),
+		[]*types.Transaction{tx3}, nil, nil, nil)
+	ins := &Storage{}
+	ins.Init("1.1.1.1", "3334", true)
+	defer ins.GetDB().Close()
+	ins.Dump(block1, 1)
+	ins.Dump(block2, 2)
+
+	height, err := ins.ReadBlockHeightByHash(block2.Hash().Hex())
+	assert.Nil(t, err, "should be nil")
+	assert.Equal(t, 2, height, "should be the height of block2")
+
+	page, err := ins.ReadTXs(0, math.MaxInt64, "", 2)
+	assert.Nil(t, err, "should be nil")
+	assert.ElementsMatch(t, []string{tx1.Hash().Hex(), tx2.Hash().Hex()}, txIDs(page), "should be the txs of block1")
+	assert.NotEqual(t, "", page.Next, "should have a next page")
+	page, err = ins.ReadTXs(0, math.MaxInt64, page.Next, 2)
+	assert.Nil(t, err, "should be nil")
+	assert.Equal(t, []string{tx3.Hash().Hex()}, txIDs(page), "should be the txs of block2")
+	assert.Equal(t, "", page.Next, "should be the last page")
+	page, err = ins.ReadTXs(10000, 20000, "", 10)
+	assert.Nil(t, err, "should be nil")
+	assert.ElementsMatch(t, []string{tx1.Hash().Hex(), tx2.Hash().Hex()}, txIDs(page), "should be the txs in the time window")
+
+	page, err = ins.ReadCXTXs(1, 0, math.MaxInt64, "", 10)
+	assert.Nil(t, err, "should be nil")
+	assert.Equal(t, []string{tx2.Hash().Hex()}, txIDs(page), "should be the cross-shard txs to shard 1")
+	page, err = ins.ReadCXTXs(2, 0, math.MaxInt64, "", 10)
+	assert.Nil(t, err, "should be nil")
+	assert.Empty(t, page.TXs, "should have no cross-shard txs to shard 2")
+
+	page, err = ins.ReadContractCreations(0, math.MaxInt64, "", 10)
+	assert.Nil(t, err, "should be nil")
+	assert.Equal(t, []string{tx3.Hash().Hex()}, txIDs(page), "should be the contract creations")
+	assert.NotEqual(t, "", page.TXs[0].Contract, "should have a contract address")
+
+	recipient := common2.MustAddressToBech32(to)
+	page, err = ins.ReadAddressTXs(recipient, Received, "", 1)
+	assert.Nil(t, err, "should be nil")
+	assert.Equal(t, []string{tx1.Hash().Hex()}, txIDs(page), "should be the first tx received")
+	page, err = ins.ReadAddressTXs(recipient, Received, page.Next, 1)
+	assert.Nil(t, err, "should be nil")
+	assert.Equal(t, []string{tx2.Hash().Hex()}, txIDs(page), "should be the second tx received")
+	assert.Equal(t, "", page.Next, "should be the last page")
+	page, err = ins.ReadAddressTXs(recipient, Sent, "", 10)
+	assert.Nil(t, err, "should be nil")
+	assert.Empty(t, page.TXs, "should have sent no tx")
+}
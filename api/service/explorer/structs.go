@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 
 	"github.com/harmony-one/harmony/core/types"
 	common2 "github.com/harmony-one/harmony/internal/common"
@@ -58,6 +59,29 @@ type Transaction struct {
 	Bytes     string   `json:"bytes"`
 	Data      string   `json:"data"`
 	Type      string   `json:"type"`
+	// Contract is the address of the contract the transaction creates, if
+	// it has no recipient.
+	Contract string `json:"contract,omitempty"`
+}
+
+// TXPage is a page of transactions, with the cursor of the next page, which
+// is empty after the last page.
+type TXPage struct {
+	TXs  []*Transaction `json:"txs"`
+	Next string         `json:"next"`
+}
+
+// AddressPage is an address with a page of its transactions.
+type AddressPage struct {
+	Address
+	Next string `json:"next"`
+}
+
+// BlockPage is a page of blocks, with the cursor of the next page, which is
+// empty after the last page.
+type BlockPage struct {
+	Blocks []*Block `json:"blocks"`
+	Next   string   `json:"next"`
 }
 
 // Block ...
@@ -109,23 +133,35 @@ func NewBlock(block *types.Block, height int) *Block {
 	}
 }
 
-// GetTransaction ...
+// GetTransaction returns the explorer transaction of the given one.  A
+// transaction creating a contract has no recipient but the contract address.
 func GetTransaction(tx *types.Transaction, addressBlock *types.Block) *Transaction {
-	if tx.To() == nil {
-		return nil
-	}
 	msg, err := tx.AsMessage(types.NewEIP155Signer(tx.ChainID()))
 	if err != nil {
 		utils.Logger().Error().Err(err).Msg("Error when parsing tx into message")
 	}
-	return &Transaction{
+	transaction := &Transaction{
 		ID:        tx.Hash().Hex(),
 		Timestamp: strconv.Itoa(int(addressBlock.Time().Int64() * 1000)),
 		From:      common2.MustAddressToBech32(common.HexToAddress(msg.From().Hex())),
-		To:        common2.MustAddressToBech32(common.HexToAddress(msg.To().Hex())),
 		Value:     msg.Value(),
 		Bytes:     strconv.Itoa(int(tx.Size())),
 		Data:      hex.EncodeToString(tx.Data()),
 		Type:      "",
 	}
+	if to := msg.To(); to != nil {
+		transaction.To = common2.MustAddressToBech32(*to)
+	} else {
+		transaction.Contract = common2.MustAddressToBech32(crypto.CreateAddress(msg.From(), tx.Nonce()))
+	}
+	return transaction
+}
+
+// GetTransactions returns the explorer transactions of the given block.
+func GetTransactions(block *types.Block) []*Transaction {
+	txs := make([]*Transaction, 0, block.Transactions().Len())
+	for _, tx := range block.Transactions() {
+		txs = append(txs, GetTransaction(tx, block))
+	}
+	return txs
 }
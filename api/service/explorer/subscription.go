@@ -0,0 +1,339 @@
+package explorer
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/harmony-one/harmony/core/types"
+	common2 "github.com/harmony-one/harmony/internal/common"
+	"github.com/harmony-one/harmony/internal/ctxerror"
+	"github.com/harmony-one/harmony/internal/utils"
+	"github.com/harmony-one/harmony/shard"
+)
+
+// Keys of the parameters of NotifyService.
+const (
+	// NotifyBlock is the key of a *types.Block the node committed.
+	NotifyBlock = "block"
+	// NotifyCommittee is the key of the *CommitteeChange of a new epoch.
+	NotifyCommittee = "committee"
+)
+
+// subscriptionBufferSize is the number of events a subscription holds for
+// a slow subscriber before it misses events.
+const subscriptionBufferSize = 16
+
+// subscriptionFeed passes events to subscribers, each with a buffer of its
+// own.  Unlike event.Feed, sending never waits for a subscriber: one whose
+// buffer is full has fallen behind, and misses the event, so that a slow
+// WebSocket client cannot hold up the node.  The zero value is ready to use.
+type subscriptionFeed struct {
+	mu   sync.Mutex
+	subs map[chan interface{}]struct{}
+}
+
+// subscribe returns a new subscription channel, which the subscriber must
+// pass to unsubscribe when done.
+func (f *subscriptionFeed) subscribe() chan interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.subs == nil {
+		f.subs = make(map[chan interface{}]struct{})
+	}
+	ch := make(chan interface{}, subscriptionBufferSize)
+	f.subs[ch] = struct{}{}
+	return ch
+}
+
+func (f *subscriptionFeed) unsubscribe(ch chan interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.subs, ch)
+}
+
+// send passes the event to the subscribers having room for it, and returns
+// the number of subscribers which missed it.
+func (f *subscriptionFeed) send(event interface{}) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	missed := 0
+	for ch := range f.subs {
+		select {
+		case ch <- event:
+		default:
+			missed++
+		}
+	}
+	if missed > 0 {
+		utils.Logger().Debug().Int("subscribers", missed).Msg("[Explorer] Lagging subscribers missed an event")
+	}
+	return missed
+}
+
+// CommitteeChange is the committee of a shard for a new epoch.
+type CommitteeChange struct {
+	ShardID    uint32   `json:"shardID"`
+	Epoch      uint64   `json:"epoch"`
+	Validators []string `json:"validators"`
+}
+
+// NewCommitteeChange returns the change to the given committee at the given
+// epoch.
+func NewCommitteeChange(epoch uint64, committee *shard.Committee) *CommitteeChange {
+	change := &CommitteeChange{
+		ShardID:    committee.ShardID,
+		Epoch:      epoch,
+		Validators: []string{},
+	}
+	for _, validator := range committee.NodeList {
+		if oneAddress, err := common2.AddressToBech32(validator.EcdsaAddress); err == nil {
+			change.Validators = append(change.Validators, oneAddress)
+		}
+	}
+	return change
+}
+
+// SubscriptionFilter selects the events a subscription pushes: those which
+// involve one of the addresses, if there are any, and the shard, if set.
+type SubscriptionFilter struct {
+	// Addresses are the senders and recipients of transactions, or the
+	// validators of committees, in bech32 or hex.  A block involves the
+	// addresses of its transactions.
+	Addresses []string `json:"addresses"`
+	// ShardID is the shard of blocks and committees, and the source or the
+	// destination shard of transactions.
+	ShardID *uint32 `json:"shardID"`
+}
+
+// subscriptionFilter is a SubscriptionFilter with the addresses in bech32.
+type subscriptionFilter struct {
+	addresses map[string]bool
+	shardID   *uint32
+}
+
+func newSubscriptionFilter(filter *SubscriptionFilter) (*subscriptionFilter, error) {
+	f := &subscriptionFilter{addresses: map[string]bool{}}
+	if filter == nil {
+		return f, nil
+	}
+	for _, address := range filter.Addresses {
+		addr, err := common2.Bech32ToAddress(address)
+		if err != nil {
+			if !common.IsHexAddress(address) {
+				return nil, ctxerror.New("invalid address", "address", address)
+			}
+			addr = common.HexToAddress(address)
+		}
+		f.addresses[common2.MustAddressToBech32(addr)] = true
+	}
+	f.shardID = filter.ShardID
+	return f, nil
+}
+
+func (f *subscriptionFilter) matchesAddress(addresses ...string) bool {
+	if len(f.addresses) == 0 {
+		return true
+	}
+	for _, address := range addresses {
+		if f.addresses[address] {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *subscriptionFilter) matchesShard(shardIDs ...uint32) bool {
+	if f.shardID == nil {
+		return true
+	}
+	for _, shardID := range shardIDs {
+		if shardID == *f.shardID {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *subscriptionFilter) matchesTX(event *txEvent) bool {
+	return f.matchesShard(event.shardID, event.toShardID) &&
+		f.matchesAddress(event.tx.From, event.tx.To, event.tx.Contract)
+}
+
+func (f *subscriptionFilter) matchesBlock(event *blockEvent) bool {
+	if !f.matchesShard(event.shardID) {
+		return false
+	}
+	if len(f.addresses) == 0 {
+		return true
+	}
+	for _, tx := range event.txs {
+		if f.matchesAddress(tx.tx.From, tx.tx.To, tx.tx.Contract) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *subscriptionFilter) matchesCommittee(change *CommitteeChange) bool {
+	return f.matchesShard(change.ShardID) && f.matchesAddress(change.Validators...)
+}
+
+// txEvent is a committed transaction with its shards.
+type txEvent struct {
+	tx        *Transaction
+	shardID   uint32
+	toShardID uint32
+}
+
+// blockEvent is a committed block with its transactions.
+type blockEvent struct {
+	block   *Block
+	shardID uint32
+	txs     []*txEvent
+}
+
+// newBlockEvent returns the event of the given committed block.  Its signers
+// are in the next block, so the event has none.
+func newBlockEvent(block *types.Block) *blockEvent {
+	explorerBlock := NewBlock(block, int(block.NumberU64()))
+	if block.NumberU64() > 0 {
+		explorerBlock.PrevBlock = RefBlock{
+			ID:     block.ParentHash().Hex(),
+			Height: strconv.FormatUint(block.NumberU64()-1, 10),
+		}
+	}
+	event := &blockEvent{block: explorerBlock, shardID: block.ShardID()}
+	for _, tx := range block.Transactions() {
+		explorerTransaction := GetTransaction(tx, block)
+		explorerBlock.TXs = append(explorerBlock.TXs, explorerTransaction)
+		event.txs = append(event.txs, &txEvent{
+			tx:        explorerTransaction,
+			shardID:   tx.ShardID(),
+			toShardID: tx.ToShardID(),
+		})
+	}
+	return event
+}
+
+// PublicExplorerAPI pushes the blocks, transactions and committee changes
+// the explorer node commits to the subscribers of the WebSocket endpoint of
+// the node, e.g.
+//
+//	{"method": "explorer_subscribe", "params": ["newTransactions", {"addresses": ["one1..."]}]}
+type PublicExplorerAPI struct {
+	s *Service
+}
+
+// NewPublicExplorerAPI returns the subscription API of the given service.
+func NewPublicExplorerAPI(s *Service) *PublicExplorerAPI {
+	return &PublicExplorerAPI{s: s}
+}
+
+// NewBlocks sends a notification with each committed block the filter
+// selects.
+func (api *PublicExplorerAPI) NewBlocks(ctx context.Context, filter *SubscriptionFilter) (*rpc.Subscription, error) {
+	f, err := newSubscriptionFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := api.s.blockFeed.subscribe()
+		defer api.s.blockFeed.unsubscribe(events)
+
+		for {
+			select {
+			case e := <-events:
+				if event := e.(*blockEvent); f.matchesBlock(event) {
+					notifier.Notify(rpcSub.ID, event.block)
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// NewTransactions sends a notification with each committed transaction the
+// filter selects.
+func (api *PublicExplorerAPI) NewTransactions(ctx context.Context, filter *SubscriptionFilter) (*rpc.Subscription, error) {
+	f, err := newSubscriptionFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := api.s.txFeed.subscribe()
+		defer api.s.txFeed.unsubscribe(events)
+
+		for {
+			select {
+			case e := <-events:
+				if event := e.(*txEvent); f.matchesTX(event) {
+					notifier.Notify(rpcSub.ID, event.tx)
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// CommitteeChanges sends a notification with the committee of each new
+// epoch the filter selects.
+func (api *PublicExplorerAPI) CommitteeChanges(ctx context.Context, filter *SubscriptionFilter) (*rpc.Subscription, error) {
+	f, err := newSubscriptionFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		changes := api.s.committeeFeed.subscribe()
+		defer api.s.committeeFeed.unsubscribe(changes)
+
+		for {
+			select {
+			case c := <-changes:
+				if change := c.(*CommitteeChange); f.matchesCommittee(change) {
+					notifier.Notify(rpcSub.ID, change)
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
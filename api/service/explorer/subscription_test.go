@@ -0,0 +1,101 @@
+package explorer
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+
+	blockfactory "github.com/harmony-one/harmony/block/factory"
+	"github.com/harmony-one/harmony/core/types"
+	common2 "github.com/harmony-one/harmony/internal/common"
+	"github.com/harmony-one/harmony/shard"
+)
+
+func TestSubscriptionFilter(t *testing.T) {
+	to := common.BytesToAddress([]byte{0x11})
+	other := common.BytesToAddress([]byte{0x22})
+	tx1 := types.NewTransaction(1, to, 0, big.NewInt(111), 1111, big.NewInt(11111), nil)
+	tx2 := types.NewCrossShardTransaction(2, &other, 0, 1, big.NewInt(222), 2222, big.NewInt(22222), nil)
+	block := types.NewBlock(blockfactory.NewTestHeader().With().Number(big.NewInt(3)).Header(),
+		[]*types.Transaction{tx1, tx2}, nil, nil, nil)
+	event := newBlockEvent(block)
+	assert.Equal(t, 2, len(event.block.TXs), "should have the txs of the block")
+	assert.Equal(t, block.ParentHash().Hex(), event.block.PrevBlock.ID, "should refer to the parent block")
+
+	f, err := newSubscriptionFilter(nil)
+	assert.Nil(t, err, "should be nil")
+	assert.True(t, f.matchesBlock(event), "should select everything")
+
+	f, err = newSubscriptionFilter(&SubscriptionFilter{Addresses: []string{to.Hex()}})
+	assert.Nil(t, err, "should be nil")
+	assert.True(t, f.matchesBlock(event), "should select the block of a tx to the address")
+	assert.True(t, f.matchesTX(event.txs[0]), "should select the tx to the address")
+	assert.False(t, f.matchesTX(event.txs[1]), "should not select the tx to another address")
+
+	shardID := uint32(1)
+	f, err = newSubscriptionFilter(&SubscriptionFilter{
+		Addresses: []string{common2.MustAddressToBech32(other)},
+		ShardID:   &shardID,
+	})
+	assert.Nil(t, err, "should be nil")
+	assert.False(t, f.matchesBlock(event), "should not select the block of shard 0")
+	assert.False(t, f.matchesTX(event.txs[0]), "should not select the tx within shard 0")
+	assert.True(t, f.matchesTX(event.txs[1]), "should select the tx to shard 1")
+
+	change := NewCommitteeChange(2, &shard.Committee{ShardID: 1, NodeList: []shard.NodeID{{EcdsaAddress: other}}})
+	assert.True(t, f.matchesCommittee(change), "should select the committee of the address")
+	change.ShardID = 0
+	assert.False(t, f.matchesCommittee(change), "should not select the committee of shard 0")
+
+	_, err = newSubscriptionFilter(&SubscriptionFilter{Addresses: []string{"nonsense"}})
+	assert.NotNil(t, err, "should reject an invalid address")
+}
+
+func TestNotifyService(t *testing.T) {
+	tx := types.NewTransaction(1, common.BytesToAddress([]byte{0x11}), 0, big.NewInt(111), 1111, big.NewInt(11111), nil)
+	block := types.NewBlock(blockfactory.NewTestHeader().With().Number(big.NewInt(3)).Header(),
+		[]*types.Transaction{tx}, nil, nil, nil)
+	s := &Service{}
+	blocks := s.blockFeed.subscribe()
+	txs := s.txFeed.subscribe()
+	changes := s.committeeFeed.subscribe()
+	defer s.blockFeed.unsubscribe(blocks)
+	defer s.txFeed.unsubscribe(txs)
+	defer s.committeeFeed.unsubscribe(changes)
+
+	s.NotifyService(map[string]interface{}{NotifyBlock: block})
+	assert.Equal(t, block.Hash().Hex(), (<-blocks).(*blockEvent).block.ID, "should push the block")
+	assert.Equal(t, tx.Hash().Hex(), (<-txs).(*txEvent).tx.ID, "should push the tx")
+
+	change := &CommitteeChange{ShardID: 0, Epoch: 1}
+	s.NotifyService(map[string]interface{}{NotifyCommittee: change})
+	assert.Equal(t, change, <-changes, "should push the committee change")
+}
+
+func TestSubscriptionFeed(t *testing.T) {
+	var feed subscriptionFeed
+	assert.Equal(t, 0, feed.send(0), "should send without subscribers")
+
+	slow := feed.subscribe()
+	fast := feed.subscribe()
+	for i := 0; i < subscriptionBufferSize; i++ {
+		assert.Equal(t, 0, feed.send(i), "should buffer the event")
+		assert.Equal(t, i, <-fast, "should pass the event")
+	}
+	// the slow subscriber misses events without holding up the others
+	assert.Equal(t, 1, feed.send(-1), "should not wait for the lagging subscriber")
+	assert.Equal(t, -1, <-fast, "should pass the event")
+	for i := 0; i < subscriptionBufferSize; i++ {
+		assert.Equal(t, i, <-slow, "should keep the buffered events")
+	}
+	assert.Equal(t, 0, feed.send(-2), "should pass events once there is room")
+	assert.Equal(t, -2, <-slow, "should pass the event")
+	assert.Equal(t, -2, <-fast, "should pass the event")
+
+	feed.unsubscribe(slow)
+	feed.unsubscribe(fast)
+	assert.Equal(t, 0, feed.send(-3), "should not send to former subscribers")
+	assert.Empty(t, slow)
+}
@@ -2,11 +2,8 @@ package staking
 
 import (
 	"math/big"
-	"strings"
 	"time"
 
-	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 	protobuf "github.com/golang/protobuf/proto"
@@ -14,17 +11,12 @@ import (
 	"github.com/harmony-one/harmony/internal/params"
 
 	"github.com/harmony-one/harmony/accounts"
-	"github.com/harmony-one/harmony/accounts/abi"
-	proto "github.com/harmony-one/harmony/api/client/service/proto"
 	proto_common "github.com/harmony-one/harmony/api/proto"
 	"github.com/harmony-one/harmony/api/proto/message"
 	msg_pb "github.com/harmony-one/harmony/api/proto/message"
 	"github.com/harmony-one/harmony/common/denominations"
-	"github.com/harmony-one/harmony/contracts"
 	"github.com/harmony-one/harmony/core"
 	"github.com/harmony-one/harmony/core/types"
-	common2 "github.com/harmony-one/harmony/internal/common"
-	"github.com/harmony-one/harmony/internal/genesis"
 	hmykey "github.com/harmony-one/harmony/internal/keystore"
 	"github.com/harmony-one/harmony/internal/utils"
 	"github.com/harmony-one/harmony/p2p"
@@ -34,9 +26,7 @@ import (
 const (
 	// WaitTime is the delay time for resending staking transaction if the previous transaction did not get approved.
 	WaitTime = 5 * time.Second
-	// StakingContractAddress is the staking deployed contract address
-	StakingContractAddress = "TODO(minhdoan): Create a PR to generate staking contract address"
-	// StakingAmount is the amount of stake to put
+	// StakingAmount is the amount of stake to put, in ONE
 	StakingAmount = 10
 )
 
@@ -64,6 +54,7 @@ func New(host p2p.Host, account accounts.Account, beaconChain *core.BlockChain,
 		host:          host,
 		stopChan:      make(chan struct{}),
 		stoppedChan:   make(chan struct{}),
+		account:       account,
 		blsPublicKey:  blsPublicKey,
 		stakingAmount: StakingAmount,
 		beaconChain:   beaconChain,
@@ -100,9 +91,23 @@ func (s *Service) Run() {
 	}()
 }
 
-// IsStaked checks if the txn gets accepted and approved in the beacon chain.
+// IsStaked checks if the txn gets accepted and approved in the beacon chain,
+// i.e. the account is a validator of the registry.
 func (s *Service) IsStaked() bool {
-	return false
+	if s.beaconChain == nil {
+		return false
+	}
+	state, err := s.beaconChain.State()
+	if err != nil {
+		utils.Logger().Error().Err(err).Msg("error to get beacon chain state when checking stake")
+		return false
+	}
+	validator, err := core.ReadValidator(state, s.account.Address)
+	if err != nil {
+		utils.Logger().Error().Err(err).Msg("error to read validator registry when checking stake")
+		return false
+	}
+	return validator != nil
 }
 
 // DoService does staking.
@@ -124,45 +129,6 @@ func (s *Service) DoService() {
 	}
 }
 
-func (s *Service) getStakingInfo() *proto.StakingContractInfoResponse {
-	address := s.account.Address
-	state, err := s.beaconChain.State()
-	if err != nil {
-		utils.Logger().Error().Msg("error to get beacon chain state when getting staking info")
-		return nil
-	}
-	balance := state.GetBalance(address)
-	if balance == common.Big0 {
-		utils.Logger().Error().Msg("account balance empty when getting staking info")
-		return nil
-	}
-	nonce := state.GetNonce(address)
-	if nonce == 0 {
-		utils.Logger().Error().Msg("nonce zero when getting staking info")
-		return nil
-	}
-	return &proto.StakingContractInfoResponse{
-		ContractAddress: StakingContractAddress,
-		Balance:         balance.Bytes(),
-		Nonce:           nonce,
-	}
-}
-
-func (s *Service) getFakeStakingInfo() *proto.StakingContractInfoResponse {
-	balance := big.NewInt(denominations.One)
-	nonce := uint64(0) // TODO: make it a incrementing field
-
-	priKey := genesis.GenesisBeaconAccountPriKey
-	contractAddress := crypto.PubkeyToAddress(priKey.PublicKey)
-
-	stakingContractAddress := crypto.CreateAddress(contractAddress, uint64(nonce))
-	return &proto.StakingContractInfoResponse{
-		ContractAddress: common2.MustAddressToBech32(stakingContractAddress),
-		Balance:         balance.Bytes(),
-		Nonce:           nonce,
-	}
-}
-
 // Constructs the staking message
 func constructStakingMessage(ts types.Transactions) []byte {
 	tsBytes, err := rlp.EncodeToBytes(ts)
@@ -185,43 +151,34 @@ func constructStakingMessage(ts types.Transactions) []byte {
 }
 
 func (s *Service) createRawStakingMessage() []byte {
-	// TODO(minhdoan): Enable getStakingInfo back after testing.
-	stakingInfo := s.getFakeStakingInfo()
-	toAddress := common2.ParseAddr(stakingInfo.ContractAddress)
-
-	abi, err := abi.JSON(strings.NewReader(contracts.StakeLockContractABI))
+	if s.beaconChain == nil {
+		utils.Logger().Error().Msg("no beacon chain to get the nonce of the staking account")
+		return nil
+	}
+	state, err := s.beaconChain.State()
 	if err != nil {
-		utils.Logger().Error().Err(err).Msg("Failed to generate staking contract's ABI")
+		utils.Logger().Error().Err(err).Msg("error to get beacon chain state when creating staking transaction")
+		return nil
 	}
 	// TODO: the bls address should be signed by the bls private key
-	blsPubKeyBytes := s.blsPublicKey.Serialize()
-	if len(blsPubKeyBytes) != 96 {
-		utils.Logger().Error().Int("size", len(blsPubKeyBytes)).Msg("Wrong bls pubkey size")
-		return []byte{}
-	}
-	blsPubKeyPart1 := [32]byte{}
-	blsPubKeyPart2 := [32]byte{}
-	blsPubKeyPart3 := [32]byte{}
-	copy(blsPubKeyPart1[:], blsPubKeyBytes[:32])
-	copy(blsPubKeyPart2[:], blsPubKeyBytes[32:64])
-	copy(blsPubKeyPart3[:], blsPubKeyBytes[64:96])
-	bytesData, err := abi.Pack("lock", blsPubKeyPart1, blsPubKeyPart2, blsPubKeyPart3)
-
-	if err != nil {
-		utils.Logger().Error().Err(err).Msg("Failed to generate ABI function bytes data")
+	payload := &types.CreateValidator{}
+	if err := payload.BlsPublicKey.FromLibBLSPublicKey(s.blsPublicKey); err != nil {
+		utils.Logger().Error().Err(err).Msg("Wrong bls pubkey")
+		return nil
 	}
-
-	tx := types.NewTransaction(
-		stakingInfo.Nonce,
-		toAddress,
-		0,
-		big.NewInt(s.stakingAmount),
-		params.TxGas*10,
+	amount := new(big.Int).Mul(big.NewInt(s.stakingAmount), big.NewInt(denominations.One))
+	tx, err := types.NewStakingTransaction(
+		state.GetNonce(s.account.Address),
+		payload,
+		amount,
+		params.TxGas*20,
 		nil,
-		bytesData,
 	)
+	if err != nil {
+		utils.Logger().Error().Err(err).Msg("Failed to encode staking message")
+		return nil
+	}
 
-	// This is currently not called.
 	chainID := big.NewInt(1) // TODO: wire the correct chain ID after staking flow is revamped.
 	if signedTx, err := hmykey.SignTx(s.account, tx, chainID); err == nil {
 		ts := types.Transactions{signedTx}
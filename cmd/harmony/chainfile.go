@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/harmony-one/harmony/consensus"
+	"github.com/harmony-one/harmony/core"
+	"github.com/harmony-one/harmony/internal/chain"
+	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
+	"github.com/harmony-one/harmony/internal/shardchain"
+	"github.com/harmony-one/harmony/internal/utils"
+	"github.com/harmony-one/harmony/node"
+)
+
+// runCommand runs the command given after the node flags, on the chain
+// database of the shard -shard_id in -db_dir, and returns the exit code.
+//
+//	harmony -network_type testnet -shard_id 1 export [-first n] [-last n] FILE
+//	harmony -network_type testnet -shard_id 1 import FILE
+//	harmony -network_type testnet -shard_id 1 -ip IP -port PORT explorer-reindex [-from n] [-workers n]
+func runCommand(args []string) int {
+	utils.SetLogVerbosity(log.Lvl(*verbosity))
+	switch args[0] {
+	case "export":
+		return exportChain(args[1:])
+	case "import":
+		return importChain(args[1:])
+	case "explorer-reindex":
+		return reindexExplorer(args[1:])
+	}
+	fmt.Fprintf(os.Stderr, "Unknown command: %s\n", args[0])
+	return 1
+}
+
+// openShardChain opens the chain database of the shard -shard_id, setting up
+// the genesis block of the network if it is new.
+func openShardChain() (*core.BlockChain, shardchain.Collection, error) {
+	if *shardID < 0 {
+		return nil, nil, fmt.Errorf("-shard_id is required")
+	}
+	netType := nodeconfig.NetworkType(*networkType)
+	switch netType {
+	case nodeconfig.Mainnet, nodeconfig.Testnet, nodeconfig.Pangaea, nodeconfig.Localnet, nodeconfig.Devnet:
+	default:
+		return nil, nil, fmt.Errorf("invalid network type: %s", *networkType)
+	}
+	nodeConfig := nodeconfig.GetShardConfig(uint32(*shardID))
+	nodeConfig.SetNetworkType(netType)
+	chains := node.NewChainCollection(nodeConfig, &shardchain.LDBFactory{RootDir: *dbDir}, *isArchival)
+	bc, err := chains.ShardChain(nodeConfig.ShardID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bc, chains, nil
+}
+
+func exportChain(args []string) int {
+	exportCommand := flag.NewFlagSet("export", flag.ExitOnError)
+	first := exportCommand.Uint64("first", 0, "number of the first block to export")
+	last := exportCommand.Int64("last", -1, "number of the last block to export; negative (default) means the head block")
+	if err := exportCommand.Parse(args); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR cannot parse export flags: %v\n", err)
+		return 1
+	}
+	if exportCommand.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: harmony [node flags] export [-first n] [-last n] FILE")
+		return 1
+	}
+
+	bc, chains, err := openShardChain()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR cannot open shard chain: %v\n", err)
+		return 1
+	}
+	defer chains.Close()
+	lastNum := bc.CurrentBlock().NumberU64()
+	if *last >= 0 {
+		lastNum = uint64(*last)
+	}
+
+	file, err := os.Create(exportCommand.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR cannot create chain file: %v\n", err)
+		return 1
+	}
+	defer file.Close()
+	w := bufio.NewWriter(file)
+	if err := bc.ExportChain(w, *first, lastNum); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR cannot export chain: %v\n", err)
+		return 1
+	}
+	if err := w.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR cannot write chain file: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Exported blocks %d to %d of shard %d\n", *first, lastNum, bc.ShardID())
+	return 0
+}
+
+func importChain(args []string) int {
+	importCommand := flag.NewFlagSet("import", flag.ExitOnError)
+	if err := importCommand.Parse(args); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR cannot parse import flags: %v\n", err)
+		return 1
+	}
+	if importCommand.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: harmony [node flags] import FILE")
+		return 1
+	}
+
+	file, err := os.Open(importCommand.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR cannot open chain file: %v\n", err)
+		return 1
+	}
+	defer file.Close()
+	bc, chains, err := openShardChain()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR cannot open shard chain: %v\n", err)
+		return 1
+	}
+	defer chains.Close()
+	// The commit proofs are checked against the quorum policy of the node,
+	// weighing stakes by the snapshots of the validator registry the local
+	// beacon chain took at the start of the epochs of the blocks.
+	beaconChain, err := chains.ShardChain(0)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR cannot open beacon chain: %v\n", err)
+		return 1
+	}
+	stakeInfoFinder := consensus.NewRegistryStakeInfoFinder()
+	stakeInfoFinder.SetBeaconChain(beaconChain)
+	quorumDecider, err := newQuorumDecider(stakeInfoFinder)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR %v\n", err)
+		return 1
+	}
+	chain.Engine.SetQuorumDecider(quorumDecider)
+
+	imported, err := bc.ImportChain(bufio.NewReader(file))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR cannot import chain after %d blocks: %v\n", imported, err)
+		return 1
+	}
+	fmt.Printf("Imported %d blocks; head of shard %d is block %d\n",
+		imported, bc.ShardID(), bc.CurrentBlock().NumberU64())
+	return 0
+}
@@ -7,10 +7,12 @@ import (
 	"math/big"
 	"math/rand"
 	"os"
+	"os/signal"
 	"path"
 	"runtime"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	ethCommon "github.com/ethereum/go-ethereum/common"
@@ -20,8 +22,11 @@ import (
 
 	"github.com/harmony-one/harmony/api/service/syncing"
 	"github.com/harmony-one/harmony/consensus"
+	"github.com/harmony-one/harmony/consensus/quorum"
 	"github.com/harmony-one/harmony/core"
+	"github.com/harmony-one/harmony/drand"
 	"github.com/harmony-one/harmony/internal/blsgen"
+	"github.com/harmony-one/harmony/internal/chain"
 	"github.com/harmony-one/harmony/internal/common"
 	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
 	shardingconfig "github.com/harmony-one/harmony/internal/configs/sharding"
@@ -121,6 +126,17 @@ var (
 	// Disable view change.
 	disableViewChange = flag.Bool("disable_view_change", false, "Do not propose view change (testing only)")
 
+	// quorumPolicy decides how votes are counted toward consensus quorum
+	quorumPolicy = flag.String("quorum_policy", "count", "how votes are counted toward quorum: count (one vote per key), stake (weighted by stake)")
+	// leaderRotation decides how the leader of each block is chosen
+	leaderRotation = flag.String("leader_rotation", "none", "how the leader of each block is chosen: none (changes only on view change), roundrobin, random (from block VRF/VDF)")
+	// pipelining lets the leader propose the next block while the current one is finalized
+	pipelining = flag.Bool("pipelining", false, "announce the next block while collecting the remaining commit signatures of the current one (all validators of a shard must agree)")
+	// adaptiveBlockPeriod adapts the block period and consensus timeouts within the limits of the network
+	adaptiveBlockPeriod = flag.Bool("adaptive_block_period", false, "adapt the block period to pending transactions and the consensus timeouts to round latency and view changes, overriding block_period")
+	// consensusTraceFile is where the consensus round traces are dumped for offline analysis
+	consensusTraceFile = flag.String("consensus_trace_file", "", "file the consensus round traces are written to as JSON when the node receives SIGUSR1 (disabled if empty)")
+
 	// metrics flag to collct meetrics or not, pushgateway ip and port for metrics
 	metricsFlag     = flag.Bool("metrics", false, "Collect and upload node metrics")
 	pushgatewayIP   = flag.String("pushgateway_ip", "grafana.harmony.one", "Metrics view ip")
@@ -274,6 +290,17 @@ func createGlobalConfig() *nodeconfig.ConfigType {
 	return nodeConfig
 }
 
+// newQuorumDecider returns the quorum decider of the -quorum_policy flag.
+func newQuorumDecider(stakeFinder quorum.StakeFinder) (quorum.Decider, error) {
+	switch *quorumPolicy {
+	case "count":
+		return quorum.NewCountDecider(), nil
+	case "stake":
+		return quorum.NewStakeWeightedDecider(stakeFinder), nil
+	}
+	return nil, fmt.Errorf("invalid quorum policy %#v", *quorumPolicy)
+}
+
 func setupConsensusAndNode(nodeConfig *nodeconfig.ConfigType) *node.Node {
 	// Consensus object.
 	// TODO: consensus object shouldn't start here
@@ -297,9 +324,36 @@ func setupConsensusAndNode(nodeConfig *nodeconfig.ConfigType) *node.Node {
 		currentConsensus.DisableViewChangeForTestingOnly()
 	}
 
+	// Stakes are read from the validator registry of the beacon chain, and
+	// from its snapshots at the start of each epoch for weighing votes, once
+	// the node has opened it below.
+	stakeInfoFinder := consensus.NewRegistryStakeInfoFinder()
+	currentConsensus.SetStakeInfoFinder(stakeInfoFinder)
+
+	quorumDecider, err := newQuorumDecider(stakeInfoFinder)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR %v", err)
+		os.Exit(1)
+	}
+	currentConsensus.SetQuorumDecider(quorumDecider)
+	// Seals and cross-shard commit signatures are checked against the same
+	// quorum policy consensus uses.
+	chain.Engine.SetQuorumDecider(currentConsensus.QuorumDecider())
+	rotation, err := consensus.ParseLeaderRotation(*leaderRotation)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR invalid leader rotation: %v", err)
+		os.Exit(1)
+	}
+	currentConsensus.SetLeaderRotation(rotation)
+	currentConsensus.SetPipelining(*pipelining)
+	if *adaptiveBlockPeriod {
+		currentConsensus.SetAdaptiveTiming(core.ShardingSchedule.BlockPeriodConfig())
+	}
+
 	// Current node.
 	chainDBFactory := &shardchain.LDBFactory{RootDir: nodeConfig.DBDir}
 	currentNode := node.New(nodeConfig.Host, currentConsensus, chainDBFactory, *isArchival)
+	stakeInfoFinder.SetBeaconChain(currentNode.Beaconchain())
 
 	switch {
 	case *networkType == nodeconfig.Localnet:
@@ -358,12 +412,13 @@ func setupConsensusAndNode(nodeConfig *nodeconfig.ConfigType) *node.Node {
 	// Setup block period for currentNode.
 	currentNode.BlockPeriod = time.Duration(*blockPeriod) * time.Second
 
-	// TODO: Disable drand. Currently drand isn't functioning but we want to compeletely turn it off for full protection.
-	// Enable it back after mainnet.
-	// dRand := drand.New(nodeConfig.Host, nodeConfig.ShardID, []p2p.Peer{}, nodeConfig.Leader, currentNode.ConfirmedBlockChannel, nodeConfig.ConsensusPriKey)
-	// currentNode.Consensus.RegisterPRndChannel(dRand.PRndChannel)
-	// currentNode.Consensus.RegisterRndChannel(dRand.RndChannel)
-	// currentNode.DRand = dRand
+	// The randomness beacon runs on the beacon chain, where it stores the
+	// randomness of each epoch.
+	if nodeConfig.ShardID == 0 {
+		dRand := drand.New(nodeConfig.Host, nodeConfig.ShardID, []p2p.Peer{}, nodeConfig.Leader, currentNode.ConfirmedBlockChannel, nodeConfig.ConsensusPriKey)
+		dRand.Chain = currentNode.Blockchain()
+		currentNode.DRand = dRand
+	}
 
 	// This needs to be executed after consensus and drand are setup
 	if err := currentNode.GetInitShardState(); err != nil {
@@ -382,6 +437,7 @@ func setupConsensusAndNode(nodeConfig *nodeconfig.ConfigType) *node.Node {
 	// Assign closure functions to the consensus object
 	currentConsensus.BlockVerifier = currentNode.VerifyNewBlock
 	currentConsensus.OnConsensusDone = currentNode.PostConsensusProcessing
+	currentConsensus.OnDoubleSign = currentNode.BroadcastDoubleSignEvidence
 	currentNode.State = node.NodeWaitToJoin
 
 	// update consensus information based on the blockchain
@@ -436,6 +492,11 @@ func main() {
 		core.ShardingSchedule = shardingconfig.NewFixedSchedule(devnetConfig)
 	}
 
+	// Commands after the flags work on the chain database offline.
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(flag.Args()))
+	}
+
 	initSetup()
 
 	// Set up manual call for garbage collection.
@@ -497,5 +558,38 @@ func main() {
 		go currentNode.CommitCommittee()
 	}
 
+	if *consensusTraceFile != "" {
+		dumpConsensusTraceOnSignal(currentNode.Consensus.Tracer, *consensusTraceFile)
+	}
+
 	currentNode.StartServer()
 }
+
+// dumpConsensusTraceOnSignal writes the consensus round traces to the given
+// file whenever the node receives SIGUSR1.
+func dumpConsensusTraceOnSignal(tracer *consensus.RoundTracer, file string) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1)
+	go func() {
+		for range signals {
+			if err := writeConsensusTrace(tracer, file); err != nil {
+				utils.Logger().Warn().Err(err).Str("file", file).Msg("cannot dump consensus trace")
+			} else {
+				utils.Logger().Info().Str("file", file).Msg("dumped consensus trace")
+			}
+		}
+	}()
+}
+
+// writeConsensusTrace writes all the consensus round traces kept to file.
+func writeConsensusTrace(tracer *consensus.RoundTracer, file string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := tracer.WriteJSON(f, 0); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
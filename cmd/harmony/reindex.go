@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/harmony-one/harmony/api/service/explorer"
+)
+
+// reindexExplorer rebuilds the explorer storage of the node -ip:-port from
+// its shard chain.  Without -from, it resumes after the last block a previous
+// reindex stored; -from 0 clears the storage first.
+func reindexExplorer(args []string) int {
+	reindexCommand := flag.NewFlagSet("explorer-reindex", flag.ExitOnError)
+	from := reindexCommand.Int64("from", -1, "height of the first block to reindex; 0 rebuilds the storage from scratch, negative (default) resumes the last reindex")
+	workers := reindexCommand.Int("workers", runtime.NumCPU(), "number of blocks to process concurrently")
+	if err := reindexCommand.Parse(args); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR cannot parse explorer-reindex flags: %v\n", err)
+		return 1
+	}
+	if reindexCommand.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "Usage: harmony [node flags] explorer-reindex [-from n] [-workers n]")
+		return 1
+	}
+
+	bc, chains, err := openShardChain()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR cannot open shard chain: %v\n", err)
+		return 1
+	}
+	defer chains.Close()
+	storage := explorer.GetStorageInstance(*ip, *port, *from == 0)
+	// ResetForGenesis may reopen the database.
+	defer func() { storage.GetDB().Close() }()
+	if err := storage.ResetForGenesis(*ip, *port, bc.Genesis().Hash().Hex()); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR cannot reset explorer storage: %v\n", err)
+		return 1
+	}
+
+	first := uint64(0)
+	if *from >= 0 {
+		first = uint64(*from)
+	} else if checkpoint, ok := storage.ReadCheckpoint(); ok {
+		first = checkpoint + 1
+	}
+	stored, err := storage.Reindex(bc, first, *workers)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR cannot reindex explorer storage after %d blocks: %v\n", stored, err)
+		return 1
+	}
+	fmt.Printf("Reindexed %d blocks of shard %d from block %d\n", stored, bc.ShardID(), first)
+	return 0
+}
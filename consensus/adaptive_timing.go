@@ -0,0 +1,191 @@
+package consensus
+
+import (
+	"sync"
+	"time"
+
+	shardingconfig "github.com/harmony-one/harmony/internal/configs/sharding"
+	"github.com/harmony-one/harmony/internal/utils"
+)
+
+// maxTimeoutBackoff is the number of view changes after which the phase
+// timeout stops doubling.
+const maxTimeoutBackoff = 6
+
+// AdaptiveTiming adapts the block period of the leader and the consensus
+// timeouts to the observed round latency, the pending transactions and the
+// view changes, within the limits of a BlockPeriodConfig.  A nil
+// AdaptiveTiming records nothing.
+type AdaptiveTiming struct {
+	mutex      sync.Mutex
+	config     shardingconfig.BlockPeriodConfig
+	latency    time.Duration // moving average of the round latency
+	roundNum   uint64        // block number of the round being timed
+	roundStart time.Time
+	backoff    uint // view changes since the last committed block
+	now        func() time.Time
+}
+
+// NewAdaptiveTiming returns an AdaptiveTiming within the given limits.
+func NewAdaptiveTiming(config shardingconfig.BlockPeriodConfig) *AdaptiveTiming {
+	if config.TimeoutLatencyFactor < 1 {
+		config.TimeoutLatencyFactor = 1
+	}
+	return &AdaptiveTiming{config: config, now: time.Now}
+}
+
+// RoundStarted records the announce of the block.
+func (t *AdaptiveTiming) RoundStarted(blockNum uint64) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.roundNum == blockNum && !t.roundStart.IsZero() {
+		// a re-announce after a view change does not restart the round
+		return
+	}
+	t.roundNum = blockNum
+	t.roundStart = t.now()
+}
+
+// RoundCommitted records the commit of the block, and folds the latency of
+// its round into the average.
+func (t *AdaptiveTiming) RoundCommitted(blockNum uint64) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.roundNum == blockNum && !t.roundStart.IsZero() {
+		latency := t.now().Sub(t.roundStart)
+		if t.latency == 0 {
+			t.latency = latency
+		} else {
+			// exponential moving average weighing the new round by 1/8
+			t.latency += (latency - t.latency) / 8
+		}
+	}
+	t.roundStart = time.Time{}
+	t.backoff = 0
+}
+
+// ViewChanged records a view change, which doubles the phase timeout until
+// the next block is committed.
+func (t *AdaptiveTiming) ViewChanged() {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.backoff < maxTimeoutBackoff {
+		t.backoff++
+	}
+}
+
+// Latency returns the average round latency, or 0 if no round was timed yet.
+func (t *AdaptiveTiming) Latency() time.Duration {
+	if t == nil {
+		return 0
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.latency
+}
+
+// PhaseTimeout returns the announce/prepare/commit timeout.  Until a round
+// is timed, it is the maximum.
+func (t *AdaptiveTiming) PhaseTimeout() time.Duration {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.latency == 0 {
+		return t.config.MaxPhaseTimeout
+	}
+	timeout := t.fromLatency(t.config.MinPhaseTimeout, t.config.MaxPhaseTimeout)
+	for i := uint(0); i < t.backoff && timeout < t.config.MaxPhaseTimeout; i++ {
+		timeout *= 2
+	}
+	if timeout > t.config.MaxPhaseTimeout {
+		timeout = t.config.MaxPhaseTimeout
+	}
+	return timeout
+}
+
+// ViewChangeTimeout returns the base view change timeout.  Until a round is
+// timed, it is the maximum.
+func (t *AdaptiveTiming) ViewChangeTimeout() time.Duration {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.latency == 0 {
+		return t.config.MaxViewChangeTimeout
+	}
+	return t.fromLatency(t.config.MinViewChangeTimeout, t.config.MaxViewChangeTimeout)
+}
+
+func (t *AdaptiveTiming) fromLatency(min, max time.Duration) time.Duration {
+	timeout := t.latency * time.Duration(t.config.TimeoutLatencyFactor)
+	if timeout < min {
+		return min
+	}
+	if timeout > max {
+		return max
+	}
+	return timeout
+}
+
+// BlockPeriod returns how long the leader waits after proposing a block
+// before proposing the next one, given the number of pending transactions.
+// It shrinks linearly from the maximum period with no pending transaction to
+// the minimum period with enough transactions to fill a block.
+func (t *AdaptiveTiming) BlockPeriod(pendingTxs int) time.Duration {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	min, max := t.config.MinBlockPeriod, t.config.MaxBlockPeriod
+	if pendingTxs >= t.config.FullBlockTxs || max <= min {
+		return min
+	}
+	if pendingTxs <= 0 {
+		return max
+	}
+	return max - (max-min)*time.Duration(pendingTxs)/time.Duration(t.config.FullBlockTxs)
+}
+
+// SetAdaptiveTiming makes the consensus timeouts adapt to the observed round
+// latency and back off after view changes, and the block period returned by
+// BlockPeriod adapt to the pending transactions, within the given limits.
+// It must be called before Start, and overrides SetTimeouts.
+func (consensus *Consensus) SetAdaptiveTiming(config *shardingconfig.BlockPeriodConfig) {
+	if config == nil {
+		consensus.timing = nil
+		return
+	}
+	consensus.timing = NewAdaptiveTiming(*config)
+	consensus.updateTimeouts()
+}
+
+// BlockPeriod returns how long the leader waits after proposing a block
+// before proposing the next one, given the number of pending transactions.
+// Without adaptive timing, it is the given fixed period.
+func (consensus *Consensus) BlockPeriod(pendingTxs int, fixed time.Duration) time.Duration {
+	if consensus.timing == nil {
+		return fixed
+	}
+	return consensus.timing.BlockPeriod(pendingTxs)
+}
+
+// updateTimeouts applies the adaptive timeouts to the timers started from now
+// on.
+func (consensus *Consensus) updateTimeouts() {
+	if consensus.timing == nil {
+		return
+	}
+	phase := consensus.timing.PhaseTimeout()
+	viewChange := consensus.timing.ViewChangeTimeout()
+	consensus.consensusTimeout[timeoutConsensus].SetDuration(phase)
+	consensus.viewChangeTimeout = viewChange
+	utils.Logger().Debug().
+		Dur("latency", consensus.timing.Latency()).
+		Dur("phaseTimeout", phase).
+		Dur("viewChangeTimeout", viewChange).
+		Msg("[AdaptiveTiming] Updated consensus timeouts")
+}
@@ -0,0 +1,97 @@
+package consensus
+
+import (
+	"testing"
+	"time"
+
+	shardingconfig "github.com/harmony-one/harmony/internal/configs/sharding"
+)
+
+func newTestTiming() (*AdaptiveTiming, *time.Time) {
+	timing := NewAdaptiveTiming(shardingconfig.BlockPeriodConfig{
+		MinBlockPeriod:       2 * time.Second,
+		MaxBlockPeriod:       10 * time.Second,
+		FullBlockTxs:         100,
+		MinPhaseTimeout:      5 * time.Second,
+		MaxPhaseTimeout:      60 * time.Second,
+		MinViewChangeTimeout: 10 * time.Second,
+		MaxViewChangeTimeout: 30 * time.Second,
+		TimeoutLatencyFactor: 4,
+	})
+	now := time.Unix(1561734000, 0)
+	timing.now = func() time.Time { return now }
+	return timing, &now
+}
+
+func TestAdaptiveTimingBlockPeriod(t *testing.T) {
+	timing, _ := newTestTiming()
+	tests := []struct {
+		pending int
+		want    time.Duration
+	}{
+		{0, 10 * time.Second},
+		{25, 8 * time.Second},
+		{50, 6 * time.Second},
+		{100, 2 * time.Second},
+		{1000, 2 * time.Second},
+	}
+	for _, test := range tests {
+		if got := timing.BlockPeriod(test.pending); got != test.want {
+			t.Errorf("block period with %d pending txs is %v, want %v", test.pending, got, test.want)
+		}
+	}
+}
+
+func TestAdaptiveTimingTimeouts(t *testing.T) {
+	timing, now := newTestTiming()
+	if timing.PhaseTimeout() != 60*time.Second || timing.ViewChangeTimeout() != 30*time.Second {
+		t.Errorf("untimed timeouts are %v and %v, want the maximums", timing.PhaseTimeout(), timing.ViewChangeTimeout())
+	}
+
+	timing.RoundStarted(1)
+	*now = now.Add(2 * time.Second)
+	timing.RoundCommitted(1)
+	if timing.Latency() != 2*time.Second {
+		t.Errorf("latency is %v, want 2s", timing.Latency())
+	}
+	if timing.PhaseTimeout() != 8*time.Second || timing.ViewChangeTimeout() != 10*time.Second {
+		t.Errorf("timeouts are %v and %v, want 8s and 10s", timing.PhaseTimeout(), timing.ViewChangeTimeout())
+	}
+
+	// the latency is averaged over the rounds
+	timing.RoundStarted(2)
+	*now = now.Add(10 * time.Second)
+	timing.RoundCommitted(2)
+	if timing.Latency() != 3*time.Second {
+		t.Errorf("latency is %v, want 3s", timing.Latency())
+	}
+
+	// view changes double the phase timeout up to the maximum
+	timing.RoundStarted(3)
+	timing.ViewChanged()
+	if timing.PhaseTimeout() != 24*time.Second {
+		t.Errorf("phase timeout after a view change is %v, want 24s", timing.PhaseTimeout())
+	}
+	timing.ViewChanged()
+	timing.ViewChanged()
+	if timing.PhaseTimeout() != 60*time.Second {
+		t.Errorf("phase timeout after three view changes is %v, want 60s", timing.PhaseTimeout())
+	}
+	// a re-announce in the new view does not restart the round
+	*now = now.Add(3 * time.Second)
+	timing.RoundStarted(3)
+	*now = now.Add(3 * time.Second)
+	timing.RoundCommitted(3)
+	if timing.Latency() != 3*time.Second+(3*time.Second)/8 {
+		t.Errorf("latency is %v, want 3.375s", timing.Latency())
+	}
+	if timing.PhaseTimeout() != 13500*time.Millisecond {
+		t.Errorf("phase timeout after a commit is %v, want 13.5s", timing.PhaseTimeout())
+	}
+
+	// a block committed without an announce, e.g. by syncing, is not timed
+	timing.RoundCommitted(4)
+	if timing.Latency() != 3*time.Second+(3*time.Second)/8 {
+		t.Errorf("latency changed to %v by an untimed round", timing.Latency())
+	}
+}
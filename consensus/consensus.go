@@ -11,10 +11,12 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/harmony-one/bls/ffi/go/bls"
 
+	"github.com/harmony-one/harmony/consensus/quorum"
 	"github.com/harmony-one/harmony/contracts/structs"
 	"github.com/harmony-one/harmony/core"
 	"github.com/harmony-one/harmony/core/types"
 	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/crypto/vdf"
 	"github.com/harmony-one/harmony/internal/ctxerror"
 	"github.com/harmony-one/harmony/internal/genesis"
 	"github.com/harmony-one/harmony/internal/memprofiling"
@@ -24,8 +26,8 @@ import (
 )
 
 const (
-	vdFAndProofSize = 516 // size of VDF and Proof
-	vdfAndSeedSize  = 548 // size of VDF/Proof and Seed
+	vdFAndProofSize = vdf.Size      // size of VDF and Proof
+	vdfAndSeedSize  = vdf.Size + 32 // size of VDF/Proof and Seed
 )
 
 // Consensus is the main struct with all states and data related to consensus process.
@@ -53,6 +55,8 @@ type Consensus struct {
 
 	// 2 types of timeouts: normal and viewchange
 	consensusTimeout map[TimeoutType]*utils.Timeout
+	// timeout of view change v+1; view change v+n times out after n times this
+	viewChangeTimeout time.Duration
 
 	// Commits collected from validators.
 	prepareSigs          map[string]*bls.Sign // key is the bls public key
@@ -99,9 +103,6 @@ type Consensus struct {
 	// the publickey of leader
 	LeaderPubKey *bls.PublicKey
 
-	// number of publickeys of previous epoch
-	numPrevPubKeys int
-
 	viewID uint64
 
 	// Blockhash - 32 byte
@@ -130,6 +131,17 @@ type Consensus struct {
 	OnConsensusDone func(*types.Block, []byte)
 	// The verifier func passed from Node object
 	BlockVerifier func(*types.Block) error
+	// The double sign reporting func passed from Node object
+	// Called when a new double sign evidence is detected
+	OnDoubleSign func(*DoubleSignEvidence)
+
+	// Double sign evidence detected locally or received from other nodes
+	EvidencePool *EvidencePool
+
+	// Traces of the latest consensus rounds
+	Tracer *RoundTracer
+	// adapts the block period and timeouts; nil keeps them fixed
+	timing *AdaptiveTiming
 
 	// verified block to state sync broadcast
 	VerifiedNewBlock chan *types.Block
@@ -153,6 +165,24 @@ type Consensus struct {
 	// Staking information finder
 	stakeInfoFinder StakeInfoFinder
 
+	// Decides whether collected signatures reach quorum
+	quorumDecider quorum.Decider
+
+	// How the leader of each block is chosen
+	leaderRotation LeaderRotation
+
+	// Whether the leader proposes the next block before the current one is finalized
+	pipelining bool
+	// Block the leader builds the next block on before the block is finalized
+	pipelineParent *PipelinedParent
+	pipelineMutex  sync.Mutex
+	// Number of the block whose commit quorum made the leader propose the next block
+	pipelineSignalled uint64
+	// Next block announced by the leader before the current one is finalized
+	pipelinedBlock *types.Block
+	// Announce of the next block a validator received before committing the current one
+	pendingAnnounce []byte
+
 	// Used to convey to the consensus main loop that block syncing has finished.
 	syncReadyChan chan struct{}
 	// Used to convey to the consensus main loop that node is out of sync
@@ -171,6 +201,17 @@ func (consensus *Consensus) SetCommitDelay(delay time.Duration) {
 	consensus.delayCommit = delay
 }
 
+// SetTimeouts overrides the announce/prepare/commit phase timeout, the base
+// view change timeout and the bootstrap timeout.  It must be called before
+// Start; it is meant for local and simulated networks where waiting minutes
+// for a view change is impractical.
+func (consensus *Consensus) SetTimeouts(phase, viewChange, bootstrap time.Duration) {
+	consensus.consensusTimeout[timeoutConsensus].SetDuration(phase)
+	consensus.consensusTimeout[timeoutViewChange].SetDuration(viewChange)
+	consensus.consensusTimeout[timeoutBootstrap].SetDuration(bootstrap)
+	consensus.viewChangeTimeout = viewChange
+}
+
 // StakeInfoFinder returns the stake information finder instance this
 // consensus uses, e.g. for block reward distribution.
 func (consensus *Consensus) StakeInfoFinder() StakeInfoFinder {
@@ -183,6 +224,18 @@ func (consensus *Consensus) SetStakeInfoFinder(stakeInfoFinder StakeInfoFinder)
 	consensus.stakeInfoFinder = stakeInfoFinder
 }
 
+// QuorumDecider returns the quorum policy this consensus uses to decide
+// whether enough validators signed.
+func (consensus *Consensus) QuorumDecider() quorum.Decider {
+	return consensus.quorumDecider
+}
+
+// SetQuorumDecider sets the quorum policy this consensus uses to decide
+// whether enough validators signed.
+func (consensus *Consensus) SetQuorumDecider(quorumDecider quorum.Decider) {
+	consensus.quorumDecider = quorumDecider
+}
+
 // DisableViewChangeForTestingOnly makes the receiver not propose view
 // changes when it should, e.g. leader timeout.
 //
@@ -210,16 +263,28 @@ func (consensus *Consensus) WaitForSyncing() {
 }
 
 // Quorum returns the consensus quorum of the current committee (2f+1).
+// It is the number of keys needed under the count-based policy; use
+// IsQuorumAchieved to check a set of signers against the configured policy.
 func (consensus *Consensus) Quorum() int {
 	return len(consensus.PublicKeys)*2/3 + 1
 }
 
-// PreviousQuorum returns the quorum size of previous epoch
-func (consensus *Consensus) PreviousQuorum() int {
-	return consensus.numPrevPubKeys*2/3 + 1
+// IsQuorumAchieved returns whether the signers in the mask reach quorum on a
+// block of the current epoch according to the configured quorum policy.
+func (consensus *Consensus) IsQuorumAchieved(mask *bls_cosi.Mask) bool {
+	return consensus.quorumDecider.IsQuorumAchieved(new(big.Int).SetUint64(consensus.epoch), mask)
 }
 
-// VdfSeedSize returns the number of VRFs for VDF computation
+// IsRewardThresholdAchieved returns whether the signers in the mask reach the
+// reward threshold on a block of the current epoch according to the
+// configured quorum policy.
+func (consensus *Consensus) IsRewardThresholdAchieved(mask *bls_cosi.Mask) bool {
+	return consensus.quorumDecider.IsRewardThresholdAchieved(new(big.Int).SetUint64(consensus.epoch), mask)
+}
+
+// VdfSeedSize returns the number of VRFs for VDF computation.  It counts the
+// blocks whose VRFs seed the VDF, not votes, so it does not depend on the
+// quorum policy.
 func (consensus *Consensus) VdfSeedSize() int {
 	return len(consensus.PublicKeys) * 2 / 3
 }
@@ -258,10 +323,14 @@ func New(host p2p.Host, ShardID uint32, leader p2p.Peer, blsPriKey *bls.SecretKe
 
 	// pbft related
 	consensus.PbftLog = NewPbftLog()
+	consensus.EvidencePool = NewEvidencePool()
+	consensus.Tracer = NewRoundTracer(DefaultTraceSize)
 	consensus.phase = Announce
 	consensus.mode = PbftMode{mode: Normal}
 	// pbft timeout
 	consensus.consensusTimeout = createTimeout()
+	consensus.viewChangeTimeout = viewChangeDuration
+	consensus.quorumDecider = quorum.NewCountDecider()
 
 	consensus.prepareSigs = map[string]*bls.Sign{}
 	consensus.commitSigs = map[string]*bls.Sign{}
@@ -370,3 +439,128 @@ func NewGenesisStakeInfoFinder() (*GenesisStakeInfoFinder, error) {
 	}
 	return f, nil
 }
+
+// RegistryStakeInfoFinder is a stake info finder over the validator registry
+// in the state of the current beacon block, as core.ReadStakeInfo reads it.
+// It reads the registry again whenever the beacon chain advances.
+//
+// It also finds the stakes of the snapshots the beacon chain takes of the
+// registry at the start of each epoch, which weigh the votes on the blocks of
+// the epoch.
+type RegistryStakeInfoFinder struct {
+	mu          sync.Mutex
+	beaconChain *core.BlockChain
+	blockHash   common.Hash // of the beacon block the maps are as of
+	byNodeKey   map[shard.BlsPublicKey][]*structs.StakeInfo
+	byAccount   map[common.Address][]*structs.StakeInfo
+	epoch       *big.Int // of the snapshot epochStakes is of
+	epochStakes map[shard.BlsPublicKey]*big.Int
+}
+
+// NewRegistryStakeInfoFinder returns a stake info finder which finds nothing
+// until SetBeaconChain gives it the beacon chain.
+func NewRegistryStakeInfoFinder() *RegistryStakeInfoFinder {
+	return &RegistryStakeInfoFinder{}
+}
+
+// SetBeaconChain sets the beacon chain whose registry the finder reads.
+func (f *RegistryStakeInfoFinder) SetBeaconChain(beaconChain *core.BlockChain) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.beaconChain = beaconChain
+	f.blockHash = common.Hash{}
+	f.byNodeKey, f.byAccount = nil, nil
+	f.epoch, f.epochStakes = nil, nil
+}
+
+// FindEpochStakes returns the amounts staked on the node keys in the
+// registry of the beacon chain as of the start of the given epoch.  It fails
+// until SetBeaconChain gives it the beacon chain, or if the beacon chain has
+// no snapshot of the epoch.
+func (f *RegistryStakeInfoFinder) FindEpochStakes(
+	epoch *big.Int,
+) (map[shard.BlsPublicKey]*big.Int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.epoch != nil && f.epoch.Cmp(epoch) == 0 {
+		return f.epochStakes, nil
+	}
+	if f.beaconChain == nil {
+		return nil, ctxerror.New("no beacon chain to find the stakes of", "epoch", epoch)
+	}
+	snapshot, err := f.beaconChain.ReadEpochStakes(epoch)
+	if err != nil {
+		return nil, ctxerror.New("cannot read epoch stakes", "epoch", epoch).WithCause(err)
+	}
+	stakes := make(map[shard.BlsPublicKey]*big.Int)
+	for _, stake := range snapshot.Stakes {
+		amount, ok := stakes[stake.BlsPublicKey]
+		if !ok {
+			amount = big.NewInt(0)
+			stakes[stake.BlsPublicKey] = amount
+		}
+		amount.Add(amount, stake.Amount)
+	}
+	f.epoch, f.epochStakes = new(big.Int).Set(epoch), stakes
+	return stakes, nil
+}
+
+// update reads the registry of the current beacon block, if it has not
+// yet.  The caller must hold f.mu.
+func (f *RegistryStakeInfoFinder) update() {
+	if f.beaconChain == nil {
+		return
+	}
+	block := f.beaconChain.CurrentBlock()
+	if block.Hash() == f.blockHash {
+		return
+	}
+	db, err := f.beaconChain.StateAt(block.Root())
+	if err != nil {
+		utils.Logger().Warn().Err(err).
+			Uint64("blockNum", block.NumberU64()).
+			Msg("[RegistryStakeInfoFinder] cannot open beacon chain state")
+		return
+	}
+	stakeInfo, err := core.ReadStakeInfo(db)
+	if err != nil {
+		utils.Logger().Warn().Err(err).
+			Uint64("blockNum", block.NumberU64()).
+			Msg("[RegistryStakeInfoFinder] cannot read validator registry")
+		return
+	}
+	f.byNodeKey = make(map[shard.BlsPublicKey][]*structs.StakeInfo)
+	f.byAccount = make(map[common.Address][]*structs.StakeInfo)
+	for _, info := range stakeInfo {
+		f.byNodeKey[info.BlsPublicKey] = append(f.byNodeKey[info.BlsPublicKey], info)
+		f.byAccount[info.Account] = append(f.byAccount[info.Account], info)
+	}
+	f.blockHash = block.Hash()
+}
+
+// FindStakeInfoByNodeKey returns the stake of the validator with the given
+// node key, as a single-item StakeInfo list, or nil if it has none.
+func (f *RegistryStakeInfoFinder) FindStakeInfoByNodeKey(
+	key *bls.PublicKey,
+) []*structs.StakeInfo {
+	var pk shard.BlsPublicKey
+	if err := pk.FromLibBLSPublicKey(key); err != nil {
+		utils.Logger().Warn().Err(err).Msg("cannot convert BLS public key")
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.update()
+	return append([]*structs.StakeInfo(nil), f.byNodeKey[pk]...)
+}
+
+// FindStakeInfoByAccount returns the stake of the validator of the given
+// address, as a single-item StakeInfo list, or nil if it has none.
+func (f *RegistryStakeInfoFinder) FindStakeInfoByAccount(
+	addr common.Address,
+) []*structs.StakeInfo {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.update()
+	return append([]*structs.StakeInfo(nil), f.byAccount[addr]...)
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/harmony-one/bls/ffi/go/bls"
 	"github.com/harmony-one/harmony/api/proto"
 	msg_pb "github.com/harmony-one/harmony/api/proto/message"
+	"github.com/harmony-one/harmony/core/types"
 	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
 	"github.com/harmony-one/harmony/internal/utils"
 )
@@ -30,6 +31,31 @@ func (consensus *Consensus) constructAnnounceMessage() []byte {
 	return proto.ConstructConsensusMessage(marshaledMessage)
 }
 
+// Constructs the announce message of the block after the current one, for
+// pipelining
+func (consensus *Consensus) constructPipelinedAnnounceMessage(block *types.Block, encodedHeader []byte) []byte {
+	message := &msg_pb.Message{
+		ServiceType: msg_pb.ServiceType_CONSENSUS,
+		Type:        msg_pb.MessageType_ANNOUNCE,
+		Request: &msg_pb.Message_Consensus{
+			Consensus: &msg_pb.ConsensusRequest{},
+		},
+	}
+	consensusMsg := message.GetConsensus()
+	consensus.populateMessageFields(consensusMsg)
+	blockHash := block.Hash()
+	consensusMsg.ViewId = consensus.viewID + 1
+	consensusMsg.BlockNum = block.NumberU64()
+	consensusMsg.BlockHash = blockHash[:]
+	consensusMsg.Payload = encodedHeader
+
+	marshaledMessage, err := consensus.signAndMarshalConsensusMessage(message)
+	if err != nil {
+		utils.Logger().Error().Err(err).Msg("Failed to sign and marshal the pipelined Announce message")
+	}
+	return proto.ConstructConsensusMessage(marshaledMessage)
+}
+
 // Construct the prepared message, returning prepared message in bytes.
 func (consensus *Consensus) constructPreparedMessage() ([]byte, *bls.Sign) {
 	message := &msg_pb.Message{
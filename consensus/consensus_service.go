@@ -22,6 +22,7 @@ import (
 
 	msg_pb "github.com/harmony-one/harmony/api/proto/message"
 	consensus_engine "github.com/harmony-one/harmony/consensus/engine"
+	"github.com/harmony-one/harmony/consensus/quorum"
 	"github.com/harmony-one/harmony/core/types"
 	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
 	"github.com/harmony-one/harmony/internal/ctxerror"
@@ -152,7 +153,7 @@ func (consensus *Consensus) UpdatePublicKeys(pubKeys []*bls.PublicKey) int {
 
 // NewFaker returns a faker consensus.
 func NewFaker() *Consensus {
-	return &Consensus{}
+	return &Consensus{quorumDecider: quorum.NewCountDecider()}
 }
 
 // Sign on the hash of the message
@@ -254,6 +255,7 @@ func (consensus *Consensus) ResetState() {
 	consensus.commitBitmap = commitBitmap
 	consensus.aggregatedPrepareSig = nil
 	consensus.aggregatedCommitSig = nil
+	consensus.setPipelinedParent(nil)
 }
 
 // Returns a string representation of this consensus
@@ -506,7 +508,6 @@ func (consensus *Consensus) UpdateConsensusInformation() Mode {
 
 	epoch := header.Epoch()
 	curPubKeys := core.GetPublicKeys(epoch, header.ShardID())
-	consensus.numPrevPubKeys = len(curPubKeys)
 
 	consensus.getLogger().Info().Msg("[UpdateConsensusInformation] Updating.....")
 
@@ -533,7 +534,13 @@ func (consensus *Consensus) UpdateConsensusInformation() Mode {
 	consensus.UpdatePublicKeys(pubKeys)
 
 	// take care of possible leader change during the epoch
-	if !core.IsEpochLastBlockByHeader(header) && header.Number().Uint64() != 0 {
+	if leaderPubKey := consensus.scheduledLeader(header.Number().Uint64()+1, header); leaderPubKey != nil {
+		consensus.getLogger().Debug().
+			Str("leaderPubKey", leaderPubKey.SerializeToHexStr()).
+			Str("leaderRotation", consensus.leaderRotation.String()).
+			Msg("[SYNC] LeaderPubKey Updated Based on Leader Rotation")
+		consensus.LeaderPubKey = leaderPubKey
+	} else if !core.IsEpochLastBlockByHeader(header) && header.Number().Uint64() != 0 {
 		leaderPubKey, err := consensus.getLeaderPubKeyFromCoinbase(header)
 		if err != nil || leaderPubKey == nil {
 			consensus.getLogger().Debug().Err(err).Msg("[SYNC] Unable to get leaderPubKey from coinbase")
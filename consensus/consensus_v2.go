@@ -10,13 +10,13 @@ import (
 	"github.com/ethereum/go-ethereum/rlp"
 	protobuf "github.com/golang/protobuf/proto"
 	"github.com/harmony-one/bls/ffi/go/bls"
-	"github.com/harmony-one/vdf/src/vdf_go"
 
 	"github.com/harmony-one/harmony/api/proto"
 	msg_pb "github.com/harmony-one/harmony/api/proto/message"
 	"github.com/harmony-one/harmony/block"
 	"github.com/harmony-one/harmony/core"
 	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/crypto/vdf"
 	vrf_bls "github.com/harmony-one/harmony/crypto/vrf/bls"
 	"github.com/harmony-one/harmony/internal/chain"
 	"github.com/harmony-one/harmony/internal/ctxerror"
@@ -122,6 +122,8 @@ func (consensus *Consensus) announce(block *types.Block) {
 		Uint64("MsgBlockNum", pbftMsg.BlockNum).
 		Msg("[Announce] Added Announce message in pbftLog")
 	consensus.PbftLog.AddBlock(block)
+	consensus.Tracer.Announce(consensus.blockNum, consensus.viewID, blockHash, consensus.PubKey.SerializeToHexStr())
+	consensus.timing.RoundStarted(consensus.blockNum)
 
 	// Leader sign the block hash itself
 	consensus.prepareSigs[consensus.PubKey.SerializeToHexStr()] = consensus.priKey.SignHash(consensus.blockHash[:])
@@ -202,6 +204,9 @@ func (consensus *Consensus) onAnnounce(msg *msg_pb.Message) {
 			Msg("[OnAnnounce] BlockNum does not match")
 		return
 	}
+	if consensus.holdAnnounce(msg, recvMsg) {
+		return
+	}
 	if consensus.mode.Mode() == Normal {
 		if err = chain.Engine.VerifyHeader(consensus.ChainReader, header, true); err != nil {
 			utils.Logger().Warn().
@@ -230,9 +235,10 @@ func (consensus *Consensus) onAnnounce(msg *msg_pb.Message) {
 		}
 	}
 
+	evidence := consensus.checkDoubleSign(msg, recvMsg)
 	logMsgs := consensus.PbftLog.GetMessagesByTypeSeqView(msg_pb.MessageType_ANNOUNCE, recvMsg.BlockNum, recvMsg.ViewID)
 	if len(logMsgs) > 0 {
-		if logMsgs[0].BlockHash != recvMsg.BlockHash {
+		if evidence != nil || logMsgs[0].BlockHash != recvMsg.BlockHash {
 			utils.Logger().Debug().
 				Str("leaderKey", consensus.LeaderPubKey.SerializeToHexStr()).
 				Msg("[OnAnnounce] Leader is malicious")
@@ -249,6 +255,8 @@ func (consensus *Consensus) onAnnounce(msg *msg_pb.Message) {
 		Uint64("MsgBlockNum", recvMsg.BlockNum).
 		Msg("[OnAnnounce] Announce message Added")
 	consensus.PbftLog.AddMessage(recvMsg)
+	consensus.Tracer.Announce(recvMsg.BlockNum, recvMsg.ViewID, recvMsg.BlockHash, senderKey.SerializeToHexStr())
+	consensus.timing.RoundStarted(recvMsg.BlockNum)
 
 	consensus.mutex.Lock()
 	defer consensus.mutex.Unlock()
@@ -316,6 +324,13 @@ func (consensus *Consensus) onPrepare(msg *msg_pb.Message) {
 		utils.Logger().Error().Err(err).Msg("[OnPrepare] Unparseable validator message")
 		return
 	}
+	if consensus.checkDoubleSign(msg, recvMsg) != nil {
+		utils.Logger().Warn().
+			Str("validatorPubKey", recvMsg.SenderPubkey.SerializeToHexStr()).
+			Msg("[OnPrepare] Validator signed conflicting prepare messages")
+		return
+	}
+	consensus.logVote(recvMsg)
 
 	if recvMsg.ViewID != consensus.viewID || recvMsg.BlockNum != consensus.blockNum {
 		utils.Logger().Debug().
@@ -344,7 +359,7 @@ func (consensus *Consensus) onPrepare(msg *msg_pb.Message) {
 	consensus.mutex.Lock()
 	defer consensus.mutex.Unlock()
 	logger := utils.Logger().With().Str("validatorPubKey", validatorPubKey).Logger()
-	if len(prepareSigs) >= consensus.Quorum() {
+	if consensus.IsQuorumAchieved(prepareBitmap) {
 		// already have enough signatures
 		logger.Debug().Msg("[OnPrepare] Received Additional Prepare Message")
 		return
@@ -376,9 +391,12 @@ func (consensus *Consensus) onPrepare(msg *msg_pb.Message) {
 		utils.Logger().Warn().Err(err).Msg("[OnPrepare] prepareBitmap.SetKey failed")
 		return
 	}
+	consensus.Tracer.Vote(recvMsg.BlockNum, TracePrepare, validatorPubKey)
 
-	if len(prepareSigs) >= consensus.Quorum() {
+	if consensus.IsQuorumAchieved(prepareBitmap) {
 		logger.Debug().Msg("[OnPrepare] Received Enough Prepare Signatures")
+		consensus.Tracer.Event(consensus.blockNum, TracePrepared)
+		consensus.Tracer.Missing(consensus.blockNum, TracePrepare, prepareBitmap)
 		// Construct and broadcast prepared message
 		msgToSend, aggSig := consensus.constructPreparedMessage()
 		consensus.aggregatedPrepareSig = aggSig
@@ -466,10 +484,10 @@ func (consensus *Consensus) onPrepared(msg *msg_pb.Message) {
 		utils.Logger().Error().Err(err).Msg("ReadSignatureBitmapPayload failed!!")
 		return
 	}
-	if count := utils.CountOneBits(mask.Bitmap); count < consensus.Quorum() {
+	if !consensus.IsQuorumAchieved(mask) {
 		utils.Logger().Debug().
 			Int("Need", consensus.Quorum()).
-			Int("Got", count).
+			Int("Got", utils.CountOneBits(mask.Bitmap)).
 			Msg("Not enough signatures in the Prepared msg")
 		return
 	}
@@ -529,6 +547,8 @@ func (consensus *Consensus) onPrepared(msg *msg_pb.Message) {
 	consensus.PbftLog.AddBlock(&blockObj)
 	recvMsg.Block = []byte{} // save memory space
 	consensus.PbftLog.AddMessage(recvMsg)
+	consensus.Tracer.Event(recvMsg.BlockNum, TracePrepared)
+	consensus.Tracer.Missing(recvMsg.BlockNum, TracePrepare, mask)
 	utils.Logger().Debug().
 		Uint64("MsgViewID", recvMsg.ViewID).
 		Uint64("MsgBlockNum", recvMsg.BlockNum).
@@ -627,6 +647,13 @@ func (consensus *Consensus) onCommit(msg *msg_pb.Message) {
 		utils.Logger().Debug().Err(err).Msg("[OnCommit] Parse pbft message failed")
 		return
 	}
+	if consensus.checkDoubleSign(msg, recvMsg) != nil {
+		utils.Logger().Warn().
+			Str("validatorPubKey", recvMsg.SenderPubkey.SerializeToHexStr()).
+			Msg("[OnCommit] Validator signed conflicting commit messages")
+		return
+	}
+	consensus.logVote(recvMsg)
 
 	if recvMsg.ViewID != consensus.viewID || recvMsg.BlockNum != consensus.blockNum {
 		utils.Logger().Debug().
@@ -678,7 +705,14 @@ func (consensus *Consensus) onCommit(msg *msg_pb.Message) {
 		return
 	}
 
-	quorumWasMet := len(commitSigs) >= consensus.Quorum()
+	// the next block is already sealed with the commits of the quorum, which
+	// the committed message must carry too
+	if consensus.commitsFrozen() {
+		logger.Debug().Msg("[OnCommit] Commit arrived after the pipelined quorum, not counted")
+		return
+	}
+
+	quorumWasMet := consensus.IsQuorumAchieved(commitBitmap)
 
 	// Verify the signature on commitPayload is correct
 	var sign bls.Sign
@@ -704,12 +738,14 @@ func (consensus *Consensus) onCommit(msg *msg_pb.Message) {
 		utils.Logger().Warn().Err(err).Msg("[OnCommit] commitBitmap.SetKey failed")
 		return
 	}
+	consensus.Tracer.Vote(recvMsg.BlockNum, TraceCommit, validatorPubKey)
 
-	quorumIsMet := len(commitSigs) >= consensus.Quorum()
-	rewardThresholdIsMet := len(commitSigs) >= consensus.RewardThreshold()
+	quorumIsMet := consensus.IsQuorumAchieved(commitBitmap)
+	rewardThresholdIsMet := consensus.IsRewardThresholdAchieved(commitBitmap)
 
 	if !quorumWasMet && quorumIsMet {
 		logger.Info().Msg("[OnCommit] 2/3 Enough commits received")
+		consensus.Tracer.Event(recvMsg.BlockNum, TraceCommitted)
 		go func(viewID uint64) {
 			time.Sleep(2 * time.Second)
 			logger.Debug().Msg("[OnCommit] Commit Grace Period Ended")
@@ -717,6 +753,7 @@ func (consensus *Consensus) onCommit(msg *msg_pb.Message) {
 		}(consensus.viewID)
 
 		consensus.msgSender.StopRetry(msg_pb.MessageType_PREPARED)
+		consensus.startPipeline()
 	}
 
 	if rewardThresholdIsMet {
@@ -732,6 +769,8 @@ func (consensus *Consensus) finalizeCommits() {
 
 	beforeCatchupNum := consensus.blockNum
 	//beforeCatchupViewID := consensus.viewID
+	consensus.Tracer.Event(beforeCatchupNum, TraceFinalized)
+	consensus.Tracer.Missing(beforeCatchupNum, TraceCommit, consensus.commitBitmap)
 
 	// Construct committed message
 	msgToSend, aggSig := consensus.constructCommittedMessage()
@@ -801,7 +840,11 @@ func (consensus *Consensus) finalizeCommits() {
 	utils.GetLogInstance().Info("HOORAY!!!!!!! CONSENSUS REACHED!!!!!!!", "BlockNum", block.NumberU64())
 
 	// Send signal to Node so the new block can be added and new round of consensus can be triggered
-	consensus.ReadySignal <- struct{}{}
+	// With leader rotation, the next leader is signaled in onCommitted instead
+	// With pipelining, the node may have been signaled at the commit quorum
+	if consensus.IsLeader() && !consensus.continuePipeline(beforeCatchupNum) {
+		consensus.ReadySignal <- struct{}{}
+	}
 }
 
 func (consensus *Consensus) onCommitted(msg *msg_pb.Message) {
@@ -846,10 +889,10 @@ func (consensus *Consensus) onCommitted(msg *msg_pb.Message) {
 	}
 
 	// check has 2f+1 signatures
-	if count := utils.CountOneBits(mask.Bitmap); count < consensus.Quorum() {
+	if !consensus.IsQuorumAchieved(mask) {
 		utils.Logger().Warn().
 			Int("need", consensus.Quorum()).
-			Int("got", count).
+			Int("got", utils.CountOneBits(mask.Bitmap)).
 			Msg("[OnCommitted] Not enough signature in committed msg")
 		return
 	}
@@ -866,6 +909,8 @@ func (consensus *Consensus) onCommitted(msg *msg_pb.Message) {
 
 	consensus.PbftLog.AddMessage(recvMsg)
 	consensus.ChainReader.WriteLastCommits(recvMsg.Payload)
+	consensus.Tracer.Event(recvMsg.BlockNum, TraceCommitted)
+	consensus.Tracer.Missing(recvMsg.BlockNum, TraceCommit, mask)
 	utils.Logger().Debug().
 		Uint64("MsgViewID", recvMsg.ViewID).
 		Uint64("MsgBlockNum", recvMsg.BlockNum).
@@ -897,11 +942,20 @@ func (consensus *Consensus) onCommitted(msg *msg_pb.Message) {
 	//		return
 	//	}
 
+	beforeCatchupNum := consensus.blockNum
 	consensus.tryCatchup()
 	if consensus.mode.Mode() == ViewChanging {
 		utils.Logger().Debug().Msg("[OnCommitted] Still in ViewChanging mode, Exiting!!")
 		return
 	}
+	if consensus.blockNum > beforeCatchupNum && consensus.leaderRotation != NoRotation && consensus.IsLeader() {
+		utils.Logger().Info().
+			Uint64("blockNum", consensus.blockNum).
+			Msg("[OnCommitted] Scheduled as the leader of the next block")
+		go func() {
+			consensus.ReadySignal <- struct{}{}
+		}()
+	}
 
 	if consensus.consensusTimeout[timeoutBootstrap].IsActive() {
 		consensus.consensusTimeout[timeoutBootstrap].Stop()
@@ -988,6 +1042,10 @@ func (consensus *Consensus) tryCatchup() {
 
 		utils.Logger().Info().Msg("[TryCatchup] Adding block to chain")
 		consensus.OnConsensusDone(block, msgs[0].Payload)
+		consensus.Tracer.Finish(block.NumberU64())
+		consensus.timing.RoundCommitted(block.NumberU64())
+		consensus.updateTimeouts()
+		consensus.rotateLeader()
 		consensus.ResetState()
 
 		select {
@@ -1007,6 +1065,7 @@ func (consensus *Consensus) tryCatchup() {
 			Uint64("To", consensus.blockNum).
 			Msg("[TryCatchup] Caught up!")
 		consensus.switchPhase(Announce, true)
+		consensus.replayAnnounce()
 	}
 	// catup up and skip from view change trap
 	if currentBlockNum < consensus.blockNum && consensus.mode.Mode() == ViewChanging {
@@ -1020,6 +1079,10 @@ func (consensus *Consensus) tryCatchup() {
 
 // Start waits for the next new block and run consensus
 func (consensus *Consensus) Start(blockChannel chan *types.Block, stopChan chan struct{}, stoppedChan chan struct{}, startChannel chan struct{}) {
+	// replay the pbft log written before the last shutdown, if any
+	if err := consensus.restorePbftLog(); err != nil {
+		utils.Logger().Warn().Err(err).Msg("[ConsensusMainLoop] Cannot restore pbft log")
+	}
 	go func() {
 		if consensus.IsLeader() {
 			utils.Logger().Info().Time("time", time.Now()).Msg("[ConsensusMainLoop] Waiting for consensus start")
@@ -1079,6 +1142,9 @@ func (consensus *Consensus) Start(blockChannel chan *types.Block, stopChan chan
 				utils.Logger().Info().
 					Uint64("MsgBlockNum", newBlock.NumberU64()).
 					Msg("[ConsensusMainLoop] Received Proposed New Block!")
+				if consensus.onPipelinedProposal(newBlock) {
+					break
+				}
 
 				//VRF/VDF is only generated in the beacon chain
 				if consensus.NeedsRandomNumberGeneration(newBlock.Header().Epoch()) {
@@ -1128,7 +1194,7 @@ func (consensus *Consensus) Start(blockChannel chan *types.Block, stopChan chan
 					if err == nil {
 						vdfInProgress = false
 						// Verify the randomness
-						vdfObject := vdf_go.New(core.ShardingSchedule.VdfDifficulty(), seed)
+						vdfObject := vdf.New(core.ShardingSchedule.VdfDifficulty(), seed)
 						if !vdfObject.Verify(vdfOutput) {
 							consensus.getLogger().Warn().
 								Uint64("MsgBlockNum", newBlock.NumberU64()).
@@ -1259,20 +1325,20 @@ func (consensus *Consensus) GenerateVdfAndProof(newBlock *types.Block, vrfBlockN
 		Msg("[ConsensusMainLoop] VDF computation started")
 
 	go func() {
-		vdf := vdf_go.New(core.ShardingSchedule.VdfDifficulty(), seed)
-		outputChannel := vdf.GetOutputChannel()
+		vdfObject := vdf.New(core.ShardingSchedule.VdfDifficulty(), seed)
+		outputChannel := vdfObject.GetOutputChannel()
 		start := time.Now()
-		vdf.Execute()
+		vdfObject.Execute()
 		duration := time.Now().Sub(start)
 		consensus.getLogger().Info().
 			Dur("duration", duration).
 			Msg("[ConsensusMainLoop] VDF computation finished")
 		output := <-outputChannel
 
-		// The first 516 bytes are the VDF+proof and the last 32 bytes are XORed VRF as seed
-		rndBytes := [548]byte{}
-		copy(rndBytes[:516], output[:])
-		copy(rndBytes[516:], seed[:])
+		// The first vdFAndProofSize bytes are the VDF+proof and the last 32 bytes are XORed VRF as seed
+		rndBytes := [vdfAndSeedSize]byte{}
+		copy(rndBytes[:vdFAndProofSize], output[:])
+		copy(rndBytes[vdFAndProofSize:], seed[:])
 		consensus.RndChannel <- rndBytes
 	}()
 }
@@ -1300,8 +1366,15 @@ func (consensus *Consensus) ValidateVdfAndProof(headerObj *block.Header) bool {
 		}
 	}
 
-	vdfObject := vdf_go.New(core.ShardingSchedule.VdfDifficulty(), seed)
-	vdfOutput := [516]byte{}
+	if len(headerObj.Vdf()) != vdFAndProofSize {
+		consensus.getLogger().Warn().
+			Str("MsgBlockNum", headerObj.Number().String()).
+			Int("vdfLength", len(headerObj.Vdf())).
+			Msg("[OnAnnounce] VDF proof has a wrong length")
+		return false
+	}
+	vdfObject := vdf.New(core.ShardingSchedule.VdfDifficulty(), seed)
+	vdfOutput := [vdFAndProofSize]byte{}
 	copy(vdfOutput[:], headerObj.Vdf())
 	if vdfObject.Verify(vdfOutput) {
 		consensus.getLogger().Info().
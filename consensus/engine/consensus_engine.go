@@ -79,6 +79,12 @@ type Engine interface {
 	Finalize(chain ChainReader, header *block.Header, state *state.DB, txs []*types.Transaction,
 		receipts []*types.Receipt, outcxs []*types.CXReceipt, incxs []*types.CXReceiptsProof) (*types.Block, error)
 
+	// BlockRewards returns the payouts of the block rewards credited by the
+	// given header, the same Finalize credits, or nil if it credits none.
+	// The state is the one of the block, whose staking registry weights the
+	// rewards.
+	BlockRewards(chain ChainReader, header *block.Header, state *state.DB) (*types.BlockRewards, error)
+
 	// Seal generates a new sealing request for the given input block and pushes
 	// the result into the given channel.
 	//
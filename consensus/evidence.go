@@ -0,0 +1,232 @@
+package consensus
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+	protobuf "github.com/golang/protobuf/proto"
+	"github.com/harmony-one/bls/ffi/go/bls"
+
+	msg_pb "github.com/harmony-one/harmony/api/proto/message"
+	"github.com/harmony-one/harmony/crypto/hash"
+	"github.com/harmony-one/harmony/internal/ctxerror"
+	"github.com/harmony-one/harmony/internal/utils"
+)
+
+// maxEvidencePoolSize is the number of double sign evidence records kept in
+// memory; the oldest records are dropped first.
+const maxEvidencePoolSize = 1000
+
+// DoubleSignEvidence is the proof that a BLS key signed two conflicting
+// consensus messages, i.e. two messages of the same type, block number and
+// view ID for different block hashes.
+// Both messages are kept in their signed wire format so that anyone can
+// verify the evidence without trusting the reporter.
+type DoubleSignEvidence struct {
+	ShardID uint32
+	// Epoch is the epoch of the block the messages were for, which gives the
+	// committee the signer must belong to.  The signed messages do not carry
+	// it, and a shard's epoch cannot be derived from its block number.
+	Epoch         uint64
+	MessageType   uint64
+	BlockNum      uint64
+	ViewID        uint64
+	SignerPubKey  []byte // serialized BLS public key of the double signer
+	FirstMessage  []byte // protobuf encoded, signed msg_pb.Message
+	SecondMessage []byte // protobuf encoded, signed msg_pb.Message
+}
+
+// NewDoubleSignEvidence creates the evidence of the two given messages of the
+// given shard and epoch, which must carry their signed wire format.
+func NewDoubleSignEvidence(shardID uint32, epoch uint64, first, second *PbftMessage) *DoubleSignEvidence {
+	return &DoubleSignEvidence{
+		ShardID:       shardID,
+		Epoch:         epoch,
+		MessageType:   uint64(first.MessageType),
+		BlockNum:      first.BlockNum,
+		ViewID:        first.ViewID,
+		SignerPubKey:  first.SenderPubkey.Serialize(),
+		FirstMessage:  first.SignedMessage,
+		SecondMessage: second.SignedMessage,
+	}
+}
+
+// Hash returns the hash identifying the evidence.  Swapping the two messages
+// does not change the hash.
+func (e *DoubleSignEvidence) Hash() common.Hash {
+	first, second := e.FirstMessage, e.SecondMessage
+	if bytes.Compare(first, second) > 0 {
+		first, second = second, first
+	}
+	return hash.FromRLP([]interface{}{
+		e.ShardID, e.Epoch, e.MessageType, e.BlockNum, e.ViewID, e.SignerPubKey, first, second,
+	})
+}
+
+// Signer returns the BLS public key of the double signer.
+func (e *DoubleSignEvidence) Signer() (*bls.PublicKey, error) {
+	key := &bls.PublicKey{}
+	if err := key.Deserialize(e.SignerPubKey); err != nil {
+		return nil, ctxerror.New("cannot deserialize signer public key").WithCause(err)
+	}
+	return key, nil
+}
+
+// Verify checks that both messages are validly signed by the signer and that
+// they indeed conflict.  It does not check that the signer was a member of
+// the committee; see Signer.
+func (e *DoubleSignEvidence) Verify() error {
+	signer, err := e.Signer()
+	if err != nil {
+		return err
+	}
+	first, err := e.verifyMessage(signer, e.FirstMessage)
+	if err != nil {
+		return ctxerror.New("invalid first message").WithCause(err)
+	}
+	second, err := e.verifyMessage(signer, e.SecondMessage)
+	if err != nil {
+		return ctxerror.New("invalid second message").WithCause(err)
+	}
+	if first.BlockHash == second.BlockHash {
+		return ctxerror.New("messages do not conflict", "blockHash", first.BlockHash)
+	}
+	return nil
+}
+
+// verifyMessage checks that the signed message was signed by signer and
+// matches the type, block number and view ID of the evidence.
+func (e *DoubleSignEvidence) verifyMessage(signer *bls.PublicKey, data []byte) (*PbftMessage, error) {
+	msg := &msg_pb.Message{}
+	if err := protobuf.Unmarshal(data, msg); err != nil {
+		return nil, ctxerror.New("cannot unmarshal message").WithCause(err)
+	}
+	if msg.GetConsensus() == nil {
+		return nil, ctxerror.New("not a consensus message", "type", msg.GetType())
+	}
+	if err := verifyMessageSig(signer, msg); err != nil {
+		return nil, ctxerror.New("cannot verify message signature").WithCause(err)
+	}
+	pbftMsg, err := ParsePbftMessage(msg)
+	if err != nil {
+		return nil, ctxerror.New("cannot parse message").WithCause(err)
+	}
+	if !pbftMsg.SenderPubkey.IsEqual(signer) {
+		return nil, ctxerror.New("message not sent by the signer")
+	}
+	if msg.GetConsensus().ShardId != e.ShardID ||
+		uint64(pbftMsg.MessageType) != e.MessageType ||
+		pbftMsg.BlockNum != e.BlockNum ||
+		pbftMsg.ViewID != e.ViewID {
+		return nil, ctxerror.New("message does not match the evidence",
+			"shardID", msg.GetConsensus().ShardId,
+			"type", pbftMsg.MessageType,
+			"blockNum", pbftMsg.BlockNum,
+			"viewID", pbftMsg.ViewID)
+	}
+	return pbftMsg, nil
+}
+
+// EvidencePool keeps the double sign evidence found locally or received from
+// other nodes.
+type EvidencePool struct {
+	mutex   sync.Mutex
+	records map[common.Hash]*DoubleSignEvidence
+	order   []common.Hash
+}
+
+// NewEvidencePool returns an empty evidence pool.
+func NewEvidencePool() *EvidencePool {
+	return &EvidencePool{records: make(map[common.Hash]*DoubleSignEvidence)}
+}
+
+// Add adds the evidence to the pool and returns whether it was new.
+// The evidence is expected to have been verified by the caller.
+func (pool *EvidencePool) Add(evidence *DoubleSignEvidence) bool {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	h := evidence.Hash()
+	if _, ok := pool.records[h]; ok {
+		return false
+	}
+	if len(pool.order) >= maxEvidencePoolSize {
+		delete(pool.records, pool.order[0])
+		pool.order = pool.order[1:]
+	}
+	pool.records[h] = evidence
+	pool.order = append(pool.order, h)
+	return true
+}
+
+// Evidences returns the evidence in the pool, oldest first.
+func (pool *EvidencePool) Evidences() []*DoubleSignEvidence {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	evidences := make([]*DoubleSignEvidence, 0, len(pool.order))
+	for _, h := range pool.order {
+		evidences = append(evidences, pool.records[h])
+	}
+	return evidences
+}
+
+// EncodeDoubleSignEvidences encodes evidence records for gossiping.
+func EncodeDoubleSignEvidences(evidences []*DoubleSignEvidence) ([]byte, error) {
+	return rlp.EncodeToBytes(evidences)
+}
+
+// DecodeDoubleSignEvidences decodes gossiped evidence records.
+func DecodeDoubleSignEvidences(data []byte) ([]*DoubleSignEvidence, error) {
+	evidences := []*DoubleSignEvidence{}
+	if err := rlp.DecodeBytes(data, &evidences); err != nil {
+		return nil, ctxerror.New("cannot decode double sign evidence").WithCause(err)
+	}
+	return evidences, nil
+}
+
+// checkDoubleSign keeps the signed wire format of recvMsg and looks up the
+// PbftLog for a message of the same sender, type, block number and view ID
+// but for a different block hash.  If one is found, the evidence is added to
+// the evidence pool, reported through OnDoubleSign, and returned.
+func (consensus *Consensus) checkDoubleSign(msg *msg_pb.Message, recvMsg *PbftMessage) *DoubleSignEvidence {
+	signed, err := protobuf.Marshal(msg)
+	if err != nil {
+		utils.Logger().Warn().Err(err).Msg("[DoubleSign] Cannot marshal signed message")
+		return nil
+	}
+	recvMsg.SignedMessage = signed
+
+	conflict := consensus.PbftLog.FindConflictingMessage(recvMsg)
+	if conflict == nil {
+		return nil
+	}
+	evidence := NewDoubleSignEvidence(consensus.ShardID, consensus.epoch, conflict, recvMsg)
+	if !consensus.EvidencePool.Add(evidence) {
+		return evidence
+	}
+	utils.Logger().Warn().
+		Str("signer", recvMsg.SenderPubkey.SerializeToHexStr()).
+		Str("msgType", recvMsg.MessageType.String()).
+		Uint64("MsgBlockNum", recvMsg.BlockNum).
+		Uint64("MsgViewID", recvMsg.ViewID).
+		Hex("firstBlockHash", conflict.BlockHash[:]).
+		Hex("secondBlockHash", recvMsg.BlockHash[:]).
+		Msg("[DoubleSign] Conflicting signed messages detected")
+	if consensus.OnDoubleSign != nil {
+		consensus.OnDoubleSign(evidence)
+	}
+	return evidence
+}
+
+// logVote adds a prepare or commit vote to the PbftLog, so that a later
+// conflicting vote of the same validator can be detected.
+func (consensus *Consensus) logVote(vote *PbftMessage) {
+	logged := consensus.PbftLog.GetMessagesByTypeSeqViewHash(vote.MessageType, vote.BlockNum, vote.ViewID, vote.BlockHash)
+	for _, msg := range logged {
+		if msg.SenderPubkey.IsEqual(vote.SenderPubkey) {
+			return
+		}
+	}
+	consensus.PbftLog.AddMessage(vote)
+}
@@ -0,0 +1,180 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	protobuf "github.com/golang/protobuf/proto"
+
+	"github.com/harmony-one/harmony/api/proto"
+	msg_pb "github.com/harmony-one/harmony/api/proto/message"
+	"github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/p2p"
+	"github.com/harmony-one/harmony/p2p/memnet"
+)
+
+func newEvidenceTestConsensus(t *testing.T) *Consensus {
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "9902"}
+	host := memnet.New(1).NewHost(&leader)
+	consensus, err := New(host, 0, leader, bls.RandPrivateKey())
+	if err != nil {
+		t.Fatalf("Cannot create consensus: %v", err)
+	}
+	return consensus
+}
+
+// signedPrepare returns the prepare message the consensus signs for blockHash.
+func signedPrepare(t *testing.T, consensus *Consensus, blockHash common.Hash) (*msg_pb.Message, *PbftMessage) {
+	consensus.blockHash = blockHash
+	payload, err := proto.GetConsensusMessagePayload(consensus.constructPrepareMessage())
+	if err != nil {
+		t.Fatalf("Cannot get consensus message payload: %v", err)
+	}
+	msg := &msg_pb.Message{}
+	if err := protobuf.Unmarshal(payload, msg); err != nil {
+		t.Fatalf("Cannot unmarshal message: %v", err)
+	}
+	pbftMsg, err := ParsePbftMessage(msg)
+	if err != nil {
+		t.Fatalf("Cannot parse message: %v", err)
+	}
+	return msg, pbftMsg
+}
+
+func TestCheckDoubleSign(t *testing.T) {
+	consensus := newEvidenceTestConsensus(t)
+	reported := []*DoubleSignEvidence{}
+	consensus.OnDoubleSign = func(evidence *DoubleSignEvidence) {
+		reported = append(reported, evidence)
+	}
+
+	msg1, vote1 := signedPrepare(t, consensus, common.Hash{1})
+	if consensus.checkDoubleSign(msg1, vote1) != nil {
+		t.Fatal("first vote reported as double sign")
+	}
+	consensus.logVote(vote1)
+
+	// the same vote again is not a double sign
+	msg1Again, vote1Again := signedPrepare(t, consensus, common.Hash{1})
+	if consensus.checkDoubleSign(msg1Again, vote1Again) != nil {
+		t.Error("repeated vote reported as double sign")
+	}
+	consensus.logVote(vote1Again)
+	if n := len(consensus.PbftLog.GetMessagesByTypeSeqView(msg_pb.MessageType_PREPARE, vote1.BlockNum, vote1.ViewID)); n != 1 {
+		t.Errorf("expected 1 logged vote, got %d", n)
+	}
+
+	msg2, vote2 := signedPrepare(t, consensus, common.Hash{2})
+	evidence := consensus.checkDoubleSign(msg2, vote2)
+	if evidence == nil {
+		t.Fatal("conflicting vote not reported as double sign")
+	}
+	if err := evidence.Verify(); err != nil {
+		t.Errorf("evidence does not verify: %v", err)
+	}
+	signer, err := evidence.Signer()
+	if err != nil || !signer.IsEqual(consensus.PubKey) {
+		t.Errorf("wrong signer: %v", err)
+	}
+
+	// the same conflict is reported only once
+	consensus.checkDoubleSign(msg2, vote2)
+	if len(reported) != 1 {
+		t.Errorf("expected 1 report, got %d", len(reported))
+	}
+	if n := len(consensus.EvidencePool.Evidences()); n != 1 {
+		t.Errorf("expected 1 pooled evidence, got %d", n)
+	}
+}
+
+func TestDoubleSignEvidenceVerify(t *testing.T) {
+	consensus := newEvidenceTestConsensus(t)
+	msg1, vote1 := signedPrepare(t, consensus, common.Hash{1})
+	msg2, vote2 := signedPrepare(t, consensus, common.Hash{2})
+	consensus.checkDoubleSign(msg1, vote1)
+	consensus.checkDoubleSign(msg2, vote2)
+	evidence := NewDoubleSignEvidence(consensus.ShardID, 0, vote1, vote2)
+	if err := evidence.Verify(); err != nil {
+		t.Fatalf("evidence does not verify: %v", err)
+	}
+
+	// swapping the messages gives the same evidence
+	swapped := NewDoubleSignEvidence(consensus.ShardID, 0, vote2, vote1)
+	if swapped.Hash() != evidence.Hash() {
+		t.Error("evidence hash depends on message order")
+	}
+
+	otherEpoch := *evidence
+	otherEpoch.Epoch++
+	if otherEpoch.Hash() == evidence.Hash() {
+		t.Error("evidence hash does not depend on the epoch")
+	}
+
+	same := *evidence
+	same.SecondMessage = same.FirstMessage
+	if same.Verify() == nil {
+		t.Error("non conflicting messages verified as evidence")
+	}
+
+	otherSigner := *evidence
+	otherSigner.SignerPubKey = bls.RandPrivateKey().GetPublicKey().Serialize()
+	if otherSigner.Verify() == nil {
+		t.Error("messages verified against another signer")
+	}
+
+	otherView := *evidence
+	otherView.ViewID++
+	if otherView.Verify() == nil {
+		t.Error("messages verified against another view ID")
+	}
+
+	tampered := *evidence
+	tampered.SecondMessage = append([]byte{}, evidence.SecondMessage...)
+	tampered.SecondMessage[len(tampered.SecondMessage)-1] ^= 0xff
+	if tampered.Verify() == nil {
+		t.Error("tampered message verified")
+	}
+}
+
+func TestEncodeDoubleSignEvidences(t *testing.T) {
+	consensus := newEvidenceTestConsensus(t)
+	msg1, vote1 := signedPrepare(t, consensus, common.Hash{1})
+	msg2, vote2 := signedPrepare(t, consensus, common.Hash{2})
+	consensus.checkDoubleSign(msg1, vote1)
+	consensus.checkDoubleSign(msg2, vote2)
+	evidence := NewDoubleSignEvidence(consensus.ShardID, 0, vote1, vote2)
+
+	data, err := EncodeDoubleSignEvidences([]*DoubleSignEvidence{evidence})
+	if err != nil {
+		t.Fatalf("cannot encode evidence: %v", err)
+	}
+	decoded, err := DecodeDoubleSignEvidences(data)
+	if err != nil {
+		t.Fatalf("cannot decode evidence: %v", err)
+	}
+	if len(decoded) != 1 || decoded[0].Hash() != evidence.Hash() {
+		t.Fatal("decoded evidence differs")
+	}
+	if err := decoded[0].Verify(); err != nil {
+		t.Errorf("decoded evidence does not verify: %v", err)
+	}
+}
+
+func TestEvidencePool(t *testing.T) {
+	pool := NewEvidencePool()
+	for i := 0; i < maxEvidencePoolSize+1; i++ {
+		if !pool.Add(&DoubleSignEvidence{BlockNum: uint64(i)}) {
+			t.Fatalf("evidence %d not added", i)
+		}
+	}
+	if pool.Add(&DoubleSignEvidence{BlockNum: maxEvidencePoolSize}) {
+		t.Error("duplicate evidence added")
+	}
+	evidences := pool.Evidences()
+	if len(evidences) != maxEvidencePoolSize {
+		t.Fatalf("expected %d evidences, got %d", maxEvidencePoolSize, len(evidences))
+	}
+	if evidences[0].BlockNum != 1 {
+		t.Errorf("oldest evidence not dropped, first is %d", evidences[0].BlockNum)
+	}
+}
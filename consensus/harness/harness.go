@@ -0,0 +1,438 @@
+// Package harness runs a whole shard of consensus instances in one process,
+// over an in-memory network, to check the consensus protocol for safety and
+// liveness under scripted faults.
+package harness
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/harmony-one/bls/ffi/go/bls"
+	libp2p_peer "github.com/libp2p/go-libp2p-peer"
+
+	blockfactory "github.com/harmony-one/harmony/block/factory"
+	"github.com/harmony-one/harmony/consensus"
+	"github.com/harmony-one/harmony/core"
+	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/core/vm"
+	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
+	chain2 "github.com/harmony-one/harmony/internal/chain"
+	shardingconfig "github.com/harmony-one/harmony/internal/configs/sharding"
+	"github.com/harmony-one/harmony/internal/ctxerror"
+	"github.com/harmony-one/harmony/internal/genesis"
+	"github.com/harmony-one/harmony/internal/params"
+	"github.com/harmony-one/harmony/internal/utils"
+	"github.com/harmony-one/harmony/node/worker"
+	"github.com/harmony-one/harmony/p2p"
+	"github.com/harmony-one/harmony/p2p/memnet"
+)
+
+const (
+	shardID     = 0
+	genesisTime = 1561734000
+)
+
+var chainConfig = params.TestChainConfig
+
+// Config configures a simulated shard.
+type Config struct {
+	// NumValidators is the committee size.
+	NumValidators int
+	// Seed drives the random network rules.
+	Seed int64
+	// BlockPeriod is how long a leader waits before proposing a block.
+	BlockPeriod time.Duration
+	// PhaseTimeout is the timeout of the announce/prepare/commit phases and
+	// of the very first block.
+	PhaseTimeout time.Duration
+	// ViewChangeTimeout is the base timeout of a view change.
+	ViewChangeTimeout time.Duration
+	// Twins lists the validators that get a twin: a second instance signing
+	// with the same BLS key and proposing conflicting blocks, i.e. an
+	// equivocating node.
+	Twins []int
+	// LeaderRotation is how the leader of each block is chosen.
+	LeaderRotation consensus.LeaderRotation
+	// Pipelining is whether leaders propose the next block before the
+	// current one is finalized.
+	Pipelining bool
+}
+
+// DefaultConfig returns the configuration of a shard of n validators with
+// timeouts short enough for tests.
+func DefaultConfig(n int) Config {
+	return Config{
+		NumValidators:     n,
+		Seed:              1,
+		BlockPeriod:       100 * time.Millisecond,
+		PhaseTimeout:      2 * time.Second,
+		ViewChangeTimeout: 3 * time.Second,
+	}
+}
+
+// Harness is a simulated shard.
+//
+// A harness installs its committee as the global sharding schedule, so only
+// one harness may run at a time.
+type Harness struct {
+	Config  Config
+	Network *memnet.Network
+	// Validators are the honest validators, in committee order.
+	Validators []*Validator
+	// Twins are the equivocating twins of the validators in Config.Twins.
+	Twins []*Validator
+
+	bankKey      *ecdsa.PrivateKey
+	prevSchedule shardingconfig.Schedule
+
+	mutex        sync.Mutex
+	commits      map[uint64]map[common.Hash][]int // height -> block hash -> committing validators
+	commitSets   map[uint64][]byte                // height -> bitmap of the committed message
+	violations   []error
+	lastProposer *Validator
+	pipelined    int // number of blocks proposed on a block not yet finalized
+}
+
+// New creates a simulated shard.  The validators are not started yet.
+func New(config Config) (*Harness, error) {
+	if config.NumValidators < 1 {
+		return nil, ctxerror.New("harness needs at least one validator",
+			"numValidators", config.NumValidators)
+	}
+	h := &Harness{
+		Config:     config,
+		Network:    memnet.New(config.Seed),
+		commits:    make(map[uint64]map[common.Hash][]int),
+		commitSets: make(map[uint64][]byte),
+	}
+
+	keys := []*bls.SecretKey{}
+	addresses := []common.Address{}
+	accounts := []genesis.DeployAccount{}
+	for i := 0; i < config.NumValidators; i++ {
+		key := bls_cosi.RandPrivateKey()
+		ecdsaKey, err := crypto.GenerateKey()
+		if err != nil {
+			return nil, ctxerror.New("cannot generate validator account").WithCause(err)
+		}
+		address := crypto.PubkeyToAddress(ecdsaKey.PublicKey)
+		keys = append(keys, key)
+		addresses = append(addresses, address)
+		accounts = append(accounts, genesis.DeployAccount{
+			Index:        fmt.Sprintf("%d", i),
+			Address:      address.Hex(),
+			BlsPublicKey: key.GetPublicKey().SerializeToHexStr(),
+			ShardID:      shardID,
+		})
+	}
+	instance, err := shardingconfig.NewInstance(
+		1, config.NumValidators, config.NumValidators, accounts, nil, nil)
+	if err != nil {
+		return nil, ctxerror.New("cannot create sharding config").WithCause(err)
+	}
+	h.prevSchedule = core.ShardingSchedule
+	core.ShardingSchedule = shardingconfig.NewFixedSchedule(instance)
+	chain2.PurgeCommitteeCache()
+
+	h.bankKey, err = crypto.GenerateKey()
+	if err != nil {
+		h.restoreSchedule()
+		return nil, ctxerror.New("cannot generate bank account").WithCause(err)
+	}
+
+	leader := p2p.Peer{ConsensusPubKey: keys[0].GetPublicKey()}
+	for i := range keys {
+		v, err := h.newValidator(i, keys[i], addresses[i], leader, false)
+		if err != nil {
+			h.restoreSchedule()
+			return nil, err
+		}
+		h.Validators = append(h.Validators, v)
+	}
+	for _, i := range config.Twins {
+		if i < 0 || i >= len(keys) {
+			h.restoreSchedule()
+			return nil, ctxerror.New("twin of unknown validator", "index", i)
+		}
+		v, err := h.newValidator(i, keys[i], addresses[i], leader, true)
+		if err != nil {
+			h.restoreSchedule()
+			return nil, err
+		}
+		h.Twins = append(h.Twins, v)
+	}
+	return h, nil
+}
+
+// Start starts every validator and twin.
+func (h *Harness) Start() {
+	for _, v := range h.all() {
+		v.start()
+	}
+}
+
+// Stop stops every validator and twin and restores the sharding schedule.
+func (h *Harness) Stop() {
+	for _, v := range h.all() {
+		v.stop()
+	}
+	for _, v := range h.all() {
+		if !v.started {
+			v.Chain.Stop()
+			continue
+		}
+		select {
+		case <-v.stoppedChan:
+			v.Chain.Stop()
+		case <-time.After(time.Second):
+			// the main loop is stuck on a send nobody listens to any more
+		}
+	}
+	h.restoreSchedule()
+}
+
+// Crash stops the given honest validators; they neither send nor receive
+// any message afterwards.
+func (h *Harness) Crash(indexes ...int) {
+	for _, i := range indexes {
+		h.Validators[i].stop()
+	}
+}
+
+// CrashTwins stops every twin, ending the equivocation.
+func (h *Harness) CrashTwins() {
+	for _, v := range h.Twins {
+		v.stop()
+	}
+}
+
+// LastProposer returns the honest validator that proposed a block last, or
+// nil if none has proposed yet.
+func (h *Harness) LastProposer() *Validator {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.lastProposer
+}
+
+// Live returns the honest validators that have not crashed.
+func (h *Harness) Live() []*Validator {
+	live := []*Validator{}
+	for _, v := range h.Validators {
+		if !v.Crashed() {
+			live = append(live, v)
+		}
+	}
+	return live
+}
+
+// WaitForHeight waits until every live honest validator has committed the
+// block at the given height.
+func (h *Harness) WaitForHeight(height uint64, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		behind := 0
+		for _, v := range h.Live() {
+			if v.Height() < height {
+				behind++
+			}
+		}
+		if behind == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return ctxerror.New("liveness violation: validators did not reach height",
+				"height", height, "behind", behind, "timeout", timeout)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// CheckSafety returns an error if two honest validators committed different
+// blocks at the same height.
+func (h *Harness) CheckSafety() error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if len(h.violations) > 0 {
+		return h.violations[0]
+	}
+	return nil
+}
+
+// CheckSeals returns an error if a block in the chain of the first live
+// validator is sealed with other commit signatures than the committed
+// message of its parent carried.
+func (h *Harness) CheckSeals() error {
+	live := h.Live()
+	if len(live) == 0 {
+		return ctxerror.New("no live validator")
+	}
+	chain := live[0].Chain
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	checked := 0
+	for num := uint64(2); num <= chain.CurrentHeader().Number().Uint64(); num++ {
+		committed, ok := h.commitSets[num-1]
+		if !ok {
+			continue
+		}
+		if seal := chain.GetHeaderByNumber(num).LastCommitBitmap(); !bytes.Equal(seal, committed) {
+			return ctxerror.New("block sealed with other signatures than its parent's committed message",
+				"blockNum", num,
+				"seal", hex.EncodeToString(seal),
+				"committed", hex.EncodeToString(committed))
+		}
+		checked++
+	}
+	if checked == 0 {
+		return ctxerror.New("no seal to check")
+	}
+	return nil
+}
+
+// HostIDs returns the network IDs of the given honest validators.
+func (h *Harness) HostIDs(indexes ...int) []libp2p_peer.ID {
+	ids := []libp2p_peer.ID{}
+	for _, i := range indexes {
+		ids = append(ids, h.Validators[i].Host.GetID())
+	}
+	return ids
+}
+
+// recordCommit records that v committed block with the given commit
+// signature and bitmap, flagging a safety violation if another honest
+// validator committed a different block at that height.
+func (h *Harness) recordCommit(v *Validator, block *types.Block, commitSigAndBitmap []byte) {
+	if v.Twin {
+		return
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	height := block.NumberU64()
+	if _, ok := h.commitSets[height]; !ok && len(commitSigAndBitmap) > 96 {
+		h.commitSets[height] = append([]byte{}, commitSigAndBitmap[96:]...)
+	}
+	byHash, ok := h.commits[height]
+	if !ok {
+		byHash = make(map[common.Hash][]int)
+		h.commits[height] = byHash
+	}
+	byHash[block.Hash()] = append(byHash[block.Hash()], v.Index)
+	if len(byHash) > 1 {
+		err := ctxerror.New("safety violation: conflicting blocks committed",
+			"height", height, "commits", fmt.Sprintf("%v", byHash))
+		h.violations = append(h.violations, err)
+		utils.Logger().Error().Err(err).Msg("[Harness] Conflicting commits")
+	}
+}
+
+func (h *Harness) setLastProposer(v *Validator, pipelined bool) {
+	if v.Twin {
+		return
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.lastProposer = v
+	if pipelined {
+		h.pipelined++
+	}
+}
+
+// PipelinedProposals returns the number of blocks honest validators proposed
+// on a block not yet finalized.
+func (h *Harness) PipelinedProposals() int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.pipelined
+}
+
+func (h *Harness) all() []*Validator {
+	return append(append([]*Validator{}, h.Validators...), h.Twins...)
+}
+
+func (h *Harness) restoreSchedule() {
+	core.ShardingSchedule = h.prevSchedule
+	chain2.PurgeCommitteeCache()
+}
+
+// genesisAlloc funds the bank account twins spend from.
+func (h *Harness) genesisAlloc() core.GenesisAlloc {
+	funds := new(big.Int).Mul(big.NewInt(1000), big.NewInt(1e18))
+	return core.GenesisAlloc{
+		crypto.PubkeyToAddress(h.bankKey.PublicKey): {Balance: funds},
+	}
+}
+
+// newValidator sets up a validator with its own chain, host and consensus.
+func (h *Harness) newValidator(
+	index int, key *bls.SecretKey, address common.Address, leader p2p.Peer, twin bool,
+) (*Validator, error) {
+	shardState := core.GetInitShardState()
+	db := ethdb.NewMemDatabase()
+	gspec := core.Genesis{
+		Config:         chainConfig,
+		Factory:        blockfactory.NewFactory(chainConfig),
+		Alloc:          h.genesisAlloc(),
+		ShardID:        shardID,
+		GasLimit:       params.GenesisGasLimit,
+		ShardStateHash: shardState.Hash(),
+		ShardState:     shardState.DeepCopy(),
+		Timestamp:      genesisTime,
+	}
+	gspec.MustCommit(db)
+	chain, err := core.NewBlockChain(db, nil, chainConfig, chain2.Engine, vm.Config{}, nil)
+	if err != nil {
+		return nil, ctxerror.New("cannot create blockchain", "index", index).WithCause(err)
+	}
+
+	host := h.Network.NewHost(&p2p.Peer{
+		IP:              "127.0.0.1",
+		Port:            fmt.Sprintf("%d", 9000+index),
+		ConsensusPubKey: key.GetPublicKey(),
+	})
+	receiver, err := host.GroupReceiver(p2p.NewGroupIDByShardID(shardID))
+	if err != nil {
+		return nil, ctxerror.New("cannot create group receiver", "index", index).WithCause(err)
+	}
+	c, err := consensus.New(host, shardID, leader, key)
+	if err != nil {
+		return nil, ctxerror.New("cannot create consensus", "index", index).WithCause(err)
+	}
+
+	v := &Validator{
+		Index:        index,
+		Twin:         twin,
+		Key:          key,
+		Address:      address,
+		Host:         host,
+		Consensus:    c,
+		Chain:        chain,
+		harness:      h,
+		worker:       worker.New(chainConfig, chain, chain2.Engine, shardID),
+		receiver:     receiver,
+		blockChannel: make(chan *types.Block),
+		stopChan:     make(chan struct{}),
+		stoppedChan:  make(chan struct{}),
+		startChan:    make(chan struct{}),
+	}
+
+	height := chain.CurrentBlock().NumberU64()
+	c.ChainReader = chain
+	c.SelfAddress = address
+	c.SetTimeouts(h.Config.PhaseTimeout, h.Config.ViewChangeTimeout, h.Config.PhaseTimeout)
+	c.SetBlockNum(height + 1)
+	c.SetViewID(height)
+	c.BlockVerifier = v.verifyBlock
+	c.OnConsensusDone = v.onConsensusDone
+	c.SetLeaderRotation(h.Config.LeaderRotation)
+	c.SetPipelining(h.Config.Pipelining)
+	c.SetMode(c.UpdateConsensusInformation())
+	return v, nil
+}
@@ -0,0 +1,198 @@
+package harness
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/harmony-one/harmony/consensus"
+	"github.com/harmony-one/harmony/internal/ctxerror"
+	"github.com/harmony-one/harmony/p2p/memnet"
+)
+
+func runScenario(t *testing.T, scenario Scenario) {
+	if err := scenario.Run(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHappyPath(t *testing.T) {
+	runScenario(t, Scenario{
+		Name:   "happy path",
+		Config: DefaultConfig(4),
+		Steps: []Step{
+			Start(),
+			WaitForHeight(3, 30*time.Second),
+		},
+	})
+}
+
+func TestLatencyAndReorder(t *testing.T) {
+	runScenario(t, Scenario{
+		Name:   "latency and reorder",
+		Config: DefaultConfig(4),
+		Steps: []Step{
+			AddRule(memnet.Latency(20 * time.Millisecond)),
+			AddRule(memnet.Reorder(50 * time.Millisecond)),
+			Start(),
+			WaitForHeight(3, 30*time.Second),
+		},
+	})
+}
+
+func TestLeaderCrash(t *testing.T) {
+	if testing.Short() {
+		t.Skip("view change takes several seconds")
+	}
+	runScenario(t, Scenario{
+		Name:   "leader crash",
+		Config: DefaultConfig(4),
+		Steps: []Step{
+			Start(),
+			WaitForHeight(2, 30*time.Second),
+			CrashLeader(),
+			WaitForHeight(4, 60*time.Second),
+		},
+	})
+}
+
+func TestLeaderRotation(t *testing.T) {
+	config := DefaultConfig(4)
+	config.LeaderRotation = consensus.RoundRobinRotation
+	runScenario(t, Scenario{
+		Name:   "round robin leader rotation",
+		Config: config,
+		Steps: []Step{
+			Start(),
+			WaitForHeight(5, 30*time.Second),
+			{Name: "check proposers", Run: func(h *Harness) error {
+				chain := h.Validators[0].Chain
+				for num := uint64(1); num <= 4; num++ {
+					want := h.Validators[num%4].Address
+					if got := chain.GetHeaderByNumber(num).Coinbase(); got != want {
+						return ctxerror.New("block proposed by the wrong leader",
+							"blockNum", num, "want", want, "got", got)
+					}
+				}
+				return nil
+			}},
+		},
+	})
+}
+
+func TestPipelining(t *testing.T) {
+	config := DefaultConfig(4)
+	config.Pipelining = true
+	runScenario(t, Scenario{
+		Name:   "pipelining",
+		Config: config,
+		Steps: []Step{
+			Start(),
+			// without the fourth commit the leader waits out the commit grace
+			// period, during which it announces the next block
+			Crash(3),
+			WaitForHeight(4, 60*time.Second),
+			{Name: "check pipelined proposals", Run: func(h *Harness) error {
+				if h.PipelinedProposals() == 0 {
+					return ctxerror.New("no block proposed before its parent was finalized")
+				}
+				return nil
+			}},
+		},
+	})
+}
+
+func TestPipeliningLateCommits(t *testing.T) {
+	config := DefaultConfig(7)
+	config.Pipelining = true
+	runScenario(t, Scenario{
+		Name:   "pipelining with late commits",
+		Config: config,
+		Steps: []Step{
+			{Name: "slow down two validators", Run: func(h *Harness) error {
+				slow := h.HostIDs(5, 6)
+				h.Network.AddRule(func(env *memnet.Envelope, rnd *rand.Rand) memnet.Verdict {
+					if env.From == slow[0] || env.From == slow[1] {
+						return memnet.Verdict{Delay: 300 * time.Millisecond}
+					}
+					return memnet.Verdict{}
+				})
+				return nil
+			}},
+			Start(),
+			WaitForHeight(4, 60*time.Second),
+			// the commits of the slow validators arrive after the quorum the
+			// next block is sealed with, and must not be counted
+			{Name: "check seals", Run: func(h *Harness) error {
+				return h.CheckSeals()
+			}},
+		},
+	})
+}
+
+func TestEquivocatingLeader(t *testing.T) {
+	if testing.Short() {
+		t.Skip("view change takes several seconds")
+	}
+	config := DefaultConfig(7)
+	config.Twins = []int{0}
+	runScenario(t, Scenario{
+		Name:   "equivocating leader",
+		Config: config,
+		Steps: []Step{
+			// neither side reaches the quorum of 5 on its block
+			SplitTwins([]int{1, 2, 3}, []int{4, 5, 6}),
+			Start(),
+			Sleep(2 * config.PhaseTimeout),
+			CrashTwins(),
+			Heal(),
+			WaitForHeight(2, 60*time.Second),
+		},
+	})
+}
+
+func TestSplitVotes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("view change takes several seconds")
+	}
+	config := DefaultConfig(4)
+	runScenario(t, Scenario{
+		Name:   "split votes",
+		Config: config,
+		Steps: []Step{
+			// two votes on each side, three needed
+			Partition([]int{0, 1}, []int{2, 3}),
+			Start(),
+			Sleep(2 * config.PhaseTimeout),
+			Heal(),
+			WaitForHeight(2, 60*time.Second),
+		},
+	})
+}
+
+func TestSafetyCheck(t *testing.T) {
+	h, err := New(DefaultConfig(1))
+	if err != nil {
+		t.Fatalf("cannot create harness: %v", err)
+	}
+	defer h.Stop()
+	v := h.Validators[0]
+	first, err := v.proposeNewBlock(nil)
+	if err != nil {
+		t.Fatalf("cannot propose block: %v", err)
+	}
+	v.Twin = true
+	second, err := v.proposeNewBlock(nil)
+	v.Twin = false
+	if err != nil {
+		t.Fatalf("cannot propose block: %v", err)
+	}
+	h.recordCommit(v, first)
+	if err := h.CheckSafety(); err != nil {
+		t.Errorf("single commit reported unsafe: %v", err)
+	}
+	h.recordCommit(&Validator{Index: 1, harness: h}, second)
+	if err := h.CheckSafety(); err == nil {
+		t.Error("conflicting commits not reported")
+	}
+}
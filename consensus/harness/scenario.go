@@ -0,0 +1,138 @@
+package harness
+
+import (
+	"time"
+
+	libp2p_peer "github.com/libp2p/go-libp2p-peer"
+
+	"github.com/harmony-one/harmony/internal/ctxerror"
+	"github.com/harmony-one/harmony/p2p/memnet"
+)
+
+// Step is one action of a scripted scenario.
+type Step struct {
+	Name string
+	Run  func(h *Harness) error
+}
+
+// Scenario is a scripted run of a simulated shard.
+type Scenario struct {
+	Name   string
+	Config Config
+	Steps  []Step
+}
+
+// Run creates the shard, runs the steps in order and checks safety after
+// every step.  The shard is stopped when Run returns.
+func (s Scenario) Run() error {
+	h, err := New(s.Config)
+	if err != nil {
+		return ctxerror.New("cannot create harness", "scenario", s.Name).WithCause(err)
+	}
+	defer h.Stop()
+	for _, step := range s.Steps {
+		if err := step.Run(h); err != nil {
+			return ctxerror.New("step failed", "scenario", s.Name, "step", step.Name).WithCause(err)
+		}
+		if err := h.CheckSafety(); err != nil {
+			return ctxerror.New("unsafe", "scenario", s.Name, "step", step.Name).WithCause(err)
+		}
+	}
+	return nil
+}
+
+// Start starts the shard.
+func Start() Step {
+	return Step{Name: "start", Run: func(h *Harness) error {
+		h.Start()
+		return nil
+	}}
+}
+
+// WaitForHeight checks liveness: every live honest validator must commit the
+// block at the given height within the timeout.
+func WaitForHeight(height uint64, timeout time.Duration) Step {
+	return Step{Name: "wait for height", Run: func(h *Harness) error {
+		return h.WaitForHeight(height, timeout)
+	}}
+}
+
+// Sleep lets the shard run for d.
+func Sleep(d time.Duration) Step {
+	return Step{Name: "sleep", Run: func(h *Harness) error {
+		time.Sleep(d)
+		return nil
+	}}
+}
+
+// CrashLeader crashes the honest validator that proposed a block last.
+func CrashLeader() Step {
+	return Step{Name: "crash leader", Run: func(h *Harness) error {
+		leader := h.LastProposer()
+		if leader == nil {
+			return ctxerror.New("no block has been proposed yet")
+		}
+		h.Crash(leader.Index)
+		return nil
+	}}
+}
+
+// Crash crashes the given honest validators.
+func Crash(indexes ...int) Step {
+	return Step{Name: "crash", Run: func(h *Harness) error {
+		h.Crash(indexes...)
+		return nil
+	}}
+}
+
+// CrashTwins crashes every equivocating twin.
+func CrashTwins() Step {
+	return Step{Name: "crash twins", Run: func(h *Harness) error {
+		h.CrashTwins()
+		return nil
+	}}
+}
+
+// Partition splits the honest validators into the given sides, by index.
+func Partition(sides ...[]int) Step {
+	return Step{Name: "partition", Run: func(h *Harness) error {
+		ids := [][]libp2p_peer.ID{}
+		for _, side := range sides {
+			ids = append(ids, h.HostIDs(side...))
+		}
+		h.Network.AddRule(memnet.Partition(ids...))
+		return nil
+	}}
+}
+
+// SplitTwins partitions the shard so that every validator in Config.Twins
+// talks to the validators in sideA and its twin talks to the validators in
+// sideB, each side seeing a different block from the same key.
+func SplitTwins(sideA, sideB []int) Step {
+	return Step{Name: "split twins", Run: func(h *Harness) error {
+		a := h.HostIDs(sideA...)
+		b := h.HostIDs(sideB...)
+		for _, twin := range h.Twins {
+			a = append(a, h.Validators[twin.Index].Host.GetID())
+			b = append(b, twin.Host.GetID())
+		}
+		h.Network.AddRule(memnet.Partition(a, b))
+		return nil
+	}}
+}
+
+// AddRule installs a network rule, e.g. latency or message loss.
+func AddRule(rule memnet.Rule) Step {
+	return Step{Name: "add rule", Run: func(h *Harness) error {
+		h.Network.AddRule(rule)
+		return nil
+	}}
+}
+
+// Heal removes every network rule.
+func Heal() Step {
+	return Step{Name: "heal", Run: func(h *Harness) error {
+		h.Network.ClearRules()
+		return nil
+	}}
+}
@@ -0,0 +1,209 @@
+package harness
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/harmony-one/bls/ffi/go/bls"
+
+	"github.com/harmony-one/harmony/api/proto"
+	"github.com/harmony-one/harmony/consensus"
+	"github.com/harmony-one/harmony/core"
+	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/internal/ctxerror"
+	"github.com/harmony-one/harmony/internal/params"
+	"github.com/harmony-one/harmony/internal/utils"
+	"github.com/harmony-one/harmony/node/worker"
+	"github.com/harmony-one/harmony/p2p"
+	"github.com/harmony-one/harmony/p2p/memnet"
+)
+
+// Validator is one consensus instance of the simulated shard, together with
+// the parts of a node it needs: a chain, a block proposer and a message pump.
+type Validator struct {
+	// Index is the position of the validator's key in the committee.
+	Index int
+	// Twin is whether this is the equivocating twin of validator Index.
+	Twin      bool
+	Key       *bls.SecretKey
+	Address   common.Address
+	Host      *memnet.Host
+	Consensus *consensus.Consensus
+	Chain     *core.BlockChain
+
+	harness      *Harness
+	worker       *worker.Worker
+	receiver     p2p.GroupReceiver
+	blockChannel chan *types.Block
+	stopChan     chan struct{}
+	stoppedChan  chan struct{}
+	startChan    chan struct{}
+	started      bool
+	stopOnce     sync.Once
+}
+
+// Height returns the number of the validator's latest committed block.
+func (v *Validator) Height() uint64 {
+	return v.Chain.CurrentBlock().NumberU64()
+}
+
+// Crashed returns whether the validator has been stopped.
+func (v *Validator) Crashed() bool {
+	select {
+	case <-v.stopChan:
+		return true
+	default:
+		return false
+	}
+}
+
+func (v *Validator) start() {
+	v.started = true
+	go v.receiveMessages()
+	go v.proposeBlocks()
+	v.Consensus.Start(v.blockChannel, v.stopChan, v.stoppedChan, v.startChan)
+	close(v.startChan)
+}
+
+func (v *Validator) stop() {
+	v.stopOnce.Do(func() {
+		close(v.stopChan)
+		v.Host.Close()
+	})
+}
+
+// verifyBlock checks a proposed block the way the node does before voting.
+func (v *Validator) verifyBlock(block *types.Block) error {
+	if err := v.Chain.Validator().ValidateHeader(block, true); err != nil {
+		return ctxerror.New("cannot ValidateHeader for the new block",
+			"blockHash", block.Hash()).WithCause(err)
+	}
+	if err := v.Chain.ValidateNewBlock(block); err != nil {
+		return ctxerror.New("cannot ValidateNewBlock",
+			"blockHash", block.Hash()).WithCause(err)
+	}
+	return nil
+}
+
+// onConsensusDone adds a committed block to the chain and records it for the
+// safety check.
+func (v *Validator) onConsensusDone(block *types.Block, commitSigAndBitmap []byte) {
+	if _, err := v.Chain.InsertChain([]*types.Block{block}); err != nil {
+		utils.Logger().Error().Err(err).
+			Int("index", v.Index).
+			Uint64("blockNum", block.NumberU64()).
+			Msg("[Harness] Error when adding new block")
+		return
+	}
+	v.harness.recordCommit(v, block, commitSigAndBitmap)
+}
+
+// receiveMessages feeds consensus messages from the network to consensus,
+// the way the node's group message handler does.
+func (v *Validator) receiveMessages() {
+	ctx := context.Background()
+	for {
+		msg, sender, err := v.receiver.Receive(ctx)
+		if err != nil {
+			return
+		}
+		// skip own messages and the 5 byte p2p header, as the node does
+		if sender == v.Host.GetID() || len(msg) < 5 {
+			continue
+		}
+		content := msg[5:]
+		category, err := proto.GetMessageCategory(content)
+		if err != nil || category != proto.Consensus {
+			continue
+		}
+		payload, err := proto.GetConsensusMessagePayload(content)
+		if err != nil {
+			continue
+		}
+		select {
+		case v.Consensus.MsgChan <- payload:
+		case <-v.stopChan:
+			return
+		}
+	}
+}
+
+// proposeBlocks proposes a new block whenever consensus is ready for one.
+func (v *Validator) proposeBlocks() {
+	for {
+		select {
+		case <-v.Consensus.ReadySignal:
+		case <-v.stopChan:
+			return
+		}
+		for {
+			select {
+			case <-time.After(v.harness.Config.BlockPeriod):
+			case <-v.stopChan:
+				return
+			}
+			pipelined := v.Consensus.PipelinedParent()
+			block, err := v.proposeNewBlock(pipelined)
+			if err != nil {
+				utils.Logger().Warn().Err(err).
+					Int("index", v.Index).
+					Msg("[Harness] Cannot propose new block, retrying")
+				continue
+			}
+			v.harness.setLastProposer(v, pipelined != nil)
+			select {
+			case v.blockChannel <- block:
+			case <-v.stopChan:
+				return
+			}
+			break
+		}
+	}
+}
+
+// proposeNewBlock builds a block on top of the validator's chain, or on the
+// pipelined parent if there is one.  A twin includes a transfer in its block,
+// so that it conflicts with the block of the validator it impersonates.
+func (v *Validator) proposeNewBlock(pipelined *consensus.PipelinedParent) (*types.Block, error) {
+	if pipelined != nil {
+		v.worker.SetPendingParent(pipelined.Block)
+	} else {
+		v.worker.SetPendingParent(nil)
+	}
+	if err := v.worker.UpdateCurrent(v.Address); err != nil {
+		return nil, ctxerror.New("cannot update worker").WithCause(err)
+	}
+	txs := types.Transactions{}
+	if v.Twin {
+		tx, err := v.conflictingTransaction(v.harness.bankKey)
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, tx)
+	}
+	if err := v.worker.CommitTransactions(txs, v.Address); err != nil {
+		return nil, ctxerror.New("cannot commit transactions").WithCause(err)
+	}
+	shardState := v.worker.ProposeShardStateWithoutBeaconSync()
+	if pipelined != nil {
+		return v.worker.FinalizeNewBlock(pipelined.CommitSig, pipelined.CommitBitmap, pipelined.ViewID, v.Address, nil, shardState)
+	}
+	sig, mask, err := v.Consensus.LastCommitSig()
+	if err != nil {
+		return nil, ctxerror.New("Cannot get commit signatures from last block").WithCause(err)
+	}
+	return v.worker.FinalizeNewBlock(sig, mask, v.Consensus.GetViewID(), v.Address, nil, shardState)
+}
+
+// conflictingTransaction returns a transfer from the bank account to itself.
+func (v *Validator) conflictingTransaction(bankKey *ecdsa.PrivateKey) (*types.Transaction, error) {
+	bank := crypto.PubkeyToAddress(bankKey.PublicKey)
+	nonce := v.worker.GetCurrentState().GetNonce(bank)
+	tx := types.NewTransaction(nonce, bank, shardID, big.NewInt(1), params.TxGas, nil, nil)
+	return types.SignTx(tx, types.HomesteadSigner{}, bankKey)
+}
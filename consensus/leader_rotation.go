@@ -0,0 +1,121 @@
+package consensus
+
+import (
+	"encoding/binary"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/harmony-one/bls/ffi/go/bls"
+	"github.com/pkg/errors"
+
+	"github.com/harmony-one/harmony/block"
+	"github.com/harmony-one/harmony/internal/utils"
+)
+
+// LeaderRotation is how the leader of each block is chosen from the committee.
+type LeaderRotation byte
+
+const (
+	// NoRotation keeps the leader until a view change replaces it.
+	NoRotation LeaderRotation = iota
+	// RoundRobinRotation makes the committee member at index N mod size the
+	// leader of block N.
+	RoundRobinRotation
+	// RandomRotation picks the leader of block N using the randomness of
+	// block N-1: its VRF, or else its VDF, or else its hash.
+	RandomRotation
+)
+
+var leaderRotationNames = map[LeaderRotation]string{
+	NoRotation:         "none",
+	RoundRobinRotation: "roundrobin",
+	RandomRotation:     "random",
+}
+
+func (r LeaderRotation) String() string {
+	if name, ok := leaderRotationNames[r]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseLeaderRotation returns the leader rotation of the given name, as
+// returned by LeaderRotation.String.
+func ParseLeaderRotation(name string) (LeaderRotation, error) {
+	for rotation, rotationName := range leaderRotationNames {
+		if rotationName == name {
+			return rotation, nil
+		}
+	}
+	return NoRotation, errors.Errorf("unknown leader rotation %#v", name)
+}
+
+// LeaderRotation returns how the leader of each block is chosen.
+func (consensus *Consensus) LeaderRotation() LeaderRotation {
+	return consensus.leaderRotation
+}
+
+// SetLeaderRotation sets how the leader of each block is chosen.  All
+// validators of a shard must use the same leader rotation.
+func (consensus *Consensus) SetLeaderRotation(rotation LeaderRotation) {
+	consensus.leaderRotation = rotation
+}
+
+// scheduledLeader returns the leader of the given block number in its first
+// view, given the header of the previous block, or nil if leaders do not
+// rotate.  A view change still passes leadership on from the scheduled leader
+// with GetNextLeaderKey.
+func (consensus *Consensus) scheduledLeader(blockNum uint64, parent *block.Header) *bls.PublicKey {
+	pubKeys := consensus.PublicKeys
+	if len(pubKeys) == 0 {
+		return nil
+	}
+	switch consensus.leaderRotation {
+	case RoundRobinRotation:
+		return pubKeys[blockNum%uint64(len(pubKeys))]
+	case RandomRotation:
+		if parent == nil {
+			return pubKeys[blockNum%uint64(len(pubKeys))]
+		}
+		var blockNumBytes [8]byte
+		binary.BigEndian.PutUint64(blockNumBytes[:], blockNum)
+		seed := crypto.Keccak256(leaderRandomness(parent), blockNumBytes[:])
+		return pubKeys[binary.BigEndian.Uint64(seed[:8])%uint64(len(pubKeys))]
+	}
+	return nil
+}
+
+// leaderRandomness returns the randomness in the header used to pick the
+// leader of the next block.
+func leaderRandomness(header *block.Header) []byte {
+	if vrf := header.Vrf(); len(vrf) > 0 {
+		return vrf
+	}
+	if vdf := header.Vdf(); len(vdf) > 0 {
+		return vdf
+	}
+	hash := header.Hash()
+	return hash[:]
+}
+
+// rotateLeader makes the scheduled leader of the current block number the
+// leader, if leaders rotate.  It returns whether the leader was set.
+func (consensus *Consensus) rotateLeader() bool {
+	if consensus.leaderRotation == NoRotation {
+		return false
+	}
+	var parent *block.Header
+	if consensus.ChainReader != nil && consensus.blockNum > 0 {
+		parent = consensus.ChainReader.GetHeaderByNumber(consensus.blockNum - 1)
+	}
+	leader := consensus.scheduledLeader(consensus.blockNum, parent)
+	if leader == nil {
+		return false
+	}
+	consensus.LeaderPubKey = leader
+	utils.Logger().Debug().
+		Uint64("blockNum", consensus.blockNum).
+		Str("leaderRotation", consensus.leaderRotation.String()).
+		Str("leaderKey", leader.SerializeToHexStr()).
+		Msg("[RotateLeader] Scheduled leader of the next block")
+	return true
+}
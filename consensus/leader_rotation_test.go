@@ -0,0 +1,63 @@
+package consensus
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	blockfactory "github.com/harmony-one/harmony/block/factory"
+)
+
+func TestParseLeaderRotation(t *testing.T) {
+	for _, rotation := range []LeaderRotation{NoRotation, RoundRobinRotation, RandomRotation} {
+		parsed, err := ParseLeaderRotation(rotation.String())
+		if err != nil || parsed != rotation {
+			t.Errorf("cannot parse %s: got %s, %v", rotation, parsed, err)
+		}
+	}
+	if _, err := ParseLeaderRotation("nobody"); err == nil {
+		t.Error("unknown leader rotation parsed")
+	}
+}
+
+func TestScheduledLeader(t *testing.T) {
+	consensus := &Consensus{PublicKeys: newTestCommittee(4)}
+	parent := blockfactory.NewTestHeader().With().Number(big.NewInt(6)).Header()
+	if consensus.scheduledLeader(7, parent) != nil {
+		t.Error("leader scheduled without rotation")
+	}
+
+	consensus.SetLeaderRotation(RoundRobinRotation)
+	for num := uint64(0); num < 8; num++ {
+		if !consensus.scheduledLeader(num, nil).IsEqual(consensus.PublicKeys[num%4]) {
+			t.Errorf("wrong round robin leader of block %d", num)
+		}
+	}
+
+	consensus.SetLeaderRotation(RandomRotation)
+	leader := consensus.scheduledLeader(7, parent)
+	if leader == nil || !leader.IsEqual(consensus.scheduledLeader(7, parent)) {
+		t.Fatal("random leader is not deterministic")
+	}
+	// different randomness eventually picks a different leader
+	picked := map[string]bool{}
+	for i := byte(0); i < 64; i++ {
+		header := blockfactory.NewTestHeader().With().
+			Number(big.NewInt(6)).
+			ParentHash(common.Hash{i}).
+			Header()
+		picked[consensus.scheduledLeader(7, header).SerializeToHexStr()] = true
+	}
+	if len(picked) != 4 {
+		t.Errorf("random rotation picked %d of 4 leaders", len(picked))
+	}
+	vrfHeader := blockfactory.NewTestHeader().With().Vrf([]byte{1, 2, 3}).Header()
+	otherVrfHeader := blockfactory.NewTestHeader().With().
+		Vrf([]byte{1, 2, 3}).
+		ParentHash(common.Hash{1}).
+		Header()
+	if !consensus.scheduledLeader(7, vrfHeader).IsEqual(consensus.scheduledLeader(7, otherVrfHeader)) {
+		t.Error("random leader does not depend only on the VRF")
+	}
+}
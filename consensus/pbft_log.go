@@ -6,6 +6,7 @@ import (
 
 	mapset "github.com/deckarep/golang-set"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/harmony-one/bls/ffi/go/bls"
 	msg_pb "github.com/harmony-one/harmony/api/proto/message"
 	"github.com/harmony-one/harmony/core/types"
@@ -19,6 +20,7 @@ type PbftLog struct {
 	messages   mapset.Set // store messages received in PBFT
 	maxLogSize uint32
 	mutex      sync.Mutex
+	db         ethdb.Database // write-ahead log of messages and blocks, nil if the log is in-memory only
 }
 
 // PbftMessage is the record of pbft messages received by a node during PBFT process
@@ -37,6 +39,7 @@ type PbftMessage struct {
 	M2Bitmap      *bls_cosi.Mask
 	M3AggSig      *bls.Sign
 	M3Bitmap      *bls_cosi.Mask
+	SignedMessage []byte // signed wire format, kept as double sign evidence
 }
 
 // NewPbftLog returns new instance of PbftLog
@@ -60,7 +63,15 @@ func (log *PbftLog) Messages() mapset.Set {
 
 // AddBlock add a new block into the log
 func (log *PbftLog) AddBlock(block *types.Block) {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
 	log.blocks.Add(block)
+	if log.db == nil {
+		return
+	}
+	if err := log.persistBlock(block); err != nil {
+		utils.Logger().Warn().Err(err).Uint64("blockNum", block.NumberU64()).Msg("[PbftLog] Failed to persist block")
+	}
 }
 
 // GetBlockByHash returns the block matches the given block hash
@@ -90,6 +101,8 @@ func (log *PbftLog) GetBlocksByNumber(number uint64) []*types.Block {
 
 // DeleteBlocksLessThan deletes blocks less than given block number
 func (log *PbftLog) DeleteBlocksLessThan(number uint64) {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
 	found := mapset.NewSet()
 	it := log.Blocks().Iterator()
 	for block := range it.C {
@@ -98,10 +111,18 @@ func (log *PbftLog) DeleteBlocksLessThan(number uint64) {
 		}
 	}
 	log.blocks = log.blocks.Difference(found)
+	if log.db == nil {
+		return
+	}
+	if err := log.pruneBlocks(number); err != nil {
+		utils.Logger().Warn().Err(err).Uint64("blockNum", number).Msg("[PbftLog] Failed to prune persisted blocks")
+	}
 }
 
 // DeleteBlockByNumber deletes block of specific number
 func (log *PbftLog) DeleteBlockByNumber(number uint64) {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
 	found := mapset.NewSet()
 	it := log.Blocks().Iterator()
 	for block := range it.C {
@@ -110,10 +131,18 @@ func (log *PbftLog) DeleteBlockByNumber(number uint64) {
 		}
 	}
 	log.blocks = log.blocks.Difference(found)
+	if log.db == nil {
+		return
+	}
+	if err := log.persistBlockDeletion(number); err != nil {
+		utils.Logger().Warn().Err(err).Uint64("blockNum", number).Msg("[PbftLog] Failed to delete persisted blocks")
+	}
 }
 
 // DeleteMessagesLessThan deletes messages less than given block number
 func (log *PbftLog) DeleteMessagesLessThan(number uint64) {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
 	found := mapset.NewSet()
 	it := log.Messages().Iterator()
 	for msg := range it.C {
@@ -122,11 +151,27 @@ func (log *PbftLog) DeleteMessagesLessThan(number uint64) {
 		}
 	}
 	log.messages = log.messages.Difference(found)
+	if log.db == nil {
+		return
+	}
+	if err := log.pruneMessages(number); err != nil {
+		utils.Logger().Warn().Err(err).Uint64("blockNum", number).Msg("[PbftLog] Failed to prune persisted messages")
+	}
 }
 
 // AddMessage adds a pbft message into the log
 func (log *PbftLog) AddMessage(msg *PbftMessage) {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
 	log.messages.Add(msg)
+	// prepare and commit votes are only logged to detect double signing
+	// and are not worth a database write each
+	if log.db == nil || msg.MessageType == msg_pb.MessageType_PREPARE || msg.MessageType == msg_pb.MessageType_COMMIT {
+		return
+	}
+	if err := log.persistMessage(msg); err != nil {
+		utils.Logger().Warn().Err(err).Uint64("blockNum", msg.BlockNum).Msg("[PbftLog] Failed to persist message")
+	}
 }
 
 // GetMessagesByTypeSeqViewHash returns pbft messages with matching type, blockNum, viewID and blockHash
@@ -202,6 +247,19 @@ func (log *PbftLog) GetMessagesByTypeSeqView(typ msg_pb.MessageType, blockNum ui
 	return found
 }
 
+// FindConflictingMessage returns a message with the same type, blockNum,
+// viewID and sender as msg but a different blockHash, or nil if there is none.
+// Only messages kept with their signed wire format are considered.
+func (log *PbftLog) FindConflictingMessage(msg *PbftMessage) *PbftMessage {
+	for _, logged := range log.GetMessagesByTypeSeqView(msg.MessageType, msg.BlockNum, msg.ViewID) {
+		if logged.BlockHash != msg.BlockHash && len(logged.SignedMessage) > 0 &&
+			logged.SenderPubkey != nil && logged.SenderPubkey.IsEqual(msg.SenderPubkey) {
+			return logged
+		}
+	}
+	return nil
+}
+
 // FindMessageByMaxViewID returns the message that has maximum ViewID
 func (log *PbftLog) FindMessageByMaxViewID(msgs []*PbftMessage) *PbftMessage {
 	if len(msgs) == 0 {
@@ -0,0 +1,377 @@
+package consensus
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/harmony-one/bls/ffi/go/bls"
+	msg_pb "github.com/harmony-one/harmony/api/proto/message"
+	"github.com/harmony-one/harmony/core/rawdb"
+	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/internal/ctxerror"
+	"github.com/harmony-one/harmony/internal/utils"
+)
+
+// storedPbftMessage is the RLP encoding of PbftMessage written to the database.
+// The M2/M3 bitmaps are not stored because they are only used while
+// processing NEWVIEW messages and are never kept in the log.
+type storedPbftMessage struct {
+	MessageType   uint64
+	ViewID        uint64
+	BlockNum      uint64
+	BlockHash     common.Hash
+	Block         []byte
+	SenderPubkey  []byte
+	LeaderPubkey  []byte
+	Payload       []byte
+	ViewchangeSig []byte
+	ViewidSig     []byte
+}
+
+func newStoredPbftMessage(msg *PbftMessage) *storedPbftMessage {
+	stored := &storedPbftMessage{
+		MessageType: uint64(msg.MessageType),
+		ViewID:      msg.ViewID,
+		BlockNum:    msg.BlockNum,
+		BlockHash:   msg.BlockHash,
+		Block:       msg.Block,
+		Payload:     msg.Payload,
+	}
+	if msg.SenderPubkey != nil {
+		stored.SenderPubkey = msg.SenderPubkey.Serialize()
+	}
+	if msg.LeaderPubkey != nil {
+		stored.LeaderPubkey = msg.LeaderPubkey.Serialize()
+	}
+	if msg.ViewchangeSig != nil {
+		stored.ViewchangeSig = msg.ViewchangeSig.Serialize()
+	}
+	if msg.ViewidSig != nil {
+		stored.ViewidSig = msg.ViewidSig.Serialize()
+	}
+	return stored
+}
+
+func (s *storedPbftMessage) toPbftMessage() (*PbftMessage, error) {
+	msg := &PbftMessage{
+		MessageType: msg_pb.MessageType(s.MessageType),
+		ViewID:      s.ViewID,
+		BlockNum:    s.BlockNum,
+		BlockHash:   s.BlockHash,
+		Block:       s.Block,
+		Payload:     s.Payload,
+	}
+	if len(s.SenderPubkey) > 0 {
+		msg.SenderPubkey = &bls.PublicKey{}
+		if err := msg.SenderPubkey.Deserialize(s.SenderPubkey); err != nil {
+			return nil, ctxerror.New("cannot deserialize sender pubkey").WithCause(err)
+		}
+	}
+	if len(s.LeaderPubkey) > 0 {
+		msg.LeaderPubkey = &bls.PublicKey{}
+		if err := msg.LeaderPubkey.Deserialize(s.LeaderPubkey); err != nil {
+			return nil, ctxerror.New("cannot deserialize leader pubkey").WithCause(err)
+		}
+	}
+	if len(s.ViewchangeSig) > 0 {
+		msg.ViewchangeSig = &bls.Sign{}
+		if err := msg.ViewchangeSig.Deserialize(s.ViewchangeSig); err != nil {
+			return nil, ctxerror.New("cannot deserialize viewchange signature").WithCause(err)
+		}
+	}
+	if len(s.ViewidSig) > 0 {
+		msg.ViewidSig = &bls.Sign{}
+		if err := msg.ViewidSig.Deserialize(s.ViewidSig); err != nil {
+			return nil, ctxerror.New("cannot deserialize viewid signature").WithCause(err)
+		}
+	}
+	return msg, nil
+}
+
+// readStoredMessages returns the messages persisted for the given block number
+func readStoredMessages(db ethdb.Database, number uint64) ([]*storedPbftMessage, error) {
+	count, err := rawdb.ReadPbftLogMessageCount(db, number)
+	if err != nil {
+		return nil, err
+	}
+	msgs := make([]*storedPbftMessage, 0, count)
+	for index := uint32(0); index < count; index++ {
+		data, err := rawdb.ReadPbftLogMessage(db, number, index)
+		if err != nil {
+			return nil, ctxerror.New("cannot read pbft log message",
+				"number", number,
+				"index", index,
+			).WithCause(err)
+		}
+		msg := &storedPbftMessage{}
+		if err := rlp.DecodeBytes(data, msg); err != nil {
+			return nil, ctxerror.New("cannot decode pbft log message",
+				"number", number,
+				"index", index,
+			).WithCause(err)
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+// readStoredBlocks returns the blocks persisted for the given block number
+func readStoredBlocks(db ethdb.Database, number uint64) ([]*types.Block, error) {
+	count, err := rawdb.ReadPbftLogBlockCount(db, number)
+	if err != nil {
+		return nil, err
+	}
+	blocks := make([]*types.Block, 0, count)
+	for index := uint32(0); index < count; index++ {
+		block, err := rawdb.ReadPbftLogBlock(db, number, index)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+// extendRange returns the range widened to cover number
+func extendRange(low, high uint64, found bool, number uint64) (uint64, uint64) {
+	if !found {
+		return number, number
+	}
+	if number < low {
+		low = number
+	}
+	if number > high {
+		high = number
+	}
+	return low, high
+}
+
+// persistMessage appends the message to the write-ahead log of its block
+// number, together with the updated count and range in one batch
+func (log *PbftLog) persistMessage(msg *PbftMessage) error {
+	index, err := rawdb.ReadPbftLogMessageCount(log.db, msg.BlockNum)
+	if err != nil {
+		return err
+	}
+	low, high, found, err := rawdb.ReadPbftLogMessagesRange(log.db)
+	if err != nil {
+		return err
+	}
+	data, err := rlp.EncodeToBytes(newStoredPbftMessage(msg))
+	if err != nil {
+		return ctxerror.New("cannot encode pbft log message",
+			"number", msg.BlockNum,
+		).WithCause(err)
+	}
+	batch := log.db.NewBatch()
+	if err := rawdb.WritePbftLogMessage(batch, msg.BlockNum, index, data); err != nil {
+		return err
+	}
+	low, high = extendRange(low, high, found, msg.BlockNum)
+	if err := rawdb.WritePbftLogMessagesRange(batch, low, high); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// persistBlock appends the block to the write-ahead log of its block number,
+// together with the updated count and range in one batch
+func (log *PbftLog) persistBlock(block *types.Block) error {
+	number := block.NumberU64()
+	if has, err := rawdb.HasPbftLogBlock(log.db, number, block.Hash()); err != nil || has {
+		return err
+	}
+	index, err := rawdb.ReadPbftLogBlockCount(log.db, number)
+	if err != nil {
+		return err
+	}
+	low, high, found, err := rawdb.ReadPbftLogBlocksRange(log.db)
+	if err != nil {
+		return err
+	}
+	batch := log.db.NewBatch()
+	if err := rawdb.WritePbftLogBlock(batch, index, block); err != nil {
+		return err
+	}
+	low, high = extendRange(low, high, found, number)
+	if err := rawdb.WritePbftLogBlocksRange(batch, low, high); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// deleteMessages adds the removal of the messages persisted for the given
+// block number to the batch
+func (log *PbftLog) deleteMessages(batch ethdb.Batch, number uint64) error {
+	count, err := rawdb.ReadPbftLogMessageCount(log.db, number)
+	if err != nil {
+		return err
+	}
+	return rawdb.DeletePbftLogMessages(batch, number, count)
+}
+
+// deleteBlocks adds the removal of the blocks persisted for the given block
+// number to the batch
+func (log *PbftLog) deleteBlocks(batch ethdb.Batch, number uint64) error {
+	blocks, err := readStoredBlocks(log.db, number)
+	if err != nil {
+		return err
+	}
+	for index, block := range blocks {
+		if err := rawdb.DeletePbftLogBlock(batch, uint32(index), block); err != nil {
+			return err
+		}
+	}
+	return rawdb.DeletePbftLogBlockCount(batch, number)
+}
+
+// persistBlockDeletion removes the blocks persisted for the given block number
+func (log *PbftLog) persistBlockDeletion(number uint64) error {
+	batch := log.db.NewBatch()
+	if err := log.deleteBlocks(batch, number); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// pruneMessages removes persisted messages whose block number is less than number
+func (log *PbftLog) pruneMessages(number uint64) error {
+	low, high, found, err := rawdb.ReadPbftLogMessagesRange(log.db)
+	if err != nil || !found || low >= number {
+		return err
+	}
+	batch := log.db.NewBatch()
+	for n := low; n < number && n <= high; n++ {
+		if err := log.deleteMessages(batch, n); err != nil {
+			return err
+		}
+	}
+	if number > high {
+		high = number
+	}
+	if err := rawdb.WritePbftLogMessagesRange(batch, number, high); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// pruneBlocks removes persisted blocks whose block number is less than number
+func (log *PbftLog) pruneBlocks(number uint64) error {
+	low, high, found, err := rawdb.ReadPbftLogBlocksRange(log.db)
+	if err != nil || !found || low >= number {
+		return err
+	}
+	batch := log.db.NewBatch()
+	for n := low; n < number && n <= high; n++ {
+		if err := log.deleteBlocks(batch, n); err != nil {
+			return err
+		}
+	}
+	if number > high {
+		high = number
+	}
+	if err := rawdb.WritePbftLogBlocksRange(batch, number, high); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// SetDB attaches a database to the log, so that every message and block added
+// from now on is also written to the database, and loads the entries that
+// survived a previous run into memory.
+func (log *PbftLog) SetDB(db ethdb.Database) error {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+	log.db = db
+
+	low, high, found, err := rawdb.ReadPbftLogBlocksRange(db)
+	if err != nil {
+		return ctxerror.New("cannot read pbft log blocks range").WithCause(err)
+	}
+	for n := low; found && n <= high; n++ {
+		blocks, err := readStoredBlocks(db, n)
+		if err != nil {
+			return ctxerror.New("cannot restore pbft log blocks",
+				"number", n,
+			).WithCause(err)
+		}
+		for _, block := range blocks {
+			log.blocks.Add(block)
+		}
+	}
+	low, high, found, err = rawdb.ReadPbftLogMessagesRange(db)
+	if err != nil {
+		return ctxerror.New("cannot read pbft log messages range").WithCause(err)
+	}
+	for n := low; found && n <= high; n++ {
+		stored, err := readStoredMessages(db, n)
+		if err != nil {
+			return err
+		}
+		for _, s := range stored {
+			msg, err := s.toPbftMessage()
+			if err != nil {
+				return ctxerror.New("cannot restore pbft message",
+					"number", n,
+				).WithCause(err)
+			}
+			log.messages.Add(msg)
+		}
+	}
+	utils.Logger().Info().
+		Int("numBlocks", log.blocks.Cardinality()).
+		Int("numMessages", log.messages.Cardinality()).
+		Msg("[PbftLog] Restored pbft log from database")
+	return nil
+}
+
+// restorePbftLog attaches the chain database to the PbftLog and replays the
+// messages written before the last shutdown, so that the node rejoins the
+// round and view it was in instead of waiting for a view change.
+// Start runs it before the consensus loop, once ChainReader is set and
+// UpdateConsensusInformation has been run; the chain database is not open
+// yet when New is called.
+func (consensus *Consensus) restorePbftLog() error {
+	if consensus.ChainReader == nil || consensus.PbftLog.db != nil {
+		return nil
+	}
+	if err := consensus.PbftLog.SetDB(consensus.ChainReader.ChainDb()); err != nil {
+		return err
+	}
+	height := consensus.ChainReader.CurrentHeader().Number().Uint64()
+	consensus.PbftLog.DeleteBlocksLessThan(height + 1)
+	consensus.PbftLog.DeleteMessagesLessThan(height + 1)
+
+	blockNum := height + 1
+	announces := consensus.PbftLog.GetMessagesByTypeSeq(msg_pb.MessageType_ANNOUNCE, blockNum)
+	announce := consensus.PbftLog.FindMessageByMaxViewID(announces)
+	if announce == nil {
+		return nil
+	}
+
+	consensus.mutex.Lock()
+	defer consensus.mutex.Unlock()
+	consensus.blockNum = blockNum
+	if announce.ViewID > consensus.viewID {
+		consensus.viewID = announce.ViewID
+		consensus.mode.SetViewID(announce.ViewID)
+	}
+	consensus.LeaderPubKey = announce.SenderPubkey
+	consensus.blockHash = announce.BlockHash
+
+	prepareds := consensus.PbftLog.GetMessagesByTypeSeq(msg_pb.MessageType_PREPARED, blockNum)
+	if prepared := consensus.PbftLog.FindMessageByMaxViewID(prepareds); prepared != nil {
+		consensus.blockHash = prepared.BlockHash
+		if block := consensus.PbftLog.GetBlockByHash(prepared.BlockHash); block != nil {
+			if encodedBlock, err := rlp.EncodeToBytes(block); err == nil {
+				consensus.block = encodedBlock
+			}
+		}
+	}
+	consensus.getLogger().Info().
+		Uint64("blockNum", consensus.blockNum).
+		Uint64("viewID", consensus.viewID).
+		Hex("blockHash", consensus.blockHash[:]).
+		Msg("[restorePbftLog] Rejoining round from pbft log")
+	return nil
+}
@@ -1,11 +1,16 @@
 package consensus
 
 import (
+	"math/big"
+	"sync"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/ethdb"
 	protobuf "github.com/golang/protobuf/proto"
 	"github.com/harmony-one/harmony/api/proto"
 	msg_pb "github.com/harmony-one/harmony/api/proto/message"
+	blockfactory "github.com/harmony-one/harmony/block/factory"
+	"github.com/harmony-one/harmony/core/types"
 	"github.com/harmony-one/harmony/crypto/bls"
 	"github.com/harmony-one/harmony/internal/utils"
 	"github.com/harmony-one/harmony/p2p"
@@ -81,3 +86,121 @@ func TestHasMatchingAnnounce(t *testing.T) {
 		t.Error("notFound should be false")
 	}
 }
+
+func TestPbftLogPersistence(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	log := NewPbftLog()
+	if err := log.SetDB(db); err != nil {
+		t.Fatalf("cannot set db: %v", err)
+	}
+	senderKey := bls.RandPrivateKey().GetPublicKey()
+	log.AddMessage(&PbftMessage{MessageType: msg_pb.MessageType_ANNOUNCE, BlockNum: 2, ViewID: 3, BlockHash: [32]byte{01, 02}, SenderPubkey: senderKey})
+	log.AddMessage(&PbftMessage{MessageType: msg_pb.MessageType_PREPARED, BlockNum: 3, ViewID: 4, BlockHash: [32]byte{01, 03}, SenderPubkey: senderKey})
+
+	restored := NewPbftLog()
+	if err := restored.SetDB(db); err != nil {
+		t.Fatalf("cannot restore from db: %v", err)
+	}
+	if !restored.HasMatchingViewAnnounce(2, 3, [32]byte{01, 02}) {
+		t.Error("announce message not restored")
+	}
+	msgs := restored.GetMessagesByTypeSeq(msg_pb.MessageType_PREPARED, 3)
+	if len(msgs) != 1 || !msgs[0].SenderPubkey.IsEqual(senderKey) {
+		t.Error("prepared message not restored")
+	}
+
+	restored.DeleteMessagesLessThan(3)
+	pruned := NewPbftLog()
+	if err := pruned.SetDB(db); err != nil {
+		t.Fatalf("cannot restore from db: %v", err)
+	}
+	if pruned.HasMatchingViewAnnounce(2, 3, [32]byte{01, 02}) {
+		t.Error("pruned message restored")
+	}
+	if len(pruned.GetMessagesByTypeSeq(msg_pb.MessageType_PREPARED, 3)) != 1 {
+		t.Error("message above pruning height not restored")
+	}
+}
+
+func TestPbftLogBlockPersistence(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	log := NewPbftLog()
+	if err := log.SetDB(db); err != nil {
+		t.Fatalf("cannot set db: %v", err)
+	}
+	newBlock := func(number int64, viewID uint64) *types.Block {
+		header := blockfactory.NewTestHeader().With().Number(big.NewInt(number)).ViewID(new(big.Int).SetUint64(viewID)).Header()
+		return types.NewBlockWithHeader(header)
+	}
+	block1, block2a, block2b := newBlock(1, 1), newBlock(2, 2), newBlock(2, 3)
+	log.AddBlock(block1)
+	log.AddBlock(block2a)
+	log.AddBlock(block2b)
+	log.AddBlock(block2a)
+
+	restored := NewPbftLog()
+	if err := restored.SetDB(db); err != nil {
+		t.Fatalf("cannot restore from db: %v", err)
+	}
+	if restored.Blocks().Cardinality() != 3 {
+		t.Errorf("expected 3 restored blocks, got %d", restored.Blocks().Cardinality())
+	}
+	if restored.GetBlockByHash(block2b.Hash()) == nil {
+		t.Error("block not restored")
+	}
+
+	restored.DeleteBlockByNumber(2)
+	restored.DeleteBlocksLessThan(2)
+	pruned := NewPbftLog()
+	if err := pruned.SetDB(db); err != nil {
+		t.Fatalf("cannot restore from db: %v", err)
+	}
+	if pruned.Blocks().Cardinality() != 0 {
+		t.Errorf("expected no restored blocks, got %d", pruned.Blocks().Cardinality())
+	}
+
+	// a block deleted from the log can be added again
+	pruned.AddBlock(block2a)
+	again := NewPbftLog()
+	if err := again.SetDB(db); err != nil {
+		t.Fatalf("cannot restore from db: %v", err)
+	}
+	if again.GetBlockByHash(block2a.Hash()) == nil {
+		t.Error("re-added block not restored")
+	}
+}
+
+func TestPbftLogConcurrentBlockDeletion(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	log := NewPbftLog()
+	if err := log.SetDB(db); err != nil {
+		t.Fatalf("cannot set db: %v", err)
+	}
+	var wg sync.WaitGroup
+	for i := int64(1); i <= 20; i++ {
+		wg.Add(1)
+		go func(number int64) {
+			defer wg.Done()
+			header := blockfactory.NewTestHeader().With().Number(big.NewInt(number)).Header()
+			log.AddBlock(types.NewBlockWithHeader(header))
+			if number%2 == 0 {
+				log.DeleteBlockByNumber(uint64(number))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// the database holds the blocks the log does
+	restored := NewPbftLog()
+	if err := restored.SetDB(db); err != nil {
+		t.Fatalf("cannot restore from db: %v", err)
+	}
+	if restored.Blocks().Cardinality() != 10 || log.Blocks().Cardinality() != 10 {
+		t.Errorf("restored %d blocks of %d, want 10", restored.Blocks().Cardinality(), log.Blocks().Cardinality())
+	}
+	for i := uint64(1); i <= 20; i++ {
+		if found := len(restored.GetBlocksByNumber(i)); found != int(i%2) {
+			t.Errorf("restored %d blocks of number %d, want %d", found, i, i%2)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package consensus
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/rlp"
+	protobuf "github.com/golang/protobuf/proto"
+
+	"github.com/harmony-one/harmony/api/proto"
+	msg_pb "github.com/harmony-one/harmony/api/proto/message"
+	"github.com/harmony-one/harmony/core"
+	"github.com/harmony-one/harmony/core/types"
+	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/internal/utils"
+	"github.com/harmony-one/harmony/p2p"
+	"github.com/harmony-one/harmony/p2p/host"
+)
+
+// PipelinedParent is a block which reached its commit quorum but is not
+// finalized yet, which the leader builds the next block on.
+type PipelinedParent struct {
+	Block *types.Block
+	// CommitSig and CommitBitmap are the commit signatures of the quorum,
+	// which seal the next block.
+	CommitSig    []byte
+	CommitBitmap []byte
+	// ViewID is the view ID of the next block.
+	ViewID uint64
+}
+
+// Pipelining returns whether the leader proposes the next block before the
+// current one is finalized.
+func (consensus *Consensus) Pipelining() bool {
+	return consensus.pipelining
+}
+
+// SetPipelining sets whether the leader proposes the next block as soon as
+// the current one reaches its commit quorum, and announces it during the
+// commit grace period.  Validators hold such an announce until they commit
+// the current block.  The next block carries the commit signatures of the
+// quorum as its seal, so it cannot be proposed any earlier.
+//
+// Pipelining has these limits:
+//   - The commit set is frozen at the quorum: commits arriving later are not
+//     counted, so that the committed message and the seal of the next block
+//     carry the same signatures, and late signers earn no block reward.
+//   - Only one block is in flight ahead of the chain.  The PbftLog and the
+//     held announce only cover the height after the current one; a validator
+//     further behind catches up through committed messages or syncing.
+//   - Transactions are throttled against the recent blocks of the chain plus
+//     the pipelined parent, which is not in the chain yet.
+//
+// Blocks are not pipelined with leader rotation, at the end of an epoch, or
+// while the beacon chain generates randomness, since the leader, committee
+// or randomness of the next block depend on the finalized current block.
+func (consensus *Consensus) SetPipelining(enabled bool) {
+	consensus.pipelining = enabled
+}
+
+// PipelinedParent returns the block to build the next block on if it is not
+// finalized yet, or nil if the next block is built on the current block of
+// the chain.
+func (consensus *Consensus) PipelinedParent() *PipelinedParent {
+	consensus.pipelineMutex.Lock()
+	defer consensus.pipelineMutex.Unlock()
+	return consensus.pipelineParent
+}
+
+func (consensus *Consensus) setPipelinedParent(parent *PipelinedParent) {
+	consensus.pipelineMutex.Lock()
+	defer consensus.pipelineMutex.Unlock()
+	consensus.pipelineParent = parent
+}
+
+// canPipeline returns whether the leader may propose the block after the
+// given one before it is finalized.
+func (consensus *Consensus) canPipeline(block *types.Block) bool {
+	return consensus.pipelining &&
+		consensus.leaderRotation == NoRotation &&
+		consensus.IsLeader() &&
+		consensus.mode.Mode() == Normal &&
+		!core.ShardingSchedule.IsLastBlock(block.NumberU64()) &&
+		!consensus.NeedsRandomNumberGeneration(block.Header().Epoch())
+}
+
+// commitsFrozen returns whether the next block was already proposed on the
+// current one, sealed with the commit signatures received so far.
+func (consensus *Consensus) commitsFrozen() bool {
+	return consensus.pipelineSignalled != 0 && consensus.pipelineSignalled == consensus.blockNum
+}
+
+// startPipeline signals the node to propose the next block on top of the
+// current block, which just reached its commit quorum.
+func (consensus *Consensus) startPipeline() {
+	block := consensus.PbftLog.GetBlockByHash(consensus.blockHash)
+	if block == nil || !consensus.canPipeline(block) {
+		return
+	}
+	aggSig := bls_cosi.AggregateSig(consensus.GetCommitSigsArray())
+	consensus.setPipelinedParent(&PipelinedParent{
+		Block:        block,
+		CommitSig:    aggSig.Serialize(),
+		CommitBitmap: append([]byte{}, consensus.commitBitmap.Bitmap...),
+		ViewID:       consensus.viewID + 1,
+	})
+	consensus.pipelineSignalled = consensus.blockNum
+	utils.Logger().Info().
+		Uint64("blockNum", consensus.blockNum).
+		Msg("[Pipeline] Proposing the next block before finalizing")
+	go func() {
+		consensus.ReadySignal <- struct{}{}
+	}()
+}
+
+// onPipelinedProposal announces a proposed block built on the current block
+// before the current block is finalized, and drops a proposed block whose
+// parent was never finalized.  It returns whether it handled the block.
+func (consensus *Consensus) onPipelinedProposal(newBlock *types.Block) bool {
+	if !consensus.pipelining {
+		return false
+	}
+	if newBlock.NumberU64() == consensus.blockNum+1 {
+		if newBlock.ParentHash() == consensus.blockHash && consensus.IsLeader() && consensus.mode.Mode() == Normal {
+			consensus.announcePipelined(newBlock)
+		} else {
+			utils.Logger().Warn().
+				Uint64("MsgBlockNum", newBlock.NumberU64()).
+				Uint64("blockNum", consensus.blockNum).
+				Msg("[Pipeline] Dropping block proposed on an abandoned block")
+		}
+		return true
+	}
+	if newBlock.ParentHash() != consensus.ChainReader.CurrentHeader().Hash() {
+		utils.Logger().Warn().
+			Uint64("MsgBlockNum", newBlock.NumberU64()).
+			Uint64("blockNum", consensus.blockNum).
+			Msg("[Pipeline] Dropping block proposed on an abandoned block")
+		return true
+	}
+	return false
+}
+
+// announcePipelined broadcasts the announce of the next block without
+// touching the state of the current round, which is still collecting commit
+// signatures.  The round of the next block starts once the current block is
+// finalized.
+func (consensus *Consensus) announcePipelined(block *types.Block) {
+	encodedHeader, err := rlp.EncodeToBytes(block.Header())
+	if err != nil {
+		utils.Logger().Debug().Msg("[Pipeline] Failed encoding block header")
+		return
+	}
+	msgToSend := consensus.constructPipelinedAnnounceMessage(block, encodedHeader)
+
+	msgPayload, _ := proto.GetConsensusMessagePayload(msgToSend)
+	msg := &msg_pb.Message{}
+	_ = protobuf.Unmarshal(msgPayload, msg)
+	pbftMsg, err := ParsePbftMessage(msg)
+	if err != nil {
+		utils.Logger().Warn().Err(err).Msg("[Pipeline] Unable to parse pbft message")
+		return
+	}
+	consensus.PbftLog.AddMessage(pbftMsg)
+	consensus.PbftLog.AddBlock(block)
+	consensus.pipelinedBlock = block
+	consensus.Tracer.Announce(block.NumberU64(), pbftMsg.ViewID, block.Hash(), consensus.PubKey.SerializeToHexStr())
+	consensus.timing.RoundStarted(block.NumberU64())
+
+	consensus.msgSender.Reset(block.NumberU64())
+	if err := consensus.msgSender.SendWithRetry(block.NumberU64(), msg_pb.MessageType_ANNOUNCE, []p2p.GroupID{p2p.NewGroupIDByShardID(p2p.ShardID(consensus.ShardID))}, host.ConstructP2pMessage(byte(17), msgToSend)); err != nil {
+		utils.Logger().Warn().
+			Uint64("blockNum", block.NumberU64()).
+			Msg("[Pipeline] Cannot send announce message")
+	} else {
+		utils.Logger().Info().
+			Str("blockHash", block.Hash().Hex()).
+			Uint64("blockNum", block.NumberU64()).
+			Msg("[Pipeline] Sent Announce Message before finalizing the previous block")
+	}
+}
+
+// continuePipeline starts the round of the next block after the leader
+// finalized the block of the given number, if the next block was already
+// proposed.  It returns whether the node was already asked for the next
+// block, in which case it must not be asked again.
+func (consensus *Consensus) continuePipeline(finalizedNum uint64) bool {
+	if !consensus.pipelining || consensus.pipelineSignalled != finalizedNum {
+		return false
+	}
+	block := consensus.pipelinedBlock
+	consensus.pipelinedBlock = nil
+	if block == nil {
+		// not proposed yet; it will be announced as usual when it is
+		return true
+	}
+	if block.NumberU64() != consensus.blockNum || block.ParentHash() != consensus.ChainReader.CurrentHeader().Hash() {
+		utils.Logger().Warn().
+			Uint64("MsgBlockNum", block.NumberU64()).
+			Uint64("blockNum", consensus.blockNum).
+			Msg("[Pipeline] Announced block does not extend the finalized block")
+		return false
+	}
+
+	encodedBlock, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		utils.Logger().Debug().Msg("[Pipeline] Failed encoding block")
+		return false
+	}
+	encodedHeader, err := rlp.EncodeToBytes(block.Header())
+	if err != nil {
+		utils.Logger().Debug().Msg("[Pipeline] Failed encoding block header")
+		return false
+	}
+	blockHash := block.Hash()
+	copy(consensus.blockHash[:], blockHash[:])
+	consensus.block = encodedBlock
+	consensus.blockHeader = encodedHeader
+	startTime = time.Now()
+
+	// Leader sign the block hash itself
+	consensus.prepareSigs[consensus.PubKey.SerializeToHexStr()] = consensus.priKey.SignHash(consensus.blockHash[:])
+	if err := consensus.prepareBitmap.SetKey(consensus.PubKey, true); err != nil {
+		utils.Logger().Warn().Err(err).Msg("[Pipeline] Leader prepareBitmap SetKey failed")
+	}
+	utils.Logger().Debug().
+		Str("From", consensus.phase.String()).
+		Str("To", Prepare.String()).
+		Uint64("blockNum", consensus.blockNum).
+		Msg("[Pipeline] Switching phase")
+	consensus.switchPhase(Prepare, true)
+	return true
+}
+
+// stopPipeline forgets the blocks proposed ahead of the current one, which a
+// view change abandons.
+func (consensus *Consensus) stopPipeline() {
+	consensus.pipelineSignalled = 0
+	consensus.pipelinedBlock = nil
+	consensus.pendingAnnounce = nil
+	consensus.setPipelinedParent(nil)
+}
+
+// holdAnnounce keeps the announce of the block after the current one until
+// the current block is committed, since the announced block can only be
+// verified on top of it.  It returns whether it kept the announce.
+func (consensus *Consensus) holdAnnounce(msg *msg_pb.Message, recvMsg *PbftMessage) bool {
+	if !consensus.pipelining || consensus.mode.Mode() != Normal || recvMsg.BlockNum != consensus.blockNum+1 {
+		return false
+	}
+	payload, err := protobuf.Marshal(msg)
+	if err != nil {
+		return false
+	}
+	consensus.pendingAnnounce = payload
+	utils.Logger().Debug().
+		Uint64("MsgBlockNum", recvMsg.BlockNum).
+		Uint64("blockNum", consensus.blockNum).
+		Msg("[Pipeline] Holding announce until the current block is committed")
+	return true
+}
+
+// replayAnnounce processes the announce held by holdAnnounce once the block
+// before it is committed.
+func (consensus *Consensus) replayAnnounce() {
+	payload := consensus.pendingAnnounce
+	if payload == nil {
+		return
+	}
+	consensus.pendingAnnounce = nil
+	go func() {
+		consensus.MsgChan <- payload
+	}()
+}
@@ -0,0 +1,136 @@
+// Package quorum implements the policies deciding whether the signers of a
+// committee have enough voting power to advance consensus, shared by
+// consensus and by the verification of the seals and commit signatures of
+// blocks.
+package quorum
+
+import (
+	"math/big"
+
+	"github.com/harmony-one/bls/ffi/go/bls"
+
+	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/internal/utils"
+	"github.com/harmony-one/harmony/shard"
+)
+
+// Decider decides whether the signers recorded in a participation mask have
+// enough voting power to advance consensus on a block of the given epoch.
+// The committee the votes are counted against is the list of public keys the
+// mask was created with.
+type Decider interface {
+	// IsQuorumAchieved returns whether the signers in the mask reach the
+	// quorum (2f+1) needed for prepare, commit and view change.
+	IsQuorumAchieved(epoch *big.Int, mask *bls_cosi.Mask) bool
+
+	// IsRewardThresholdAchieved returns whether the signers in the mask reach
+	// the threshold at which the leader stops waiting for more commits.
+	IsRewardThresholdAchieved(epoch *big.Int, mask *bls_cosi.Mask) bool
+}
+
+// StakeFinder finds the stakes the votes on the blocks of an epoch are
+// weighed by.  It must find the same stakes for an epoch whenever it is
+// asked, so that a vote weighs the same however late it is checked.
+type StakeFinder interface {
+	// FindEpochStakes returns the amounts staked on the node keys as of the
+	// start of the given epoch.  Keys without stake may be left out.
+	FindEpochStakes(epoch *big.Int) (map[shard.BlsPublicKey]*big.Int, error)
+}
+
+// countDecider counts every BLS key in the committee equally.
+type countDecider struct{}
+
+// NewCountDecider returns a quorum decider that requires 2f+1 of the
+// committee keys to sign, regardless of their stake.
+func NewCountDecider() Decider {
+	return countDecider{}
+}
+
+func (countDecider) IsQuorumAchieved(epoch *big.Int, mask *bls_cosi.Mask) bool {
+	return mask.CountEnabled() >= mask.CountTotal()*2/3+1
+}
+
+func (countDecider) IsRewardThresholdAchieved(epoch *big.Int, mask *bls_cosi.Mask) bool {
+	return mask.CountEnabled() >= mask.CountTotal()*9/10
+}
+
+// stakeWeightedDecider weights every BLS key in the committee by the amount
+// staked on it, on top of counting the keys.
+type stakeWeightedDecider struct {
+	finder StakeFinder
+	count  Decider
+}
+
+// NewStakeWeightedDecider returns a quorum decider that requires more than
+// 2/3 of the committee's stake to sign, as well as 2f+1 of the committee
+// keys, so that a few staked keys cannot reach quorum without the rest of
+// the committee.  The stakes are those the given finder finds for the epoch
+// of the block voted on, and no quorum is reached if it cannot find them.  If
+// the committee has no stake at all, e.g. a committee made of genesis nodes
+// only, only the keys are counted.
+func NewStakeWeightedDecider(finder StakeFinder) Decider {
+	return stakeWeightedDecider{
+		finder: finder,
+		count:  NewCountDecider(),
+	}
+}
+
+// votingPower returns the stake of the signers in the mask and the stake of
+// the whole committee as of the start of the given epoch.
+func (d stakeWeightedDecider) votingPower(epoch *big.Int, mask *bls_cosi.Mask) (*big.Int, *big.Int, error) {
+	stakes, err := d.finder.FindEpochStakes(epoch)
+	if err != nil {
+		return nil, nil, err
+	}
+	stakeOf := func(key *bls.PublicKey) *big.Int {
+		var pk shard.BlsPublicKey
+		if err := pk.FromLibBLSPublicKey(key); err != nil {
+			return nil
+		}
+		return stakes[pk]
+	}
+	signed, total := big.NewInt(0), big.NewInt(0)
+	for _, key := range mask.GetPubKeyFromMask(true) {
+		if stake := stakeOf(key); stake != nil {
+			signed.Add(signed, stake)
+			total.Add(total, stake)
+		}
+	}
+	for _, key := range mask.GetPubKeyFromMask(false) {
+		if stake := stakeOf(key); stake != nil {
+			total.Add(total, stake)
+		}
+	}
+	return signed, total, nil
+}
+
+// hasStakeShare returns whether the signers in the mask hold more than, or
+// at least if orEqual, num/denom of the stake of the committee as of the
+// start of the given epoch.
+func (d stakeWeightedDecider) hasStakeShare(
+	epoch *big.Int, mask *bls_cosi.Mask, num, denom int64, orEqual bool,
+) bool {
+	signed, total, err := d.votingPower(epoch, mask)
+	if err != nil {
+		utils.Logger().Warn().Err(err).
+			Str("epoch", epoch.String()).
+			Msg("[StakeWeightedDecider] cannot find the stakes of the epoch")
+		return false
+	}
+	if total.Sign() == 0 {
+		// nobody has stake
+		return true
+	}
+	cmp := new(big.Int).Mul(signed, big.NewInt(denom)).Cmp(new(big.Int).Mul(total, big.NewInt(num)))
+	return cmp > 0 || (orEqual && cmp == 0)
+}
+
+func (d stakeWeightedDecider) IsQuorumAchieved(epoch *big.Int, mask *bls_cosi.Mask) bool {
+	// signed > total * 2/3
+	return d.count.IsQuorumAchieved(epoch, mask) && d.hasStakeShare(epoch, mask, 2, 3, false)
+}
+
+func (d stakeWeightedDecider) IsRewardThresholdAchieved(epoch *big.Int, mask *bls_cosi.Mask) bool {
+	// signed >= total * 9/10
+	return d.count.IsRewardThresholdAchieved(epoch, mask) && d.hasStakeShare(epoch, mask, 9, 10, true)
+}
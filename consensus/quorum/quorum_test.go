@@ -0,0 +1,172 @@
+package quorum
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/harmony-one/bls/ffi/go/bls"
+
+	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/shard"
+)
+
+// fakeStakeFinder finds the stakes given by epoch and by committee key.
+type fakeStakeFinder map[int64]map[*bls.PublicKey]int64
+
+func (f fakeStakeFinder) FindEpochStakes(epoch *big.Int) (map[shard.BlsPublicKey]*big.Int, error) {
+	byKey, ok := f[epoch.Int64()]
+	if !ok {
+		return nil, errors.New("no stakes for the epoch")
+	}
+	stakes := make(map[shard.BlsPublicKey]*big.Int)
+	for key, amount := range byKey {
+		var pk shard.BlsPublicKey
+		if err := pk.FromLibBLSPublicKey(key); err != nil {
+			return nil, err
+		}
+		stakes[pk] = big.NewInt(amount)
+	}
+	return stakes, nil
+}
+
+// testEpoch is the epoch of the votes of the tests, whose stakes are at key 1
+// of the fake stake finders.
+var testEpoch = big.NewInt(1)
+
+func newTestCommittee(size int) []*bls.PublicKey {
+	pubKeys := []*bls.PublicKey{}
+	for i := 0; i < size; i++ {
+		pubKeys = append(pubKeys, bls_cosi.RandPrivateKey().GetPublicKey())
+	}
+	return pubKeys
+}
+
+func TestCountDecider(t *testing.T) {
+	pubKeys := newTestCommittee(4)
+	mask, err := bls_cosi.NewMask(pubKeys, nil)
+	if err != nil {
+		t.Fatalf("cannot create mask: %v", err)
+	}
+	decider := NewCountDecider()
+	mask.SetKey(pubKeys[0], true)
+	mask.SetKey(pubKeys[1], true)
+	if decider.IsQuorumAchieved(testEpoch, mask) {
+		t.Error("2 of 4 keys should not reach quorum")
+	}
+	if decider.IsRewardThresholdAchieved(testEpoch, mask) {
+		t.Error("2 of 4 keys should not reach reward threshold")
+	}
+	mask.SetKey(pubKeys[2], true)
+	if !decider.IsQuorumAchieved(testEpoch, mask) {
+		t.Error("3 of 4 keys should reach quorum")
+	}
+	if !decider.IsRewardThresholdAchieved(testEpoch, mask) {
+		t.Error("3 of 4 keys should reach reward threshold")
+	}
+}
+
+func TestStakeWeightedDecider(t *testing.T) {
+	pubKeys := newTestCommittee(4)
+	finder := fakeStakeFinder{1: {
+		pubKeys[0]: 70,
+		pubKeys[1]: 10,
+		pubKeys[2]: 10,
+		pubKeys[3]: 10,
+	}}
+	mask, err := bls_cosi.NewMask(pubKeys, nil)
+	if err != nil {
+		t.Fatalf("cannot create mask: %v", err)
+	}
+	decider := NewStakeWeightedDecider(finder)
+	mask.SetKey(pubKeys[1], true)
+	mask.SetKey(pubKeys[2], true)
+	mask.SetKey(pubKeys[3], true)
+	if decider.IsQuorumAchieved(testEpoch, mask) {
+		t.Error("30% of stake should not reach quorum")
+	}
+	mask.SetKey(pubKeys[1], false)
+	mask.SetKey(pubKeys[0], true)
+	if !decider.IsQuorumAchieved(testEpoch, mask) {
+		t.Error("90% of stake should reach quorum")
+	}
+	if !decider.IsRewardThresholdAchieved(testEpoch, mask) {
+		t.Error("90% of stake should reach reward threshold")
+	}
+}
+
+func TestStakeWeightedDeciderWithoutStake(t *testing.T) {
+	pubKeys := newTestCommittee(4)
+	mask, err := bls_cosi.NewMask(pubKeys, nil)
+	if err != nil {
+		t.Fatalf("cannot create mask: %v", err)
+	}
+	decider := NewStakeWeightedDecider(fakeStakeFinder{1: {}})
+	mask.SetKey(pubKeys[0], true)
+	mask.SetKey(pubKeys[1], true)
+	mask.SetKey(pubKeys[2], true)
+	if !decider.IsQuorumAchieved(testEpoch, mask) {
+		t.Error("committee without stake should fall back to counting keys")
+	}
+}
+
+func TestStakeWeightedDeciderWithUnstakedKeys(t *testing.T) {
+	// two of six keys hold all the stake
+	pubKeys := newTestCommittee(6)
+	finder := fakeStakeFinder{1: {
+		pubKeys[0]: 50,
+		pubKeys[1]: 50,
+	}}
+	mask, err := bls_cosi.NewMask(pubKeys, nil)
+	if err != nil {
+		t.Fatalf("cannot create mask: %v", err)
+	}
+	decider := NewStakeWeightedDecider(finder)
+	mask.SetKey(pubKeys[0], true)
+	mask.SetKey(pubKeys[1], true)
+	if decider.IsQuorumAchieved(testEpoch, mask) {
+		t.Error("the staked keys alone should not reach quorum")
+	}
+	if decider.IsRewardThresholdAchieved(testEpoch, mask) {
+		t.Error("the staked keys alone should not reach reward threshold")
+	}
+	mask.SetKey(pubKeys[2], true)
+	mask.SetKey(pubKeys[3], true)
+	mask.SetKey(pubKeys[4], true)
+	if !decider.IsQuorumAchieved(testEpoch, mask) {
+		t.Error("5 of 6 keys with all the stake should reach quorum")
+	}
+	// and the unstaked keys alone reach no quorum either
+	mask.SetKey(pubKeys[0], false)
+	mask.SetKey(pubKeys[1], false)
+	mask.SetKey(pubKeys[5], true)
+	if decider.IsQuorumAchieved(testEpoch, mask) {
+		t.Error("the unstaked keys alone should not reach quorum")
+	}
+}
+
+func TestStakeWeightedDeciderByEpoch(t *testing.T) {
+	pubKeys := newTestCommittee(4)
+	// the stake moves from the first key to the last one in epoch 2
+	finder := fakeStakeFinder{
+		1: {pubKeys[0]: 70, pubKeys[1]: 10, pubKeys[2]: 10, pubKeys[3]: 10},
+		2: {pubKeys[0]: 10, pubKeys[1]: 10, pubKeys[2]: 10, pubKeys[3]: 70},
+	}
+	mask, err := bls_cosi.NewMask(pubKeys, nil)
+	if err != nil {
+		t.Fatalf("cannot create mask: %v", err)
+	}
+	decider := NewStakeWeightedDecider(finder)
+	mask.SetKey(pubKeys[0], true)
+	mask.SetKey(pubKeys[1], true)
+	mask.SetKey(pubKeys[2], true)
+	if !decider.IsQuorumAchieved(big.NewInt(1), mask) {
+		t.Error("90% of the stake of epoch 1 should reach quorum in epoch 1")
+	}
+	if decider.IsQuorumAchieved(big.NewInt(2), mask) {
+		t.Error("30% of the stake of epoch 2 should not reach quorum in epoch 2")
+	}
+	if decider.IsQuorumAchieved(big.NewInt(3), mask) {
+		t.Error("unknown stakes should not reach quorum")
+	}
+}
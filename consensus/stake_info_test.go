@@ -0,0 +1,167 @@
+package consensus
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/harmony-one/bls/ffi/go/bls"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/harmony-one/harmony/block"
+	blockfactory "github.com/harmony-one/harmony/block/factory"
+	consensus_engine "github.com/harmony-one/harmony/consensus/engine"
+	"github.com/harmony-one/harmony/consensus/quorum"
+	"github.com/harmony-one/harmony/core"
+	"github.com/harmony-one/harmony/core/state"
+	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/core/vm"
+	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/internal/params"
+	"github.com/harmony-one/harmony/shard"
+)
+
+// storageRecorder is a state recording the storage it is given.
+type storageRecorder struct {
+	vm.StateDB
+	storage map[common.Hash]common.Hash
+}
+
+func (r *storageRecorder) SetState(addr common.Address, key, value common.Hash) {
+	r.StateDB.SetState(addr, key, value)
+	r.storage[key] = value
+}
+
+func TestRegistryStakeInfoFinder(t *testing.T) {
+	staked, unstaked := bls_cosi.RandPrivateKey().GetPublicKey(), bls_cosi.RandPrivateKey().GetPublicKey()
+	db, err := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	if err != nil {
+		t.Fatalf("cannot create state: %v", err)
+	}
+	recorder := &storageRecorder{StateDB: db, storage: make(map[common.Hash]common.Hash)}
+	validator := &types.Validator{
+		Address:     common.HexToAddress("0x0a"),
+		Stake:       new(big.Int).Set(core.MinValidatorStake),
+		TotalShares: common.Big1,
+	}
+	assert.NoError(t, validator.BlsPublicKey.FromLibBLSPublicKey(staked))
+	assert.NoError(t, core.WriteValidator(recorder, validator))
+
+	chainDB := ethdb.NewMemDatabase()
+	gspec := core.Genesis{
+		Config:  params.TestChainConfig,
+		Factory: blockfactory.ForTest,
+		Alloc: core.GenesisAlloc{
+			types.StakingAddress: {Balance: common.Big0, Storage: recorder.storage},
+		},
+	}
+	gspec.MustCommit(chainDB)
+	bc, err := core.NewBlockChain(chainDB, nil, gspec.Config, nil, vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("cannot create the blockchain: %v", err)
+	}
+
+	f := NewRegistryStakeInfoFinder()
+	assert.Empty(t, f.FindStakeInfoByNodeKey(staked))
+	f.SetBeaconChain(bc)
+	if found := f.FindStakeInfoByNodeKey(staked); assert.Len(t, found, 1) {
+		assert.Equal(t, validator.Address, found[0].Account)
+		assert.Equal(t, core.MinValidatorStake, found[0].Amount)
+	}
+	assert.Empty(t, f.FindStakeInfoByNodeKey(unstaked))
+	if found := f.FindStakeInfoByAccount(validator.Address); assert.Len(t, found, 1) {
+		var key shard.BlsPublicKey
+		assert.NoError(t, key.FromLibBLSPublicKey(staked))
+		assert.Equal(t, key, found[0].BlsPublicKey)
+	}
+	assert.Empty(t, f.FindStakeInfoByAccount(common.HexToAddress("0x0b")))
+}
+
+// noRewardEngine pays no block rewards, so that blocks can be written without
+// the signatures of their committee.
+type noRewardEngine struct {
+	consensus_engine.Engine
+}
+
+func (noRewardEngine) BlockRewards(
+	chain consensus_engine.ChainReader, header *block.Header, state *state.DB,
+) (*types.BlockRewards, error) {
+	return nil, nil
+}
+
+func TestRegistryStakeInfoFinderEpochStakes(t *testing.T) {
+	keys := make([]*bls.PublicKey, 4)
+	for i := range keys {
+		keys[i] = bls_cosi.RandPrivateKey().GetPublicKey()
+	}
+	db, err := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	if err != nil {
+		t.Fatalf("cannot create state: %v", err)
+	}
+	// the first key holds most of the stake in the genesis registry
+	recorder := &storageRecorder{StateDB: db, storage: make(map[common.Hash]common.Hash)}
+	validators := make([]*types.Validator, len(keys))
+	for i, key := range keys {
+		validators[i] = &types.Validator{
+			Address:     common.BigToAddress(big.NewInt(int64(i + 1))),
+			Stake:       new(big.Int).Set(core.MinValidatorStake),
+			TotalShares: common.Big1,
+		}
+		assert.NoError(t, validators[i].BlsPublicKey.FromLibBLSPublicKey(key))
+	}
+	validators[0].Stake.Mul(validators[0].Stake, big.NewInt(7))
+	for _, validator := range validators {
+		assert.NoError(t, core.WriteValidator(recorder, validator))
+	}
+
+	chainDB := ethdb.NewMemDatabase()
+	gspec := core.Genesis{
+		Config:  params.TestChainConfig,
+		Factory: blockfactory.ForTest,
+		Alloc: core.GenesisAlloc{
+			types.StakingAddress: {Balance: common.Big0, Storage: recorder.storage},
+		},
+	}
+	genesis := gspec.MustCommit(chainDB)
+	bc, err := core.NewBlockChain(chainDB, nil, gspec.Config, noRewardEngine{}, vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("cannot create the blockchain: %v", err)
+	}
+
+	// after a block of epoch 0 is sealed by the first three keys, the stake
+	// moves from the first key to the last one
+	mask, err := bls_cosi.NewMask(keys, nil)
+	if err != nil {
+		t.Fatalf("cannot create mask: %v", err)
+	}
+	for _, key := range keys[:3] {
+		assert.NoError(t, mask.SetKey(key, true))
+	}
+	head, err := bc.StateAt(genesis.Root())
+	if err != nil {
+		t.Fatalf("cannot open genesis state: %v", err)
+	}
+	validators[0].Stake, validators[3].Stake = validators[3].Stake, validators[0].Stake
+	assert.NoError(t, core.WriteValidator(head, validators[0]))
+	assert.NoError(t, core.WriteValidator(head, validators[3]))
+	header := blockfactory.ForTest.NewHeader(common.Big0).With().
+		ParentHash(genesis.Hash()).
+		Number(common.Big1).
+		Root(head.IntermediateRoot(true)).
+		Header()
+	status, err := bc.WriteBlockWithState(types.NewBlockWithHeader(header), nil, nil, head)
+	assert.NoError(t, err)
+	assert.Equal(t, core.CanonStatTy, status)
+
+	f := NewRegistryStakeInfoFinder()
+	f.SetBeaconChain(bc)
+	if found := f.FindStakeInfoByNodeKey(keys[3]); assert.Len(t, found, 1) {
+		assert.Equal(t, validators[3].Stake, found[0].Amount)
+	}
+	// the seal still reaches quorum with the stakes of the start of epoch 0
+	decider := quorum.NewStakeWeightedDecider(f)
+	assert.True(t, decider.IsQuorumAchieved(common.Big0, mask))
+	// but not with the stakes the beacon chain has no snapshot of yet
+	assert.False(t, decider.IsQuorumAchieved(common.Big1, mask))
+}
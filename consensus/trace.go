@@ -0,0 +1,287 @@
+package consensus
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
+)
+
+// DefaultTraceSize is the number of rounds a RoundTracer keeps by default.
+const DefaultTraceSize = 256
+
+// Names of the events of a round trace.
+const (
+	// TraceAnnounce is when the leader announced the block, or when a
+	// validator received the announce.
+	TraceAnnounce = "announce"
+	// TracePrepared is when the leader reached the prepare quorum, or when a
+	// validator received the prepared message.
+	TracePrepared = "prepared"
+	// TraceCommitted is when the leader reached the commit quorum, or when a
+	// validator received the committed message.
+	TraceCommitted = "committed"
+	// TraceFinalized is when the leader finalized the block.
+	TraceFinalized = "finalized"
+)
+
+// Phases of the votes of a round trace.
+const (
+	TracePrepare = "prepare"
+	TraceCommit  = "commit"
+)
+
+// TraceEvent is a phase transition of a round.
+type TraceEvent struct {
+	Name string    `json:"name"`
+	Time time.Time `json:"time"`
+	// Elapsed is the time since the round started.
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+// VoteTrace is the arrival of a vote at the leader.
+type VoteTrace struct {
+	Phase     string        `json:"phase"`
+	Validator string        `json:"validator"`
+	Time      time.Time     `json:"time"`
+	Elapsed   time.Duration `json:"elapsed"`
+}
+
+// ViewChangeTrace is a view change started during a round.
+type ViewChangeTrace struct {
+	ViewID  uint64        `json:"viewID"`
+	Time    time.Time     `json:"time"`
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+// RoundTrace is the trace of the consensus on one block, as seen by one node.
+// Durations are in nanoseconds.
+type RoundTrace struct {
+	BlockNum  uint64       `json:"blockNum"`
+	ViewID    uint64       `json:"viewID"`
+	BlockHash common.Hash  `json:"blockHash"`
+	Leader    string       `json:"leader"`
+	Start     time.Time    `json:"start"`
+	Events    []TraceEvent `json:"events"`
+	Votes     []VoteTrace  `json:"votes"`
+	// MissingPrepares and MissingCommits are the validators missing from the
+	// aggregated signatures.
+	MissingPrepares []string          `json:"missingPrepares"`
+	MissingCommits  []string          `json:"missingCommits"`
+	ViewChanges     []ViewChangeTrace `json:"viewChanges"`
+	// Done is whether the block was committed.
+	Done bool `json:"done"`
+}
+
+func (r *RoundTrace) copy() *RoundTrace {
+	c := *r
+	c.Events = append([]TraceEvent{}, r.Events...)
+	c.Votes = append([]VoteTrace{}, r.Votes...)
+	c.MissingPrepares = append([]string{}, r.MissingPrepares...)
+	c.MissingCommits = append([]string{}, r.MissingCommits...)
+	c.ViewChanges = append([]ViewChangeTrace{}, r.ViewChanges...)
+	return &c
+}
+
+// RoundTracer records the traces of the latest consensus rounds in a ring
+// buffer.  A nil RoundTracer records nothing.
+type RoundTracer struct {
+	mutex  sync.Mutex
+	rounds []*RoundTrace // ring buffer of the finished rounds
+	next   int           // index of the next finished round in rounds
+	full   bool
+	active map[uint64]*RoundTrace // rounds in progress by block number
+	now    func() time.Time
+}
+
+// NewRoundTracer returns a tracer keeping the traces of the latest size
+// rounds.
+func NewRoundTracer(size int) *RoundTracer {
+	if size < 1 {
+		size = 1
+	}
+	return &RoundTracer{
+		rounds: make([]*RoundTrace, size),
+		active: make(map[uint64]*RoundTrace),
+		now:    time.Now,
+	}
+}
+
+// round returns the round of the block in progress, starting it if needed.
+func (t *RoundTracer) round(blockNum uint64) *RoundTrace {
+	r, ok := t.active[blockNum]
+	if !ok {
+		r = &RoundTrace{BlockNum: blockNum, Start: t.now()}
+		t.active[blockNum] = r
+		// rounds that never finish, e.g. skipped by syncing, must not pile up
+		if len(t.active) > len(t.rounds) {
+			t.finishBefore(blockNum)
+		}
+	}
+	return r
+}
+
+// finishBefore moves the rounds in progress before the block to the ring
+// buffer.
+func (t *RoundTracer) finishBefore(blockNum uint64) {
+	nums := []uint64{}
+	for num := range t.active {
+		if num < blockNum {
+			nums = append(nums, num)
+		}
+	}
+	sort.Slice(nums, func(i, j int) bool { return nums[i] < nums[j] })
+	for _, num := range nums {
+		t.push(t.active[num])
+		delete(t.active, num)
+	}
+}
+
+func (t *RoundTracer) push(r *RoundTrace) {
+	t.rounds[t.next] = r
+	t.next = (t.next + 1) % len(t.rounds)
+	if t.next == 0 {
+		t.full = true
+	}
+}
+
+// Announce records the announce of a block.
+func (t *RoundTracer) Announce(blockNum, viewID uint64, blockHash common.Hash, leader string) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	r := t.round(blockNum)
+	r.ViewID = viewID
+	r.BlockHash = blockHash
+	r.Leader = leader
+	t.event(r, TraceAnnounce)
+}
+
+// Event records a phase transition of the round of a block.
+func (t *RoundTracer) Event(blockNum uint64, name string) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.event(t.round(blockNum), name)
+}
+
+func (t *RoundTracer) event(r *RoundTrace, name string) {
+	now := t.now()
+	r.Events = append(r.Events, TraceEvent{Name: name, Time: now, Elapsed: now.Sub(r.Start)})
+}
+
+// Vote records the arrival of the vote of a validator.
+func (t *RoundTracer) Vote(blockNum uint64, phase, validator string) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	r := t.round(blockNum)
+	now := t.now()
+	r.Votes = append(r.Votes, VoteTrace{
+		Phase:     phase,
+		Validator: validator,
+		Time:      now,
+		Elapsed:   now.Sub(r.Start),
+	})
+}
+
+// Missing records the validators missing from the aggregated signature of
+// the prepare or commit phase.
+func (t *RoundTracer) Missing(blockNum uint64, phase string, mask *bls_cosi.Mask) {
+	if t == nil || mask == nil {
+		return
+	}
+	missing := []string{}
+	for _, key := range mask.GetPubKeyFromMask(false) {
+		missing = append(missing, key.SerializeToHexStr())
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	r := t.round(blockNum)
+	switch phase {
+	case TracePrepare:
+		r.MissingPrepares = missing
+	case TraceCommit:
+		r.MissingCommits = missing
+	}
+}
+
+// ViewChange records a view change started during the round of a block.
+func (t *RoundTracer) ViewChange(blockNum, viewID uint64) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	r := t.round(blockNum)
+	now := t.now()
+	r.ViewChanges = append(r.ViewChanges, ViewChangeTrace{
+		ViewID:  viewID,
+		Time:    now,
+		Elapsed: now.Sub(r.Start),
+	})
+}
+
+// Finish records that the block was committed, and moves its round and any
+// earlier round still in progress to the ring buffer.
+func (t *RoundTracer) Finish(blockNum uint64) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.round(blockNum).Done = true
+	t.finishBefore(blockNum + 1)
+}
+
+// Rounds returns copies of the latest n round traces, oldest first, followed
+// by the rounds in progress.  If n is not positive, all are returned.
+func (t *RoundTracer) Rounds(n int) []*RoundTrace {
+	if t == nil {
+		return nil
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	result := []*RoundTrace{}
+	if t.full {
+		for _, r := range t.rounds[t.next:] {
+			result = append(result, r.copy())
+		}
+	}
+	for _, r := range t.rounds[:t.next] {
+		result = append(result, r.copy())
+	}
+	nums := []uint64{}
+	for num := range t.active {
+		nums = append(nums, num)
+	}
+	sort.Slice(nums, func(i, j int) bool { return nums[i] < nums[j] })
+	for _, num := range nums {
+		result = append(result, t.active[num].copy())
+	}
+	if n > 0 && len(result) > n {
+		result = result[len(result)-n:]
+	}
+	return result
+}
+
+// WriteJSON writes the latest n round traces to w as a JSON array, for
+// offline analysis.  If n is not positive, all are written.
+func (t *RoundTracer) WriteJSON(w io.Writer, n int) error {
+	rounds := t.Rounds(n)
+	if rounds == nil {
+		rounds = []*RoundTrace{}
+	}
+	return json.NewEncoder(w).Encode(rounds)
+}
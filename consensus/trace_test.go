@@ -0,0 +1,133 @@
+package consensus
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
+)
+
+func newTestTracer(size int) *RoundTracer {
+	tracer := NewRoundTracer(size)
+	now := time.Unix(1561734000, 0)
+	tracer.now = func() time.Time {
+		now = now.Add(time.Millisecond)
+		return now
+	}
+	return tracer
+}
+
+func TestRoundTracer(t *testing.T) {
+	tracer := newTestTracer(4)
+	tracer.Announce(1, 1, common.Hash{1}, "leader")
+	tracer.Vote(1, TracePrepare, "validator")
+	tracer.Event(1, TracePrepared)
+	tracer.Vote(1, TraceCommit, "validator")
+	tracer.Event(1, TraceCommitted)
+	tracer.Finish(1)
+
+	rounds := tracer.Rounds(0)
+	if len(rounds) != 1 {
+		t.Fatalf("got %d rounds, want 1", len(rounds))
+	}
+	r := rounds[0]
+	if r.BlockNum != 1 || r.ViewID != 1 || r.BlockHash != (common.Hash{1}) || r.Leader != "leader" || !r.Done {
+		t.Errorf("wrong round trace %+v", r)
+	}
+	names := []string{}
+	for _, event := range r.Events {
+		names = append(names, event.Name)
+	}
+	if len(names) != 3 || names[0] != TraceAnnounce || names[1] != TracePrepared || names[2] != TraceCommitted {
+		t.Errorf("wrong events %v", names)
+	}
+	if len(r.Votes) != 2 || r.Votes[1].Phase != TraceCommit || r.Votes[1].Elapsed <= r.Votes[0].Elapsed {
+		t.Errorf("wrong votes %+v", r.Votes)
+	}
+
+	// the copy is not changed by later events
+	r.Events = nil
+	if len(tracer.Rounds(0)[0].Events) != 3 {
+		t.Error("round trace modified through its copy")
+	}
+}
+
+func TestRoundTracerRingBuffer(t *testing.T) {
+	tracer := newTestTracer(4)
+	for num := uint64(1); num <= 10; num++ {
+		tracer.Announce(num, num, common.Hash{}, "leader")
+		tracer.Finish(num)
+	}
+	tracer.Announce(11, 11, common.Hash{}, "leader")
+	tracer.ViewChange(11, 12)
+
+	rounds := tracer.Rounds(0)
+	if len(rounds) != 5 {
+		t.Fatalf("got %d rounds, want 4 finished and 1 in progress", len(rounds))
+	}
+	for i, r := range rounds {
+		if r.BlockNum != uint64(7+i) {
+			t.Errorf("round %d is of block %d, want %d", i, r.BlockNum, 7+i)
+		}
+	}
+	if last := rounds[4]; last.Done || len(last.ViewChanges) != 1 || last.ViewChanges[0].ViewID != 12 {
+		t.Errorf("wrong round in progress %+v", last)
+	}
+	if latest := tracer.Rounds(2); len(latest) != 2 || latest[0].BlockNum != 10 {
+		t.Errorf("wrong latest rounds %+v", latest)
+	}
+
+	// a round that never finishes is moved out when a later block finishes
+	tracer.Finish(12)
+	rounds = tracer.Rounds(0)
+	if len(rounds) != 4 || rounds[2].BlockNum != 11 || rounds[2].Done || rounds[3].BlockNum != 12 {
+		t.Errorf("unfinished round not moved to the ring buffer: %+v", rounds)
+	}
+}
+
+func TestRoundTracerMissing(t *testing.T) {
+	keys := newTestCommittee(4)
+	mask, err := bls_cosi.NewMask(keys, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range keys[:3] {
+		if err := mask.SetKey(key, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tracer := newTestTracer(4)
+	tracer.Missing(1, TraceCommit, mask)
+	missing := tracer.Rounds(0)[0].MissingCommits
+	if len(missing) != 1 || missing[0] != keys[3].SerializeToHexStr() {
+		t.Errorf("wrong missing commits %v", missing)
+	}
+}
+
+func TestRoundTracerWriteJSON(t *testing.T) {
+	tracer := newTestTracer(4)
+	tracer.Announce(1, 1, common.Hash{1}, "leader")
+	tracer.Finish(1)
+	var buffer bytes.Buffer
+	if err := tracer.WriteJSON(&buffer, 0); err != nil {
+		t.Fatal(err)
+	}
+	rounds := []*RoundTrace{}
+	if err := json.Unmarshal(buffer.Bytes(), &rounds); err != nil {
+		t.Fatalf("cannot decode exported traces: %v", err)
+	}
+	if len(rounds) != 1 || rounds[0].BlockHash != (common.Hash{1}) || rounds[0].Events[0].Elapsed != time.Millisecond {
+		t.Errorf("wrong exported traces %+v", rounds)
+	}
+
+	var nilTracer *RoundTracer
+	nilTracer.Announce(1, 1, common.Hash{}, "leader")
+	buffer.Reset()
+	if err := nilTracer.WriteJSON(&buffer, 0); err != nil || buffer.String() != "[]\n" {
+		t.Errorf("nil tracer exported %q, %v", buffer.String(), err)
+	}
+}
@@ -177,9 +177,13 @@ func (consensus *Consensus) startViewChange(viewID uint64) {
 	consensus.mode.SetMode(ViewChanging)
 	consensus.mode.SetViewID(viewID)
 	consensus.LeaderPubKey = consensus.GetNextLeaderKey()
+	consensus.stopPipeline()
+	consensus.Tracer.ViewChange(consensus.blockNum, viewID)
+	consensus.timing.ViewChanged()
+	consensus.updateTimeouts()
 
 	diff := viewID - consensus.viewID
-	duration := time.Duration(int64(diff) * int64(viewChangeDuration))
+	duration := time.Duration(int64(diff) * int64(consensus.viewChangeTimeout))
 	utils.Logger().Info().
 		Uint64("ViewChangingID", viewID).
 		Dur("timeoutDuration", duration).
@@ -207,7 +211,7 @@ func (consensus *Consensus) onViewChange(msg *msg_pb.Message) {
 		return
 	}
 
-	if len(consensus.viewIDSigs) >= consensus.Quorum() {
+	if consensus.IsQuorumAchieved(consensus.viewIDBitmap) {
 		utils.Logger().Debug().
 			Int("have", len(consensus.viewIDSigs)).
 			Int("need", consensus.Quorum()).
@@ -327,10 +331,10 @@ func (consensus *Consensus) onViewChange(msg *msg_pb.Message) {
 				return
 			}
 			// check has 2f+1 signature in m1 type message
-			if count := utils.CountOneBits(mask.Bitmap); count < consensus.Quorum() {
+			if !consensus.IsQuorumAchieved(mask) {
 				utils.Logger().Debug().
 					Int("need", consensus.Quorum()).
-					Int("have", count).
+					Int("have", utils.CountOneBits(mask.Bitmap)).
 					Msg("[onViewChange] M1 Payload Not Have Enough Signature")
 				return
 			}
@@ -391,7 +395,7 @@ func (consensus *Consensus) onViewChange(msg *msg_pb.Message) {
 		Msg("[onViewChange]")
 
 	// received enough view change messages, change state to normal consensus
-	if len(consensus.viewIDSigs) >= consensus.Quorum() {
+	if consensus.IsQuorumAchieved(consensus.viewIDBitmap) {
 		consensus.mode.SetMode(Normal)
 		consensus.LeaderPubKey = consensus.PubKey
 		consensus.ResetState()
@@ -480,10 +484,10 @@ func (consensus *Consensus) onNewView(msg *msg_pb.Message) {
 	viewIDBytes := make([]byte, 8)
 	binary.LittleEndian.PutUint64(viewIDBytes, recvMsg.ViewID)
 	// check total number of sigs >= 2f+1
-	if count := utils.CountOneBits(m3Mask.Bitmap); count < consensus.Quorum() {
+	if !consensus.IsQuorumAchieved(m3Mask) {
 		utils.Logger().Debug().
 			Int("need", consensus.Quorum()).
-			Int("have", count).
+			Int("have", utils.CountOneBits(m3Mask.Bitmap)).
 			Msg("[onNewView] Not Have Enough M3 (ViewID) Signature")
 		return
 	}
@@ -1,4 +1,3 @@
 # abigen -sol Lottery.sol  -out Lottery.go --pkg contracts
 abigen -sol Puzzle.sol  -out Puzzle.go --pkg contracts
 # abigen -sol Faucet.sol  -out Faucet.go --pkg contracts
-abigen -sol StakeLockContract.sol  -out StakeLockContract.go --pkg contracts
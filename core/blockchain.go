@@ -40,11 +40,11 @@ import (
 
 	"github.com/harmony-one/harmony/block"
 	consensus_engine "github.com/harmony-one/harmony/consensus/engine"
-	"github.com/harmony-one/harmony/contracts/structs"
 	"github.com/harmony-one/harmony/core/rawdb"
 	"github.com/harmony-one/harmony/core/state"
 	"github.com/harmony-one/harmony/core/types"
 	"github.com/harmony-one/harmony/core/vm"
+	shardingconfig "github.com/harmony-one/harmony/internal/configs/sharding"
 	"github.com/harmony-one/harmony/internal/ctxerror"
 	"github.com/harmony-one/harmony/internal/utils"
 	"github.com/harmony-one/harmony/shard"
@@ -59,17 +59,19 @@ var (
 )
 
 const (
-	bodyCacheLimit       = 256
-	blockCacheLimit      = 256
-	receiptsCacheLimit   = 32
-	maxFutureBlocks      = 256
-	maxTimeFutureBlocks  = 30
-	badBlockLimit        = 10
-	triesInMemory        = 128
-	shardCacheLimit      = 2
-	commitsCacheLimit    = 10
-	epochCacheLimit      = 10
-	randomnessCacheLimit = 10
+	bodyCacheLimit        = 256
+	blockCacheLimit       = 256
+	receiptsCacheLimit    = 32
+	maxFutureBlocks       = 256
+	maxTimeFutureBlocks   = 30
+	badBlockLimit         = 10
+	triesInMemory         = 128
+	shardCacheLimit       = 2
+	commitsCacheLimit     = 10
+	epochCacheLimit       = 10
+	randomnessCacheLimit  = 10
+	epochStakesCacheLimit = 10
+	txsCountsCacheLimit   = 1024
 
 	// BlockChainVersion ensures that an incompatible database forces a resync from scratch.
 	BlockChainVersion = 3
@@ -121,6 +123,7 @@ type BlockChain struct {
 	checkpoint       int          // checkpoint counts towards the new checkpoint
 	currentBlock     atomic.Value // Current head of the block chain
 	currentFastBlock atomic.Value // Current head of the fast-sync chain (may be above the block chain!)
+	finalizedBlock   atomic.Value // Latest block whose commit proof is on the block chain
 
 	stateCache       state.Database // State database to reuse between imports (contains state cache)
 	bodyCache        *lru.Cache     // Cache for the most recent block bodies
@@ -132,6 +135,8 @@ type BlockChain struct {
 	lastCommitsCache *lru.Cache
 	epochCache       *lru.Cache // Cache epoch number → first block number
 	randomnessCache  *lru.Cache // Cache for vrf/vdf
+	epochStakesCache *lru.Cache // Cache epoch number → stakes of the registry
+	txsCountsCache   *lru.Cache // Cache for block number → transactions counts per sender
 
 	quit    chan struct{} // blockchain quit channel
 	running int32         // running must be called atomically
@@ -168,6 +173,8 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 	commitsCache, _ := lru.New(commitsCacheLimit)
 	epochCache, _ := lru.New(epochCacheLimit)
 	randomnessCache, _ := lru.New(randomnessCacheLimit)
+	epochStakesCache, _ := lru.New(epochStakesCacheLimit)
+	txsCountsCache, _ := lru.New(txsCountsCacheLimit)
 
 	bc := &BlockChain{
 		chainConfig:      chainConfig,
@@ -186,6 +193,8 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 		lastCommitsCache: commitsCache,
 		epochCache:       epochCache,
 		randomnessCache:  randomnessCache,
+		epochStakesCache: epochStakesCache,
+		txsCountsCache:   txsCountsCache,
 		engine:           engine,
 		vmConfig:         vmConfig,
 		badBlocks:        badBlocks,
@@ -315,8 +324,17 @@ func (bc *BlockChain) loadLastState() error {
 		}
 	}
 
+	// Restore the last known finalized block, which cannot be above the head
+	bc.finalizedBlock.Store(bc.genesisBlock)
+	if hash := rawdb.ReadFinalizedBlockHash(bc.db); hash != (common.Hash{}) {
+		if block := bc.GetBlockByHash(hash); block != nil && block.NumberU64() <= currentBlock.NumberU64() {
+			bc.finalizedBlock.Store(block)
+		}
+	}
+
 	// Issue a status log for the user
 	currentFastBlock := bc.CurrentFastBlock()
+	finalizedBlock := bc.FinalizedBlock()
 
 	headerTd := bc.GetTd(currentHeader.Hash(), currentHeader.Number().Uint64())
 	blockTd := bc.GetTd(currentBlock.Hash(), currentBlock.NumberU64())
@@ -340,6 +358,11 @@ func (bc *BlockChain) loadLastState() error {
 		Str("td", fastTd.String()).
 		Str("age", common.PrettyAge(time.Unix(currentFastBlock.Time().Int64(), 0)).String()).
 		Msg("Loaded most recent local fast block")
+	utils.Logger().Info().
+		Str("number", finalizedBlock.Number().String()).
+		Str("hash", finalizedBlock.Hash().Hex()).
+		Str("age", common.PrettyAge(time.Unix(finalizedBlock.Time().Int64(), 0)).String()).
+		Msg("Loaded most recent finalized block")
 
 	return nil
 }
@@ -347,13 +370,36 @@ func (bc *BlockChain) loadLastState() error {
 // SetHead rewinds the local chain to a new head. In the case of headers, everything
 // above the new head will be deleted and the new one set. In the case of blocks
 // though, the head may be further rewound if block bodies are missing (non-archive
-// nodes after a fast sync).
+// nodes after a fast sync).  The chain cannot be rewound below the latest
+// finalized block.
 func (bc *BlockChain) SetHead(head uint64) error {
 	utils.Logger().Warn().Uint64("target", head).Msg("Rewinding blockchain")
 
 	bc.mu.Lock()
 	defer bc.mu.Unlock()
 
+	if finalized := bc.FinalizedBlock().NumberU64(); head < finalized {
+		return ctxerror.New("cannot rewind the chain",
+			"target", head,
+			"finalized", finalized,
+		).WithCause(ErrBelowFinalized)
+	}
+
+	// Stop counting the uptime of the rewound blocks while their headers are
+	// still there
+	if currentBlock := bc.CurrentBlock(); currentBlock != nil && currentBlock.NumberU64() > head {
+		var dropped []uint64
+		for number := head + 1; number <= currentBlock.NumberU64(); number++ {
+			dropped = append(dropped, number)
+		}
+		batch := bc.db.NewBatch()
+		if err := bc.recountValidatorUptime(batch, nil, dropped); err != nil {
+			utils.Logger().Warn().Err(err).Msg("[SetHead] cannot recount validator uptime")
+		} else if err := batch.Write(); err != nil {
+			utils.Logger().Warn().Err(err).Msg("[SetHead] cannot write validator uptime")
+		}
+	}
+
 	// Rewind the header chain, deleting all block bodies until then
 	delFn := func(db rawdb.DatabaseDeleter, hash common.Hash, num uint64) {
 		rawdb.DeleteBody(db, hash, num)
@@ -445,6 +491,14 @@ func (bc *BlockChain) CurrentFastBlock() *types.Block {
 	return bc.currentFastBlock.Load().(*types.Block)
 }
 
+// FinalizedBlock retrieves the latest finalized block of the canonical chain,
+// the parent of the latest block carrying a valid commit proof, which no
+// reorganisation or rewind can remove.  The block is retrieved from the
+// blockchain's internal cache.
+func (bc *BlockChain) FinalizedBlock() *types.Block {
+	return bc.finalizedBlock.Load().(*types.Block)
+}
+
 // SetProcessor sets the processor required for making state modifications.
 func (bc *BlockChain) SetProcessor(processor Processor) {
 	bc.procmu.Lock()
@@ -491,6 +545,10 @@ func (bc *BlockChain) Reset() error {
 // ResetWithGenesisBlock purges the entire blockchain, restoring it to the
 // specified genesis state.
 func (bc *BlockChain) ResetWithGenesisBlock(genesis *types.Block) error {
+	// Purging the chain drops its finality as well
+	rawdb.WriteFinalizedBlockHash(bc.db, genesis.Hash())
+	bc.finalizedBlock.Store(genesis)
+
 	// Dump the entire block chain and purge the caches
 	if err := bc.SetHead(0); err != nil {
 		return err
@@ -507,6 +565,7 @@ func (bc *BlockChain) ResetWithGenesisBlock(genesis *types.Block) error {
 	bc.hc.SetGenesis(bc.genesisBlock.Header())
 	bc.hc.SetCurrentHeader(bc.genesisBlock.Header())
 	bc.currentFastBlock.Store(bc.genesisBlock)
+	bc.finalizedBlock.Store(bc.genesisBlock)
 
 	return nil
 }
@@ -1097,7 +1156,7 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 	// Second clause in the if statement reduces the vulnerability to selfish mining.
 	// Please refer to http://www.cs.cornell.edu/~ie53/publications/btcProcFC.pdf
 	// TODO: Remove reorg code, it's not used in our code
-	reorg := true
+	reorg, finalized := true, false
 	if reorg {
 		// Reorganise the chain if the parent is not the head block
 		if block.ParentHash() != currentBlock.Hash() {
@@ -1109,6 +1168,37 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 		rawdb.WriteTxLookupEntries(batch, block)
 		rawdb.WritePreimages(batch, block.NumberU64(), state.Preimages())
 
+		if err := bc.recountValidatorUptime(batch, []*block.Header{block.Header()}, nil); err != nil {
+			block.Logger(utils.Logger()).Warn().Err(err).Msg("[WriteBlockWithState] cannot update validator uptime")
+		}
+		if err := bc.writeBlockTxsCounts(batch, block); err != nil {
+			block.Logger(utils.Logger()).Warn().Err(err).Msg("[WriteBlockWithState] cannot write block transactions counts")
+		}
+		if err := bc.writeBeaconRandomness(batch, block); err != nil {
+			block.Logger(utils.Logger()).Warn().Err(err).Msg("[WriteBlockWithState] cannot write beacon randomness")
+		}
+		if err := bc.writeEpochStakes(batch, block, state); err != nil {
+			block.Logger(utils.Logger()).Warn().Err(err).Msg("[WriteBlockWithState] cannot write epoch stakes")
+		}
+		// The rewards of the next cross-linked blocks look up the parent
+		// blocks among the cross-links of this one.
+		if err := bc.writeBlockCrossLinks(batch, block); err != nil {
+			return NonStatTy, err
+		}
+		// The commit proof of the parent, which insertChain verified with the
+		// seal of the block, makes the parent final.
+		finalized = block.NumberU64() > 1 && block.NumberU64()-1 > bc.FinalizedBlock().NumberU64()
+		if finalized {
+			rawdb.WriteFinalizedBlockHash(batch, block.ParentHash())
+		}
+		rewards, err := bc.writeBlockRewards(batch, block.Header(), state)
+		if err != nil {
+			block.Logger(utils.Logger()).Warn().Err(err).Msg("[WriteBlockWithState] cannot write block rewards")
+		}
+		if err := bc.writeShardSupply(batch, block, receipts, cxReceipts, rewards); err != nil {
+			block.Logger(utils.Logger()).Warn().Err(err).Msg("[WriteBlockWithState] cannot write shard supply")
+		}
+
 		status = CanonStatTy
 	} else {
 		status = SideStatTy
@@ -1120,6 +1210,9 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 	// Set new head.
 	if status == CanonStatTy {
 		bc.insert(block)
+		if finalized {
+			bc.finalizedBlock.Store(bc.GetBlock(block.ParentHash(), block.NumberU64()-1))
+		}
 	}
 	bc.futureBlocks.Remove(block.Hash())
 	return status, nil
@@ -1151,27 +1244,6 @@ func (bc *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 					return n, err
 				}
 			}
-
-			// TODO: move into WriteBlockWithState
-			if len(header.CrossLinks()) > 0 {
-				crossLinks := &types.CrossLinks{}
-				err = rlp.DecodeBytes(header.CrossLinks(), crossLinks)
-				if err != nil {
-					header.Logger(utils.Logger()).Warn().Err(err).Msg("[insertChain] cannot parse cross links")
-					return n, err
-				}
-				if !crossLinks.IsSorted() {
-					header.Logger(utils.Logger()).Warn().Err(err).Msg("[insertChain] cross links are not sorted")
-					return n, errors.New("proposed cross links are not sorted")
-				}
-				for _, crossLink := range *crossLinks {
-					if err := bc.WriteCrossLinks(types.CrossLinks{crossLink}, false); err == nil {
-						utils.Logger().Info().Uint64("blockNum", crossLink.BlockNum().Uint64()).Uint32("shardID", crossLink.ShardID()).Msg("[InsertChain] Cross Link Added to Beaconchain")
-					}
-					bc.DeleteCrossLinks(types.CrossLinks{crossLink}, true)
-					bc.WriteShardLastCrossLink(crossLink.ShardID(), crossLink)
-				}
-			}
 		}
 	}
 
@@ -1545,6 +1617,13 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 			return fmt.Errorf("Invalid new chain")
 		}
 	}
+	// Finalized blocks are never dropped from the canonical chain
+	if finalized := bc.FinalizedBlock().NumberU64(); len(oldChain) > 0 && commonBlock.NumberU64() < finalized {
+		return ctxerror.New("cannot reorganise the chain",
+			"commonNumber", commonBlock.NumberU64(),
+			"finalized", finalized,
+		).WithCause(ErrBelowFinalized)
+	}
 	// Ensure the user sees large reorgs
 	if len(oldChain) > 0 && len(newChain) > 0 {
 		logEvent := utils.Logger().Debug()
@@ -1584,6 +1663,20 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 	for _, tx := range diff {
 		rawdb.DeleteTxLookupEntry(batch, tx.Hash())
 	}
+	// Move the validator uptime counts over to the new chain
+	newHeaders := make([]*block.Header, 0, len(newChain))
+	for i := len(newChain) - 1; i >= 0; i-- {
+		newHeaders = append(newHeaders, newChain[i].Header())
+	}
+	var droppedNumbers []uint64
+	for _, oldBlock := range oldChain {
+		if len(newChain) == 0 || oldBlock.NumberU64() > newChain[0].NumberU64() {
+			droppedNumbers = append(droppedNumbers, oldBlock.NumberU64())
+		}
+	}
+	if err := bc.recountValidatorUptime(batch, newHeaders, droppedNumbers); err != nil {
+		utils.Logger().Warn().Err(err).Msg("[reorg] cannot recount validator uptime")
+	}
 	batch.Write()
 
 	if len(deletedLogs) > 0 {
@@ -1862,6 +1955,430 @@ func (bc *BlockChain) WriteShardStateBytes(
 	return nil
 }
 
+// ReadValidatorUptime retrieves the number of blocks the given BLS key signed
+// and missed in the given epoch.
+func (bc *BlockChain) ReadValidatorUptime(
+	epoch *big.Int, key shard.BlsPublicKey,
+) (*types.ValidatorUptime, error) {
+	return rawdb.ReadValidatorUptime(bc.db, epoch, key)
+}
+
+// uptimeChange is a change of the signed and missed block counts of a BLS
+// key in an epoch.
+type uptimeChange struct {
+	epoch          *big.Int
+	key            shard.BlsPublicKey
+	signed, missed int64
+}
+
+// uptimeChanges accumulates changes of validator uptime counts, in the order
+// the keys first appear.
+type uptimeChanges struct {
+	index   map[string]int
+	changes []*uptimeChange
+}
+
+func (c *uptimeChanges) add(epoch *big.Int, key shard.BlsPublicKey, signed, missed int64) {
+	id := string(append(epoch.Bytes(), key[:]...))
+	if c.index == nil {
+		c.index = make(map[string]int)
+	}
+	i, ok := c.index[id]
+	if !ok {
+		i = len(c.changes)
+		c.index[id] = i
+		c.changes = append(c.changes, &uptimeChange{epoch: epoch, key: key})
+	}
+	c.changes[i].signed += signed
+	c.changes[i].missed += missed
+}
+
+// addValidatorUptime adds delta, 1 to count or -1 to uncount the given
+// block, to the uptime of the signers and non-signers of the parent of the
+// block, which the block carries the commit bitmap of.
+func (bc *BlockChain) addValidatorUptime(
+	changes *uptimeChanges, header *block.Header, delta int64,
+) error {
+	if header.Number().Cmp(common.Big1) <= 0 {
+		// Genesis block has no parent, and the parent of block 1 is not
+		// signed in the usual manner.
+		return nil
+	}
+	parentHeader := bc.GetHeaderByHash(header.ParentHash())
+	if parentHeader == nil {
+		return ctxerror.New("cannot find parent block header in DB",
+			"parentHash", header.ParentHash())
+	}
+	epoch := parentHeader.Epoch()
+	parentShardState, err := bc.ReadShardState(epoch)
+	if err != nil {
+		return ctxerror.New("cannot read shard state",
+			"epoch", epoch,
+		).WithCause(err)
+	}
+	parentCommittee := parentShardState.FindCommitteeByID(parentHeader.ShardID())
+	if parentCommittee == nil {
+		return ctxerror.New("cannot find shard in the shard state",
+			"parentBlockNumber", parentHeader.Number(),
+			"shardID", parentHeader.ShardID(),
+		)
+	}
+	bitmap := header.LastCommitBitmap()
+	if len(bitmap) != (len(parentCommittee.NodeList)+7)>>3 {
+		return ctxerror.New("mismatching bitmap lengths",
+			"expectedBitmapLength", (len(parentCommittee.NodeList)+7)>>3,
+			"providedBitmapLength", len(bitmap))
+	}
+	for idx, member := range parentCommittee.NodeList {
+		if bitmap[idx>>3]&(byte(1)<<uint(idx&7)) != 0 {
+			changes.add(epoch, member.BlsPublicKey, delta, 0)
+		} else {
+			changes.add(epoch, member.BlsPublicKey, 0, delta)
+		}
+	}
+	return nil
+}
+
+// recountValidatorUptime makes the validator uptime counts include the given
+// canonical headers, in place of the blocks of the same numbers they
+// included before, and no longer include the blocks of the dropped numbers.
+// The counts record the hash of the block they include at each number, so
+// writing a block again does not count it twice.
+func (bc *BlockChain) recountValidatorUptime(
+	batch ethdb.Batch, headers []*block.Header, dropped []uint64,
+) error {
+	changes := &uptimeChanges{}
+	uncount := func(number uint64) error {
+		hash := rawdb.ReadUptimeCountedBlockHash(bc.db, number)
+		if hash == (common.Hash{}) {
+			return nil
+		}
+		counted := bc.GetHeaderByHash(hash)
+		if counted == nil {
+			return ctxerror.New("cannot find counted block header in DB",
+				"number", number,
+				"hash", hash)
+		}
+		return bc.addValidatorUptime(changes, counted, -1)
+	}
+	for _, header := range headers {
+		number := header.Number().Uint64()
+		if rawdb.ReadUptimeCountedBlockHash(bc.db, number) == header.Hash() {
+			continue
+		}
+		if err := uncount(number); err != nil {
+			return err
+		}
+		if err := bc.addValidatorUptime(changes, header, 1); err != nil {
+			return err
+		}
+		if err := rawdb.WriteUptimeCountedBlockHash(batch, number, header.Hash()); err != nil {
+			return err
+		}
+	}
+	for _, number := range dropped {
+		if err := uncount(number); err != nil {
+			return err
+		}
+		if err := rawdb.DeleteUptimeCountedBlockHash(batch, number); err != nil {
+			return err
+		}
+	}
+	for _, change := range changes.changes {
+		uptime, err := rawdb.ReadValidatorUptime(bc.db, change.epoch, change.key)
+		if err != nil {
+			return err
+		}
+		signed := int64(uptime.Signed) + change.signed
+		missed := int64(uptime.Missed) + change.missed
+		if signed < 0 || missed < 0 {
+			return ctxerror.New("validator uptime would become negative",
+				"epoch", change.epoch,
+				"blsPublicKey", change.key.Hex(),
+				"signed", signed,
+				"missed", missed)
+		}
+		uptime.Signed, uptime.Missed = uint64(signed), uint64(missed)
+		if err := rawdb.WriteValidatorUptime(batch, change.epoch, change.key, uptime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadBlockTxsCounts retrieves the number of transactions of each sender
+// account in the canonical block of the given number.
+func (bc *BlockChain) ReadBlockTxsCounts(number uint64) (types.BlockTxsCounts, error) {
+	if cached, ok := bc.txsCountsCache.Get(number); ok {
+		return cached.(types.BlockTxsCounts), nil
+	}
+	counts, err := rawdb.ReadBlockTxsCounts(bc.db, number)
+	if err != nil {
+		return nil, err
+	}
+	bc.txsCountsCache.Add(number, counts)
+	return counts, nil
+}
+
+// writeBlockTxsCounts counts the transactions of each sender account in the
+// given canonical block.
+func (bc *BlockChain) writeBlockTxsCounts(
+	batch rawdb.DatabaseWriter, block *types.Block,
+) error {
+	counts, err := CountBlockTxs(bc.chainConfig, block)
+	if err != nil {
+		return err
+	}
+	// a reorg may replace the block of the number
+	bc.txsCountsCache.Remove(block.NumberU64())
+	return rawdb.WriteBlockTxsCounts(batch, block.NumberU64(), counts)
+}
+
+// CountBlockTxs returns the number of transactions of each sender in the
+// given block.
+func CountBlockTxs(config *params.ChainConfig, block *types.Block) (types.BlockTxsCounts, error) {
+	signer := types.MakeSigner(config, block.Epoch())
+	counts := make(types.BlockTxsCounts)
+	for _, tx := range block.Transactions() {
+		sender, err := types.Sender(signer, tx)
+		if err != nil {
+			return nil, ctxerror.New("cannot recover transaction sender",
+				"txHash", tx.Hash(),
+			).WithCause(err)
+		}
+		counts[sender]++
+	}
+	return counts, nil
+}
+
+// ReadBlockRewards retrieves the payouts of the block reward credited by the
+// given block, or nil if it credits none.
+func (bc *BlockChain) ReadBlockRewards(hash common.Hash, number uint64) (*types.BlockRewards, error) {
+	return rawdb.ReadBlockRewards(bc.db, hash, number)
+}
+
+// writeBlockRewards records the payouts of the block reward credited by the
+// given canonical block, whose state is given, so that they can be queried
+// later, and returns them.
+func (bc *BlockChain) writeBlockRewards(
+	batch rawdb.DatabaseWriter, header *block.Header, state *state.DB,
+) (*types.BlockRewards, error) {
+	rewards, err := bc.engine.BlockRewards(bc, header, state)
+	if err != nil || rewards == nil {
+		return nil, err
+	}
+	return rewards, rawdb.WriteBlockRewards(batch, header.Hash(), header.Number().Uint64(), rewards)
+}
+
+// ReadBlockFees retrieves the transaction fees collected and burned in the
+// given block, or nil if none are recorded.
+func (bc *BlockChain) ReadBlockFees(hash common.Hash, number uint64) (*types.BlockFees, error) {
+	return rawdb.ReadBlockFees(bc.db, hash, number)
+}
+
+// ReadShardSupply retrieves the supply of the shard as of the given block, or
+// nil if none is recorded.
+func (bc *BlockChain) ReadShardSupply(hash common.Hash, number uint64) (*types.ShardSupply, error) {
+	return rawdb.ReadShardSupply(bc.db, hash, number)
+}
+
+// writeShardSupply records the transaction fees of the given block, and the
+// supply of the shard as of the block, following the one as of its parent.
+func (bc *BlockChain) writeShardSupply(
+	batch rawdb.DatabaseWriter, block *types.Block, receipts []*types.Receipt,
+	cxReceipts []*types.CXReceipt, rewards *types.BlockRewards,
+) error {
+	fees, err := blockFees(block, receipts)
+	if err != nil {
+		return err
+	}
+	if err := rawdb.WriteBlockFees(batch, block.Hash(), block.NumberU64(), fees); err != nil {
+		return err
+	}
+
+	parentSupply, err := rawdb.ReadShardSupply(bc.db, block.ParentHash(), block.NumberU64()-1)
+	if err != nil {
+		return err
+	}
+	if parentSupply == nil {
+		// the database predates supply tracking, and BackfillShardSupply
+		// has yet to reach the block
+		return ctxerror.New("no supply recorded for the parent block",
+			"parentHash", block.ParentHash())
+	}
+	supply := nextShardSupply(parentSupply, block, fees, cxReceipts, rewards)
+	return rawdb.WriteShardSupply(batch, block.Hash(), block.NumberU64(), supply)
+}
+
+// blockFees returns the transaction fees collected and burned in the given
+// block, whose transactions have the given receipts.
+func blockFees(block *types.Block, receipts []*types.Receipt) (*types.BlockFees, error) {
+	txs := block.Transactions()
+	if len(receipts) != len(txs) {
+		return nil, ctxerror.New("mismatching numbers of transactions and receipts",
+			"numTxs", len(txs),
+			"numReceipts", len(receipts))
+	}
+	fees := &types.BlockFees{Collected: big.NewInt(0), Burned: big.NewInt(0)}
+	for i, tx := range txs {
+		fee := new(big.Int).SetUint64(receipts[i].GasUsed)
+		fees.Collected.Add(fees.Collected, fee.Mul(fee, tx.GasPrice()))
+	}
+	if ShardingSchedule.FeeConfig().PolicyAt(block.Epoch()) == shardingconfig.FeeBurn {
+		fees.Burned.Set(fees.Collected)
+	}
+	return fees, nil
+}
+
+// nextShardSupply returns the supply of the shard as of the given block: the
+// supply as of its parent, plus the block reward it paid out and the amounts
+// it received from other shards, less the fees it burned and the amounts it
+// sent to other shards.
+func nextShardSupply(
+	parentSupply *types.ShardSupply, block *types.Block, fees *types.BlockFees,
+	cxReceipts []*types.CXReceipt, rewards *types.BlockRewards,
+) *types.ShardSupply {
+	supply := parentSupply.Copy()
+	if rewards != nil {
+		supply.Issued.Add(supply.Issued, rewards.Paid())
+	}
+	supply.Burned.Add(supply.Burned, fees.Burned)
+	for _, cx := range cxReceipts {
+		supply.CrossShardOut.Add(supply.CrossShardOut, cx.Amount)
+	}
+	for _, cxp := range block.IncomingReceipts() {
+		for _, cx := range cxp.Receipts {
+			supply.CrossShardIn.Add(supply.CrossShardIn, cx.Amount)
+		}
+	}
+	return supply
+}
+
+// shardSupplyBackfillBatch is the number of blocks BackfillShardSupply
+// records at a time, holding the chain insertion lock.
+const shardSupplyBackfillBatch = 1000
+
+// BackfillShardSupply records the transaction fees and the supply of the
+// shard for the canonical blocks which have none, in a database predating
+// supply tracking: from the balances of the genesis state, then from the
+// receipts, the block rewards and the cross-shard transfers of each block.
+// It locks the chain a batch of blocks at a time, so that blocks can be
+// inserted meanwhile, and returns once the current block has a record.
+func (bc *BlockChain) BackfillShardSupply() error {
+	for {
+		bc.chainmu.Lock()
+		done, err := bc.backfillShardSupply(shardSupplyBackfillBatch)
+		bc.chainmu.Unlock()
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+// backfillShardSupply records the supply of up to the given number of
+// canonical blocks following the last one having a record, and returns
+// whether the current block has one.
+func (bc *BlockChain) backfillShardSupply(count int) (bool, error) {
+	current := bc.CurrentBlock().NumberU64()
+	number := current
+	var supply *types.ShardSupply
+	for {
+		hash := rawdb.ReadCanonicalHash(bc.db, number)
+		var err error
+		if supply, err = rawdb.ReadShardSupply(bc.db, hash, number); err != nil {
+			return false, err
+		}
+		if supply != nil || number == 0 {
+			break
+		}
+		number--
+	}
+	if number == current && supply != nil {
+		return true, nil
+	}
+
+	batch := bc.db.NewBatch()
+	if supply == nil {
+		genesis := bc.GetBlockByNumber(0)
+		genesisState, err := bc.StateAt(genesis.Root())
+		if err != nil {
+			return false, ctxerror.New("cannot open genesis state").WithCause(err)
+		}
+		genesisSupply := big.NewInt(0)
+		for _, account := range genesisState.RawDump().Accounts {
+			if balance, ok := new(big.Int).SetString(account.Balance, 10); ok {
+				genesisSupply.Add(genesisSupply, balance)
+			}
+		}
+		supply = types.NewShardSupply(genesisSupply)
+		if err := rawdb.WriteShardSupply(batch, genesis.Hash(), 0, supply); err != nil {
+			return false, err
+		}
+	}
+	for ; number < current && count > 0; count-- {
+		number++
+		block := bc.GetBlockByNumber(number)
+		if block == nil {
+			return false, ctxerror.New("cannot find canonical block",
+				"blockNumber", number)
+		}
+		fees, err := blockFees(block, rawdb.ReadReceipts(bc.db, block.Hash(), number))
+		if err != nil {
+			return false, err
+		}
+		var cxReceipts []*types.CXReceipt
+		numShards := ShardingSchedule.InstanceForEpoch(block.Epoch()).NumShards()
+		for shardID := uint32(0); shardID < numShards; shardID++ {
+			// no receipts to a shard read as an error, as in CXMerkleProof
+			if cxs, err := rawdb.ReadCXReceipts(bc.db, shardID, number, block.Hash(), false); err == nil {
+				cxReceipts = append(cxReceipts, cxs...)
+			}
+		}
+		rewards, err := rawdb.ReadBlockRewards(bc.db, block.Hash(), number)
+		if err != nil {
+			return false, err
+		}
+		if rewards == nil {
+			// the state weighting the payouts may be gone, but they add
+			// up to the same whatever the weights
+			if rewards, err = bc.engine.BlockRewards(bc, block.Header(), nil); err != nil {
+				return false, err
+			}
+		}
+		supply = nextShardSupply(supply, block, fees, cxReceipts, rewards)
+		if err := rawdb.WriteBlockFees(batch, block.Hash(), number, fees); err != nil {
+			return false, err
+		}
+		if err := rawdb.WriteShardSupply(batch, block.Hash(), number, supply); err != nil {
+			return false, err
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return false, err
+	}
+	return number == current, nil
+}
+
+// RecentTxsStats returns the number of transactions of each sender account in
+// the canonical blocks up to the current one whose timestamps are within the
+// given duration before the current block, by block number.  Since it only
+// depends on the chain, every node of the shard computes the same stats.
+func (bc *BlockChain) RecentTxsStats(duration time.Duration) (types.RecentTxsStats, error) {
+	stats := make(types.RecentTxsStats)
+	header := bc.CurrentHeader()
+	since := new(big.Int).Sub(header.Time(), big.NewInt(int64(duration/time.Second)))
+	for header != nil && header.Number().Sign() > 0 && header.Time().Cmp(since) >= 0 {
+		number := header.Number().Uint64()
+		counts, err := bc.ReadBlockTxsCounts(number)
+		if err != nil {
+			return nil, err
+		}
+		stats[number] = counts
+		header = bc.GetHeader(header.ParentHash(), number-1)
+	}
+	return stats, nil
+}
+
 // ReadLastCommits retrieves last commits.
 func (bc *BlockChain) ReadLastCommits() ([]byte, error) {
 	if cached, ok := bc.lastCommitsCache.Get("lastCommits"); ok {
@@ -1906,15 +2423,12 @@ func (bc *BlockChain) GetVrfByNumber(number uint64) []byte {
 
 // GetShardState returns the shard state for the given epoch,
 // creating one if needed.
-func (bc *BlockChain) GetShardState(
-	epoch *big.Int,
-	stakeInfo *map[common.Address]*structs.StakeInfo,
-) (shard.State, error) {
+func (bc *BlockChain) GetShardState(epoch *big.Int) (shard.State, error) {
 	shardState, err := bc.ReadShardState(epoch)
 	if err == nil { // TODO ek – distinguish ErrNotFound
 		return shardState, err
 	}
-	shardState, err = CalculateNewShardState(bc, epoch, stakeInfo)
+	shardState, err = CalculateNewShardState(bc, epoch)
 	if err != nil {
 		return nil, err
 	}
@@ -2025,6 +2539,141 @@ func (bc *BlockChain) WriteEpochVdfBlockNum(epoch *big.Int, blockNum *big.Int) e
 	return nil
 }
 
+// ReadBeaconRandomness retrieves the output of the distributed randomness
+// beacon for the specified epoch, or nil if no canonical beacon block
+// committed it.
+func (bc *BlockChain) ReadBeaconRandomness(epoch *big.Int) (*types.BeaconRandomness, error) {
+	if cached, ok := bc.randomnessCache.Get("beacon-" + string(epoch.Bytes())); ok {
+		return cached.(*types.BeaconRandomness), nil
+	}
+	randomness, err := rawdb.ReadBeaconRandomness(bc.db, epoch)
+	if err != nil || randomness == nil {
+		return nil, err
+	}
+	bc.randomnessCache.Add("beacon-"+string(epoch.Bytes()), randomness)
+	return randomness, nil
+}
+
+// writeBeaconRandomness saves the output of the distributed randomness
+// beacon the given canonical beacon block commits for its epoch.
+func (bc *BlockChain) writeBeaconRandomness(
+	batch rawdb.DatabaseWriter, block *types.Block,
+) error {
+	randomness, err := block.BeaconRandomness()
+	if err != nil {
+		return ctxerror.New("cannot decode beacon randomness",
+			"blockHash", block.Hash(),
+		).WithCause(err)
+	}
+	if randomness == nil {
+		return nil
+	}
+	if err := rawdb.WriteBeaconRandomness(batch, randomness); err != nil {
+		return err
+	}
+	bc.randomnessCache.Add("beacon-"+string(randomness.Epoch.Bytes()), randomness)
+	return nil
+}
+
+// ReadEpochStakes returns the stakes of the validator registry of the beacon
+// chain as of the start of the given epoch, i.e. as of the last block of the
+// previous epoch, or of the genesis block for epoch 0.  Unless the snapshot
+// was saved as that block was written, it is taken from the state of the
+// block, which must be available.
+func (bc *BlockChain) ReadEpochStakes(epoch *big.Int) (*types.EpochStakes, error) {
+	if cached, ok := bc.epochStakesCache.Get(string(epoch.Bytes())); ok {
+		return cached.(*types.EpochStakes), nil
+	}
+	stakes, err := rawdb.ReadEpochStakes(bc.db, epoch)
+	if err != nil {
+		return nil, err
+	}
+	if stakes == nil {
+		number := EpochFirstBlock(epoch).Uint64()
+		if number > 0 {
+			number--
+		}
+		block := bc.GetBlockByNumber(number)
+		if block == nil {
+			return nil, ctxerror.New("epoch not started",
+				"epoch", epoch,
+				"blockNum", number,
+			)
+		}
+		db, err := bc.StateAt(block.Root())
+		if err != nil {
+			return nil, ctxerror.New("cannot open the state of the epoch start",
+				"epoch", epoch,
+				"blockNum", number,
+			).WithCause(err)
+		}
+		if stakes, err = NewEpochStakes(db, epoch); err != nil {
+			return nil, err
+		}
+		if err := rawdb.WriteEpochStakes(bc.db, stakes); err != nil {
+			return nil, err
+		}
+	}
+	bc.epochStakesCache.Add(string(epoch.Bytes()), stakes)
+	return stakes, nil
+}
+
+// writeEpochStakes saves the snapshot of the stakes of the validator registry
+// for the next epoch if the given canonical beacon block is the last of its
+// epoch, while its state is at hand.
+func (bc *BlockChain) writeEpochStakes(
+	batch rawdb.DatabaseWriter, block *types.Block, state *state.DB,
+) error {
+	if bc.ShardID() != types.StakingShardID || !IsEpochLastBlock(block) {
+		return nil
+	}
+	stakes, err := NewEpochStakes(state, new(big.Int).Add(block.Epoch(), common.Big1))
+	if err != nil {
+		return err
+	}
+	if err := rawdb.WriteEpochStakes(batch, stakes); err != nil {
+		return err
+	}
+	bc.epochStakesCache.Add(string(stakes.Epoch.Bytes()), stakes)
+	return nil
+}
+
+// writeBlockCrossLinks saves the cross-links the given canonical beacon block
+// commits, as the last cross-links of their shards.
+func (bc *BlockChain) writeBlockCrossLinks(batch ethdb.Batch, block *types.Block) error {
+	if len(block.Header().CrossLinks()) == 0 {
+		return nil
+	}
+	crossLinks := types.CrossLinks{}
+	if err := rlp.DecodeBytes(block.Header().CrossLinks(), &crossLinks); err != nil {
+		return ctxerror.New("cannot parse cross links",
+			"blockHash", block.Hash(),
+		).WithCause(err)
+	}
+	if !crossLinks.IsSorted() {
+		return ctxerror.New("proposed cross links are not sorted",
+			"blockHash", block.Hash(),
+		)
+	}
+	for _, crossLink := range crossLinks {
+		shardID, blockNum := crossLink.ShardID(), crossLink.BlockNum().Uint64()
+		if err := rawdb.WriteCrossLinkShardBlock(batch, shardID, blockNum, crossLink.Serialize(), false); err != nil {
+			return err
+		}
+		if err := rawdb.DeleteCrossLinkShardBlock(batch, shardID, blockNum, true); err != nil {
+			return err
+		}
+		if err := rawdb.WriteShardLastCrossLink(batch, shardID, crossLink.Serialize()); err != nil {
+			return err
+		}
+		utils.Logger().Info().
+			Uint64("blockNum", blockNum).
+			Uint32("shardID", shardID).
+			Msg("[WriteBlockWithState] Cross Link Added to Beaconchain")
+	}
+	return nil
+}
+
 // WriteCrossLinks saves the hashes of crosslinks by shardID and blockNum combination key
 // temp=true is to write the just received cross link that's not committed into blockchain with consensus
 func (bc *BlockChain) WriteCrossLinks(cls []types.CrossLink, temp bool) error {
@@ -2209,6 +2858,19 @@ func (bc *BlockChain) WriteCXReceiptsProofSpent(cxps []*types.CXReceiptsProof) {
 	}
 }
 
+// WriteCXReceiptsProofSent records that the proof of the cross-shard receipts
+// of the given block was sent to the given shard now
+func (bc *BlockChain) WriteCXReceiptsProofSent(toShardID uint32, number uint64, hash common.Hash) error {
+	return rawdb.WriteCXReceiptsProofSent(bc.db, toShardID, number, hash, uint64(time.Now().Unix()))
+}
+
+// IsCXReceiptsProofSent returns whether the proof of the cross-shard receipts
+// of the given block was sent to the given shard
+func (bc *BlockChain) IsCXReceiptsProofSent(toShardID uint32, number uint64, hash common.Hash) bool {
+	_, err := rawdb.ReadCXReceiptsProofSent(bc.db, toShardID, number, hash)
+	return err == nil
+}
+
 // IsSpent checks whether a CXReceiptsProof is unspent
 func (bc *BlockChain) IsSpent(cxp *types.CXReceiptsProof) bool {
 	shardID := cxp.MerkleProof.ShardID
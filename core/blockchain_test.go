@@ -0,0 +1,187 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/harmony-one/harmony/block"
+	blockfactory "github.com/harmony-one/harmony/block/factory"
+	consensus_engine "github.com/harmony-one/harmony/consensus/engine"
+	"github.com/harmony-one/harmony/core/rawdb"
+	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/core/vm"
+	"github.com/harmony-one/harmony/internal/params"
+	"github.com/harmony-one/harmony/shard"
+)
+
+func newTestBlockChain(t *testing.T) *BlockChain {
+	return newTestBlockChainWith(t, nil, nil)
+}
+
+// newTestBlockChainWith returns a block chain whose genesis block allocates
+// alloc and which uses the given consensus engine.
+func newTestBlockChainWith(t *testing.T, alloc GenesisAlloc, engine consensus_engine.Engine) *BlockChain {
+	db := ethdb.NewMemDatabase()
+	gspec := Genesis{Config: params.TestChainConfig, Factory: blockfactory.ForTest, Alloc: alloc}
+	gspec.MustCommit(db)
+	bc, err := NewBlockChain(db, nil, gspec.Config, engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("cannot create the blockchain: %v", err)
+	}
+	return bc
+}
+
+func newTestChildHeader(parent *block.Header, bitmap []byte, extra string) *block.Header {
+	return blockfactory.NewTestHeader().With().
+		ParentHash(parent.Hash()).
+		Number(new(big.Int).Add(parent.Number(), common.Big1)).
+		LastCommitBitmap(bitmap).
+		Extra([]byte(extra)).
+		Header()
+}
+
+// writeTestBlocks writes n empty blocks following the given parent, and
+// returns them.
+func writeTestBlocks(bc *BlockChain, parent *block.Header, n int, extra string) []*types.Block {
+	var blocks []*types.Block
+	for i := 0; i < n; i++ {
+		header := newTestChildHeader(parent, nil, extra)
+		block := types.NewBlock(header, nil, nil, nil, nil)
+		rawdb.WriteBlock(bc.db, block)
+		blocks = append(blocks, block)
+		parent = header
+	}
+	return blocks
+}
+
+func TestRecountValidatorUptime(t *testing.T) {
+	bc := newTestBlockChain(t)
+	key1, key2 := shard.BlsPublicKey{1}, shard.BlsPublicKey{2}
+	committee := shard.Committee{NodeList: shard.NodeIDList{{BlsPublicKey: key1}, {BlsPublicKey: key2}}}
+	assert.NoError(t, bc.WriteShardState(common.Big0, shard.State{committee}))
+
+	header1 := newTestChildHeader(bc.Genesis().Header(), nil, "")
+	rawdb.WriteHeader(bc.db, header1)
+	header2a := newTestChildHeader(header1, []byte{0x01}, "a")
+	header2b := newTestChildHeader(header1, []byte{0x02}, "b")
+
+	recount := func(headers []*block.Header, dropped []uint64) {
+		batch := bc.db.NewBatch()
+		assert.NoError(t, bc.recountValidatorUptime(batch, headers, dropped))
+		assert.NoError(t, batch.Write())
+	}
+	uptimes := func() (types.ValidatorUptime, types.ValidatorUptime) {
+		uptime1, err := bc.ReadValidatorUptime(common.Big0, key1)
+		assert.NoError(t, err)
+		uptime2, err := bc.ReadValidatorUptime(common.Big0, key2)
+		assert.NoError(t, err)
+		return *uptime1, *uptime2
+	}
+
+	recount([]*block.Header{header1, header2a}, nil)
+	uptime1, uptime2 := uptimes()
+	assert.Equal(t, types.ValidatorUptime{Signed: 1}, uptime1)
+	assert.Equal(t, types.ValidatorUptime{Missed: 1}, uptime2)
+
+	// writing the same block again does not count it twice
+	recount([]*block.Header{header2a}, nil)
+	uptime1, uptime2 = uptimes()
+	assert.Equal(t, types.ValidatorUptime{Signed: 1}, uptime1)
+	assert.Equal(t, types.ValidatorUptime{Missed: 1}, uptime2)
+
+	// a reorg replaces the counts of the block it drops
+	recount([]*block.Header{header2b}, nil)
+	uptime1, uptime2 = uptimes()
+	assert.Equal(t, types.ValidatorUptime{Missed: 1}, uptime1)
+	assert.Equal(t, types.ValidatorUptime{Signed: 1}, uptime2)
+
+	// and rewinding drops them
+	recount(nil, []uint64{2})
+	uptime1, uptime2 = uptimes()
+	assert.Equal(t, types.ValidatorUptime{}, uptime1)
+	assert.Equal(t, types.ValidatorUptime{}, uptime2)
+	assert.Equal(t, common.Hash{}, rawdb.ReadUptimeCountedBlockHash(bc.db, 2))
+}
+
+func TestBackfillShardSupply(t *testing.T) {
+	bc := newTestBlockChainWith(t, GenesisAlloc{
+		common.HexToAddress("0x0a"): {Balance: big.NewInt(1000)},
+	}, nil)
+	for i, block := range writeTestBlocks(bc, bc.Genesis().Header(), 3, "") {
+		reward := big.NewInt(10 * int64(i+1))
+		rewards := &types.BlockRewards{
+			BlockNum: block.Number(),
+			Total:    reward,
+			Payouts:  []types.RewardPayout{{Amount: reward}},
+		}
+		assert.NoError(t, rawdb.WriteBlockRewards(bc.db, block.Hash(), block.NumberU64(), rewards))
+		bc.insert(block)
+	}
+
+	// two blocks at a time: the first run stops short of the current block
+	done, err := bc.backfillShardSupply(2)
+	assert.NoError(t, err)
+	assert.False(t, done)
+	supply, err := rawdb.ReadShardSupply(bc.db, rawdb.ReadCanonicalHash(bc.db, 2), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(1030), supply.Minted())
+	supply, err = rawdb.ReadShardSupply(bc.db, rawdb.ReadCanonicalHash(bc.db, 3), 3)
+	assert.NoError(t, err)
+	assert.Nil(t, supply)
+
+	assert.NoError(t, bc.BackfillShardSupply())
+	supply, err = rawdb.ReadShardSupply(bc.db, rawdb.ReadCanonicalHash(bc.db, 3), 3)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(1000), supply.Genesis)
+	assert.Equal(t, big.NewInt(60), supply.Issued)
+	fees, err := rawdb.ReadBlockFees(bc.db, rawdb.ReadCanonicalHash(bc.db, 3), 3)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(0), fees.Collected)
+
+	done, err = bc.backfillShardSupply(2)
+	assert.NoError(t, err)
+	assert.True(t, done)
+}
+
+func TestFinalizedBlock(t *testing.T) {
+	bc := newTestBlockChain(t)
+	blocks := writeTestBlocks(bc, bc.Genesis().Header(), 3, "")
+	for _, block := range blocks {
+		bc.insert(block)
+	}
+	assert.Equal(t, bc.Genesis().Hash(), bc.FinalizedBlock().Hash())
+
+	// the finalized block is restored, as long as it is not above the head
+	rawdb.WriteFinalizedBlockHash(bc.db, blocks[1].Hash())
+	assert.NoError(t, bc.loadLastState())
+	assert.Equal(t, blocks[1].Hash(), bc.FinalizedBlock().Hash())
+
+	// a fork from below the finalized block cannot replace it
+	fork := writeTestBlocks(bc, blocks[0].Header(), 3, "fork")
+	err := bc.reorg(blocks[2], fork[2])
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), ErrBelowFinalized.Error())
+	}
+	assert.Equal(t, blocks[2].Hash(), bc.CurrentBlock().Hash())
+	assert.Equal(t, blocks[1].Hash(), rawdb.ReadCanonicalHash(bc.db, 2))
+
+	// neither can rewinding
+	err = bc.SetHead(1)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), ErrBelowFinalized.Error())
+	}
+	assert.Equal(t, blocks[2].Hash(), bc.CurrentBlock().Hash())
+
+	// while the blocks above it can change
+	fork = writeTestBlocks(bc, blocks[1].Header(), 2, "fork")
+	assert.NoError(t, bc.reorg(blocks[2], fork[1]))
+	assert.Equal(t, fork[1].Hash(), bc.CurrentBlock().Hash())
+	assert.Equal(t, blocks[1].Hash(), rawdb.ReadCanonicalHash(bc.db, 2))
+	assert.NoError(t, bc.SetHead(2))
+	assert.Equal(t, blocks[1].Hash(), bc.CurrentBlock().Hash())
+	assert.Equal(t, blocks[1].Hash(), bc.FinalizedBlock().Hash())
+}
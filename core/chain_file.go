@@ -0,0 +1,220 @@
+package core
+
+import (
+	"io"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/internal/ctxerror"
+	"github.com/harmony-one/harmony/internal/utils"
+)
+
+const (
+	// ChainFileVersion is the version of the chain file format that
+	// ExportChain writes and ImportChain reads.
+	ChainFileVersion = 1
+
+	// chainFileMagic starts every chain file.
+	chainFileMagic = "HMYCHAIN"
+
+	// importBatchSize is the number of blocks ImportChain inserts at once.
+	importBatchSize = 256
+)
+
+// chainFileHeader is the first item of a chain file, which is an RLP stream.
+type chainFileHeader struct {
+	Magic   string
+	Version uint32
+	ShardID uint32
+}
+
+// chainFileBlock is a block of a chain file with its commit proof, the
+// aggregated signature of the committee that committed the block and the
+// bitmap of the signers.  The genesis block has no commit proof.
+type chainFileBlock struct {
+	Block        *types.Block
+	CommitSig    []byte
+	CommitBitmap []byte
+}
+
+// ExportChain writes the given range of the active chain to the given writer
+// as a chain file, with the commit proof of every block.  The proof of a
+// block is in the header of the next one, except for the head block, whose
+// proof is the last commits the node recorded.
+func (bc *BlockChain) ExportChain(w io.Writer, first uint64, last uint64) error {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	if first > last {
+		return ctxerror.New("first block is greater than last block",
+			"first", first,
+			"last", last,
+		)
+	}
+	header := &chainFileHeader{
+		Magic:   chainFileMagic,
+		Version: ChainFileVersion,
+		ShardID: bc.ShardID(),
+	}
+	if err := rlp.Encode(w, header); err != nil {
+		return ctxerror.New("cannot write chain file header").WithCause(err)
+	}
+	utils.Logger().Info().Uint64("count", last-first+1).Msg("[ExportChain] Exporting blocks")
+
+	start, reported := time.Now(), time.Now()
+	for nr := first; nr <= last; nr++ {
+		block := bc.GetBlockByNumber(nr)
+		if block == nil {
+			return ctxerror.New("cannot find block", "number", nr)
+		}
+		entry := &chainFileBlock{Block: block}
+		if nr > 0 {
+			sig, bitmap, err := bc.commitProof(block)
+			if err != nil {
+				return err
+			}
+			entry.CommitSig, entry.CommitBitmap = sig, bitmap
+		}
+		if err := rlp.Encode(w, entry); err != nil {
+			return ctxerror.New("cannot write block", "number", nr).WithCause(err)
+		}
+		if time.Since(reported) >= statsReportLimit {
+			utils.Logger().Info().
+				Uint64("exported", nr-first+1).
+				Str("elapsed", common.PrettyDuration(time.Since(start)).String()).
+				Msg("[ExportChain] Exporting blocks")
+			reported = time.Now()
+		}
+	}
+	return nil
+}
+
+// commitProof returns the commit signature and bitmap of the given canonical
+// block.
+func (bc *BlockChain) commitProof(block *types.Block) ([]byte, []byte, error) {
+	if child := bc.GetHeaderByNumber(block.NumberU64() + 1); child != nil {
+		sig := child.LastCommitSignature()
+		return sig[:], child.LastCommitBitmap(), nil
+	}
+	if block.Hash() != bc.CurrentBlock().Hash() {
+		return nil, nil, ctxerror.New("cannot find the next block",
+			"number", block.NumberU64())
+	}
+	lastCommits, err := bc.ReadLastCommits()
+	if err != nil {
+		return nil, nil, ctxerror.New("cannot read the commit proof of the head block",
+			"number", block.NumberU64()).WithCause(err)
+	}
+	if len(lastCommits) < 96 {
+		return nil, nil, ctxerror.New("no commit proof of the head block",
+			"number", block.NumberU64())
+	}
+	return lastCommits[:96], lastCommits[96:], nil
+}
+
+// ImportChain inserts the blocks of a chain file written by ExportChain and
+// returns the number of blocks it inserted.  The commit proof of every block
+// must pass the same check as the seals of the chain, that of
+// VerifyHeaderWithSignature of the consensus engine.  Blocks already in the
+// chain are skipped, so that an interrupted import can be resumed with the
+// same file.
+func (bc *BlockChain) ImportChain(r io.Reader) (int, error) {
+	return bc.importChain(r, bc.InsertChain)
+}
+
+// importChain reads the chain file as ImportChain does, passing the verified
+// blocks to insert in batches.
+func (bc *BlockChain) importChain(r io.Reader, insert func(types.Blocks) (int, error)) (int, error) {
+	stream := rlp.NewStream(r, 0)
+	var header chainFileHeader
+	if err := stream.Decode(&header); err != nil {
+		return 0, ctxerror.New("cannot read chain file header").WithCause(err)
+	}
+	if header.Magic != chainFileMagic {
+		return 0, ctxerror.New("not a chain file")
+	}
+	if header.Version != ChainFileVersion {
+		return 0, ctxerror.New("unsupported chain file version",
+			"version", header.Version,
+			"supported", ChainFileVersion,
+		)
+	}
+	if header.ShardID != bc.ShardID() {
+		return 0, ctxerror.New("chain file is of another shard",
+			"fileShardID", header.ShardID,
+			"shardID", bc.ShardID(),
+		)
+	}
+
+	imported := 0
+	var batch types.Blocks
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if n, err := insert(batch); err != nil {
+			return ctxerror.New("cannot insert block",
+				"number", batch[n].NumberU64()).WithCause(err)
+		}
+		imported += len(batch)
+		batch = nil
+		return nil
+	}
+	var last *chainFileBlock
+	for {
+		entry := &chainFileBlock{}
+		if err := stream.Decode(entry); err == io.EOF {
+			break
+		} else if err != nil {
+			return imported, ctxerror.New("cannot read block").WithCause(err)
+		}
+		block := entry.Block
+		if block.NumberU64() == 0 {
+			if block.Hash() != bc.Genesis().Hash() {
+				return imported, ctxerror.New("chain file is of another genesis block",
+					"fileGenesis", block.Hash(),
+					"genesis", bc.Genesis().Hash(),
+				)
+			}
+			continue
+		}
+		if block.ShardID() != bc.ShardID() {
+			return imported, ctxerror.New("block is of another shard",
+				"number", block.NumberU64(),
+				"blockShardID", block.ShardID(),
+				"shardID", bc.ShardID(),
+			)
+		}
+		if bc.HasBlockAndState(block.Hash(), block.NumberU64()) {
+			continue
+		}
+		err := bc.engine.VerifyHeaderWithSignature(block.Header(), entry.CommitSig, entry.CommitBitmap)
+		if err != nil {
+			return imported, ctxerror.New("invalid commit proof",
+				"number", block.NumberU64(),
+			).WithCause(err)
+		}
+		batch = append(batch, block)
+		last = entry
+		if len(batch) >= importBatchSize {
+			if err := flush(); err != nil {
+				return imported, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return imported, err
+	}
+	// No block carries the commit proof of the head, so record it as the
+	// node does when it commits a block.
+	if last != nil && last.Block.Hash() == bc.CurrentBlock().Hash() {
+		lastCommits := append(append([]byte{}, last.CommitSig...), last.CommitBitmap...)
+		if err := bc.WriteLastCommits(lastCommits); err != nil {
+			return imported, ctxerror.New("cannot write last commits").WithCause(err)
+		}
+	}
+	return imported, nil
+}
@@ -0,0 +1,178 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/harmony-one/bls/ffi/go/bls"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/harmony-one/harmony/block"
+	blockfactory "github.com/harmony-one/harmony/block/factory"
+	consensus_engine "github.com/harmony-one/harmony/consensus/engine"
+	"github.com/harmony-one/harmony/core/rawdb"
+	"github.com/harmony-one/harmony/core/types"
+	bls2 "github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/internal/ctxerror"
+)
+
+// epochKeyEngine is a consensus engine whose committee of every epoch is one
+// key, checking commit proofs only.
+type epochKeyEngine struct {
+	consensus_engine.Engine
+	keys map[uint64]*bls.SecretKey
+}
+
+func (e *epochKeyEngine) VerifyHeaderWithSignature(header *block.Header, commitSig []byte, commitBitmap []byte) error {
+	key, ok := e.keys[header.Epoch().Uint64()]
+	if !ok || !bytes.Equal(commitBitmap, []byte{0x01}) {
+		return ctxerror.New("not signed by the committee")
+	}
+	var sig bls.Sign
+	if err := sig.Deserialize(commitSig); err != nil {
+		return ctxerror.New("cannot deserialize the commit signature").WithCause(err)
+	}
+	if !sig.VerifyHash(key.GetPublicKey(), commitPayload(header)) {
+		return ctxerror.New("invalid commit signature")
+	}
+	return nil
+}
+
+// commitPayload returns the payload the committee signs to commit the given
+// header.
+func commitPayload(header *block.Header) []byte {
+	hash := header.Hash()
+	payload := make([]byte, 8)
+	binary.LittleEndian.PutUint64(payload, header.Number().Uint64())
+	return append(payload, hash[:]...)
+}
+
+// writeSignedTestBlocks writes a canonical chain of blocks of the given
+// epochs, each committed by the key of its epoch, and returns the blocks.
+func writeSignedTestBlocks(t *testing.T, bc *BlockChain, keys map[uint64]*bls.SecretKey, epochs ...int64) []*types.Block {
+	var blocks []*types.Block
+	parent := bc.Genesis().Header()
+	var sig [96]byte
+	for _, epoch := range epochs {
+		header := blockfactory.NewTestHeader().With().
+			ParentHash(parent.Hash()).
+			Number(new(big.Int).Add(parent.Number(), common.Big1)).
+			Epoch(big.NewInt(epoch)).
+			LastCommitSignature(sig).
+			LastCommitBitmap([]byte{0x01}).
+			Header()
+		block := types.NewBlock(header, nil, nil, nil, nil)
+		rawdb.WriteBlock(bc.db, block)
+		bc.insert(block)
+		blocks = append(blocks, block)
+		copy(sig[:], keys[uint64(epoch)].SignHash(commitPayload(header)).Serialize())
+		parent = header
+	}
+	assert.NoError(t, bc.WriteLastCommits(append(sig[:], 0x01)))
+	return blocks
+}
+
+// editChainFile decodes the given chain file, lets edit change it and
+// returns it encoded again.
+func editChainFile(t *testing.T, data []byte, edit func(*chainFileHeader, []*chainFileBlock)) []byte {
+	stream := rlp.NewStream(bytes.NewReader(data), 0)
+	header := &chainFileHeader{}
+	assert.NoError(t, stream.Decode(header))
+	var entries []*chainFileBlock
+	for {
+		entry := &chainFileBlock{}
+		if err := stream.Decode(entry); err == io.EOF {
+			break
+		} else if !assert.NoError(t, err) {
+			return nil
+		}
+		entries = append(entries, entry)
+	}
+	edit(header, entries)
+	var buf bytes.Buffer
+	assert.NoError(t, rlp.Encode(&buf, header))
+	for _, entry := range entries {
+		assert.NoError(t, rlp.Encode(&buf, entry))
+	}
+	return buf.Bytes()
+}
+
+func TestExportImportChain(t *testing.T) {
+	keys := map[uint64]*bls.SecretKey{0: bls2.RandPrivateKey(), 1: bls2.RandPrivateKey()}
+	engine := &epochKeyEngine{keys: keys}
+	source := newTestBlockChain(t)
+	blocks := writeSignedTestBlocks(t, source, keys, 0, 0, 1, 1, 1)
+	var buf bytes.Buffer
+	assert.NoError(t, source.ExportChain(&buf, 0, uint64(len(blocks))))
+	data := buf.Bytes()
+
+	// importChain imports data into a new chain and returns the hashes of
+	// the blocks it would insert
+	importChain := func(data []byte) ([]common.Hash, error) {
+		var inserted []common.Hash
+		_, err := newTestBlockChainWith(t, nil, engine).importChain(bytes.NewReader(data), func(batch types.Blocks) (int, error) {
+			for _, block := range batch {
+				inserted = append(inserted, block.Hash())
+			}
+			return 0, nil
+		})
+		return inserted, err
+	}
+
+	inserted, err := importChain(data)
+	assert.NoError(t, err)
+	if assert.Len(t, inserted, len(blocks)) {
+		for i, block := range blocks {
+			assert.Equal(t, block.Hash(), inserted[i])
+		}
+	}
+
+	// the commit proof of the head block, taken from the last commits, is
+	// checked too
+	inserted, err = importChain(editChainFile(t, data, func(_ *chainFileHeader, entries []*chainFileBlock) {
+		entries[len(entries)-1].CommitSig = entries[1].CommitSig
+	}))
+	assert.Error(t, err)
+	assert.Empty(t, inserted)
+
+	// a tampered signature is rejected
+	inserted, err = importChain(editChainFile(t, data, func(_ *chainFileHeader, entries []*chainFileBlock) {
+		entries[2].CommitSig = entries[1].CommitSig
+	}))
+	assert.Error(t, err)
+	assert.Empty(t, inserted)
+
+	// and so is a bitmap not naming the committee
+	inserted, err = importChain(editChainFile(t, data, func(_ *chainFileHeader, entries []*chainFileBlock) {
+		entries[2].CommitBitmap = []byte{0x02}
+	}))
+	assert.Error(t, err)
+	assert.Empty(t, inserted)
+
+	// the first block of an epoch is committed by the new committee, not
+	// the one of the previous epoch
+	source = newTestBlockChain(t)
+	blocks = writeSignedTestBlocks(t, source, map[uint64]*bls.SecretKey{0: keys[0], 1: keys[0]}, 0, 0, 1)
+	var stale bytes.Buffer
+	assert.NoError(t, source.ExportChain(&stale, 0, uint64(len(blocks))))
+	inserted, err = importChain(stale.Bytes())
+	assert.Error(t, err)
+	assert.Empty(t, inserted)
+
+	// neither a file nor a block of another shard is imported
+	_, err = importChain(editChainFile(t, data, func(header *chainFileHeader, _ []*chainFileBlock) {
+		header.ShardID = 1
+	}))
+	assert.Error(t, err)
+	inserted, err = importChain(editChainFile(t, data, func(_ *chainFileHeader, entries []*chainFileBlock) {
+		header := entries[1].Block.Header().With().ShardID(1).Header()
+		entries[1].Block = types.NewBlockWithHeader(header)
+	}))
+	assert.Error(t, err)
+	assert.Empty(t, inserted)
+}
@@ -35,4 +35,14 @@ var (
 
 	// ErrShardStateNotMatch is returned if the calculated shardState hash not equal that in the block header
 	ErrShardStateNotMatch = errors.New("shard state root hash not match")
+
+	// ErrBelowFinalized is returned if the chain would be rewound or
+	// reorganised below the latest finalized block.
+	ErrBelowFinalized = errors.New("below the finalized block")
+
+	// ErrStakingOutsideBeacon is the error of a staking transaction applied
+	// on a shard other than the beacon chain, which holds the registry, or
+	// sent across shards.  The transaction fails without invalidating the
+	// block.
+	ErrStakingOutsideBeacon = errors.New("staking transaction outside the beacon chain")
 )
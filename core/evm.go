@@ -54,6 +54,7 @@ func NewEVMContext(msg Message, header *block.Header, chain ChainContext, author
 		Coinbase:    beneficiary,
 		BlockNumber: header.Number(),
 		EpochNumber: header.Epoch(),
+		ShardID:     header.ShardID(),
 		Time:        header.Time(),
 		GasLimit:    header.GasLimit(),
 		GasPrice:    new(big.Int).Set(msg.GasPrice()),
@@ -279,6 +279,15 @@ func (g *Genesis) Commit(db ethdb.Database) (*types.Block, error) {
 
 	rawdb.WriteBlock(db, block)
 	rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), nil)
+	genesisSupply := big.NewInt(0)
+	for _, account := range g.Alloc {
+		if account.Balance != nil {
+			genesisSupply.Add(genesisSupply, account.Balance)
+		}
+	}
+	if err := rawdb.WriteShardSupply(db, block.Hash(), block.NumberU64(), types.NewShardSupply(genesisSupply)); err != nil {
+		utils.Logger().Error().Err(err).Msg("Failed to store genesis shard supply")
+	}
 	rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
 	rawdb.WriteHeadBlockHash(db, block.Hash())
 	rawdb.WriteHeadHeaderHash(db, block.Hash())
@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"math/big"
+	"sort"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -119,6 +120,23 @@ func WriteHeadFastBlockHash(db DatabaseWriter, hash common.Hash) {
 	}
 }
 
+// ReadFinalizedBlockHash retrieves the hash of the latest finalized block,
+// whose commit proof is on the canonical chain.
+func ReadFinalizedBlockHash(db DatabaseReader) common.Hash {
+	data, _ := db.Get(finalizedBlockKey)
+	if len(data) == 0 {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
+// WriteFinalizedBlockHash stores the hash of the latest finalized block.
+func WriteFinalizedBlockHash(db DatabaseWriter, hash common.Hash) {
+	if err := db.Put(finalizedBlockKey, hash.Bytes()); err != nil {
+		utils.Logger().Error().Msg("Failed to store last finalized block's hash")
+	}
+}
+
 // ReadFastTrieProgress retrieves the number of tries nodes fast synced to allow
 // reporting correct numbers across restarts.
 func ReadFastTrieProgress(db DatabaseReader) uint64 {
@@ -596,6 +614,24 @@ func DeleteCXReceiptsProofSpent(db DatabaseDeleter, shardID uint32, number uint6
 	}
 }
 
+// ReadCXReceiptsProofSent returns the unix time the proof of the cross-shard
+// receipts of the given block towards the given shard was last sent to it
+func ReadCXReceiptsProofSent(db DatabaseReader, toShardID uint32, number uint64, hash common.Hash) (uint64, error) {
+	data, err := db.Get(cxReceiptProofSentKey(toShardID, number, hash))
+	if err != nil || len(data) != 8 {
+		return 0, ctxerror.New("[ReadCXReceiptsProofSent] Cannot find the key", "toShardID", toShardID, "number", number, "hash", hash).WithCause(err)
+	}
+	return binary.BigEndian.Uint64(data), nil
+}
+
+// WriteCXReceiptsProofSent records the unix time the proof of the
+// cross-shard receipts of the given block was sent to the given shard
+func WriteCXReceiptsProofSent(dbw DatabaseWriter, toShardID uint32, number uint64, hash common.Hash, sentAt uint64) error {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, sentAt)
+	return dbw.Put(cxReceiptProofSentKey(toShardID, number, hash), data)
+}
+
 // ReadCXReceiptsProofUnspentCheckpoint returns the last unspent blocknumber
 func ReadCXReceiptsProofUnspentCheckpoint(db DatabaseReader, shardID uint32) (uint64, error) {
 	by, err := db.Get(cxReceiptUnspentCheckpointKey(shardID))
@@ -612,3 +648,390 @@ func WriteCXReceiptsProofUnspentCheckpoint(db DatabaseWriter, shardID uint32, bl
 	binary.BigEndian.PutUint64(by[:], blockNum)
 	return db.Put(cxReceiptUnspentCheckpointKey(shardID), by)
 }
+
+// readPbftLogCount returns the count stored at the given key, or 0 if there
+// is none.
+func readPbftLogCount(db DatabaseReader, key []byte) (uint32, error) {
+	if has, err := db.Has(key); err != nil || !has {
+		return 0, err
+	}
+	data, err := db.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) != 4 {
+		return 0, ctxerror.New("invalid pbft log count", "length", len(data))
+	}
+	return binary.BigEndian.Uint32(data), nil
+}
+
+// ReadPbftLogMessageCount returns the number of pbft messages stored for the given block number
+func ReadPbftLogMessageCount(db DatabaseReader, number uint64) (uint32, error) {
+	return readPbftLogCount(db, pbftLogMessageCountKey(number))
+}
+
+// ReadPbftLogMessage retrieves the encoded pbft message stored at the given index for the given block number
+func ReadPbftLogMessage(db DatabaseReader, number uint64, index uint32) ([]byte, error) {
+	return db.Get(pbftLogMessageKey(number, index))
+}
+
+// WritePbftLogMessage stores the encoded pbft message at the given index for
+// the given block number, and counts it
+func WritePbftLogMessage(db DatabaseWriter, number uint64, index uint32, data []byte) error {
+	if err := db.Put(pbftLogMessageKey(number, index), data); err != nil {
+		return err
+	}
+	return db.Put(pbftLogMessageCountKey(number), encodeIndex(index+1))
+}
+
+// DeletePbftLogMessages removes the given number of pbft messages stored for the given block number
+func DeletePbftLogMessages(db DatabaseDeleter, number uint64, count uint32) error {
+	for index := uint32(0); index < count; index++ {
+		if err := db.Delete(pbftLogMessageKey(number, index)); err != nil {
+			return err
+		}
+	}
+	return db.Delete(pbftLogMessageCountKey(number))
+}
+
+// ReadPbftLogBlockCount returns the number of blocks stored for the given block number
+func ReadPbftLogBlockCount(db DatabaseReader, number uint64) (uint32, error) {
+	return readPbftLogCount(db, pbftLogBlockCountKey(number))
+}
+
+// HasPbftLogBlock returns whether the given block is stored for its block number
+func HasPbftLogBlock(db DatabaseReader, number uint64, hash common.Hash) (bool, error) {
+	return db.Has(pbftLogBlockHashKey(number, hash))
+}
+
+// ReadPbftLogBlock retrieves the block stored at the given index for the given block number
+func ReadPbftLogBlock(db DatabaseReader, number uint64, index uint32) (*types.Block, error) {
+	data, err := db.Get(pbftLogBlockKey(number, index))
+	if err != nil {
+		return nil, err
+	}
+	block := new(types.Block)
+	if err := rlp.DecodeBytes(data, block); err != nil {
+		return nil, ctxerror.New("cannot decode pbft log block",
+			"number", number,
+			"index", index,
+		).WithCause(err)
+	}
+	return block, nil
+}
+
+// WritePbftLogBlock stores the block at the given index for its block number, and counts it
+func WritePbftLogBlock(db DatabaseWriter, index uint32, block *types.Block) error {
+	number := block.NumberU64()
+	data, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		return ctxerror.New("cannot encode pbft log block",
+			"number", number,
+		).WithCause(err)
+	}
+	if err := db.Put(pbftLogBlockKey(number, index), data); err != nil {
+		return err
+	}
+	if err := db.Put(pbftLogBlockHashKey(number, block.Hash()), []byte{1}); err != nil {
+		return err
+	}
+	return db.Put(pbftLogBlockCountKey(number), encodeIndex(index+1))
+}
+
+// DeletePbftLogBlock removes the block stored at the given index for its block number
+func DeletePbftLogBlock(db DatabaseDeleter, index uint32, block *types.Block) error {
+	if err := db.Delete(pbftLogBlockKey(block.NumberU64(), index)); err != nil {
+		return err
+	}
+	return db.Delete(pbftLogBlockHashKey(block.NumberU64(), block.Hash()))
+}
+
+// DeletePbftLogBlockCount removes the count of the blocks stored for the given block number
+func DeletePbftLogBlockCount(db DatabaseDeleter, number uint64) error {
+	return db.Delete(pbftLogBlockCountKey(number))
+}
+
+// ReadPbftLogMessagesRange returns the lowest and highest block numbers of the
+// stored pbft messages, and whether there are any
+func ReadPbftLogMessagesRange(db DatabaseReader) (uint64, uint64, bool, error) {
+	return readPbftLogRange(db, pbftLogMessagesRangeKey)
+}
+
+// WritePbftLogMessagesRange stores the lowest and highest block numbers of the stored pbft messages
+func WritePbftLogMessagesRange(db DatabaseWriter, low, high uint64) error {
+	return writePbftLogRange(db, pbftLogMessagesRangeKey, low, high)
+}
+
+// ReadPbftLogBlocksRange returns the lowest and highest block numbers of the
+// stored pbft blocks, and whether there are any
+func ReadPbftLogBlocksRange(db DatabaseReader) (uint64, uint64, bool, error) {
+	return readPbftLogRange(db, pbftLogBlocksRangeKey)
+}
+
+// WritePbftLogBlocksRange stores the lowest and highest block numbers of the stored pbft blocks
+func WritePbftLogBlocksRange(db DatabaseWriter, low, high uint64) error {
+	return writePbftLogRange(db, pbftLogBlocksRangeKey, low, high)
+}
+
+func readPbftLogRange(db DatabaseReader, key []byte) (uint64, uint64, bool, error) {
+	if has, err := db.Has(key); err != nil || !has {
+		return 0, 0, false, err
+	}
+	data, err := db.Get(key)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	if len(data) != 16 {
+		return 0, 0, false, ctxerror.New("invalid pbft log range", "length", len(data))
+	}
+	return binary.BigEndian.Uint64(data[:8]), binary.BigEndian.Uint64(data[8:]), true, nil
+}
+
+func writePbftLogRange(db DatabaseWriter, key []byte, low, high uint64) error {
+	data := make([]byte, 16)
+	binary.BigEndian.PutUint64(data[:8], low)
+	binary.BigEndian.PutUint64(data[8:], high)
+	return db.Put(key, data)
+}
+
+// ReadValidatorUptime retrieves the signed and missed block counts of the
+// given BLS key in the given epoch.  A key with no counts stored has zero
+// counts.
+func ReadValidatorUptime(db DatabaseReader, epoch *big.Int, key shard.BlsPublicKey) (*types.ValidatorUptime, error) {
+	data, err := db.Get(validatorUptimeKey(epoch, key))
+	if err != nil || len(data) == 0 {
+		return &types.ValidatorUptime{}, nil
+	}
+	uptime := &types.ValidatorUptime{}
+	if err := rlp.DecodeBytes(data, uptime); err != nil {
+		return nil, ctxerror.New("cannot decode validator uptime",
+			"epoch", epoch,
+			"blsPublicKey", key.Hex(),
+		).WithCause(err)
+	}
+	return uptime, nil
+}
+
+// WriteValidatorUptime stores the signed and missed block counts of the given
+// BLS key in the given epoch.
+func WriteValidatorUptime(db DatabaseWriter, epoch *big.Int, key shard.BlsPublicKey, uptime *types.ValidatorUptime) error {
+	data, err := rlp.EncodeToBytes(uptime)
+	if err != nil {
+		return ctxerror.New("cannot encode validator uptime",
+			"epoch", epoch,
+			"blsPublicKey", key.Hex(),
+		).WithCause(err)
+	}
+	return db.Put(validatorUptimeKey(epoch, key), data)
+}
+
+// ReadUptimeCountedBlockHash retrieves the hash of the block of the given
+// number the validator uptime counts include, or the zero hash.
+func ReadUptimeCountedBlockHash(db DatabaseReader, number uint64) common.Hash {
+	data, _ := db.Get(uptimeCountedBlockKey(number))
+	if len(data) == 0 {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
+// WriteUptimeCountedBlockHash stores the hash of the block of the given
+// number the validator uptime counts include.
+func WriteUptimeCountedBlockHash(db DatabaseWriter, number uint64, hash common.Hash) error {
+	return db.Put(uptimeCountedBlockKey(number), hash.Bytes())
+}
+
+// DeleteUptimeCountedBlockHash removes the hash of the block of the given
+// number the validator uptime counts include.
+func DeleteUptimeCountedBlockHash(db DatabaseDeleter, number uint64) error {
+	return db.Delete(uptimeCountedBlockKey(number))
+}
+
+// accountTxsCount is the storage form of an entry of types.BlockTxsCounts.
+type accountTxsCount struct {
+	Address common.Address
+	Count   uint64
+}
+
+// ReadBlockTxsCounts retrieves the number of transactions of each sender
+// account in the canonical block of the given number.  A block with no counts
+// stored has no transactions.
+func ReadBlockTxsCounts(db DatabaseReader, number uint64) (types.BlockTxsCounts, error) {
+	counts := make(types.BlockTxsCounts)
+	data, err := db.Get(blockTxsCountsKey(number))
+	if err != nil || len(data) == 0 {
+		return counts, nil
+	}
+	entries := []accountTxsCount{}
+	if err := rlp.DecodeBytes(data, &entries); err != nil {
+		return nil, ctxerror.New("cannot decode block transactions counts",
+			"blockNumber", number,
+		).WithCause(err)
+	}
+	for _, entry := range entries {
+		counts[entry.Address] = entry.Count
+	}
+	return counts, nil
+}
+
+// WriteBlockTxsCounts stores the number of transactions of each sender
+// account in the canonical block of the given number.
+func WriteBlockTxsCounts(db DatabaseWriter, number uint64, counts types.BlockTxsCounts) error {
+	entries := make([]accountTxsCount, 0, len(counts))
+	for address, count := range counts {
+		entries = append(entries, accountTxsCount{Address: address, Count: count})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].Address[:], entries[j].Address[:]) < 0
+	})
+	data, err := rlp.EncodeToBytes(entries)
+	if err != nil {
+		return ctxerror.New("cannot encode block transactions counts",
+			"blockNumber", number,
+		).WithCause(err)
+	}
+	return db.Put(blockTxsCountsKey(number), data)
+}
+
+// ReadBlockRewards retrieves the payouts of the block reward credited by the
+// given block, or nil if none are stored.
+func ReadBlockRewards(db DatabaseReader, hash common.Hash, number uint64) (*types.BlockRewards, error) {
+	data, err := db.Get(blockRewardsKey(number, hash))
+	if err != nil || len(data) == 0 {
+		return nil, nil
+	}
+	rewards := &types.BlockRewards{}
+	if err := rlp.DecodeBytes(data, rewards); err != nil {
+		return nil, ctxerror.New("cannot decode block rewards",
+			"blockNumber", number,
+		).WithCause(err)
+	}
+	return rewards, nil
+}
+
+// WriteBlockRewards stores the payouts of the block reward credited by the
+// given block.
+func WriteBlockRewards(db DatabaseWriter, hash common.Hash, number uint64, rewards *types.BlockRewards) error {
+	data, err := rlp.EncodeToBytes(rewards)
+	if err != nil {
+		return ctxerror.New("cannot encode block rewards",
+			"blockNumber", rewards.BlockNum,
+		).WithCause(err)
+	}
+	return db.Put(blockRewardsKey(number, hash), data)
+}
+
+// ReadBlockFees retrieves the transaction fees collected and burned in the
+// given block, or nil if none are stored.
+func ReadBlockFees(db DatabaseReader, hash common.Hash, number uint64) (*types.BlockFees, error) {
+	data, err := db.Get(blockFeesKey(number, hash))
+	if err != nil || len(data) == 0 {
+		return nil, nil
+	}
+	fees := &types.BlockFees{}
+	if err := rlp.DecodeBytes(data, fees); err != nil {
+		return nil, ctxerror.New("cannot decode block fees",
+			"blockHash", hash,
+			"blockNumber", number,
+		).WithCause(err)
+	}
+	return fees, nil
+}
+
+// WriteBlockFees stores the transaction fees collected and burned in the
+// given block.
+func WriteBlockFees(db DatabaseWriter, hash common.Hash, number uint64, fees *types.BlockFees) error {
+	data, err := rlp.EncodeToBytes(fees)
+	if err != nil {
+		return ctxerror.New("cannot encode block fees",
+			"blockHash", hash,
+			"blockNumber", number,
+		).WithCause(err)
+	}
+	return db.Put(blockFeesKey(number, hash), data)
+}
+
+// ReadShardSupply retrieves the supply of the shard as of the given block, or
+// nil if none is stored.
+func ReadShardSupply(db DatabaseReader, hash common.Hash, number uint64) (*types.ShardSupply, error) {
+	data, err := db.Get(shardSupplyKey(number, hash))
+	if err != nil || len(data) == 0 {
+		return nil, nil
+	}
+	supply := &types.ShardSupply{}
+	if err := rlp.DecodeBytes(data, supply); err != nil {
+		return nil, ctxerror.New("cannot decode shard supply",
+			"blockHash", hash,
+			"blockNumber", number,
+		).WithCause(err)
+	}
+	return supply, nil
+}
+
+// WriteShardSupply stores the supply of the shard as of the given block.
+func WriteShardSupply(db DatabaseWriter, hash common.Hash, number uint64, supply *types.ShardSupply) error {
+	data, err := rlp.EncodeToBytes(supply)
+	if err != nil {
+		return ctxerror.New("cannot encode shard supply",
+			"blockHash", hash,
+			"blockNumber", number,
+		).WithCause(err)
+	}
+	return db.Put(shardSupplyKey(number, hash), data)
+}
+
+// ReadBeaconRandomness retrieves the output of the distributed randomness
+// beacon for the given epoch, or nil if none is stored.
+func ReadBeaconRandomness(db DatabaseReader, epoch *big.Int) (*types.BeaconRandomness, error) {
+	data, err := db.Get(beaconRandomnessKey(epoch))
+	if err != nil || len(data) == 0 {
+		return nil, nil
+	}
+	randomness := &types.BeaconRandomness{}
+	if err := rlp.DecodeBytes(data, randomness); err != nil {
+		return nil, ctxerror.New("cannot decode beacon randomness",
+			"epoch", epoch,
+		).WithCause(err)
+	}
+	return randomness, nil
+}
+
+// WriteBeaconRandomness stores the output of the distributed randomness
+// beacon for its epoch.
+func WriteBeaconRandomness(db DatabaseWriter, randomness *types.BeaconRandomness) error {
+	data, err := rlp.EncodeToBytes(randomness)
+	if err != nil {
+		return ctxerror.New("cannot encode beacon randomness",
+			"epoch", randomness.Epoch,
+		).WithCause(err)
+	}
+	return db.Put(beaconRandomnessKey(randomness.Epoch), data)
+}
+
+// ReadEpochStakes retrieves the stakes of the validator registry as of the
+// start of the given epoch.
+func ReadEpochStakes(db DatabaseReader, epoch *big.Int) (*types.EpochStakes, error) {
+	data, err := db.Get(epochStakesKey(epoch))
+	if err != nil || len(data) == 0 {
+		return nil, nil
+	}
+	stakes := &types.EpochStakes{}
+	if err := rlp.DecodeBytes(data, stakes); err != nil {
+		return nil, ctxerror.New("cannot decode epoch stakes",
+			"epoch", epoch,
+		).WithCause(err)
+	}
+	return stakes, nil
+}
+
+// WriteEpochStakes stores the stakes of the validator registry as of the
+// start of their epoch.
+func WriteEpochStakes(db DatabaseWriter, stakes *types.EpochStakes) error {
+	data, err := rlp.EncodeToBytes(stakes)
+	if err != nil {
+		return ctxerror.New("cannot encode epoch stakes",
+			"epoch", stakes.Epoch,
+		).WithCause(err)
+	}
+	return db.Put(epochStakesKey(stakes.Epoch), data)
+}
@@ -34,6 +34,7 @@ import (
 	blockfactory "github.com/harmony-one/harmony/block/factory"
 	mock "github.com/harmony-one/harmony/core/rawdb/mock"
 	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/shard"
 )
 
 // Tests block header storage and retrieval operations.
@@ -254,6 +255,7 @@ func TestHeadStorage(t *testing.T) {
 	blockHead := types.NewBlockWithHeader(blockfactory.NewTestHeader().With().Extra([]byte("test block header")).Header())
 	blockFull := types.NewBlockWithHeader(blockfactory.NewTestHeader().With().Extra([]byte("test block full")).Header())
 	blockFast := types.NewBlockWithHeader(blockfactory.NewTestHeader().With().Extra([]byte("test block fast")).Header())
+	blockFinalized := types.NewBlockWithHeader(blockfactory.NewTestHeader().With().Extra([]byte("test block finalized")).Header())
 
 	// Check that no head entries are in a pristine database
 	if entry := ReadHeadHeaderHash(db); entry != (common.Hash{}) {
@@ -265,10 +267,14 @@ func TestHeadStorage(t *testing.T) {
 	if entry := ReadHeadFastBlockHash(db); entry != (common.Hash{}) {
 		t.Fatalf("Non fast head block entry returned: %v", entry)
 	}
+	if entry := ReadFinalizedBlockHash(db); entry != (common.Hash{}) {
+		t.Fatalf("Non finalized block entry returned: %v", entry)
+	}
 	// Assign separate entries for the head header and block
 	WriteHeadHeaderHash(db, blockHead.Hash())
 	WriteHeadBlockHash(db, blockFull.Hash())
 	WriteHeadFastBlockHash(db, blockFast.Hash())
+	WriteFinalizedBlockHash(db, blockFinalized.Hash())
 
 	// Check that both heads are present, and different (i.e. two heads maintained)
 	if entry := ReadHeadHeaderHash(db); entry != blockHead.Hash() {
@@ -280,6 +286,9 @@ func TestHeadStorage(t *testing.T) {
 	if entry := ReadHeadFastBlockHash(db); entry != blockFast.Hash() {
 		t.Fatalf("Fast head block hash mismatch: have %v, want %v", entry, blockFast.Hash())
 	}
+	if entry := ReadFinalizedBlockHash(db); entry != blockFinalized.Hash() {
+		t.Fatalf("Finalized block hash mismatch: have %v, want %v", entry, blockFinalized.Hash())
+	}
 }
 
 // Tests that receipts associated with a single block can be stored and retrieved.
@@ -486,3 +495,225 @@ func TestWriteEpochBlockNumber(t *testing.T) {
 		})
 	}
 }
+
+// Tests validator uptime storage and retrieval operations.
+func TestValidatorUptimeStorage(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+
+	key1, key2 := shard.BlsPublicKey{1}, shard.BlsPublicKey{2}
+	epoch1, epoch2 := big.NewInt(1), big.NewInt(2)
+	if uptime, err := ReadValidatorUptime(db, epoch1, key1); err != nil || *uptime != (types.ValidatorUptime{}) {
+		t.Fatalf("non existent uptime returned: %v, %v", uptime, err)
+	}
+	if err := WriteValidatorUptime(db, epoch1, key1, &types.ValidatorUptime{Signed: 9, Missed: 1}); err != nil {
+		t.Fatalf("failed to write uptime: %v", err)
+	}
+	if uptime, err := ReadValidatorUptime(db, epoch1, key1); err != nil || uptime.Signed != 9 || uptime.Missed != 1 {
+		t.Fatalf("stored uptime mismatch: %v, %v", uptime, err)
+	}
+	if uptime, _ := ReadValidatorUptime(db, epoch2, key1); *uptime != (types.ValidatorUptime{}) {
+		t.Fatalf("uptime of another epoch returned: %v", uptime)
+	}
+	if uptime, _ := ReadValidatorUptime(db, epoch1, key2); *uptime != (types.ValidatorUptime{}) {
+		t.Fatalf("uptime of another key returned: %v", uptime)
+	}
+	if err := db.Put(validatorUptimeKey(epoch2, key2), []byte{0x01}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadValidatorUptime(db, epoch2, key2); err == nil {
+		t.Fatal("corrupted uptime decoded")
+	}
+}
+
+// Tests storage of the blocks the validator uptime counts include.
+func TestUptimeCountedBlockHashStorage(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+
+	if hash := ReadUptimeCountedBlockHash(db, 7); hash != (common.Hash{}) {
+		t.Fatalf("non existent hash returned: %x", hash)
+	}
+	if err := WriteUptimeCountedBlockHash(db, 7, common.Hash{7}); err != nil {
+		t.Fatalf("failed to write hash: %v", err)
+	}
+	if hash := ReadUptimeCountedBlockHash(db, 7); hash != (common.Hash{7}) {
+		t.Fatalf("stored hash mismatch: %x", hash)
+	}
+	if hash := ReadUptimeCountedBlockHash(db, 8); hash != (common.Hash{}) {
+		t.Fatalf("hash of another number returned: %x", hash)
+	}
+	if err := DeleteUptimeCountedBlockHash(db, 7); err != nil {
+		t.Fatalf("failed to delete hash: %v", err)
+	}
+	if hash := ReadUptimeCountedBlockHash(db, 7); hash != (common.Hash{}) {
+		t.Fatalf("deleted hash returned: %x", hash)
+	}
+}
+
+// Tests storage of the time cross-shard receipts proofs were sent.
+func TestCXReceiptsProofSentStorage(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+
+	if _, err := ReadCXReceiptsProofSent(db, 1, 5, common.Hash{5}); err == nil {
+		t.Fatal("non existent send time returned")
+	}
+	if err := WriteCXReceiptsProofSent(db, 1, 5, common.Hash{5}, 1234); err != nil {
+		t.Fatalf("failed to write send time: %v", err)
+	}
+	if sentAt, err := ReadCXReceiptsProofSent(db, 1, 5, common.Hash{5}); err != nil || sentAt != 1234 {
+		t.Fatalf("stored send time mismatch: %v, %v", sentAt, err)
+	}
+	if _, err := ReadCXReceiptsProofSent(db, 2, 5, common.Hash{5}); err == nil {
+		t.Fatal("send time towards another shard returned")
+	}
+	if _, err := ReadCXReceiptsProofSent(db, 1, 5, common.Hash{6}); err == nil {
+		t.Fatal("send time of another block returned")
+	}
+}
+
+// Tests block transactions counts storage and retrieval operations.
+func TestBlockTxsCountsStorage(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+
+	if counts, err := ReadBlockTxsCounts(db, 1); err != nil || len(counts) != 0 {
+		t.Fatalf("non existent counts returned: %v, %v", counts, err)
+	}
+	counts := types.BlockTxsCounts{common.Address{1}: 3, common.Address{2}: 1}
+	if err := WriteBlockTxsCounts(db, 1, counts); err != nil {
+		t.Fatalf("failed to write counts: %v", err)
+	}
+	stored, err := ReadBlockTxsCounts(db, 1)
+	if err != nil || len(stored) != 2 || stored[common.Address{1}] != 3 || stored[common.Address{2}] != 1 {
+		t.Fatalf("stored counts mismatch: %v, %v", stored, err)
+	}
+	if counts, _ := ReadBlockTxsCounts(db, 2); len(counts) != 0 {
+		t.Fatalf("counts of another block returned: %v", counts)
+	}
+}
+
+// Tests block rewards storage and retrieval operations.
+func TestBlockRewardsStorage(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+
+	hash := common.Hash{5}
+	if rewards, err := ReadBlockRewards(db, hash, 5); err != nil || rewards != nil {
+		t.Fatalf("non existent rewards returned: %v, %v", rewards, err)
+	}
+	rewards := &types.BlockRewards{
+		BlockNum: big.NewInt(5),
+		Total:    big.NewInt(30),
+		Payouts: []types.RewardPayout{
+			{Account: common.Address{1}, Kind: types.LeaderReward, BlsPublicKey: shard.BlsPublicKey{2}, Amount: big.NewInt(3), SignedBlockNum: big.NewInt(4)},
+			{Account: common.Address{1}, Kind: types.SignerReward, BlsPublicKey: shard.BlsPublicKey{2}, Amount: big.NewInt(13), SignedBlockNum: big.NewInt(4)},
+			{Account: common.Address{4}, Kind: types.SignerReward, BlsPublicKey: shard.BlsPublicKey{5}, Amount: big.NewInt(14), ShardID: 1, SignedBlockNum: big.NewInt(9)},
+		},
+	}
+	if err := WriteBlockRewards(db, hash, 5, rewards); err != nil {
+		t.Fatalf("failed to write rewards: %v", err)
+	}
+	stored, err := ReadBlockRewards(db, hash, 5)
+	if err != nil || stored == nil {
+		t.Fatalf("failed to read rewards: %v, %v", stored, err)
+	}
+	if !reflect.DeepEqual(stored, rewards) || stored.Paid().Cmp(stored.Total) != 0 {
+		t.Fatalf("stored rewards mismatch: have %v, want %v", stored, rewards)
+	}
+	if rewards, _ := ReadBlockRewards(db, common.Hash{6}, 5); rewards != nil {
+		t.Fatalf("rewards of another block returned: %v", rewards)
+	}
+}
+
+// Tests block fees and shard supply storage and retrieval operations.
+func TestBlockFeesAndShardSupplyStorage(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	hash := common.Hash{1}
+
+	if fees, err := ReadBlockFees(db, hash, 7); err != nil || fees != nil {
+		t.Fatalf("non existent fees returned: %v, %v", fees, err)
+	}
+	fees := &types.BlockFees{Collected: big.NewInt(21000), Burned: big.NewInt(21000)}
+	if err := WriteBlockFees(db, hash, 7, fees); err != nil {
+		t.Fatalf("failed to write fees: %v", err)
+	}
+	if stored, err := ReadBlockFees(db, hash, 7); err != nil || !reflect.DeepEqual(stored, fees) {
+		t.Fatalf("stored fees mismatch: have %v, %v, want %v", stored, err, fees)
+	}
+	if stored, _ := ReadBlockFees(db, common.Hash{2}, 7); stored != nil {
+		t.Fatalf("fees of another block returned: %v", stored)
+	}
+
+	if supply, err := ReadShardSupply(db, hash, 7); err != nil || supply != nil {
+		t.Fatalf("non existent supply returned: %v, %v", supply, err)
+	}
+	supply := types.NewShardSupply(big.NewInt(1000))
+	supply.Issued.SetInt64(100)
+	supply.Burned.SetInt64(10)
+	supply.CrossShardOut.SetInt64(50)
+	supply.CrossShardIn.SetInt64(20)
+	if err := WriteShardSupply(db, hash, 7, supply); err != nil {
+		t.Fatalf("failed to write supply: %v", err)
+	}
+	stored, err := ReadShardSupply(db, hash, 7)
+	if err != nil || !reflect.DeepEqual(stored, supply) {
+		t.Fatalf("stored supply mismatch: have %v, %v, want %v", stored, err, supply)
+	}
+	if stored.Minted().Cmp(big.NewInt(1090)) != 0 || stored.Circulating().Cmp(big.NewInt(1060)) != 0 {
+		t.Fatalf("wrong minted %v or circulating %v supply", stored.Minted(), stored.Circulating())
+	}
+}
+
+// Tests beacon randomness storage and retrieval operations.
+func TestBeaconRandomnessStorage(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+
+	if randomness, err := ReadBeaconRandomness(db, big.NewInt(3)); err != nil || randomness != nil {
+		t.Fatalf("non existent randomness returned: %v, %v", randomness, err)
+	}
+	randomness := &types.BeaconRandomness{
+		Epoch: big.NewInt(3),
+		Seed:  common.Hash{1},
+		Dealers: []types.BeaconDealer{
+			{Index: 0, Commitment: []byte{2}, CommitmentsHash: common.Hash{3}, Signature: []byte{4}},
+			{Index: 2, Commitment: []byte{5}, CommitmentsHash: common.Hash{6}, Signature: []byte{7}},
+		},
+		Signature: []byte{8, 9},
+	}
+	if err := WriteBeaconRandomness(db, randomness); err != nil {
+		t.Fatalf("failed to write randomness: %v", err)
+	}
+	stored, err := ReadBeaconRandomness(db, big.NewInt(3))
+	if err != nil || stored == nil {
+		t.Fatalf("failed to read randomness: %v, %v", stored, err)
+	}
+	if !reflect.DeepEqual(stored, randomness) || stored.Randomness() != randomness.Randomness() {
+		t.Fatalf("stored randomness mismatch: have %v, want %v", stored, randomness)
+	}
+	if randomness, _ := ReadBeaconRandomness(db, big.NewInt(4)); randomness != nil {
+		t.Fatalf("randomness of another epoch returned: %v", randomness)
+	}
+}
+
+// Tests epoch stakes storage and retrieval operations.
+func TestEpochStakesStorage(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+
+	if stakes, err := ReadEpochStakes(db, big.NewInt(3)); err != nil || stakes != nil {
+		t.Fatalf("non existent stakes returned: %v, %v", stakes, err)
+	}
+	stakes := &types.EpochStakes{
+		Epoch: big.NewInt(3),
+		Stakes: []*types.ValidatorStake{
+			{Address: common.Address{1}, BlsPublicKey: shard.BlsPublicKey{2}, Amount: big.NewInt(100)},
+			{Address: common.Address{3}, BlsPublicKey: shard.BlsPublicKey{4}, Amount: big.NewInt(200)},
+		},
+	}
+	if err := WriteEpochStakes(db, stakes); err != nil {
+		t.Fatalf("failed to write stakes: %v", err)
+	}
+	stored, err := ReadEpochStakes(db, big.NewInt(3))
+	if err != nil || !reflect.DeepEqual(stored, stakes) {
+		t.Fatalf("stored stakes mismatch: have %v, %v, want %v", stored, err, stakes)
+	}
+	if stakes, _ := ReadEpochStakes(db, big.NewInt(4)); stakes != nil {
+		t.Fatalf("stakes of another epoch returned: %v", stakes)
+	}
+}
@@ -21,6 +21,8 @@ import (
 	"github.com/ethereum/go-ethereum/rlp"
 
 	"github.com/harmony-one/harmony/core/types"
+	shardingconfig "github.com/harmony-one/harmony/internal/configs/sharding"
+	"github.com/harmony-one/harmony/internal/ctxerror"
 	"github.com/harmony-one/harmony/internal/utils"
 )
 
@@ -63,6 +65,72 @@ func DeleteTxLookupEntry(db DatabaseDeleter, hash common.Hash) {
 	db.Delete(txLookupKey(hash))
 }
 
+// txThrottleStatus is the storage form of shardingconfig.TxThrottleStatus,
+// whose flag RLP cannot encode.
+type txThrottleStatus struct {
+	Flag     uint64
+	Reason   string
+	BlockNum uint64
+}
+
+// ReadTxThrottleStatus retrieves the throttling result of the transaction of
+// the given hash when it was last not selected for a new block, or nil.
+func ReadTxThrottleStatus(db DatabaseReader, hash common.Hash) (*shardingconfig.TxThrottleStatus, error) {
+	data, _ := db.Get(txThrottleStatusKey(hash))
+	if len(data) == 0 {
+		return nil, nil
+	}
+	stored := txThrottleStatus{}
+	if err := rlp.DecodeBytes(data, &stored); err != nil {
+		return nil, ctxerror.New("cannot decode transaction throttle status",
+			"txHash", hash,
+		).WithCause(err)
+	}
+	return &shardingconfig.TxThrottleStatus{
+		Flag:     shardingconfig.TxThrottleFlag(stored.Flag),
+		Reason:   stored.Reason,
+		BlockNum: stored.BlockNum,
+	}, nil
+}
+
+// WriteTxThrottleStatus stores the throttling result of the transaction of
+// the given hash, which was not selected for a new block.
+func WriteTxThrottleStatus(db DatabaseWriter, hash common.Hash, status *shardingconfig.TxThrottleStatus) error {
+	data, err := rlp.EncodeToBytes(txThrottleStatus{
+		Flag:     uint64(status.Flag),
+		Reason:   status.Reason,
+		BlockNum: status.BlockNum,
+	})
+	if err != nil {
+		return ctxerror.New("cannot encode transaction throttle status",
+			"txHash", hash,
+		).WithCause(err)
+	}
+	return db.Put(txThrottleStatusKey(hash), data)
+}
+
+// DeleteTxThrottleStatus removes the throttling result of the transaction of
+// the given hash.
+func DeleteTxThrottleStatus(db DatabaseDeleter, hash common.Hash) error {
+	return db.Delete(txThrottleStatusKey(hash))
+}
+
+// ReadTxReplacement retrieves the hash of the transaction which replaced the
+// transaction of the given hash in the pool, or the zero hash.
+func ReadTxReplacement(db DatabaseReader, hash common.Hash) common.Hash {
+	data, _ := db.Get(txReplacementKey(hash))
+	if len(data) == 0 {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
+// WriteTxReplacement stores the hash of the transaction which replaced the
+// transaction of the given hash in the pool.
+func WriteTxReplacement(db DatabaseWriter, hash, replacement common.Hash) error {
+	return db.Put(txReplacementKey(hash), replacement.Bytes())
+}
+
 // ReadTransaction retrieves a specific transaction from the database, along with
 // its added positional metadata.
 func ReadTransaction(db DatabaseReader, hash common.Hash) (*types.Transaction, common.Hash, uint64, uint64) {
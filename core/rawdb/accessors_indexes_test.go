@@ -25,6 +25,7 @@ import (
 
 	blockfactory "github.com/harmony-one/harmony/block/factory"
 	"github.com/harmony-one/harmony/core/types"
+	shardingconfig "github.com/harmony-one/harmony/internal/configs/sharding"
 )
 
 // Tests that positional lookup metadata can be stored and retrieved.
@@ -68,3 +69,41 @@ func TestLookupStorage(t *testing.T) {
 		}
 	}
 }
+
+// Tests that transaction throttle results can be stored and retrieved.
+func TestTxThrottleStatusStorage(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+
+	hash := common.Hash{1}
+	if status, err := ReadTxThrottleStatus(db, hash); err != nil || status != nil {
+		t.Fatalf("non existent status returned: %v, %v", status, err)
+	}
+	status := &shardingconfig.TxThrottleStatus{Flag: shardingconfig.TxUnselect, Reason: "too many", BlockNum: 7}
+	if err := WriteTxThrottleStatus(db, hash, status); err != nil {
+		t.Fatalf("failed to write status: %v", err)
+	}
+	if stored, err := ReadTxThrottleStatus(db, hash); err != nil || *stored != *status {
+		t.Fatalf("stored status mismatch: have %v, %v, want %v", stored, err, status)
+	}
+	if err := DeleteTxThrottleStatus(db, hash); err != nil {
+		t.Fatalf("failed to delete status: %v", err)
+	}
+	if stored, err := ReadTxThrottleStatus(db, hash); err != nil || stored != nil {
+		t.Fatalf("deleted status returned: %v, %v", stored, err)
+	}
+}
+
+// Tests that transaction replacements can be stored and retrieved.
+func TestTxReplacementStorage(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+
+	if replacement := ReadTxReplacement(db, common.Hash{1}); replacement != (common.Hash{}) {
+		t.Fatalf("non existent replacement returned: %x", replacement)
+	}
+	if err := WriteTxReplacement(db, common.Hash{1}, common.Hash{2}); err != nil {
+		t.Fatalf("failed to write replacement: %v", err)
+	}
+	if replacement := ReadTxReplacement(db, common.Hash{1}); replacement != (common.Hash{2}) {
+		t.Fatalf("stored replacement mismatch: %x", replacement)
+	}
+}
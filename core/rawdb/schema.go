@@ -23,6 +23,8 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/harmony-one/harmony/shard"
 )
 
 // The fields below define the low level database schema prefixing.
@@ -39,6 +41,9 @@ var (
 	// headFastBlockKey tracks the latest known incomplete block's hash duirng fast sync.
 	headFastBlockKey = []byte("LastFast")
 
+	// finalizedBlockKey tracks the latest finalized block's hash.
+	finalizedBlockKey = []byte("LastFinalized")
+
 	// fastTrieProgressKey tracks the number of trie entries imported during fast sync.
 	fastTrieProgressKey = []byte("TrieSync")
 
@@ -68,6 +73,7 @@ var (
 	tempCxReceiptPrefix              = []byte("tempCxReceipt")              // prefix for temporary cross shard transaction receipt
 	cxReceiptHashPrefix              = []byte("cxReceiptHash")              // prefix for cross shard transaction receipt hash
 	cxReceiptSpentPrefix             = []byte("cxReceiptSpent")             // prefix for indicator of unspent of cxReceiptsProof
+	cxReceiptProofSentPrefix         = []byte("cxReceiptProofSent")         // prefix for time the cxReceiptsProof was sent to the destination shard
 	cxReceiptUnspentCheckpointPrefix = []byte("cxReceiptUnspentCheckpoint") // prefix for cxReceiptsProof unspent checkpoint
 
 	// epochBlockNumberPrefix + epoch (big.Int.Bytes())
@@ -80,6 +86,63 @@ var (
 	// epochVdfBlockNumberPrefix  + epoch (big.Int.Bytes())
 	epochVdfBlockNumberPrefix = []byte("epoch-vdf-block-number-")
 
+	// validatorUptimePrefix + epoch (big.Int.Bytes()) + BLS public key (48 bytes)
+	// -> signed and missed block counts of the key in the epoch
+	validatorUptimePrefix = []byte("validator-uptime-")
+
+	// uptimeCountedBlockPrefix + num (uint64 big endian) -> hash of the block
+	// of the number the validator uptime counts include
+	uptimeCountedBlockPrefix = []byte("uptime-counted-block-")
+
+	// txThrottleStatusPrefix + hash -> throttling result of the transaction
+	// when it was last not selected for a new block
+	txThrottleStatusPrefix = []byte("tx-throttle-status-")
+
+	// txReplacementPrefix + hash -> hash of the transaction which replaced
+	// the transaction in the pool
+	txReplacementPrefix = []byte("tx-replacement-")
+
+	// blockTxsCountsPrefix + num (uint64 big endian) -> number of transactions
+	// of each sender account in the canonical block
+	blockTxsCountsPrefix = []byte("block-txs-counts-")
+
+	// blockRewardsPrefix + num (uint64 big endian) + hash -> payouts of the
+	// block reward credited by the block
+	blockRewardsPrefix = []byte("block-rewards-")
+
+	// blockFeesPrefix + num (uint64 big endian) + hash -> transaction fees
+	// collected and burned in the block
+	blockFeesPrefix = []byte("block-fees-")
+
+	// shardSupplyPrefix + num (uint64 big endian) + hash -> supply of the
+	// shard as of the block
+	shardSupplyPrefix = []byte("shard-supply-")
+
+	// beaconRandomnessPrefix + epoch (big.Int.Bytes())
+	// -> output of the distributed randomness beacon for the epoch
+	beaconRandomnessPrefix = []byte("beacon-randomness-")
+
+	// epochStakesPrefix + epoch (big.Int.Bytes())
+	// -> stakes of the validator registry as of the start of the epoch
+	epochStakesPrefix = []byte("epoch-stakes-")
+
+	// pbftLogMessagePrefix + num (uint64 big endian) + index (uint32 big endian)
+	// -> pbft message received for the block number
+	pbftLogMessagePrefix = []byte("pbft-log-message-")
+	// pbftLogMessageCountPrefix + num (uint64 big endian) -> number of pbft messages stored for the block number
+	pbftLogMessageCountPrefix = []byte("pbft-log-message-count-")
+	// pbftLogBlockPrefix + num (uint64 big endian) + index (uint32 big endian)
+	// -> block proposed for the block number
+	pbftLogBlockPrefix = []byte("pbft-log-block-")
+	// pbftLogBlockCountPrefix + num (uint64 big endian) -> number of blocks stored for the block number
+	pbftLogBlockCountPrefix = []byte("pbft-log-block-count-")
+	// pbftLogBlockHashPrefix + num (uint64 big endian) + hash -> whether the block is stored
+	pbftLogBlockHashPrefix = []byte("pbft-log-block-hash-")
+	// pbftLogMessagesRangeKey tracks the lowest and highest block numbers of the stored pbft messages
+	pbftLogMessagesRangeKey = []byte("PbftLogMessagesRange")
+	// pbftLogBlocksRangeKey tracks the lowest and highest block numbers of the stored pbft blocks
+	pbftLogBlocksRangeKey = []byte("PbftLogBlocksRange")
+
 	// Chain index prefixes (use `i` + single byte to avoid mixing data types).
 	BloomBitsIndexPrefix = []byte("iB") // BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
 
@@ -137,6 +200,16 @@ func txLookupKey(hash common.Hash) []byte {
 	return append(txLookupPrefix, hash.Bytes()...)
 }
 
+// txThrottleStatusKey = txThrottleStatusPrefix + hash
+func txThrottleStatusKey(hash common.Hash) []byte {
+	return append(append([]byte{}, txThrottleStatusPrefix...), hash.Bytes()...)
+}
+
+// txReplacementKey = txReplacementPrefix + hash
+func txReplacementKey(hash common.Hash) []byte {
+	return append(append([]byte{}, txReplacementPrefix...), hash.Bytes()...)
+}
+
 // bloomBitsKey = bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash
 func bloomBitsKey(bit uint, section uint64, hash common.Hash) []byte {
 	key := append(append(bloomBitsPrefix, make([]byte, 10)...), hash.Bytes()...)
@@ -173,6 +246,78 @@ func epochVdfBlockNumberKey(epoch *big.Int) []byte {
 	return append(epochVdfBlockNumberPrefix, epoch.Bytes()...)
 }
 
+// validatorUptimeKey = validatorUptimePrefix + epoch (big.Int.Bytes()) + BLS public key
+func validatorUptimeKey(epoch *big.Int, key shard.BlsPublicKey) []byte {
+	return append(append(append([]byte{}, validatorUptimePrefix...), epoch.Bytes()...), key[:]...)
+}
+
+// uptimeCountedBlockKey = uptimeCountedBlockPrefix + num (uint64 big endian)
+func uptimeCountedBlockKey(number uint64) []byte {
+	return append(append([]byte{}, uptimeCountedBlockPrefix...), encodeBlockNumber(number)...)
+}
+
+// blockTxsCountsKey = blockTxsCountsPrefix + num (uint64 big endian)
+func blockTxsCountsKey(number uint64) []byte {
+	return append(append([]byte{}, blockTxsCountsPrefix...), encodeBlockNumber(number)...)
+}
+
+// blockRewardsKey = blockRewardsPrefix + num (uint64 big endian) + hash
+func blockRewardsKey(number uint64, hash common.Hash) []byte {
+	return append(append(append([]byte{}, blockRewardsPrefix...), encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// blockFeesKey = blockFeesPrefix + num (uint64 big endian) + hash
+func blockFeesKey(number uint64, hash common.Hash) []byte {
+	return append(append(append([]byte{}, blockFeesPrefix...), encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// shardSupplyKey = shardSupplyPrefix + num (uint64 big endian) + hash
+func shardSupplyKey(number uint64, hash common.Hash) []byte {
+	return append(append(append([]byte{}, shardSupplyPrefix...), encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// beaconRandomnessKey = beaconRandomnessPrefix + epoch (big.Int.Bytes())
+func beaconRandomnessKey(epoch *big.Int) []byte {
+	return append(append([]byte{}, beaconRandomnessPrefix...), epoch.Bytes()...)
+}
+
+// epochStakesKey = epochStakesPrefix + epoch (big.Int.Bytes())
+func epochStakesKey(epoch *big.Int) []byte {
+	return append(append([]byte{}, epochStakesPrefix...), epoch.Bytes()...)
+}
+
+// pbftLogMessageKey = pbftLogMessagePrefix + num (uint64 big endian) + index (uint32 big endian)
+func pbftLogMessageKey(number uint64, index uint32) []byte {
+	return append(append(append([]byte{}, pbftLogMessagePrefix...), encodeBlockNumber(number)...), encodeIndex(index)...)
+}
+
+// pbftLogMessageCountKey = pbftLogMessageCountPrefix + num (uint64 big endian)
+func pbftLogMessageCountKey(number uint64) []byte {
+	return append(append([]byte{}, pbftLogMessageCountPrefix...), encodeBlockNumber(number)...)
+}
+
+// pbftLogBlockKey = pbftLogBlockPrefix + num (uint64 big endian) + index (uint32 big endian)
+func pbftLogBlockKey(number uint64, index uint32) []byte {
+	return append(append(append([]byte{}, pbftLogBlockPrefix...), encodeBlockNumber(number)...), encodeIndex(index)...)
+}
+
+// pbftLogBlockCountKey = pbftLogBlockCountPrefix + num (uint64 big endian)
+func pbftLogBlockCountKey(number uint64) []byte {
+	return append(append([]byte{}, pbftLogBlockCountPrefix...), encodeBlockNumber(number)...)
+}
+
+// pbftLogBlockHashKey = pbftLogBlockHashPrefix + num (uint64 big endian) + hash
+func pbftLogBlockHashKey(number uint64, hash common.Hash) []byte {
+	return append(append(append([]byte{}, pbftLogBlockHashPrefix...), encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// encodeIndex encodes an index as big endian uint32
+func encodeIndex(index uint32) []byte {
+	enc := make([]byte, 4)
+	binary.BigEndian.PutUint32(enc, index)
+	return enc
+}
+
 func shardLastCrosslinkKey(shardID uint32) []byte {
 	sbKey := make([]byte, 4)
 	binary.BigEndian.PutUint32(sbKey, shardID)
@@ -214,6 +359,16 @@ func cxReceiptSpentKey(shardID uint32, number uint64) []byte {
 	return append(tmp, encodeBlockNumber(number)...)
 }
 
+// cxReceiptProofSentKey = cxReceiptProofSentPrefix + shardID + num (uint64 big endian) + hash
+func cxReceiptProofSentKey(shardID uint32, number uint64, hash common.Hash) []byte {
+	prefix := cxReceiptProofSentPrefix
+	sKey := make([]byte, 4)
+	binary.BigEndian.PutUint32(sKey, shardID)
+	tmp := append(prefix, sKey...)
+	tmp1 := append(tmp, encodeBlockNumber(number)...)
+	return append(tmp1, hash.Bytes()...)
+}
+
 // cxReceiptUnspentCheckpointKey = cxReceiptsUnspentCheckpointPrefix + shardID
 func cxReceiptUnspentCheckpointKey(shardID uint32) []byte {
 	prefix := cxReceiptUnspentCheckpointPrefix
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"bytes"
 	"encoding/hex"
 	"errors"
 	"math/big"
@@ -157,11 +158,9 @@ func GetShardingStateFromBlockChain(bc *BlockChain, epoch *big.Int) (*ShardingSt
 	return &ShardingState{epoch: epoch.Uint64(), rnd: rndSeed, shardState: shardState, numShards: len(shardState)}, nil
 }
 
-// CalculateNewShardState get sharding state from previous epoch and calculate sharding state for new epoch
-func CalculateNewShardState(
-	bc *BlockChain, epoch *big.Int,
-	stakeInfo *map[common.Address]*structs.StakeInfo,
-) (shard.State, error) {
+// CalculateNewShardState get sharding state from previous epoch and calculate sharding state for new epoch.
+// The validators staked in the registry of the state of the chain head join it.
+func CalculateNewShardState(bc *BlockChain, epoch *big.Int) (shard.State, error) {
 	if epoch.Cmp(big.NewInt(GenesisEpoch)) == 0 {
 		return GetInitShardState(), nil
 	}
@@ -171,20 +170,30 @@ func CalculateNewShardState(
 		return nil, ctxerror.New("cannot retrieve previous sharding state").
 			WithCause(err)
 	}
+	stateDB, err := bc.State()
+	if err != nil {
+		return nil, ctxerror.New("cannot get state of the chain head").
+			WithCause(err)
+	}
+	stakeInfo, err := ReadStakeInfo(stateDB)
+	if err != nil {
+		return nil, ctxerror.New("cannot read validator registry").
+			WithCause(err)
+	}
 	newNodeList := ss.UpdateShardingState(stakeInfo)
 	utils.Logger().Info().Float64("percentage", CuckooRate).Msg("Cuckoo Rate")
 	ss.Reshard(newNodeList, CuckooRate)
 	return ss.shardState, nil
 }
 
-// UpdateShardingState remove the unstaked nodes and returns the newly staked node Ids.
-func (ss *ShardingState) UpdateShardingState(stakeInfo *map[common.Address]*structs.StakeInfo) []shard.NodeID {
+// UpdateShardingState remove the unstaked nodes and returns the newly staked node Ids, by address.
+func (ss *ShardingState) UpdateShardingState(stakeInfo map[common.Address]*structs.StakeInfo) []shard.NodeID {
 	oldBlsPublicKeys := make(map[shard.BlsPublicKey]bool) // map of bls public keys
 	for _, shard := range ss.shardState {
 		newNodeList := shard.NodeList
 		for _, nodeID := range shard.NodeList {
 			oldBlsPublicKeys[nodeID.BlsPublicKey] = true
-			_, ok := (*stakeInfo)[nodeID.EcdsaAddress]
+			_, ok := stakeInfo[nodeID.EcdsaAddress]
 			if ok {
 				// newNodeList = append(newNodeList, nodeID)
 			} else {
@@ -195,7 +204,7 @@ func (ss *ShardingState) UpdateShardingState(stakeInfo *map[common.Address]*stru
 	}
 
 	newAddresses := []shard.NodeID{}
-	for addr, info := range *stakeInfo {
+	for addr, info := range stakeInfo {
 		_, ok := oldBlsPublicKeys[info.BlsPublicKey]
 		if !ok {
 			newAddresses = append(newAddresses, shard.NodeID{
@@ -204,6 +213,10 @@ func (ss *ShardingState) UpdateShardingState(stakeInfo *map[common.Address]*stru
 			})
 		}
 	}
+	// map iteration order is random, so sort for every node to agree
+	sort.Slice(newAddresses, func(i, j int) bool {
+		return bytes.Compare(newAddresses[i].EcdsaAddress[:], newAddresses[j].EcdsaAddress[:]) < 0
+	})
 	return newAddresses
 }
 
@@ -0,0 +1,551 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/harmony-one/harmony/common/denominations"
+	"github.com/harmony-one/harmony/contracts/structs"
+	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/core/vm"
+	"github.com/harmony-one/harmony/internal/ctxerror"
+	"github.com/harmony-one/harmony/internal/params"
+	"github.com/harmony-one/harmony/shard"
+)
+
+const (
+	// StakingGas is the gas a staking transaction uses on top of the
+	// intrinsic gas, besides the gas of the registry slots it writes, which
+	// ApplyStakingMessage returns.
+	StakingGas = params.TxGas
+
+	// UnbondingEpochs is the number of epochs after the one of an
+	// undelegation at the end of which the undelegated amount is released.
+	UnbondingEpochs = 3
+)
+
+// Errors of staking transactions.  They fail the transaction, which still
+// pays for its gas.
+var (
+	// ErrValidatorExists is returned if the sender of a create-validator
+	// message is already a validator.
+	ErrValidatorExists = errors.New("validator already exists")
+
+	// ErrValidatorNotFound is returned if a staking message refers to an
+	// account which is not a validator.
+	ErrValidatorNotFound = errors.New("validator not found")
+
+	// ErrBlsKeyInUse is returned if another validator has the BLS key of a
+	// create-validator or edit-validator message.
+	ErrBlsKeyInUse = errors.New("BLS public key already in use")
+
+	// ErrNoStakeValue is returned if a create-validator or delegate message
+	// stakes nothing.
+	ErrNoStakeValue = errors.New("staking message stakes nothing")
+
+	// ErrUnexpectedStakeValue is returned if a staking message which stakes
+	// nothing has a value.
+	ErrUnexpectedStakeValue = errors.New("staking message takes no value")
+
+	// ErrDelegationNotFound is returned if the sender of an undelegate or
+	// collect-rewards message has not staked on the validator.
+	ErrDelegationNotFound = errors.New("delegation not found")
+
+	// ErrInsufficientStake is returned if an undelegate message withdraws more
+	// than the sender staked on the validator.
+	ErrInsufficientStake = errors.New("insufficient stake")
+)
+
+// MinValidatorStake is the least stake of the validators ReadStakeInfo
+// lists, i.e. of those taking part in resharding, stake-weighted quorums and
+// stake-weighted rewards.
+var MinValidatorStake = new(big.Int).Mul(big.NewInt(10000), big.NewInt(denominations.One))
+
+var (
+	// validatorListKey is the registry slot of the number of validators,
+	// whose addresses follow in the order they were created.
+	validatorListKey = common.BytesToHash([]byte("validators"))
+	// validatorKeyPrefix prefixes the address of a validator to make the key
+	// of its registry slot.
+	validatorKeyPrefix = []byte("validator")
+	// blsKeyPrefix prefixes a BLS public key to make the key of the registry
+	// slot holding the address of the validator with that key.
+	blsKeyPrefix = []byte("blskey")
+	// unbondingKeyPrefix prefixes an epoch to make the key of the registry
+	// slot of the list of the undelegations released at its end.
+	unbondingKeyPrefix = []byte("unbonding")
+)
+
+// The registry stores each value as an RLP blob in the storage of the staking
+// account: the slot of the value holds the length of the blob, and the
+// following slots, at the hashes of the slot and of the index, hold the
+// blob in chunks of 32 bytes.  Lists store the number of items in their slot
+// and the items in the following slots likewise, one item per slot, so that
+// adding one writes two slots.  The undelegations are listed by release
+// epoch, each a blob at the slot of its item, so that undelegating writes a
+// few slots however many undelegations are pending.
+
+func validatorKey(addr common.Address) common.Hash {
+	return crypto.Keccak256Hash(validatorKeyPrefix, addr.Bytes())
+}
+
+func blsKeyKey(key shard.BlsPublicKey) common.Hash {
+	return crypto.Keccak256Hash(blsKeyPrefix, key[:])
+}
+
+func unbondingKey(epoch *big.Int) common.Hash {
+	return crypto.Keccak256Hash(unbondingKeyPrefix, epoch.Bytes())
+}
+
+func blobChunkKey(key common.Hash, index uint64) common.Hash {
+	return crypto.Keccak256Hash(key.Bytes(), new(big.Int).SetUint64(index).Bytes())
+}
+
+func readStakingBlob(db vm.StateDB, key common.Hash) []byte {
+	size := db.GetState(types.StakingAddress, key).Big().Uint64()
+	data := make([]byte, 0, size+common.HashLength)
+	for index := uint64(0); uint64(len(data)) < size; index++ {
+		chunk := db.GetState(types.StakingAddress, blobChunkKey(key, index))
+		data = append(data, chunk.Bytes()...)
+	}
+	return data[:size]
+}
+
+func writeStakingBlob(db vm.StateDB, key common.Hash, data []byte) {
+	oldSize := db.GetState(types.StakingAddress, key).Big().Uint64()
+	db.SetState(types.StakingAddress, key, common.BigToHash(big.NewInt(int64(len(data)))))
+	index := uint64(0)
+	for ; index*common.HashLength < uint64(len(data)); index++ {
+		var chunk common.Hash
+		copy(chunk[:], data[index*common.HashLength:])
+		db.SetState(types.StakingAddress, blobChunkKey(key, index), chunk)
+	}
+	for ; index*common.HashLength < oldSize; index++ {
+		db.SetState(types.StakingAddress, blobChunkKey(key, index), common.Hash{})
+	}
+	// keep the staking account from being deleted as empty once it holds
+	// no stake
+	if db.GetNonce(types.StakingAddress) == 0 {
+		db.SetNonce(types.StakingAddress, 1)
+	}
+}
+
+func readValidatorList(db vm.StateDB) []common.Address {
+	count := db.GetState(types.StakingAddress, validatorListKey).Big().Uint64()
+	addrs := make([]common.Address, 0, count)
+	for index := uint64(0); index < count; index++ {
+		item := db.GetState(types.StakingAddress, blobChunkKey(validatorListKey, index))
+		addrs = append(addrs, common.BytesToAddress(item.Bytes()))
+	}
+	return addrs
+}
+
+func appendValidatorList(db vm.StateDB, addr common.Address) {
+	count := db.GetState(types.StakingAddress, validatorListKey).Big().Uint64()
+	db.SetState(types.StakingAddress, blobChunkKey(validatorListKey, count), addr.Hash())
+	db.SetState(types.StakingAddress, validatorListKey, common.BigToHash(new(big.Int).SetUint64(count+1)))
+}
+
+// ReadValidator returns the validator of the given address in the registry
+// of the given state, or nil if there is none.
+func ReadValidator(db vm.StateDB, addr common.Address) (*types.Validator, error) {
+	data := readStakingBlob(db, validatorKey(addr))
+	if len(data) == 0 {
+		return nil, nil
+	}
+	validator := &types.Validator{}
+	if err := rlp.DecodeBytes(data, validator); err != nil {
+		return nil, ctxerror.New("cannot decode validator",
+			"address", addr,
+		).WithCause(err)
+	}
+	return validator, nil
+}
+
+// ReadValidators returns the validators of the registry of the given state,
+// in the order they were created.
+func ReadValidators(db vm.StateDB) ([]*types.Validator, error) {
+	addrs := readValidatorList(db)
+	validators := make([]*types.Validator, 0, len(addrs))
+	for _, addr := range addrs {
+		validator, err := ReadValidator(db, addr)
+		if err != nil {
+			return nil, err
+		}
+		if validator == nil {
+			return nil, ctxerror.New("cannot find listed validator", "address", addr)
+		}
+		validators = append(validators, validator)
+	}
+	return validators, nil
+}
+
+// WriteValidator stores the given validator in the registry of the given
+// state, listing it if it is new.  The validator must own its BLS key, see
+// setValidatorBlsKey.
+func WriteValidator(db vm.StateDB, validator *types.Validator) error {
+	data, err := rlp.EncodeToBytes(validator)
+	if err != nil {
+		return ctxerror.New("cannot encode validator",
+			"address", validator.Address,
+		).WithCause(err)
+	}
+	key := validatorKey(validator.Address)
+	if db.GetState(types.StakingAddress, key) == (common.Hash{}) {
+		appendValidatorList(db, validator.Address)
+	}
+	writeStakingBlob(db, key, data)
+	return nil
+}
+
+// ReadStakeInfo returns the stakes of the validators of the registry of the
+// given state, by validator address, leaving out those with no stake or with
+// less than MinValidatorStake.
+func ReadStakeInfo(db vm.StateDB) (map[common.Address]*structs.StakeInfo, error) {
+	validators, err := ReadValidators(db)
+	if err != nil {
+		return nil, err
+	}
+	stakeInfo := make(map[common.Address]*structs.StakeInfo)
+	for _, validator := range validators {
+		if validator.Stake.Sign() > 0 && validator.Stake.Cmp(MinValidatorStake) >= 0 {
+			stakeInfo[validator.Address] = &structs.StakeInfo{
+				Account:      validator.Address,
+				BlsPublicKey: validator.BlsPublicKey,
+				Amount:       new(big.Int).Set(validator.Stake),
+			}
+		}
+	}
+	return stakeInfo, nil
+}
+
+// NewEpochStakes returns the snapshot of the stakes of the registry of the
+// given state, as ReadStakeInfo reads them, taken for the given epoch.
+func NewEpochStakes(db vm.StateDB, epoch *big.Int) (*types.EpochStakes, error) {
+	stakeInfo, err := ReadStakeInfo(db)
+	if err != nil {
+		return nil, err
+	}
+	stakes := &types.EpochStakes{
+		Epoch:  new(big.Int).Set(epoch),
+		Stakes: make([]*types.ValidatorStake, 0, len(stakeInfo)),
+	}
+	for _, info := range stakeInfo {
+		stakes.Stakes = append(stakes.Stakes, &types.ValidatorStake{
+			Address:      info.Account,
+			BlsPublicKey: info.BlsPublicKey,
+			Amount:       info.Amount,
+		})
+	}
+	sort.Slice(stakes.Stakes, func(i, j int) bool {
+		return bytes.Compare(stakes.Stakes[i].Address[:], stakes.Stakes[j].Address[:]) < 0
+	})
+	return stakes, nil
+}
+
+// ReadUnbondings returns the undelegated amounts of the registry of the given
+// state released at the end of the given epoch, in the order of the
+// undelegations.
+func ReadUnbondings(db vm.StateDB, epoch *big.Int) ([]*types.Unbonding, error) {
+	key := unbondingKey(epoch)
+	count := db.GetState(types.StakingAddress, key).Big().Uint64()
+	unbondings := make([]*types.Unbonding, 0, count)
+	for index := uint64(0); index < count; index++ {
+		unbonding := &types.Unbonding{}
+		if err := rlp.DecodeBytes(readStakingBlob(db, blobChunkKey(key, index)), unbonding); err != nil {
+			return nil, ctxerror.New("cannot decode unbonding",
+				"epoch", epoch,
+				"index", index,
+			).WithCause(err)
+		}
+		unbondings = append(unbondings, unbonding)
+	}
+	return unbondings, nil
+}
+
+func appendUnbonding(db vm.StateDB, unbonding *types.Unbonding) error {
+	data, err := rlp.EncodeToBytes(unbonding)
+	if err != nil {
+		return ctxerror.New("cannot encode unbonding").WithCause(err)
+	}
+	key := unbondingKey(unbonding.Epoch)
+	count := db.GetState(types.StakingAddress, key).Big().Uint64()
+	writeStakingBlob(db, blobChunkKey(key, count), data)
+	db.SetState(types.StakingAddress, key, common.BigToHash(new(big.Int).SetUint64(count+1)))
+	return nil
+}
+
+// ReleaseUnbonded pays the undelegated amounts of the registry of the given
+// state due at the end of the given epoch back to their delegators.  The
+// last block of every epoch releases them.
+func ReleaseUnbonded(db vm.StateDB, epoch *big.Int) error {
+	unbondings, err := ReadUnbondings(db, epoch)
+	if err != nil || len(unbondings) == 0 {
+		return err
+	}
+	key := unbondingKey(epoch)
+	for index, unbonding := range unbondings {
+		db.SubBalance(types.StakingAddress, unbonding.Amount)
+		db.AddBalance(unbonding.Delegator, unbonding.Amount)
+		writeStakingBlob(db, blobChunkKey(key, uint64(index)), nil)
+	}
+	db.SetState(types.StakingAddress, key, common.Hash{})
+	return nil
+}
+
+// AddValidatorRewards credits the given block reward to the delegations of
+// the given validator of the registry of the given state, in proportion to
+// their shares, and returns whether it is a validator.  The staking account
+// holds the rewards until the delegators collect them.
+func AddValidatorRewards(db vm.StateDB, addr common.Address, amount *big.Int) (bool, error) {
+	validator, err := ReadValidator(db, addr)
+	if err != nil || validator == nil {
+		return false, err
+	}
+	if validator.TotalShares.Sign() == 0 {
+		// nobody to split the reward, e.g. all undelegated
+		return false, nil
+	}
+	// credit the difference of the cumulative amounts, so that the rewards
+	// add up to the amount exactly
+	shares, last := big.NewInt(0), big.NewInt(0)
+	for _, delegation := range validator.Delegations {
+		shares.Add(shares, delegation.Shares)
+		cur := new(big.Int).Mul(amount, shares)
+		cur.Div(cur, validator.TotalShares)
+		delegation.Rewards.Add(delegation.Rewards, new(big.Int).Sub(cur, last))
+		last = cur
+	}
+	db.AddBalance(types.StakingAddress, amount)
+	return true, WriteValidator(db, validator)
+}
+
+// meteredStateDB meters the storage a staking message writes as SSTORE
+// does: setting a slot costs params.SstoreSetGas, changing one
+// params.SstoreResetGas and rewriting its value nothing.
+type meteredStateDB struct {
+	vm.StateDB
+	gas uint64
+}
+
+func (db *meteredStateDB) SetState(addr common.Address, key common.Hash, value common.Hash) {
+	switch current := db.StateDB.GetState(addr, key); {
+	case current == value:
+	case current == (common.Hash{}):
+		db.gas += params.SstoreSetGas
+	default:
+		db.gas += params.SstoreResetGas
+	}
+	db.StateDB.SetState(addr, key, value)
+}
+
+// ApplyStakingMessage applies the staking message of the given transaction
+// data, sent with the given value by the given account in the given epoch,
+// to the registry of the given state, and returns the gas of the registry
+// slots it wrote, which grows with the size of the validator.  Staked tokens
+// move to the staking account and back.  It returns
+// vm.ErrInsufficientBalance if the sender cannot pay the value.
+func ApplyStakingMessage(db vm.StateDB, epoch *big.Int, from common.Address, value *big.Int, data []byte) (uint64, error) {
+	msgType, payload, err := types.DecodeStakingMessage(data)
+	if err != nil {
+		return 0, err
+	}
+	switch msgType {
+	case types.CreateValidatorType, types.DelegateType:
+		if value.Sign() <= 0 {
+			return 0, ErrNoStakeValue
+		}
+		if db.GetBalance(from).Cmp(value) < 0 {
+			return 0, vm.ErrInsufficientBalance
+		}
+	default:
+		if value.Sign() != 0 {
+			return 0, ErrUnexpectedStakeValue
+		}
+	}
+	metered := &meteredStateDB{StateDB: db}
+	switch payload := payload.(type) {
+	case *types.CreateValidator:
+		err = createValidator(metered, from, value, payload)
+	case *types.EditValidator:
+		err = editValidator(metered, from, payload)
+	case *types.Delegate:
+		err = delegate(metered, from, value, payload.Validator)
+	case *types.Undelegate:
+		err = undelegate(metered, epoch, from, payload)
+	case *types.CollectRewards:
+		err = collectRewards(metered, from, payload.Validator)
+	default:
+		err = types.ErrUnknownStakingType
+	}
+	return metered.gas, err
+}
+
+// setValidatorBlsKey makes the given key that of the given validator,
+// releasing its previous key, or returns ErrBlsKeyInUse if another validator
+// has the key.
+func setValidatorBlsKey(db vm.StateDB, validator *types.Validator, key shard.BlsPublicKey) error {
+	owner := db.GetState(types.StakingAddress, blsKeyKey(key))
+	if owner != (common.Hash{}) && owner != validator.Address.Hash() {
+		return ErrBlsKeyInUse
+	}
+	if validator.BlsPublicKey != key {
+		db.SetState(types.StakingAddress, blsKeyKey(validator.BlsPublicKey), common.Hash{})
+	}
+	db.SetState(types.StakingAddress, blsKeyKey(key), validator.Address.Hash())
+	validator.BlsPublicKey = key
+	return nil
+}
+
+func createValidator(db vm.StateDB, from common.Address, value *big.Int, msg *types.CreateValidator) error {
+	if validator, err := ReadValidator(db, from); err != nil {
+		return err
+	} else if validator != nil {
+		return ErrValidatorExists
+	}
+	validator := &types.Validator{
+		Address:      from,
+		BlsPublicKey: msg.BlsPublicKey,
+		Name:         msg.Name,
+		Stake:        big.NewInt(0),
+		TotalShares:  big.NewInt(0),
+	}
+	if err := setValidatorBlsKey(db, validator, msg.BlsPublicKey); err != nil {
+		return err
+	}
+	if err := WriteValidator(db, validator); err != nil {
+		return err
+	}
+	return delegate(db, from, value, from)
+}
+
+func editValidator(db vm.StateDB, from common.Address, msg *types.EditValidator) error {
+	validator, err := ReadValidator(db, from)
+	if err != nil {
+		return err
+	}
+	if validator == nil {
+		return ErrValidatorNotFound
+	}
+	if msg.BlsPublicKey != (shard.BlsPublicKey{}) {
+		if err := setValidatorBlsKey(db, validator, msg.BlsPublicKey); err != nil {
+			return err
+		}
+	}
+	validator.Name = msg.Name
+	return WriteValidator(db, validator)
+}
+
+func delegate(db vm.StateDB, from common.Address, value *big.Int, addr common.Address) error {
+	validator, err := ReadValidator(db, addr)
+	if err != nil {
+		return err
+	}
+	if validator == nil {
+		return ErrValidatorNotFound
+	}
+	// shares are worth the same before and after the delegation
+	shares := new(big.Int).Set(value)
+	if validator.Stake.Sign() > 0 {
+		shares.Mul(shares, validator.TotalShares).Div(shares, validator.Stake)
+	}
+	if shares.Sign() == 0 {
+		return ErrNoStakeValue
+	}
+	delegation := validator.FindDelegation(from)
+	if delegation == nil {
+		delegation = &types.Delegation{
+			Delegator: from,
+			Shares:    big.NewInt(0),
+			Rewards:   big.NewInt(0),
+		}
+		validator.Delegations = append(validator.Delegations, delegation)
+	}
+	delegation.Shares.Add(delegation.Shares, shares)
+	validator.TotalShares.Add(validator.TotalShares, shares)
+	validator.Stake.Add(validator.Stake, value)
+	db.SubBalance(from, value)
+	db.AddBalance(types.StakingAddress, value)
+	return WriteValidator(db, validator)
+}
+
+func undelegate(db vm.StateDB, epoch *big.Int, from common.Address, msg *types.Undelegate) error {
+	if msg.Amount == nil || msg.Amount.Sign() <= 0 {
+		return types.ErrInvalidStakingData
+	}
+	validator, err := ReadValidator(db, msg.Validator)
+	if err != nil {
+		return err
+	}
+	if validator == nil {
+		return ErrValidatorNotFound
+	}
+	delegation := validator.FindDelegation(from)
+	if delegation == nil {
+		return ErrDelegationNotFound
+	}
+	if msg.Amount.Cmp(validator.Stake) > 0 {
+		return ErrInsufficientStake
+	}
+	// round the shares up, so that the remaining shares are worth no more
+	// than before
+	shares := new(big.Int).Mul(msg.Amount, validator.TotalShares)
+	shares.Add(shares, validator.Stake).Sub(shares, common.Big1).Div(shares, validator.Stake)
+	if delegation.Shares.Cmp(shares) < 0 {
+		return ErrInsufficientStake
+	}
+	delegation.Shares.Sub(delegation.Shares, shares)
+	validator.TotalShares.Sub(validator.TotalShares, shares)
+	validator.Stake.Sub(validator.Stake, msg.Amount)
+	removeEmptyDelegation(validator, delegation)
+	if err := WriteValidator(db, validator); err != nil {
+		return err
+	}
+	return appendUnbonding(db, &types.Unbonding{
+		Delegator: from,
+		Validator: msg.Validator,
+		Amount:    new(big.Int).Set(msg.Amount),
+		Epoch:     new(big.Int).Add(epoch, big.NewInt(UnbondingEpochs)),
+	})
+}
+
+func collectRewards(db vm.StateDB, from common.Address, addr common.Address) error {
+	validator, err := ReadValidator(db, addr)
+	if err != nil {
+		return err
+	}
+	if validator == nil {
+		return ErrValidatorNotFound
+	}
+	delegation := validator.FindDelegation(from)
+	if delegation == nil {
+		return ErrDelegationNotFound
+	}
+	rewards := delegation.Rewards
+	delegation.Rewards = big.NewInt(0)
+	removeEmptyDelegation(validator, delegation)
+	db.SubBalance(types.StakingAddress, rewards)
+	db.AddBalance(from, rewards)
+	return WriteValidator(db, validator)
+}
+
+// removeEmptyDelegation removes the given delegation from the given
+// validator if it has neither shares nor rewards left.
+func removeEmptyDelegation(validator *types.Validator, delegation *types.Delegation) {
+	if delegation.Shares.Sign() != 0 || delegation.Rewards.Sign() != 0 {
+		return
+	}
+	delegations := validator.Delegations[:0]
+	for _, d := range validator.Delegations {
+		if d != delegation {
+			delegations = append(delegations, d)
+		}
+	}
+	validator.Delegations = delegations
+}
@@ -0,0 +1,257 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/harmony-one/harmony/core/state"
+	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/core/vm"
+	"github.com/harmony-one/harmony/internal/params"
+)
+
+var stakingEpoch = big.NewInt(5)
+
+func applyStaking(t *testing.T, db *state.DB, from common.Address, value int64, payload interface{}) error {
+	_, err := applyStakingGas(t, db, from, value, payload)
+	return err
+}
+
+func applyStakingGas(t *testing.T, db *state.DB, from common.Address, value int64, payload interface{}) (uint64, error) {
+	data, err := types.EncodeStakingMessage(payload)
+	assert.NoError(t, err)
+	return ApplyStakingMessage(db, stakingEpoch, from, big.NewInt(value), data)
+}
+
+func newStakingState(t *testing.T, validatorAddr, delegatorAddr common.Address) *state.DB {
+	db, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	db.AddBalance(validatorAddr, big.NewInt(1000))
+	db.AddBalance(delegatorAddr, big.NewInt(1000))
+	create := &types.CreateValidator{BlsPublicKey: blsPubKey1, Name: "validator"}
+	assert.NoError(t, applyStaking(t, db, validatorAddr, 100, create))
+	assert.NoError(t, applyStaking(t, db, delegatorAddr, 300, &types.Delegate{Validator: validatorAddr}))
+	return db
+}
+
+func TestApplyStakingMessage(t *testing.T) {
+	validatorAddr := common.HexToAddress("0x0a")
+	delegatorAddr := common.HexToAddress("0x0d")
+	db := newStakingState(t, validatorAddr, delegatorAddr)
+
+	create := &types.CreateValidator{BlsPublicKey: blsPubKey1, Name: "validator"}
+	assert.Equal(t, vm.ErrInsufficientBalance, applyStaking(t, db, validatorAddr, 2000, create))
+	assert.Equal(t, ErrNoStakeValue, applyStaking(t, db, validatorAddr, 0, create))
+	assert.Equal(t, ErrValidatorExists, applyStaking(t, db, validatorAddr, 100, create))
+	assert.Equal(t, ErrBlsKeyInUse, applyStaking(t, db, delegatorAddr, 100, create))
+	assert.Equal(t, ErrValidatorNotFound, applyStaking(t, db, delegatorAddr, 300, &types.Delegate{Validator: delegatorAddr}))
+	assert.Equal(t, big.NewInt(900), db.GetBalance(validatorAddr))
+	assert.Equal(t, big.NewInt(700), db.GetBalance(delegatorAddr))
+	assert.Equal(t, big.NewInt(400), db.GetBalance(types.StakingAddress))
+
+	assert.NoError(t, applyStaking(t, db, validatorAddr, 0, &types.EditValidator{BlsPublicKey: blsPubKey2, Name: "renamed"}))
+	validator, err := ReadValidator(db, validatorAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, "renamed", validator.Name)
+	assert.Equal(t, blsPubKey2, [48]byte(validator.BlsPublicKey))
+	assert.Equal(t, big.NewInt(400), validator.Stake)
+	assert.Equal(t, big.NewInt(400), validator.TotalShares)
+	assert.Len(t, validator.Delegations, 2)
+	assert.Equal(t, big.NewInt(300), validator.FindDelegation(delegatorAddr).Shares)
+
+	// the old key is free again
+	other := common.HexToAddress("0x0b")
+	db.AddBalance(other, big.NewInt(1000))
+	assert.Equal(t, ErrBlsKeyInUse, applyStaking(t, db, other, 100, &types.CreateValidator{BlsPublicKey: blsPubKey2}))
+	assert.NoError(t, applyStaking(t, db, other, 100, &types.CreateValidator{BlsPublicKey: blsPubKey1}))
+	validators, err := ReadValidators(db)
+	assert.NoError(t, err)
+	if assert.Len(t, validators, 2) {
+		assert.Equal(t, validatorAddr, validators[0].Address)
+		assert.Equal(t, other, validators[1].Address)
+	}
+
+	defer func(minStake *big.Int) { MinValidatorStake = minStake }(MinValidatorStake)
+	MinValidatorStake = big.NewInt(400)
+	stakeInfo, err := ReadStakeInfo(db)
+	assert.NoError(t, err)
+	assert.Len(t, stakeInfo, 1)
+	assert.Equal(t, big.NewInt(400), stakeInfo[validatorAddr].Amount)
+	assert.Equal(t, blsPubKey2, [48]byte(stakeInfo[validatorAddr].BlsPublicKey))
+	MinValidatorStake = big.NewInt(401)
+	stakeInfo, err = ReadStakeInfo(db)
+	assert.NoError(t, err)
+	assert.Empty(t, stakeInfo)
+}
+
+func TestStakingGas(t *testing.T) {
+	validatorAddr := common.HexToAddress("0x0a")
+	db := newStakingState(t, validatorAddr, common.HexToAddress("0x0d"))
+
+	// the gas grows with the delegations the validator carries
+	delegate := &types.Delegate{Validator: validatorAddr}
+	var gas []uint64
+	for i := 0; i < 8; i++ {
+		delegator := common.BigToAddress(big.NewInt(int64(0x100 + i)))
+		db.AddBalance(delegator, big.NewInt(10))
+		used, err := applyStakingGas(t, db, delegator, 10, delegate)
+		assert.NoError(t, err)
+		gas = append(gas, used)
+	}
+	assert.True(t, gas[7] > gas[0], "gas %v does not grow", gas)
+
+	// rewriting the same name writes no slot
+	used, err := applyStakingGas(t, db, validatorAddr, 0, &types.EditValidator{Name: "validator"})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), used)
+	used, err = applyStakingGas(t, db, validatorAddr, 0, &types.EditValidator{Name: "renamed"})
+	assert.NoError(t, err)
+	assert.True(t, used > 0 && used < gas[0], "gas %d of a rename", used)
+}
+
+func TestStakingOutsideBeacon(t *testing.T) {
+	from := common.HexToAddress("0x0a")
+	db, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	db.AddBalance(from, big.NewInt(1000000100))
+	data, err := types.EncodeStakingMessage(&types.CreateValidator{BlsPublicKey: blsPubKey1})
+	assert.NoError(t, err)
+	apply := func(shardID uint32, txType types.TransactionType, gasPrice int64) (uint64, bool, error) {
+		msg := types.NewMessage(from, &types.StakingAddress, db.GetNonce(from), big.NewInt(100), 1000000, big.NewInt(gasPrice), data, true)
+		ctx := vm.Context{
+			CanTransfer: CanTransfer,
+			Transfer:    Transfer,
+			BlockNumber: big.NewInt(1),
+			EpochNumber: stakingEpoch,
+			ShardID:     shardID,
+			TxType:      txType,
+			GasPrice:    big.NewInt(gasPrice),
+		}
+		evm := vm.NewEVM(ctx, db, params.TestChainConfig, vm.Config{})
+		_, gas, failed, err := ApplyMessage(evm, msg, new(GasPool).AddGas(1000000))
+		return gas, failed, err
+	}
+
+	// staking on another shard, or towards another shard, fails without
+	// invalidating the block, charging all the gas and moving no funds
+	gas, failed, err := apply(1, types.SameShardTx, 1)
+	assert.NoError(t, err)
+	assert.True(t, failed)
+	assert.Equal(t, uint64(1000000), gas)
+	assert.Equal(t, big.NewInt(100), db.GetBalance(from))
+	assert.Equal(t, uint64(1), db.GetNonce(from))
+	gas, failed, err = apply(types.StakingShardID, types.SubtractionOnly, 0)
+	assert.NoError(t, err)
+	assert.True(t, failed)
+	assert.Equal(t, uint64(1000000), gas)
+	assert.Equal(t, big.NewInt(100), db.GetBalance(from))
+	validator, err := ReadValidator(db, from)
+	assert.NoError(t, err)
+	assert.Nil(t, validator)
+
+	gas, failed, err = apply(types.StakingShardID, types.SameShardTx, 0)
+	assert.NoError(t, err)
+	assert.False(t, failed)
+	intrinsic, err := IntrinsicGas(data, false, true)
+	assert.NoError(t, err)
+	assert.True(t, gas > intrinsic+StakingGas, "gas %d without the registry slots", gas)
+	assert.Equal(t, big.NewInt(0), db.GetBalance(from))
+}
+
+func TestUnbonding(t *testing.T) {
+	validatorAddr := common.HexToAddress("0x0a")
+	delegatorAddr := common.HexToAddress("0x0d")
+	db := newStakingState(t, validatorAddr, delegatorAddr)
+
+	undelegate := &types.Undelegate{Validator: validatorAddr, Amount: big.NewInt(500)}
+	assert.Equal(t, ErrInsufficientStake, applyStaking(t, db, delegatorAddr, 0, undelegate))
+	assert.Equal(t, ErrUnexpectedStakeValue, applyStaking(t, db, delegatorAddr, 1, undelegate))
+	assert.Equal(t, ErrDelegationNotFound, applyStaking(t, db, common.HexToAddress("0x0e"), 0, undelegate))
+	undelegate.Amount = big.NewInt(200)
+	assert.NoError(t, applyStaking(t, db, delegatorAddr, 0, undelegate))
+	undelegate.Amount = big.NewInt(100)
+	assert.NoError(t, applyStaking(t, db, delegatorAddr, 0, undelegate))
+
+	// the undelegated stake no longer counts, but is not paid back yet
+	validator, err := ReadValidator(db, validatorAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(100), validator.Stake)
+	assert.Equal(t, big.NewInt(100), validator.TotalShares)
+	assert.Nil(t, validator.FindDelegation(delegatorAddr))
+	assert.Equal(t, big.NewInt(700), db.GetBalance(delegatorAddr))
+	releaseEpoch := big.NewInt(5 + UnbondingEpochs)
+	unbondings, err := ReadUnbondings(db, releaseEpoch)
+	assert.NoError(t, err)
+	if assert.Len(t, unbondings, 2) {
+		assert.Equal(t, releaseEpoch, unbondings[0].Epoch)
+		assert.Equal(t, big.NewInt(200), unbondings[0].Amount)
+		assert.Equal(t, big.NewInt(100), unbondings[1].Amount)
+	}
+	unbondings, err = ReadUnbondings(db, new(big.Int).Sub(releaseEpoch, common.Big1))
+	assert.NoError(t, err)
+	assert.Empty(t, unbondings)
+
+	assert.NoError(t, ReleaseUnbonded(db, new(big.Int).Sub(releaseEpoch, common.Big1)))
+	assert.Equal(t, big.NewInt(700), db.GetBalance(delegatorAddr))
+	assert.NoError(t, ReleaseUnbonded(db, releaseEpoch))
+	assert.Equal(t, big.NewInt(1000), db.GetBalance(delegatorAddr))
+	assert.Equal(t, big.NewInt(100), db.GetBalance(types.StakingAddress))
+	unbondings, err = ReadUnbondings(db, releaseEpoch)
+	assert.NoError(t, err)
+	assert.Empty(t, unbondings)
+	assert.Equal(t, common.Hash{}, db.GetState(types.StakingAddress, blobChunkKey(unbondingKey(releaseEpoch), 0)))
+}
+
+func TestValidatorRewards(t *testing.T) {
+	validatorAddr := common.HexToAddress("0x0a")
+	delegatorAddr := common.HexToAddress("0x0d")
+	db := newStakingState(t, validatorAddr, delegatorAddr)
+
+	registered, err := AddValidatorRewards(db, validatorAddr, big.NewInt(41))
+	assert.NoError(t, err)
+	assert.True(t, registered)
+	registered, err = AddValidatorRewards(db, delegatorAddr, big.NewInt(50))
+	assert.NoError(t, err)
+	assert.False(t, registered)
+
+	// 1:3 by share, adding up to the reward
+	validator, err := ReadValidator(db, validatorAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(10), validator.FindDelegation(validatorAddr).Rewards)
+	assert.Equal(t, big.NewInt(31), validator.FindDelegation(delegatorAddr).Rewards)
+
+	collect := &types.CollectRewards{Validator: validatorAddr}
+	assert.NoError(t, applyStaking(t, db, delegatorAddr, 0, collect))
+	assert.Equal(t, big.NewInt(731), db.GetBalance(delegatorAddr))
+	assert.Equal(t, ErrDelegationNotFound, applyStaking(t, db, common.HexToAddress("0x0e"), 0, collect))
+
+	// a delegation with rewards left stays until they are collected
+	undelegate := &types.Undelegate{Validator: validatorAddr, Amount: big.NewInt(100)}
+	assert.NoError(t, applyStaking(t, db, validatorAddr, 0, undelegate))
+	validator, err = ReadValidator(db, validatorAddr)
+	assert.NoError(t, err)
+	assert.NotNil(t, validator.FindDelegation(validatorAddr))
+	assert.NoError(t, applyStaking(t, db, validatorAddr, 0, collect))
+	assert.Equal(t, big.NewInt(910), db.GetBalance(validatorAddr))
+	validator, err = ReadValidator(db, validatorAddr)
+	assert.NoError(t, err)
+	assert.Nil(t, validator.FindDelegation(validatorAddr))
+	assert.Equal(t, big.NewInt(400), db.GetBalance(types.StakingAddress))
+}
+
+func TestStakingBlob(t *testing.T) {
+	db, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	key := common.BytesToHash([]byte("blob"))
+	long := make([]byte, 100)
+	for i := range long {
+		long[i] = byte(i + 1)
+	}
+	writeStakingBlob(db, key, long)
+	assert.Equal(t, long, readStakingBlob(db, key))
+	writeStakingBlob(db, key, long[:10])
+	assert.Equal(t, long[:10], readStakingBlob(db, key))
+	assert.Equal(t, common.Hash{}, db.GetState(types.StakingAddress, blobChunkKey(key, 1)))
+	assert.Empty(t, readStakingBlob(db, common.BytesToHash([]byte("none"))))
+}
@@ -170,8 +170,10 @@ func ApplyTransaction(config *params.ChainConfig, bc ChainContext, author *commo
 	//receipt.Logs = statedb.GetLogs(tx.Hash())
 	receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
 
+	// a failed transaction moves no funds, so it sends nothing to the other
+	// shard
 	var cxReceipt *types.CXReceipt
-	if txType == types.SubtractionOnly {
+	if txType == types.SubtractionOnly && !failed {
 		cxReceipt = &types.CXReceipt{tx.Hash(), msg.From(), msg.To(), tx.ShardID(), tx.ToShardID(), msg.Value()}
 	} else {
 		cxReceipt = nil
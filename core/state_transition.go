@@ -24,7 +24,9 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/harmony-one/harmony/internal/params"
 
+	"github.com/harmony-one/harmony/core/types"
 	"github.com/harmony-one/harmony/core/vm"
+	shardingconfig "github.com/harmony-one/harmony/internal/configs/sharding"
 	"github.com/harmony-one/harmony/internal/utils"
 )
 
@@ -209,6 +211,10 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 	)
 	if contractCreation {
 		ret, _, st.gas, vmerr = evm.Create(sender, st.data, st.gas, st.value)
+	} else if *msg.To() == types.StakingAddress {
+		// Increment the nonce for the next transaction
+		st.state.SetNonce(msg.From(), st.state.GetNonce(sender.Address())+1)
+		vmerr = st.applyStaking()
 	} else {
 		// Increment the nonce for the next transaction
 		st.state.SetNonce(msg.From(), st.state.GetNonce(sender.Address())+1)
@@ -225,11 +231,41 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 		}
 	}
 	st.refundGas()
-	st.state.AddBalance(st.evm.Coinbase, new(big.Int).Mul(new(big.Int).SetUint64(st.gasUsed()), st.gasPrice))
+	st.payFee(new(big.Int).Mul(new(big.Int).SetUint64(st.gasUsed()), st.gasPrice))
 
 	return ret, st.gasUsed(), vmerr != nil, err
 }
 
+// applyStaking applies the staking message of the transaction to the
+// validator registry, leaving the state as it was if the message fails.  It
+// uses StakingGas, and the gas of the registry slots the message writes;
+// running out of it uses up all the gas, as in the EVM.
+func (st *StateTransition) applyStaking() error {
+	// The registry is on the beacon chain only, so a staking message anywhere
+	// else, or towards another shard, fails and uses up all the gas without
+	// moving any funds.
+	if st.evm.ShardID != types.StakingShardID || st.evm.TxType != types.SameShardTx {
+		st.gas = 0
+		return ErrStakingOutsideBeacon
+	}
+	if err := st.useGas(StakingGas); err != nil {
+		st.gas = 0
+		return err
+	}
+	snapshot := st.state.Snapshot()
+	gas, err := ApplyStakingMessage(st.state, st.evm.EpochNumber, st.msg.From(), st.value, st.data)
+	if err == nil {
+		if err = st.useGas(gas); err != nil {
+			st.gas = 0
+		}
+	}
+	if err != nil {
+		st.state.RevertToSnapshot(snapshot)
+		return err
+	}
+	return nil
+}
+
 func (st *StateTransition) refundGas() {
 	// Apply refund counter, capped to half of the used gas.
 	refund := st.gasUsed() / 2
@@ -247,6 +283,18 @@ func (st *StateTransition) refundGas() {
 	st.gp.AddGas(st.gas)
 }
 
+// payFee credits the gas fee of the transaction according to the fee policy of
+// the sharding schedule for the epoch.  Burnt fees are credited to nobody.
+func (st *StateTransition) payFee(fee *big.Int) {
+	config := ShardingSchedule.FeeConfig()
+	switch config.PolicyAt(st.evm.EpochNumber) {
+	case shardingconfig.FeeToLeader:
+		st.state.AddBalance(st.evm.Coinbase, fee)
+	case shardingconfig.FeeToTreasury:
+		st.state.AddBalance(config.Treasury, fee)
+	}
+}
+
 // gasUsed returns the amount of gas used up by the state transition.
 func (st *StateTransition) gasUsed() uint64 {
 	return st.initialGas - st.gas
@@ -28,11 +28,13 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/prque"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/harmony-one/harmony/internal/params"
 
 	"github.com/harmony-one/harmony/block"
+	"github.com/harmony-one/harmony/core/rawdb"
 	"github.com/harmony-one/harmony/core/state"
 	"github.com/harmony-one/harmony/core/types"
 	"github.com/harmony-one/harmony/internal/utils"
@@ -79,6 +81,18 @@ var (
 	// than some meaningful limit a user might use. This is not a consensus error
 	// making the transaction invalid, rather a DOS protection.
 	ErrOversizedData = errors.New("oversized data")
+
+	// ErrInvalidShard is returned if the transaction does not belong to the
+	// shard of the pool.
+	ErrInvalidShard = errors.New("transaction of another shard")
+
+	// ErrInvalidToShard is returned if the destination shard of a cross-shard
+	// transaction does not exist.
+	ErrInvalidToShard = errors.New("invalid destination shard")
+
+	// ErrInvalidStakingShard is returned if a staking transaction is not
+	// within the beacon chain.
+	ErrInvalidStakingShard = errors.New("staking transaction outside the beacon chain")
 )
 
 var (
@@ -121,6 +135,7 @@ type blockChain interface {
 	CurrentBlock() *types.Block
 	GetBlock(hash common.Hash, number uint64) *types.Block
 	StateAt(root common.Hash) (*state.DB, error)
+	ChainDb() ethdb.Database
 
 	SubscribeChainHeadEvent(ch chan<- ChainHeadEvent) event.Subscription
 }
@@ -220,6 +235,8 @@ type TxPool struct {
 	all     *txLookup                    // All transactions to allow lookups
 	priced  *txPricedList                // All transactions sorted by price
 
+	shardID uint32 // Shard of the transactions accepted by the pool
+
 	wg sync.WaitGroup // for shutdown sync
 
 	homestead bool
@@ -243,6 +260,7 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		all:         newTxLookup(),
 		chainHeadCh: make(chan ChainHeadEvent, chainHeadChanSize),
 		gasPrice:    new(big.Int).SetUint64(config.PriceLimit),
+		shardID:     chain.CurrentBlock().ShardID(),
 	}
 	pool.locals = newAccountSet(pool.signer)
 	for _, addr := range config.Locals {
@@ -292,7 +310,7 @@ func (pool *TxPool) loop() {
 	defer journal.Stop()
 
 	// Track the previous head headers for transaction reorgs
-	//head := pool.chain.CurrentBlock()
+	head := pool.chain.CurrentBlock()
 
 	// Keep waiting for and reacting to the various events
 	for {
@@ -304,8 +322,8 @@ func (pool *TxPool) loop() {
 				//if pool.chainconfig.IsHomestead(ev.Block.Number()) {
 				//	pool.homestead = true
 				//}
-				//pool.reset(head.Header(), ev.Block.Header())
-				//head = ev.Block
+				pool.reset(head.Header(), ev.Block.Header())
+				head = ev.Block
 
 				pool.mu.Unlock()
 			}
@@ -604,6 +622,16 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if tx.Size() > 32*1024 {
 		return ErrOversizedData
 	}
+	// Only accept transactions of our own shard, towards an existing shard
+	if tx.ShardID() != pool.shardID {
+		return ErrInvalidShard
+	}
+	if tx.ToShardID() >= ShardingSchedule.InstanceForEpoch(pool.chain.CurrentBlock().Epoch()).NumShards() {
+		return ErrInvalidToShard
+	}
+	if tx.IsStaking() && (tx.ShardID() != types.StakingShardID || tx.ToShardID() != types.StakingShardID) {
+		return ErrInvalidStakingShard
+	}
 	// Transactions can't be negative. This may never happen using RLP decoded
 	// transactions but may occur if you create a transaction using the RPC.
 	if tx.Value().Sign() < 0 {
@@ -699,6 +727,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 		if old != nil {
 			pool.all.Remove(old.Hash())
 			pool.priced.Removed()
+			pool.markReplaced(old, tx)
 			pendingReplaceCounter.Inc(1)
 		}
 		pool.all.Add(tx)
@@ -765,6 +794,7 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) (bool, er
 	if old != nil {
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed()
+		pool.markReplaced(old, tx)
 		queuedReplaceCounter.Inc(1)
 	}
 	if pool.all.Get(hash) == nil {
@@ -774,6 +804,29 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) (bool, er
 	return old != nil, nil
 }
 
+// markReplaced remembers that the old transaction was replaced by the new one
+// with the same nonce, e.g. to speed up or cancel a stuck cross-shard
+// transfer.
+func (pool *TxPool) markReplaced(old, tx *types.Transaction) {
+	if err := rawdb.WriteTxReplacement(pool.chain.ChainDb(), old.Hash(), tx.Hash()); err != nil {
+		utils.Logger().Warn().Err(err).Str("hash", old.Hash().Hex()).Msg("Cannot store transaction replacement")
+	}
+	utils.Logger().Info().
+		Str("hash", old.Hash().Hex()).
+		Str("replacement", tx.Hash().Hex()).
+		Uint32("toShardID", old.ToShardID()).
+		Uint32("replacementToShardID", tx.ToShardID()).
+		Msg("Replaced pooled transaction")
+}
+
+// ReplacedBy returns the hash of the transaction which replaced the given one
+// in the pool, if it was replaced.  The replacements are kept in the chain
+// database across restarts.
+func (pool *TxPool) ReplacedBy(hash common.Hash) (common.Hash, bool) {
+	replacement := rawdb.ReadTxReplacement(pool.chain.ChainDb(), hash)
+	return replacement, replacement != (common.Hash{})
+}
+
 // journalTx adds the specified transaction to the local disk journal if it is
 // deemed to have been sent from a local account.
 func (pool *TxPool) journalTx(from common.Address, tx *types.Transaction) {
@@ -810,6 +863,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	if old != nil {
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed()
+		pool.markReplaced(old, tx)
 
 		pendingReplaceCounter.Inc(1)
 	}
@@ -930,6 +984,17 @@ func (pool *TxPool) Get(hash common.Hash) *types.Transaction {
 	return pool.all.Get(hash)
 }
 
+// RemoveTxs removes the given transactions from the pool, e.g. when they are
+// found invalid while proposing a block.
+func (pool *TxPool) RemoveTxs(txs types.Transactions) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	for _, tx := range txs {
+		pool.removeTx(tx.Hash(), true)
+	}
+}
+
 // removeTx removes a single transaction from the queue, moving all subsequent
 // transactions back to the future queue.
 func (pool *TxPool) removeTx(hash common.Hash, outofbound bool) {
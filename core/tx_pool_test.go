@@ -51,6 +51,7 @@ type testBlockChain struct {
 	statedb       *state.DB
 	gasLimit      uint64
 	chainHeadFeed *event.Feed
+	db            ethdb.Database
 }
 
 func (bc *testBlockChain) CurrentBlock() *types.Block {
@@ -67,6 +68,10 @@ func (bc *testBlockChain) StateAt(common.Hash) (*state.DB, error) {
 	return bc.statedb, nil
 }
 
+func (bc *testBlockChain) ChainDb() ethdb.Database {
+	return bc.db
+}
+
 func (bc *testBlockChain) SubscribeChainHeadEvent(ch chan<- ChainHeadEvent) event.Subscription {
 	return bc.chainHeadFeed.Subscribe(ch)
 }
@@ -82,7 +87,7 @@ func pricedTransaction(nonce uint64, gaslimit uint64, gasprice *big.Int, key *ec
 
 func setupTxPool() (*TxPool, *ecdsa.PrivateKey) {
 	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
-	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed), ethdb.NewMemDatabase()}
 
 	key, _ := crypto.GenerateKey()
 	pool := NewTxPool(testTxPoolConfig, params.TestChainConfig, blockchain)
@@ -190,7 +195,7 @@ func TestStateChangeDuringTransactionPoolReset(t *testing.T) {
 
 	// setup pool with 2 transaction in it
 	statedb.SetBalance(address, new(big.Int).SetUint64(denominations.One))
-	blockchain := &testChain{&testBlockChain{statedb, 1000000000, new(event.Feed)}, address, &trigger}
+	blockchain := &testChain{&testBlockChain{statedb, 1000000000, new(event.Feed), ethdb.NewMemDatabase()}, address, &trigger}
 
 	tx0 := transaction(0, 100000, key)
 	tx1 := transaction(1, 100000, key)
@@ -341,7 +346,7 @@ func TestTransactionChainFork(t *testing.T) {
 		statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
 		statedb.AddBalance(addr, big.NewInt(100000000000000))
 
-		pool.chain = &testBlockChain{statedb, 1000000, new(event.Feed)}
+		pool.chain = &testBlockChain{statedb, 1000000, new(event.Feed), ethdb.NewMemDatabase()}
 		pool.lockedReset(nil, nil)
 	}
 	resetState()
@@ -370,7 +375,7 @@ func TestTransactionDoubleNonce(t *testing.T) {
 		statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
 		statedb.AddBalance(addr, big.NewInt(100000000000000))
 
-		pool.chain = &testBlockChain{statedb, 1000000, new(event.Feed)}
+		pool.chain = &testBlockChain{statedb, 1000000, new(event.Feed), ethdb.NewMemDatabase()}
 		pool.lockedReset(nil, nil)
 	}
 	resetState()
@@ -456,6 +461,107 @@ func TestTransactionNonceRecovery(t *testing.T) {
 	}
 }
 
+// Tests that removing transactions explicitly, e.g. invalid ones found while
+// proposing a block, postpones the later transactions of the account.
+func TestTransactionRemoval(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	pool.currentState.AddBalance(addr, big.NewInt(100000000000000))
+
+	txs := types.Transactions{transaction(0, 100000, key), transaction(1, 100000, key), transaction(2, 100000, key)}
+	for _, err := range pool.AddRemotes(txs) {
+		if err != nil {
+			t.Fatalf("failed to add transaction: %v", err)
+		}
+	}
+	pool.RemoveTxs(types.Transactions{txs[1]})
+
+	if pool.Get(txs[1].Hash()) != nil {
+		t.Error("removed transaction still in the pool")
+	}
+	if pending, queued := pool.Stats(); pending != 1 || queued != 1 {
+		t.Errorf("pending/queued transactions mismatch: have %d/%d, want 1/1", pending, queued)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that the pool only accepts transactions of its own shard towards an
+// existing shard.
+func TestTransactionShards(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	pool.currentState.AddBalance(addr, big.NewInt(100000000000000))
+
+	to := common.Address{}
+	numShards := ShardingSchedule.InstanceForEpoch(common.Big0).NumShards()
+	otherShard, _ := types.SignTx(types.NewCrossShardTransaction(0, &to, 1, 0, big.NewInt(100), 100000, big.NewInt(1), nil), types.HomesteadSigner{}, key)
+	if err := pool.AddRemote(otherShard); err != ErrInvalidShard {
+		t.Errorf("transaction of another shard: have %v, want %v", err, ErrInvalidShard)
+	}
+	noShard, _ := types.SignTx(types.NewCrossShardTransaction(0, &to, 0, numShards, big.NewInt(100), 100000, big.NewInt(1), nil), types.HomesteadSigner{}, key)
+	if err := pool.AddRemote(noShard); err != ErrInvalidToShard {
+		t.Errorf("transaction to a missing shard: have %v, want %v", err, ErrInvalidToShard)
+	}
+	crossShard, _ := types.SignTx(types.NewCrossShardTransaction(0, &to, 0, numShards-1, big.NewInt(100), 100000, big.NewInt(1), nil), types.HomesteadSigner{}, key)
+	if err := pool.AddRemote(crossShard); err != nil {
+		t.Errorf("cross-shard transaction rejected: %v", err)
+	}
+}
+
+// Tests that a pending cross-shard transaction can be replaced by a better
+// paying one, e.g. to cancel it, and that the pool remembers the replacement.
+func TestTransactionCrossShardReplacement(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+	// transactions are commonly free of charge
+	pool.SetGasPrice(big.NewInt(0))
+
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	pool.currentState.AddBalance(addr, big.NewInt(100000000000000))
+
+	to := common.Address{}
+	crossShard, _ := types.SignTx(types.NewCrossShardTransaction(0, &to, 0, 1, big.NewInt(100), 100000, big.NewInt(0), nil), types.HomesteadSigner{}, key)
+	if err := pool.AddRemote(crossShard); err != nil {
+		t.Fatalf("failed to add cross-shard transaction: %v", err)
+	}
+	// a transfer to self on the source shard cancels the cross-shard transfer
+	sameNonce, _ := types.SignTx(types.NewTransaction(0, addr, 0, big.NewInt(0), 100000, big.NewInt(0), nil), types.HomesteadSigner{}, key)
+	if err := pool.AddRemote(sameNonce); err != ErrReplaceUnderpriced {
+		t.Errorf("replacement without a price bump: have %v, want %v", err, ErrReplaceUnderpriced)
+	}
+	cancel, _ := types.SignTx(types.NewTransaction(0, addr, 0, big.NewInt(0), 100000, big.NewInt(1), nil), types.HomesteadSigner{}, key)
+	if err := pool.AddRemote(cancel); err != nil {
+		t.Fatalf("failed to replace cross-shard transaction: %v", err)
+	}
+	if pool.Get(crossShard.Hash()) != nil {
+		t.Error("replaced transaction still in the pool")
+	}
+	if replacement, ok := pool.ReplacedBy(crossShard.Hash()); !ok || replacement != cancel.Hash() {
+		t.Errorf("replacement mismatch: have %x, %v, want %x", replacement, ok, cancel.Hash())
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	// the replacement is remembered across restarts
+	restarted := NewTxPool(testTxPoolConfig, params.TestChainConfig, pool.chain)
+	defer restarted.Stop()
+	if replacement, ok := restarted.ReplacedBy(crossShard.Hash()); !ok || replacement != cancel.Hash() {
+		t.Errorf("replacement mismatch after restart: have %x, %v, want %x", replacement, ok, cancel.Hash())
+	}
+}
+
 // Tests that if an account runs out of funds, any pending and queued transactions
 // are dropped.
 func TestTransactionDropping(t *testing.T) {
@@ -558,7 +664,7 @@ func TestTransactionPostponing(t *testing.T) {
 
 	// Create the pool to test the postponing with
 	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
-	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed), ethdb.NewMemDatabase()}
 
 	pool := NewTxPool(testTxPoolConfig, params.TestChainConfig, blockchain)
 	defer pool.Stop()
@@ -716,7 +822,7 @@ func testTransactionQueueGlobalLimiting(t *testing.T, nolocals bool) {
 
 	// Create the pool to test the limit enforcement with
 	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
-	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed), ethdb.NewMemDatabase()}
 
 	config := testTxPoolConfig
 	config.NoLocals = nolocals
@@ -804,7 +910,7 @@ func testTransactionQueueTimeLimiting(t *testing.T, nolocals bool) {
 
 	// Create the pool to test the non-expiration enforcement
 	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
-	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed), ethdb.NewMemDatabase()}
 
 	config := testTxPoolConfig
 	config.Lifetime = time.Second
@@ -917,7 +1023,7 @@ func TestTransactionPendingGlobalLimiting(t *testing.T) {
 
 	// Create the pool to test the limit enforcement with
 	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
-	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed), ethdb.NewMemDatabase()}
 
 	config := testTxPoolConfig
 	config.GlobalSlots = config.AccountSlots * 10
@@ -963,7 +1069,7 @@ func TestTransactionCapClearsFromAll(t *testing.T) {
 
 	// Create the pool to test the limit enforcement with
 	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
-	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed), ethdb.NewMemDatabase()}
 
 	config := testTxPoolConfig
 	config.AccountSlots = 2
@@ -997,7 +1103,7 @@ func TestTransactionPendingMinimumAllowance(t *testing.T) {
 
 	// Create the pool to test the limit enforcement with
 	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
-	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed), ethdb.NewMemDatabase()}
 
 	config := testTxPoolConfig
 	config.GlobalSlots = 0
@@ -1042,7 +1148,7 @@ func TestTransactionPoolRepricingKeepsLocals(t *testing.T) {
 
 	// Create the pool to test the pricing enforcement with
 	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
-	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed), ethdb.NewMemDatabase()}
 
 	pool := NewTxPool(testTxPoolConfig, params.TestChainConfig, blockchain)
 	defer pool.Stop()
@@ -1116,7 +1222,7 @@ func testTransactionJournaling(t *testing.T, nolocals bool) {
 
 	// Create the original pool to inject transaction into the journal
 	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
-	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed), ethdb.NewMemDatabase()}
 
 	config := testTxPoolConfig
 	config.NoLocals = nolocals
@@ -1158,7 +1264,7 @@ func testTransactionJournaling(t *testing.T, nolocals bool) {
 	// Terminate the old pool, bump the local nonce, create a new pool and ensure relevant transaction survive
 	pool.Stop()
 	statedb.SetNonce(crypto.PubkeyToAddress(local.PublicKey), 1)
-	blockchain = &testBlockChain{statedb, 1000000, new(event.Feed)}
+	blockchain = &testBlockChain{statedb, 1000000, new(event.Feed), ethdb.NewMemDatabase()}
 
 	pool = NewTxPool(config, params.TestChainConfig, blockchain)
 
@@ -1185,7 +1291,7 @@ func testTransactionJournaling(t *testing.T, nolocals bool) {
 	pool.Stop()
 
 	statedb.SetNonce(crypto.PubkeyToAddress(local.PublicKey), 1)
-	blockchain = &testBlockChain{statedb, 1000000, new(event.Feed)}
+	blockchain = &testBlockChain{statedb, 1000000, new(event.Feed), ethdb.NewMemDatabase()}
 	pool = NewTxPool(config, params.TestChainConfig, blockchain)
 
 	pending, queued = pool.Stats()
@@ -1214,7 +1320,7 @@ func TestTransactionStatusCheck(t *testing.T) {
 
 	// Create the pool to test the status retrievals with
 	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
-	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed), ethdb.NewMemDatabase()}
 
 	pool := NewTxPool(testTxPoolConfig, params.TestChainConfig, blockchain)
 	defer pool.Stop()
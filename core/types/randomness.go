@@ -0,0 +1,69 @@
+package types
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/harmony-one/harmony/crypto/hash"
+)
+
+// BeaconDealer is the public part of the deal of one qualified dealer of the
+// distributed key generation run by the beacon committee for an epoch.
+type BeaconDealer struct {
+	// Index is the index of the dealer in the committee of the epoch.
+	Index uint32
+	// Commitment is the commitment to the constant term of the dealer's
+	// polynomial, i.e. its contribution to the group public key.
+	Commitment []byte
+	// CommitmentsHash is the hash of the commitments to the other
+	// coefficients of the polynomial.
+	CommitmentsHash common.Hash
+	// Signature is the BLS signature of the dealer over its commitments.
+	Signature []byte
+}
+
+// BeaconRandomness is the output of the distributed randomness beacon for one
+// epoch: the threshold BLS signature of the beacon committee over the seed of
+// the epoch, under the group public key generated by the qualified dealers.
+// Being unique for a given group key and seed, the signature can neither be
+// predicted nor biased by fewer than a threshold of committee members.
+type BeaconRandomness struct {
+	Epoch     *big.Int
+	Seed      common.Hash // hash of the first beacon block of the epoch
+	Dealers   []BeaconDealer
+	Signature []byte // threshold signature over the seed
+}
+
+// Randomness returns the random value of the epoch, the hash of the
+// threshold signature.
+func (r *BeaconRandomness) Randomness() common.Hash {
+	return hash.Keccak256Hash(r.Signature)
+}
+
+// BeaconRandomness returns the output of the randomness beacon the block
+// commits in the extra data of its header, or nil.  Only beacon chain blocks
+// commit randomness.
+func (b *Block) BeaconRandomness() (*BeaconRandomness, error) {
+	extra := b.header.Extra()
+	if b.ShardID() != 0 || b.NumberU64() == 0 || len(extra) == 0 {
+		return nil, nil
+	}
+	randomness := &BeaconRandomness{}
+	if err := rlp.DecodeBytes(extra, randomness); err != nil {
+		return nil, err
+	}
+	return randomness, nil
+}
+
+// AddBeaconRandomness commits the output of the randomness beacon in the
+// extra data of the block header.
+func (b *Block) AddBeaconRandomness(randomness *BeaconRandomness) error {
+	data, err := rlp.EncodeToBytes(randomness)
+	if err != nil {
+		return err
+	}
+	b.header.SetExtra(data)
+	return nil
+}
@@ -0,0 +1,63 @@
+package types
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/harmony-one/harmony/shard"
+)
+
+// RewardPayoutKind tells why an account was paid a part of a block reward.
+type RewardPayoutKind uint8
+
+const (
+	// SignerReward is the share of a validator that signed the block.
+	SignerReward RewardPayoutKind = iota
+	// LeaderReward is the bonus of the leader that proposed the block.
+	LeaderReward
+)
+
+func (k RewardPayoutKind) String() string {
+	switch k {
+	case SignerReward:
+		return "signer"
+	case LeaderReward:
+		return "leader"
+	}
+	return "unknown"
+}
+
+// RewardPayout is the part of a block reward credited to one account.
+type RewardPayout struct {
+	Account common.Address
+	Kind    RewardPayoutKind
+	// BlsPublicKey is the key of the validator the account is paid for.
+	BlsPublicKey shard.BlsPublicKey
+	Amount       *big.Int
+	// ShardID and SignedBlockNum identify the signed block: the parent of
+	// the crediting block, or the parent of a block of another shard the
+	// crediting beacon block cross-links.
+	ShardID        uint32
+	SignedBlockNum *big.Int
+}
+
+// BlockRewards records how the reward for signing a block was paid out.  The
+// reward is credited by the next block, whose commit bitmap tells who signed.
+type BlockRewards struct {
+	// BlockNum is the number of the block crediting the reward.
+	BlockNum *big.Int
+	// Total is the sum of the block rewards of the epochs of the signed
+	// blocks, which the payouts add up to.
+	Total   *big.Int
+	Payouts []RewardPayout
+}
+
+// Paid returns the sum of the payouts.
+func (r *BlockRewards) Paid() *big.Int {
+	paid := big.NewInt(0)
+	for _, payout := range r.Payouts {
+		paid.Add(paid, payout.Amount)
+	}
+	return paid
+}
@@ -0,0 +1,223 @@
+package types
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/harmony-one/harmony/shard"
+)
+
+// StakingAddress is the recipient of staking transactions.  Its account
+// holds the tokens staked on validators and, in its storage, the validator
+// registry.  No key controls it.
+var StakingAddress = common.HexToAddress("0x00000000000000000000000000000000005374616b65")
+
+// StakingShardID is the shard processing staking transactions, the beacon
+// chain.
+const StakingShardID = 0
+
+// Errors of staking messages.
+var (
+	ErrUnknownStakingType = errors.New("unknown staking message type")
+	ErrInvalidStakingData = errors.New("invalid staking message data")
+)
+
+// StakingType is the type of a staking message.
+type StakingType byte
+
+// Staking message types.
+const (
+	CreateValidatorType StakingType = iota // register the sender as a validator
+	EditValidatorType                      // change the key or name of the sender validator
+	DelegateType                           // stake the value on a validator
+	UndelegateType                         // start unbonding stake from a validator
+	CollectRewardsType                     // pay the rewards of the sender on a validator out
+)
+
+func (t StakingType) String() string {
+	switch t {
+	case CreateValidatorType:
+		return "CreateValidator"
+	case EditValidatorType:
+		return "EditValidator"
+	case DelegateType:
+		return "Delegate"
+	case UndelegateType:
+		return "Undelegate"
+	case CollectRewardsType:
+		return "CollectRewards"
+	}
+	return "Unknown"
+}
+
+// CreateValidator registers the sender as a validator with the given BLS
+// key, staking the value of the transaction on it.
+type CreateValidator struct {
+	BlsPublicKey shard.BlsPublicKey
+	Name         string
+}
+
+// EditValidator changes the BLS key, unless it is empty, and the name of the
+// sender validator.
+type EditValidator struct {
+	BlsPublicKey shard.BlsPublicKey
+	Name         string
+}
+
+// Delegate stakes the value of the transaction on the given validator.
+type Delegate struct {
+	Validator common.Address
+}
+
+// Undelegate withdraws the given amount the sender staked on the given
+// validator.  The amount stops counting as stake at once, but stays in the
+// unbonding queue for some epochs before the sender gets it back.
+type Undelegate struct {
+	Validator common.Address
+	Amount    *big.Int
+}
+
+// CollectRewards pays the block rewards the sender earned by staking on the
+// given validator out to it.
+type CollectRewards struct {
+	Validator common.Address
+}
+
+// StakingMessage is the data of a staking transaction: the type of the
+// message and its RLP encoded payload.
+type StakingMessage struct {
+	Type StakingType
+	Data []byte
+}
+
+// EncodeStakingMessage returns the staking transaction data of the given
+// payload, one of CreateValidator, EditValidator, Delegate, Undelegate and
+// CollectRewards.
+func EncodeStakingMessage(payload interface{}) ([]byte, error) {
+	var msgType StakingType
+	switch payload.(type) {
+	case *CreateValidator:
+		msgType = CreateValidatorType
+	case *EditValidator:
+		msgType = EditValidatorType
+	case *Delegate:
+		msgType = DelegateType
+	case *Undelegate:
+		msgType = UndelegateType
+	case *CollectRewards:
+		msgType = CollectRewardsType
+	default:
+		return nil, ErrUnknownStakingType
+	}
+	data, err := rlp.EncodeToBytes(payload)
+	if err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(&StakingMessage{Type: msgType, Data: data})
+}
+
+// DecodeStakingMessage returns the type and the payload of the given staking
+// transaction data, as EncodeStakingMessage encodes them.
+func DecodeStakingMessage(data []byte) (StakingType, interface{}, error) {
+	msg := &StakingMessage{}
+	if err := rlp.DecodeBytes(data, msg); err != nil {
+		return 0, nil, ErrInvalidStakingData
+	}
+	var payload interface{}
+	switch msg.Type {
+	case CreateValidatorType:
+		payload = &CreateValidator{}
+	case EditValidatorType:
+		payload = &EditValidator{}
+	case DelegateType:
+		payload = &Delegate{}
+	case UndelegateType:
+		payload = &Undelegate{}
+	case CollectRewardsType:
+		payload = &CollectRewards{}
+	default:
+		return msg.Type, nil, ErrUnknownStakingType
+	}
+	if err := rlp.DecodeBytes(msg.Data, payload); err != nil {
+		return msg.Type, nil, ErrInvalidStakingData
+	}
+	return msg.Type, payload, nil
+}
+
+// NewStakingTransaction returns a staking transaction of the beacon chain
+// sending the given payload, as EncodeStakingMessage takes it, and value.
+func NewStakingTransaction(nonce uint64, payload interface{}, amount *big.Int, gasLimit uint64, gasPrice *big.Int) (*Transaction, error) {
+	data, err := EncodeStakingMessage(payload)
+	if err != nil {
+		return nil, err
+	}
+	return NewTransaction(nonce, StakingAddress, StakingShardID, amount, gasLimit, gasPrice, data), nil
+}
+
+// IsStaking returns whether the transaction is a staking transaction.
+func (tx *Transaction) IsStaking() bool {
+	to := tx.To()
+	return to != nil && *to == StakingAddress
+}
+
+// Validator is a validator of the registry of staking transactions.
+type Validator struct {
+	Address      common.Address
+	BlsPublicKey shard.BlsPublicKey
+	Name         string
+	// Stake is the amount staked on the validator, which its shares split.
+	Stake *big.Int
+	// TotalShares is the sum of the shares of the delegations.
+	TotalShares *big.Int
+	Delegations []*Delegation
+}
+
+// Delegation is the stake of a delegator, possibly the validator itself, on
+// a validator.
+type Delegation struct {
+	Delegator common.Address
+	// Shares is the part of the stake of the validator which belongs to the
+	// delegator, and of the block rewards the validator earns.
+	Shares *big.Int
+	// Rewards are the block rewards the delegator earned and has yet to
+	// collect.
+	Rewards *big.Int
+}
+
+// Unbonding is an undelegated amount, held until the end of the given
+// epoch.
+type Unbonding struct {
+	Delegator common.Address
+	Validator common.Address
+	Amount    *big.Int
+	Epoch     *big.Int
+}
+
+// EpochStakes is a snapshot of the stakes of the validator registry taken at
+// the start of an epoch.  The votes on the blocks of the epoch are weighed by
+// it, so that they weigh the same whenever they are checked.
+type EpochStakes struct {
+	Epoch *big.Int
+	// Stakes are the stakes of the validators, sorted by address.
+	Stakes []*ValidatorStake
+}
+
+// ValidatorStake is the amount staked on a validator and its BLS key.
+type ValidatorStake struct {
+	Address      common.Address
+	BlsPublicKey shard.BlsPublicKey
+	Amount       *big.Int
+}
+
+// FindDelegation returns the delegation of the given delegator, or nil.
+func (v *Validator) FindDelegation(delegator common.Address) *Delegation {
+	for _, delegation := range v.Delegations {
+		if delegation.Delegator == delegator {
+			return delegation
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeDecodeStakingMessage(t *testing.T) {
+	payloads := []interface{}{
+		&CreateValidator{Name: "validator"},
+		&EditValidator{Name: "renamed"},
+		&Delegate{Validator: common.HexToAddress("0x01")},
+		&Undelegate{Validator: common.HexToAddress("0x01"), Amount: big.NewInt(10)},
+		&CollectRewards{Validator: common.HexToAddress("0x01")},
+	}
+	msgTypes := []StakingType{
+		CreateValidatorType, EditValidatorType, DelegateType, UndelegateType, CollectRewardsType,
+	}
+	for i, payload := range payloads {
+		data, err := EncodeStakingMessage(payload)
+		assert.NoError(t, err)
+		msgType, decoded, err := DecodeStakingMessage(data)
+		assert.NoError(t, err)
+		assert.Equal(t, msgTypes[i], msgType)
+		assert.Equal(t, payload, decoded)
+	}
+
+	_, err := EncodeStakingMessage(&Validator{})
+	assert.Equal(t, ErrUnknownStakingType, err)
+	_, _, err = DecodeStakingMessage([]byte{0x01, 0x02})
+	assert.Equal(t, ErrInvalidStakingData, err)
+}
+
+func TestNewStakingTransaction(t *testing.T) {
+	tx, err := NewStakingTransaction(3, &CollectRewards{}, big.NewInt(0), 100000, big.NewInt(1))
+	assert.NoError(t, err)
+	assert.True(t, tx.IsStaking())
+	assert.Equal(t, uint32(StakingShardID), tx.ShardID())
+	assert.Equal(t, uint32(StakingShardID), tx.ToShardID())
+
+	tx = NewTransaction(3, common.HexToAddress("0x01"), 0, big.NewInt(0), 100000, big.NewInt(1), nil)
+	assert.False(t, tx.IsStaking())
+	assert.False(t, NewContractCreation(3, 0, big.NewInt(0), 100000, big.NewInt(1), nil).IsStaking())
+}
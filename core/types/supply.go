@@ -0,0 +1,67 @@
+package types
+
+import (
+	"math/big"
+)
+
+// BlockFees records the gas fees of the transactions of a block.
+type BlockFees struct {
+	// Collected is the sum of the fees paid by the senders.
+	Collected *big.Int
+	// Burned is the part of the collected fees credited to nobody.
+	Burned *big.Int
+}
+
+// ShardSupply tracks the amount of tokens in a shard as of a block, from the
+// genesis allocations and every block since.
+type ShardSupply struct {
+	// Genesis is the sum of the genesis allocations of the shard.
+	Genesis *big.Int
+	// Issued is the sum of the block rewards paid out.
+	Issued *big.Int
+	// Burned is the sum of the burnt transaction fees.
+	Burned *big.Int
+	// CrossShardOut is the sum of the amounts sent to other shards, and
+	// CrossShardIn of the amounts received from them.
+	CrossShardOut *big.Int
+	CrossShardIn  *big.Int
+}
+
+// NewShardSupply returns the supply of a shard with the given genesis
+// allocations.
+func NewShardSupply(genesis *big.Int) *ShardSupply {
+	return &ShardSupply{
+		Genesis:       new(big.Int).Set(genesis),
+		Issued:        big.NewInt(0),
+		Burned:        big.NewInt(0),
+		CrossShardOut: big.NewInt(0),
+		CrossShardIn:  big.NewInt(0),
+	}
+}
+
+// Copy returns a deep copy of the supply.
+func (s *ShardSupply) Copy() *ShardSupply {
+	return &ShardSupply{
+		Genesis:       new(big.Int).Set(s.Genesis),
+		Issued:        new(big.Int).Set(s.Issued),
+		Burned:        new(big.Int).Set(s.Burned),
+		CrossShardOut: new(big.Int).Set(s.CrossShardOut),
+		CrossShardIn:  new(big.Int).Set(s.CrossShardIn),
+	}
+}
+
+// Minted returns the amount created in the shard, the genesis allocations
+// and the block rewards, less the burnt fees.  Unlike the circulating supply,
+// it does not depend on the cross-shard transfers, so the minted amounts of
+// all the shards add up to the supply of the network.
+func (s *ShardSupply) Minted() *big.Int {
+	minted := new(big.Int).Add(s.Genesis, s.Issued)
+	return minted.Sub(minted, s.Burned)
+}
+
+// Circulating returns the amount held in the shard.
+func (s *ShardSupply) Circulating() *big.Int {
+	circulating := s.Minted()
+	circulating.Add(circulating, s.CrossShardIn)
+	return circulating.Sub(circulating, s.CrossShardOut)
+}
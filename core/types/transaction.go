@@ -611,6 +611,16 @@ func (rts RecentTxsStats) String() string {
 	return ret
 }
 
+// AccountTxsCount returns the number of transactions made by the account in
+// all the blocks of the stats
+func (rts RecentTxsStats) AccountTxsCount(address common.Address) uint64 {
+	var count uint64
+	for _, blockTxsCounts := range rts {
+		count += blockTxsCounts[address]
+	}
+	return count
+}
+
 // BlockTxsCounts is a transactions counts map of
 // the number of transactions made by each account in a block on this node.
 type BlockTxsCounts map[common.Address]uint64
@@ -0,0 +1,22 @@
+package types
+
+// ValidatorUptime counts the blocks a validator key signed and missed in one
+// epoch, according to the commit bitmaps of the blocks.
+type ValidatorUptime struct {
+	Signed uint64
+	Missed uint64
+}
+
+// Total returns the number of blocks the key was expected to sign.
+func (u ValidatorUptime) Total() uint64 {
+	return u.Signed + u.Missed
+}
+
+// Ratio returns the fraction of the blocks the key signed, or 0 if it was
+// not expected to sign any.
+func (u ValidatorUptime) Ratio() float64 {
+	if u.Total() == 0 {
+		return 0
+	}
+	return float64(u.Signed) / float64(u.Total())
+}
@@ -90,6 +90,7 @@ type Context struct {
 	BlockNumber *big.Int       // Provides information for NUMBER
 	EpochNumber *big.Int       // Provides information for EPOCH
 	Time        *big.Int       // Provides information for TIME
+	ShardID     uint32         // Shard of the block
 
 	TxType types.TransactionType
 }
@@ -0,0 +1,113 @@
+package bls
+
+import (
+	"sync"
+
+	"github.com/harmony-one/bls/ffi/go/bls"
+)
+
+var (
+	generatorOnce sync.Once
+	generator     bls.G1 // the point public keys are multiples of
+)
+
+// publicKeyGenerator returns the generator of the public key group, which is
+// the public key of the secret key 1.
+func publicKeyGenerator() *bls.G1 {
+	generatorOnce.Do(func() {
+		var one bls.SecretKey
+		if err := one.SetDecString("1"); err != nil {
+			panic(err)
+		}
+		if err := generator.Deserialize(one.GetPublicKey().Serialize()); err != nil {
+			panic(err)
+		}
+	})
+	return &generator
+}
+
+// BatchVerifier verifies many (aggregate) signatures over different hashes at
+// once, using a random linear combination of them.
+//
+// Verifying n signatures one by one takes 2n pairings.  Batch verification
+// checks
+//
+//	e(G, sum(r_i * sig_i)) == prod(e(pub_i, r_i * H(hash_i)))
+//
+// for random scalars r_i, which takes n Miller loops and a single final
+// exponentiation, plus one pairing.  A batch containing an invalid signature
+// passes only with negligible probability; Verify does not tell which
+// signature is invalid, so callers fall back to verifying one by one.
+//
+// BatchVerifier is not safe for concurrent use.
+type BatchVerifier struct {
+	sigs   []*bls.Sign
+	pubs   []*bls.PublicKey
+	hashes [][]byte
+}
+
+// NewBatchVerifier returns an empty batch verifier.
+func NewBatchVerifier() *BatchVerifier {
+	return &BatchVerifier{}
+}
+
+// Add adds a signature to the batch, to be verified as in
+// sig.VerifyHash(pub, hash).
+func (v *BatchVerifier) Add(sig *bls.Sign, pub *bls.PublicKey, hash []byte) {
+	v.sigs = append(v.sigs, sig)
+	v.pubs = append(v.pubs, pub)
+	v.hashes = append(v.hashes, hash)
+}
+
+// Len returns the number of signatures in the batch.
+func (v *BatchVerifier) Len() int {
+	return len(v.sigs)
+}
+
+// Verify returns whether every signature in the batch is valid.  An empty
+// batch is valid.
+func (v *BatchVerifier) Verify() bool {
+	switch len(v.sigs) {
+	case 0:
+		return true
+	case 1:
+		return v.sigs[0].VerifyHash(v.pubs[0], v.hashes[0])
+	}
+	var sigSum bls.G2
+	var millerProduct bls.GT
+	for i := range v.sigs {
+		// the random coefficient doubles as a secret key, so that signing
+		// the hash with it maps the hash to the curve exactly the way
+		// VerifyHash does, and multiplies it by the coefficient.
+		var r bls.SecretKey
+		r.SetByCSPRNG()
+		var coefficient bls.Fr
+		if err := coefficient.Deserialize(r.Serialize()); err != nil {
+			return false
+		}
+		weightedHash := r.SignHash(v.hashes[i])
+		if weightedHash == nil {
+			return false
+		}
+		var hashPoint, sig bls.G2
+		var pub bls.G1
+		if hashPoint.Deserialize(weightedHash.Serialize()) != nil ||
+			sig.Deserialize(v.sigs[i].Serialize()) != nil ||
+			pub.Deserialize(v.pubs[i].Serialize()) != nil {
+			return false
+		}
+		bls.G2Mul(&sig, &sig, &coefficient)
+		var miller bls.GT
+		bls.MillerLoop(&miller, &pub, &hashPoint)
+		if i == 0 {
+			sigSum, millerProduct = sig, miller
+		} else {
+			bls.G2Add(&sigSum, &sigSum, &sig)
+			bls.GTMul(&millerProduct, &millerProduct, &miller)
+		}
+	}
+	var lhs, rhs bls.GT
+	bls.Pairing(&lhs, publicKeyGenerator(), &sigSum)
+	bls.FinalExp(&rhs, &millerProduct)
+	return lhs.IsEqual(&rhs)
+}
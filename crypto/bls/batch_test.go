@@ -0,0 +1,71 @@
+package bls
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/harmony-one/bls/ffi/go/bls"
+)
+
+func newTestBatch(n int) *BatchVerifier {
+	v := NewBatchVerifier()
+	for i := 0; i < n; i++ {
+		key := RandPrivateKey()
+		hash := []byte(fmt.Sprintf("block hash %d", i))
+		v.Add(key.SignHash(hash), key.GetPublicKey(), hash)
+	}
+	return v
+}
+
+func TestBatchVerifier(test *testing.T) {
+	if !NewBatchVerifier().Verify() {
+		test.Error("empty batch not valid")
+	}
+	for _, n := range []int{1, 2, 16} {
+		if !newTestBatch(n).Verify() {
+			test.Errorf("valid batch of %d not verified", n)
+		}
+	}
+}
+
+func TestBatchVerifierInvalidSignature(test *testing.T) {
+	v := newTestBatch(8)
+	v.hashes[5] = []byte("another block hash")
+	if v.Verify() {
+		test.Error("batch with a signature of another hash verified")
+	}
+
+	v = newTestBatch(8)
+	v.pubs[2] = RandPrivateKey().GetPublicKey()
+	if v.Verify() {
+		test.Error("batch with a signature of another key verified")
+	}
+
+	// signatures that cancel out in a plain sum must not pass either
+	v = newTestBatch(2)
+	var sum bls.Sign
+	sum.Add(v.sigs[0])
+	sum.Add(v.sigs[1])
+	v.sigs[0], v.sigs[1] = &sum, &bls.Sign{}
+	if v.Verify() {
+		test.Error("batch with swapped signature shares verified")
+	}
+}
+
+func BenchmarkVerifyHashOneByOne(b *testing.B) {
+	v := newTestBatch(b.N)
+	b.ResetTimer()
+	for i := range v.sigs {
+		if !v.sigs[i].VerifyHash(v.pubs[i], v.hashes[i]) {
+			b.Fatal("signature not verified")
+		}
+	}
+}
+
+func BenchmarkVerifyHashBatch(b *testing.B) {
+	v := newTestBatch(b.N)
+	b.ResetTimer()
+	if !v.Verify() {
+		b.Fatal("batch not verified")
+	}
+}
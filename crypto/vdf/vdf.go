@@ -1,17 +1,168 @@
-// Package vdf is a proof-of-concept implementation of a delay function
-// and the security properties are not guaranteed.
-// A more secure implementation of the VDF by Wesolowski (https://eprint.iacr.org/2018/623.pdf)
-// will be done soon.
+// Package vdf implements the verifiable delay function of Wesolowski
+// (https://eprint.iacr.org/2018/623.pdf) in the RSA group of the RSA-2048
+// challenge modulus, whose factorization is unknown.
+//
+// Evaluating the VDF takes a number of sequential squarings given by the
+// difficulty, while verifying its succinct proof takes two modular
+// exponentiations of about 128 bits, regardless of the difficulty.
 package vdf
 
-import "golang.org/x/crypto/sha3"
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+)
 
-// VDF is the struct holding necessary state for a hash chain delay function.
+const (
+	// ElementSize is the size in bytes of a group element, i.e. of the output
+	// and of the proof.
+	ElementSize = 256
+	// Size is the size in bytes of the output followed by the proof.
+	Size = 2 * ElementSize
+
+	// challengeBits is the size of the prime challenge of the proof.
+	challengeBits = 128
+)
+
+// modulus is the RSA-2048 challenge number.
+var modulus, _ = new(big.Int).SetString(
+	"c7970ceedcc3b0754490201a7aa613cd73911081c790f5f1a8726f463550bb5b"+
+		"7ff0db8e1ea1189ec72f93d1650011bd721aeeacc2acde32a04107f0648c2813"+
+		"a31f5b0b7765ff8b44b4b6ffc93384b646eb09c7cf5e8592d40ea33c80039f35"+
+		"b4f14a04b51f7bfd781be4d1673164ba8eb991c2c4d730bbbe35f592bdef524a"+
+		"f7e8daefd26c66fc02c479af89d64d373f442709439de66ceb955f3ea37d5159"+
+		"f6135809f85334b5cb1813addc80cd05609f10ac6a95ad65872c909525bdad32"+
+		"bc729592642920f24c61dc5b3c3b7923e56b16a4d9d373d8721f24a3fc0f1b31"+
+		"31f55615172866bccc30f95054c824e733a5eb6817f7bc16399d48c6361cc7e5",
+	16)
+
+// halfModulus is the largest canonical representative of a group element.
+var halfModulus = new(big.Int).Rsh(modulus, 1)
+
+// Domain separation tags of the hash functions.
+const (
+	groupTag     = 'G'
+	challengeTag = 'P'
+)
+
+// canonical maps x to the canonical representative of its class in the
+// quotient group Z_N^*/{1, -1}, where the element -1 of order 2, known to
+// everyone, cannot be used to forge proofs.
+func canonical(x *big.Int) *big.Int {
+	if x.Cmp(halfModulus) > 0 {
+		x.Sub(modulus, x)
+	}
+	return x
+}
+
+// putElement writes the group element to buf as a big-endian number padded
+// to ElementSize bytes.
+func putElement(buf []byte, x *big.Int) {
+	b := x.Bytes()
+	copy(buf[len(buf)-len(b):], b)
+}
+
+// hashToGroup maps the input to a group element.
+func hashToGroup(input []byte) *big.Int {
+	// hash to more bits than the modulus to make the bias negligible
+	buf := make([]byte, 0, ElementSize+sha256.Size)
+	for counter := byte(0); len(buf) < ElementSize+sha256.Size/2; counter++ {
+		h := sha256.Sum256(append([]byte{groupTag, counter}, input...))
+		buf = append(buf, h[:]...)
+	}
+	x := new(big.Int).SetBytes(buf)
+	x.Mod(x, modulus)
+	if x.Cmp(big.NewInt(2)) < 0 {
+		x.SetInt64(2)
+	}
+	return canonical(x)
+}
+
+// hashToPrime derives the prime challenge of the proof from the input and
+// output group elements (Fiat-Shamir).
+func hashToPrime(x, y *big.Int) *big.Int {
+	data := make([]byte, 1+8+2*ElementSize)
+	data[0] = challengeTag
+	putElement(data[9:9+ElementSize], x)
+	putElement(data[9+ElementSize:], y)
+	candidate := new(big.Int)
+	for counter := uint64(0); ; counter++ {
+		binary.BigEndian.PutUint64(data[1:9], counter)
+		h := sha256.Sum256(data)
+		candidate.SetBytes(h[:challengeBits/8])
+		candidate.SetBit(candidate, challengeBits-1, 1)
+		candidate.SetBit(candidate, 0, 1)
+		if candidate.ProbablyPrime(20) {
+			return candidate
+		}
+	}
+}
+
+// Evaluate computes the output y = x^(2^difficulty) of the VDF on the input
+// x mapped to the group, and the proof pi = x^floor(2^difficulty / l) of the
+// output for the prime challenge l derived from x and y.  It takes
+// 2*difficulty sequential modular squarings.
+func Evaluate(input [32]byte, difficulty int) (output, proof [ElementSize]byte) {
+	x := hashToGroup(input[:])
+	y := new(big.Int).Set(x)
+	for i := 0; i < difficulty; i++ {
+		y.Mul(y, y)
+		y.Mod(y, modulus)
+	}
+	canonical(y)
+	l := hashToPrime(x, y)
+
+	// compute the quotient floor(2^difficulty / l) bit by bit by long
+	// division, raising x to it at the same time
+	pi := big.NewInt(1)
+	r := big.NewInt(1)
+	for i := 0; i < difficulty; i++ {
+		r.Lsh(r, 1)
+		pi.Mul(pi, pi)
+		if r.Cmp(l) >= 0 {
+			r.Sub(r, l)
+			pi.Mul(pi, x)
+		}
+		pi.Mod(pi, modulus)
+	}
+	canonical(pi)
+
+	putElement(output[:], y)
+	putElement(proof[:], pi)
+	return output, proof
+}
+
+// Verify checks that the output is the output of the VDF on the input with
+// the given difficulty, using its proof: pi^l * x^(2^difficulty mod l) = y.
+func Verify(input [32]byte, difficulty int, output, proof [ElementSize]byte) bool {
+	if difficulty < 0 {
+		return false
+	}
+	y := new(big.Int).SetBytes(output[:])
+	pi := new(big.Int).SetBytes(proof[:])
+	for _, e := range []*big.Int{y, pi} {
+		// only accept canonical representatives of group elements
+		if e.Sign() <= 0 || e.Cmp(halfModulus) > 0 {
+			return false
+		}
+	}
+	x := hashToGroup(input[:])
+	l := hashToPrime(x, y)
+	r := new(big.Int).Exp(big.NewInt(2), big.NewInt(int64(difficulty)), l)
+
+	lhs := new(big.Int).Exp(pi, l, modulus)
+	lhs.Mul(lhs, new(big.Int).Exp(x, r, modulus))
+	lhs.Mod(lhs, modulus)
+	return canonical(lhs).Cmp(y) == 0
+}
+
+// VDF is the struct holding necessary state for an evaluation of the delay
+// function.
 type VDF struct {
 	difficulty int
 	input      [32]byte
-	output     [32]byte
-	outputChan chan [32]byte
+	output     [Size]byte
+	outputChan chan [Size]byte
 	finished   bool
 }
 
@@ -20,23 +171,22 @@ func New(difficulty int, input [32]byte) *VDF {
 	return &VDF{
 		difficulty: difficulty,
 		input:      input,
-		outputChan: make(chan [32]byte),
+		outputChan: make(chan [Size]byte),
 	}
 }
 
 // GetOutputChannel returns the vdf output channel.
-func (vdf *VDF) GetOutputChannel() chan [32]byte {
+func (vdf *VDF) GetOutputChannel() chan [Size]byte {
 	return vdf.outputChan
 }
 
-// Execute runs the VDF until it's finished and put the result into output channel.
+// Execute runs the VDF until it's finished and put the output followed by its
+// proof into output channel.
 func (vdf *VDF) Execute() {
 	vdf.finished = false
-	tempResult := vdf.input
-	for i := 0; i < vdf.difficulty; i++ {
-		tempResult = sha3.Sum256(tempResult[:])
-	}
-	vdf.output = tempResult
+	output, proof := Evaluate(vdf.input, vdf.difficulty)
+	copy(vdf.output[:ElementSize], output[:])
+	copy(vdf.output[ElementSize:], proof[:])
 	go func() {
 		vdf.outputChan <- vdf.output
 	}()
@@ -48,7 +198,17 @@ func (vdf *VDF) IsFinished() bool {
 	return vdf.finished
 }
 
-// GetOutput returns the vdf output, which can be bytes of 0s is the vdf is not finished.
-func (vdf *VDF) GetOutput() [32]byte {
+// GetOutput returns the vdf output followed by its proof, which can be bytes
+// of 0s is the vdf is not finished.
+func (vdf *VDF) GetOutput() [Size]byte {
 	return vdf.output
 }
+
+// Verify checks the given output followed by its proof against the input and
+// the difficulty of the VDF.
+func (vdf *VDF) Verify(outputAndProof [Size]byte) bool {
+	output, proof := [ElementSize]byte{}, [ElementSize]byte{}
+	copy(output[:], outputAndProof[:ElementSize])
+	copy(proof[:], outputAndProof[ElementSize:])
+	return Verify(vdf.input, vdf.difficulty, output, proof)
+}
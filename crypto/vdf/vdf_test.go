@@ -0,0 +1,113 @@
+package vdf
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func testInput(difficulty int) [32]byte {
+	return sha256.Sum256([]byte(fmt.Sprintf("harmony %d", difficulty)))
+}
+
+// The vectors are the SHA-256 digests of the output followed by the proof, for
+// the inputs of testInput.
+var testVectors = []struct {
+	difficulty int
+	digest     string
+}{
+	{0, "569c9e28086be8b97582ef45e942986ad5893b08a2528b1c05fb1b5d69b0b5fd"},
+	{1, "4115fcac4632fbac6faf16e59950fc04b1ac63b4ce85c0b554f4debb76750e39"},
+	{10, "e9627085fed349c546a4df392a2dd6f02c3e420ccdb880928ca39576cdb5358f"},
+	{100, "974c29e49bfa504ca3e9846aed10f16240a070fc596f3d54c8005e3215a347b0"},
+	{1000, "0ed7658eef19d77631967fc32be4cc0f2d189b536f0abe16291ef9597b7f595e"},
+}
+
+func TestEvaluateVectors(t *testing.T) {
+	for _, test := range testVectors {
+		input := testInput(test.difficulty)
+		output, proof := Evaluate(input, test.difficulty)
+		digest := sha256.Sum256(append(output[:], proof[:]...))
+		if got := hex.EncodeToString(digest[:]); got != test.digest {
+			t.Errorf("difficulty %d: digest %s, want %s", test.difficulty, got, test.digest)
+		}
+		if !Verify(input, test.difficulty, output, proof) {
+			t.Errorf("difficulty %d: valid output rejected", test.difficulty)
+		}
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	input := testInput(100)
+	output, proof := Evaluate(input, 100)
+
+	if Verify(input, 99, output, proof) || Verify(input, 101, output, proof) {
+		t.Error("output accepted for another difficulty")
+	}
+	if Verify(testInput(101), 100, output, proof) {
+		t.Error("output accepted for another input")
+	}
+	badOutput := output
+	badOutput[ElementSize-1] ^= 1
+	if Verify(input, 100, badOutput, proof) {
+		t.Error("modified output accepted")
+	}
+	badProof := proof
+	badProof[ElementSize-1] ^= 1
+	if Verify(input, 100, output, badProof) {
+		t.Error("modified proof accepted")
+	}
+	// the other representative of the output in Z_N^* is not canonical
+	negOutput := [ElementSize]byte{}
+	y := new(big.Int).SetBytes(output[:])
+	putElement(negOutput[:], y.Sub(modulus, y))
+	if Verify(input, 100, negOutput, proof) {
+		t.Error("non canonical output accepted")
+	}
+	if Verify(input, 100, [ElementSize]byte{}, proof) {
+		t.Error("zero output accepted")
+	}
+}
+
+func TestVDF(t *testing.T) {
+	input := testInput(1000)
+	vdf := New(1000, input)
+	outputChannel := vdf.GetOutputChannel()
+	vdf.Execute()
+	outputAndProof := <-outputChannel
+	if !vdf.IsFinished() || vdf.GetOutput() != outputAndProof {
+		t.Error("wrong VDF state after execution")
+	}
+	if !New(1000, input).Verify(outputAndProof) {
+		t.Error("VDF output rejected")
+	}
+	if New(999, input).Verify(outputAndProof) {
+		t.Error("VDF output accepted for another difficulty")
+	}
+}
+
+func BenchmarkEvaluate10000(b *testing.B) {
+	input := testInput(10000)
+	for i := 0; i < b.N; i++ {
+		Evaluate(input, 10000)
+	}
+}
+
+// BenchmarkEvaluate50000 evaluates the VDF at the mainnet difficulty.
+func BenchmarkEvaluate50000(b *testing.B) {
+	input := testInput(50000)
+	for i := 0; i < b.N; i++ {
+		Evaluate(input, 50000)
+	}
+}
+
+func BenchmarkVerify(b *testing.B) {
+	input := testInput(10000)
+	output, proof := Evaluate(input, 10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Verify(input, 10000, output, proof)
+	}
+}
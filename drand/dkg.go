@@ -0,0 +1,383 @@
+package drand
+
+import (
+	"encoding/binary"
+	"errors"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/harmony-one/bls/ffi/go/bls"
+
+	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/crypto/hash"
+	"github.com/harmony-one/harmony/internal/ctxerror"
+)
+
+// Domain separation tags of the hashes signed or derived by the protocol.
+const (
+	dealTag       = "harmony drand deal"
+	shareTag      = "harmony drand share"
+	randomnessTag = "harmony drand randomness"
+)
+
+// Threshold returns the number of signature shares needed to produce the
+// randomness of a committee of the given size, which is also the number of
+// qualified dealers needed to generate its group key.  Like the consensus
+// quorum, it is more than two thirds of the committee.
+func Threshold(committeeSize int) int {
+	return committeeSize*2/3 + 1
+}
+
+// deal is the contribution of one committee member, the dealer, to the group
+// key of an epoch: the commitments to the coefficients of a random
+// polynomial, whose constant term is the dealer's part of the group secret,
+// and the value of the polynomial at each member's ID, encrypted for that
+// member.
+type deal struct {
+	Epoch       uint64
+	Index       uint32   // index of the dealer in the committee
+	Commitments [][]byte // public keys of the coefficients
+	Shares      [][]byte // encrypted shares, by member index
+	Signature   []byte   // signature of the dealer over the commitments
+}
+
+// partialSignature is the signature of a committee member over the seed of
+// the epoch with its share of the group secret.
+type partialSignature struct {
+	Epoch     uint64
+	Index     uint32 // index of the signer in the committee
+	Signature []byte
+}
+
+// signRequest is the set of qualified dealers chosen by the leader of the
+// epoch, whose deals make up the group key.
+type signRequest struct {
+	Epoch      uint64
+	Dealers    []uint32 // indexes of the dealers, in increasing order
+	DealHashes []common.Hash
+}
+
+// complaint is sent by a member whose share of a deal does not match the
+// commitments of the deal.
+type complaint struct {
+	Epoch  uint64
+	Dealer uint32
+	Member uint32 // index of the complaining member
+}
+
+// justification answers a complaint: the dealer reveals the share it dealt
+// the complaining member, for everyone to check against the commitments.
+type justification struct {
+	Epoch  uint64
+	Dealer uint32
+	Member uint32
+	Share  []byte
+}
+
+// memberID returns the ID of the committee member with the given index, at
+// which its share of the polynomials is evaluated.  IDs start at 1, the
+// group secret being the value at 0.
+func memberID(index int) (*bls.ID, error) {
+	id := &bls.ID{}
+	if err := id.SetDecString(strconv.Itoa(index + 1)); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+func encodeUint64(n uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, n)
+	return b
+}
+
+func encodeUint32(n uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, n)
+	return b
+}
+
+// seedHash returns the hash the committee signs with the group key.
+func seedHash(epoch uint64, seed common.Hash) []byte {
+	return hash.Keccak256([]byte(randomnessTag), encodeUint64(epoch), seed[:])
+}
+
+// commitmentsHash returns the hash of the commitments to the non-constant
+// coefficients.
+func commitmentsHash(commitments [][]byte) common.Hash {
+	if len(commitments) < 2 {
+		return hash.Keccak256Hash()
+	}
+	return hash.Keccak256Hash(commitments[1:]...)
+}
+
+// dealHash returns the hash a dealer signs to vouch for its commitments.
+func dealHash(
+	epoch uint64, seed common.Hash, index uint32,
+	commitment []byte, commitmentsHash common.Hash,
+) []byte {
+	return hash.Keccak256(
+		[]byte(dealTag), encodeUint64(epoch), seed[:], encodeUint32(index),
+		commitment, commitmentsHash[:],
+	)
+}
+
+// sharePad returns the one-time pad encrypting the share of a deal for a
+// member, derived from the Diffie-Hellman key of the dealer and the member
+// BLS keys.  Either side computes it from its secret key and the public key
+// of the other.
+func sharePad(
+	sec *bls.SecretKey, pub *bls.PublicKey,
+	epoch uint64, dealer, member uint32,
+) ([]byte, error) {
+	var point bls.G1
+	var scalar bls.Fr
+	if err := point.Deserialize(pub.Serialize()); err != nil {
+		return nil, err
+	}
+	if err := scalar.Deserialize(sec.Serialize()); err != nil {
+		return nil, err
+	}
+	bls.G1Mul(&point, &point, &scalar)
+	return hash.Keccak256(
+		[]byte(shareTag), point.Serialize(), encodeUint64(epoch),
+		encodeUint32(dealer), encodeUint32(member),
+	), nil
+}
+
+func xorBytes(a, b []byte) []byte {
+	out := make([]byte, len(a))
+	for i := range a {
+		out[i] = a[i] ^ b[i%len(b)]
+	}
+	return out
+}
+
+// newDeal deals a random secret to the committee, as its member of the given
+// index with the given key.
+func newDeal(
+	priKey *bls.SecretKey, index uint32, committee []*bls.PublicKey,
+	epoch uint64, seed common.Hash,
+) (*deal, error) {
+	var secret bls.SecretKey
+	secret.SetByCSPRNG()
+	coefficients := secret.GetMasterSecretKey(Threshold(len(committee)))
+	d := &deal{Epoch: epoch, Index: index}
+	for _, commitment := range bls.GetMasterPublicKey(coefficients) {
+		d.Commitments = append(d.Commitments, commitment.Serialize())
+	}
+	for member, pubKey := range committee {
+		id, err := memberID(member)
+		if err != nil {
+			return nil, err
+		}
+		var share bls.SecretKey
+		if err := share.Set(coefficients, id); err != nil {
+			return nil, err
+		}
+		pad, err := sharePad(priKey, pubKey, epoch, index, uint32(member))
+		if err != nil {
+			return nil, err
+		}
+		d.Shares = append(d.Shares, xorBytes(share.Serialize(), pad))
+	}
+	d.Signature = priKey.SignHash(dealHash(
+		epoch, seed, index, d.Commitments[0], commitmentsHash(d.Commitments),
+	)).Serialize()
+	return d, nil
+}
+
+// publicKeys deserializes the commitments of the deal.
+func (d *deal) publicKeys() ([]bls.PublicKey, error) {
+	pubKeys := make([]bls.PublicKey, len(d.Commitments))
+	for i, commitment := range d.Commitments {
+		if err := pubKeys[i].Deserialize(commitment); err != nil {
+			return nil, err
+		}
+	}
+	return pubKeys, nil
+}
+
+// verify checks the shape of the deal and the signature of its dealer.  The
+// shares can only be checked by their recipients.
+func (d *deal) verify(committee []*bls.PublicKey, seed common.Hash) error {
+	if int(d.Index) >= len(committee) {
+		return errors.New("dealer not in committee")
+	}
+	if len(d.Commitments) != Threshold(len(committee)) {
+		return errors.New("wrong number of commitments")
+	}
+	if len(d.Shares) != len(committee) {
+		return errors.New("wrong number of shares")
+	}
+	if _, err := d.publicKeys(); err != nil {
+		return err
+	}
+	return verifyDealer(d.dealer(), d.Epoch, seed, committee[d.Index])
+}
+
+// decryptShare decrypts the share of the deal for the member of the given
+// index with the given key.
+func (d *deal) decryptShare(
+	priKey *bls.SecretKey, member int, committee []*bls.PublicKey,
+) (*bls.SecretKey, error) {
+	return d.openShare(priKey, committee[d.Index], member)
+}
+
+// revealShare decrypts the share of the deal for the member of the given
+// index with the key of the dealer, to answer a complaint of the member.
+func (d *deal) revealShare(
+	priKey *bls.SecretKey, member int, committee []*bls.PublicKey,
+) (*bls.SecretKey, error) {
+	return d.openShare(priKey, committee[member], member)
+}
+
+// openShare decrypts the share of the deal for the member of the given index
+// with the pad shared by the given key and the public key of the other end.
+func (d *deal) openShare(
+	priKey *bls.SecretKey, pubKey *bls.PublicKey, member int,
+) (*bls.SecretKey, error) {
+	pad, err := sharePad(priKey, pubKey, d.Epoch, d.Index, uint32(member))
+	if err != nil {
+		return nil, err
+	}
+	share := &bls.SecretKey{}
+	if err := share.Deserialize(xorBytes(d.Shares[member], pad)); err != nil {
+		return nil, err
+	}
+	return share, nil
+}
+
+// verifyShare checks the share of the member of the given index against the
+// commitments to the polynomial it is a value of.
+func verifyShare(share *bls.SecretKey, commitments []bls.PublicKey, member int) error {
+	expected, err := memberPublicKey(commitments, member)
+	if err != nil {
+		return err
+	}
+	if !share.GetPublicKey().IsEqual(expected) {
+		return errors.New("share does not match the commitments")
+	}
+	return nil
+}
+
+// dealer returns the part of the deal recorded with the randomness.
+func (d *deal) dealer() types.BeaconDealer {
+	return types.BeaconDealer{
+		Index:           d.Index,
+		Commitment:      d.Commitments[0],
+		CommitmentsHash: commitmentsHash(d.Commitments),
+		Signature:       d.Signature,
+	}
+}
+
+// groupCommitments sums the commitments of the qualified deals, i.e. commits
+// to the polynomial whose values are the members' shares of the group
+// secret.  Its first element is the group public key.
+func groupCommitments(deals []*deal) ([]bls.PublicKey, error) {
+	var sum []bls.PublicKey
+	for _, d := range deals {
+		commitments, err := d.publicKeys()
+		if err != nil {
+			return nil, err
+		}
+		if sum == nil {
+			sum = commitments
+			continue
+		}
+		if len(commitments) != len(sum) {
+			return nil, errors.New("deals of different thresholds")
+		}
+		for i := range sum {
+			sum[i].Add(&commitments[i])
+		}
+	}
+	if sum == nil {
+		return nil, errors.New("no deal")
+	}
+	return sum, nil
+}
+
+// memberPublicKey returns the public key of the share of the group secret of
+// the member of the given index.
+func memberPublicKey(commitments []bls.PublicKey, member int) (*bls.PublicKey, error) {
+	id, err := memberID(member)
+	if err != nil {
+		return nil, err
+	}
+	pubKey := &bls.PublicKey{}
+	if err := pubKey.Set(commitments, id); err != nil {
+		return nil, err
+	}
+	return pubKey, nil
+}
+
+// verifyDealer checks the signature of a dealer over its commitments.
+func verifyDealer(
+	dealer types.BeaconDealer, epoch uint64, seed common.Hash,
+	dealerKey *bls.PublicKey,
+) error {
+	var sig bls.Sign
+	if err := sig.Deserialize(dealer.Signature); err != nil {
+		return err
+	}
+	hash := dealHash(epoch, seed, dealer.Index, dealer.Commitment, dealer.CommitmentsHash)
+	if !sig.VerifyHash(dealerKey, hash) {
+		return errors.New("invalid dealer signature")
+	}
+	return nil
+}
+
+// VerifyRandomness checks the output of the randomness beacon against the
+// committee of its epoch: the dealers must be a threshold of distinct
+// committee members vouching for their commitments, and the signature must
+// be the signature over the seed under the group key they generated.
+func VerifyRandomness(randomness *types.BeaconRandomness, committee []*bls.PublicKey) error {
+	if randomness.Epoch == nil || !randomness.Epoch.IsUint64() {
+		return ctxerror.New("invalid randomness epoch")
+	}
+	epoch := randomness.Epoch.Uint64()
+	if len(randomness.Dealers) < Threshold(len(committee)) {
+		return ctxerror.New("not enough dealers",
+			"epoch", epoch,
+			"numDealers", len(randomness.Dealers),
+			"threshold", Threshold(len(committee)))
+	}
+	var groupKey *bls.PublicKey
+	for i, dealer := range randomness.Dealers {
+		if int(dealer.Index) >= len(committee) ||
+			(i > 0 && dealer.Index <= randomness.Dealers[i-1].Index) {
+			return ctxerror.New("invalid dealer index",
+				"epoch", epoch,
+				"dealerIndex", dealer.Index)
+		}
+		if err := verifyDealer(dealer, epoch, randomness.Seed, committee[dealer.Index]); err != nil {
+			return ctxerror.New("invalid dealer",
+				"epoch", epoch,
+				"dealerIndex", dealer.Index,
+			).WithCause(err)
+		}
+		var commitment bls.PublicKey
+		if err := commitment.Deserialize(dealer.Commitment); err != nil {
+			return ctxerror.New("invalid dealer commitment",
+				"epoch", epoch,
+				"dealerIndex", dealer.Index,
+			).WithCause(err)
+		}
+		if groupKey == nil {
+			groupKey = &commitment
+		} else {
+			groupKey.Add(&commitment)
+		}
+	}
+	var sig bls.Sign
+	if err := sig.Deserialize(randomness.Signature); err != nil {
+		return ctxerror.New("invalid randomness signature",
+			"epoch", epoch,
+		).WithCause(err)
+	}
+	if !sig.VerifyHash(groupKey, seedHash(epoch, randomness.Seed)) {
+		return ctxerror.New("wrong randomness signature", "epoch", epoch)
+	}
+	return nil
+}
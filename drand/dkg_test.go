@@ -0,0 +1,199 @@
+package drand
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/harmony-one/bls/ffi/go/bls"
+
+	"github.com/harmony-one/harmony/core/types"
+	bls2 "github.com/harmony-one/harmony/crypto/bls"
+)
+
+func newTestCommittee(size int) ([]*bls.SecretKey, []*bls.PublicKey) {
+	priKeys := []*bls.SecretKey{}
+	committee := []*bls.PublicKey{}
+	for i := 0; i < size; i++ {
+		priKeys = append(priKeys, bls2.RandPrivateKey())
+		committee = append(committee, priKeys[i].GetPublicKey())
+	}
+	return priKeys, committee
+}
+
+// dealAll returns the deals of the given dealers of the committee.
+func dealAll(
+	t *testing.T, priKeys []*bls.SecretKey, committee []*bls.PublicKey,
+	epoch uint64, seed common.Hash, dealers []int,
+) []*deal {
+	deals := []*deal{}
+	for _, index := range dealers {
+		d, err := newDeal(priKeys[index], uint32(index), committee, epoch, seed)
+		if err != nil {
+			t.Fatalf("cannot deal: %v", err)
+		}
+		if err := d.verify(committee, seed); err != nil {
+			t.Fatalf("invalid deal: %v", err)
+		}
+		deals = append(deals, d)
+	}
+	return deals
+}
+
+// thresholdSign signs the seed with the shares of the given deals of the
+// given signers, and returns the recovered randomness.
+func thresholdSign(
+	t *testing.T, priKeys []*bls.SecretKey, committee []*bls.PublicKey,
+	deals []*deal, epoch uint64, seed common.Hash, signers []int,
+) *types.BeaconRandomness {
+	commitments, err := groupCommitments(deals)
+	if err != nil {
+		t.Fatalf("cannot sum commitments: %v", err)
+	}
+	sigs, ids := []bls.Sign{}, []bls.ID{}
+	for _, member := range signers {
+		var secret *bls.SecretKey
+		for _, d := range deals {
+			share, err := d.decryptShare(priKeys[member], member, committee)
+			if err != nil {
+				t.Fatalf("cannot decrypt share: %v", err)
+			}
+			if secret == nil {
+				secret = share
+			} else {
+				secret.Add(share)
+			}
+		}
+		if err := verifyShare(secret, commitments, member); err != nil {
+			t.Fatalf("invalid share of member %d: %v", member, err)
+		}
+		sig := secret.SignHash(seedHash(epoch, seed))
+		pubKey, err := memberPublicKey(commitments, member)
+		if err != nil || !sig.VerifyHash(pubKey, seedHash(epoch, seed)) {
+			t.Fatalf("invalid signature share of member %d", member)
+		}
+		id, _ := memberID(member)
+		sigs, ids = append(sigs, *sig), append(ids, *id)
+	}
+	var sig bls.Sign
+	if err := sig.Recover(sigs, ids); err != nil {
+		t.Fatalf("cannot recover signature: %v", err)
+	}
+	randomness := &types.BeaconRandomness{
+		Epoch:     new(big.Int).SetUint64(epoch),
+		Seed:      seed,
+		Signature: sig.Serialize(),
+	}
+	for _, d := range deals {
+		randomness.Dealers = append(randomness.Dealers, d.dealer())
+	}
+	return randomness
+}
+
+func TestThresholdRandomness(t *testing.T) {
+	priKeys, committee := newTestCommittee(7)
+	if Threshold(len(committee)) != 5 {
+		t.Fatalf("threshold of 7 members is %d, want 5", Threshold(len(committee)))
+	}
+	seed := common.Hash{1, 2, 3}
+	deals := dealAll(t, priKeys, committee, 3, seed, []int{0, 1, 2, 4, 6})
+
+	randomness := thresholdSign(t, priKeys, committee, deals, 3, seed, []int{0, 1, 2, 3, 4})
+	if err := VerifyRandomness(randomness, committee); err != nil {
+		t.Fatalf("valid randomness rejected: %v", err)
+	}
+	// the threshold signature is unique, whoever signs
+	other := thresholdSign(t, priKeys, committee, deals, 3, seed, []int{6, 5, 4, 3, 2, 1})
+	if !bytes.Equal(other.Signature, randomness.Signature) || other.Randomness() != randomness.Randomness() {
+		t.Errorf("randomness depends on the signers")
+	}
+}
+
+func TestVerifyRandomnessRejects(t *testing.T) {
+	priKeys, committee := newTestCommittee(4)
+	seed := common.Hash{4, 5, 6}
+	deals := dealAll(t, priKeys, committee, 7, seed, []int{0, 1, 3})
+	randomness := thresholdSign(t, priKeys, committee, deals, 7, seed, []int{0, 2, 3})
+	if err := VerifyRandomness(randomness, committee); err != nil {
+		t.Fatalf("valid randomness rejected: %v", err)
+	}
+
+	tests := map[string]func(r *types.BeaconRandomness){
+		"other epoch": func(r *types.BeaconRandomness) { r.Epoch = big.NewInt(8) },
+		"other seed":  func(r *types.BeaconRandomness) { r.Seed = common.Hash{7} },
+		"too few dealers": func(r *types.BeaconRandomness) {
+			r.Dealers = r.Dealers[1:]
+		},
+		"unsorted dealers": func(r *types.BeaconRandomness) {
+			r.Dealers[0], r.Dealers[1] = r.Dealers[1], r.Dealers[0]
+		},
+		"duplicate dealer": func(r *types.BeaconRandomness) {
+			r.Dealers[1] = r.Dealers[0]
+		},
+		"foreign dealer": func(r *types.BeaconRandomness) {
+			r.Dealers[2].Index = 2
+		},
+		"other signature": func(r *types.BeaconRandomness) {
+			r.Signature = priKeys[0].SignHash(seedHash(7, seed)).Serialize()
+		},
+	}
+	for name, tamper := range tests {
+		tampered := *randomness
+		tampered.Dealers = append([]types.BeaconDealer{}, randomness.Dealers...)
+		tamper(&tampered)
+		if VerifyRandomness(&tampered, committee) == nil {
+			t.Errorf("%s: invalid randomness accepted", name)
+		}
+	}
+}
+
+func TestDealShareEncryption(t *testing.T) {
+	priKeys, committee := newTestCommittee(4)
+	seed := common.Hash{8}
+	d := dealAll(t, priKeys, committee, 1, seed, []int{0})[0]
+	commitments, err := d.publicKeys()
+	if err != nil {
+		t.Fatalf("invalid commitments: %v", err)
+	}
+	for member := range committee {
+		share, err := d.decryptShare(priKeys[member], member, committee)
+		if err != nil || verifyShare(share, commitments, member) != nil {
+			t.Errorf("member %d cannot decrypt its share", member)
+		}
+		// another member cannot read it
+		other := (member + 1) % len(committee)
+		share, err = d.decryptShare(priKeys[other], member, committee)
+		if err == nil && verifyShare(share, commitments, member) == nil {
+			t.Errorf("member %d decrypted the share of member %d", other, member)
+		}
+	}
+	// the deal is bound to its seed
+	if d.verify(committee, common.Hash{9}) == nil {
+		t.Errorf("deal accepted for another seed")
+	}
+}
+
+func TestRevealShare(t *testing.T) {
+	priKeys, committee := newTestCommittee(4)
+	seed := common.Hash{8}
+	d := dealAll(t, priKeys, committee, 1, seed, []int{0})[0]
+	commitments, err := d.publicKeys()
+	if err != nil {
+		t.Fatalf("invalid commitments: %v", err)
+	}
+	for member := range committee {
+		share, err := d.revealShare(priKeys[0], member, committee)
+		if err != nil || verifyShare(share, commitments, member) != nil {
+			t.Errorf("dealer cannot reveal the share of member %d", member)
+		}
+	}
+	// a tampered share is caught by the member, and by everyone once revealed
+	d.Shares[2] = xorBytes(d.Shares[2], []byte{1})
+	if share, err := d.decryptShare(priKeys[2], 2, committee); err == nil && verifyShare(share, commitments, 2) == nil {
+		t.Errorf("tampered share accepted")
+	}
+	if share, err := d.revealShare(priKeys[0], 2, committee); err == nil && verifyShare(share, commitments, 2) == nil {
+		t.Errorf("tampered share accepted when revealed")
+	}
+}
@@ -2,32 +2,44 @@ package drand
 
 import (
 	"errors"
+	"sort"
 	"sync"
 
 	"github.com/harmony-one/harmony/crypto/hash"
 
+	"github.com/ethereum/go-ethereum/common"
 	protobuf "github.com/golang/protobuf/proto"
 	"github.com/harmony-one/bls/ffi/go/bls"
 	msg_pb "github.com/harmony-one/harmony/api/proto/message"
+	"github.com/harmony-one/harmony/core"
 	"github.com/harmony-one/harmony/core/types"
-	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
-	"github.com/harmony-one/harmony/crypto/vrf"
-	"github.com/harmony-one/harmony/crypto/vrf/p256"
 	"github.com/harmony-one/harmony/p2p"
 )
 
 // DRand is the main struct which contains state for the distributed randomness protocol.
+//
+// Once per epoch, the committee of the shard runs a distributed key
+// generation: on committing the first block of the epoch, every member deals
+// shares of a random secret to the others.  A member receiving a share which
+// does not match the commitments of its deal complains, and the dealer must
+// answer by revealing the share, or be left out.  The leader of the epoch
+// picks the qualified dealers once enough deals arrived; if it does not in
+// time, the next member of the committee takes over.  The members then sign
+// the hash of the first block with their share of the group secret, and any
+// node collecting a threshold of the signature shares recovers the threshold
+// signature, whose hash is the randomness of the epoch.  The leader of the
+// beacon chain commits the output, along with what it takes to verify it, in
+// a block.
 type DRand struct {
-	vrfs                  *map[string][]byte // Key is the address hex
-	bitmap                *bls_cosi.Mask
-	pRand                 *[32]byte
-	rand                  *[32]byte
 	ConfirmedBlockChannel chan *types.Block // Channel to receive confirmed blocks
-	PRndChannel           chan []byte       // Channel to send pRnd (preimage of randomness resulting from combined vrf randomnesses) to consensus. The first 32 bytes are randomness, the rest is for bitmap.
-	RndChannel            chan [64]byte     // Channel for DRG protocol to send the final randomness to consensus. The first 32 bytes are the randomness and the last 32 bytes are the hash of the block where the corresponding pRnd was generated
+
+	// Chain is the beacon chain, which commits the randomness of each epoch
+	Chain *core.BlockChain
 
 	// global consensus mutex
 	mutex sync.Mutex
+	// the randomness generation of the latest epoch
+	round *round
 
 	// Leader's address
 	leader p2p.Peer
@@ -41,12 +53,8 @@ type DRand struct {
 	// private/public keys of current node
 	priKey *bls.SecretKey
 	pubKey *bls.PublicKey
-	// VRF private and public key
-	// TODO: directly use signature signing key (BLS) for vrf
-	vrfPriKey *vrf.PrivateKey
-	vrfPubKey *vrf.PublicKey
 
-	// Whether I am leader. False means I am validator
+	// Whether I am leader of the latest epoch. False means I am validator
 	IsLeader bool
 
 	// Leader or validator address
@@ -57,9 +65,73 @@ type DRand struct {
 
 	// Shard Id which this node belongs to
 	ShardID uint32
+}
+
+// round is the state of the randomness generation for one epoch.
+type round struct {
+	epoch     uint64
+	seed      common.Hash // hash of the first block of the epoch, zero until committed
+	committee []*bls.PublicKey
+	index     int // index of this node in the committee, -1 if not a member
+	leader    int // index of the current leader of the epoch
+	// leaders of the epoch so far, whose signature requests are accepted
+	leaders map[int]bool
+
+	pendingDeals map[uint32]*deal // deals received before the seed is known
+	deals        map[uint32]*deal
+	dealTimedOut bool // whether the leader stopped waiting for all deals
+
+	// complaints against dealers, by dealer and complaining member, true
+	// until the dealer answers them
+	complaints   map[uint32]map[uint32]bool
+	disqualified map[uint32]bool           // dealers who revealed a wrong share
+	justified    map[uint32]*bls.SecretKey // this node's shares revealed by their dealers
+
+	request     *signRequest
+	requestSeed common.Hash
+	commitments []bls.PublicKey // sum of the commitments of the qualified deals
+	signed      bool
+
+	pendingPartials map[uint32]*bls.Sign // shares received before the commitments
+	partials        map[uint32]*bls.Sign // verified signature shares
+	done            bool
+	output          *types.BeaconRandomness
+}
+
+// started returns whether this node committed the first block of the epoch.
+func (r *round) started() bool {
+	return r.seed != (common.Hash{})
+}
+
+// qualifiedDealers returns the indexes of the dealers whose deals arrived
+// and who answered every complaint against them, in increasing order.
+func (r *round) qualifiedDealers() []uint32 {
+	dealers := []uint32{}
+	for index := range r.deals {
+		if r.disqualified[index] {
+			continue
+		}
+		answered := true
+		for _, unanswered := range r.complaints[index] {
+			answered = answered && !unanswered
+		}
+		if answered {
+			dealers = append(dealers, index)
+		}
+	}
+	sort.Slice(dealers, func(i, j int) bool { return dealers[i] < dealers[j] })
+	return dealers
+}
 
-	// Blockhash - 32 byte
-	blockHash [32]byte
+// nextLeader hands the leadership of the epoch over to the next member of
+// the committee.  It returns false once every member had its turn.
+func (r *round) nextLeader() bool {
+	if len(r.leaders) >= len(r.committee) {
+		return false
+	}
+	r.leader = (r.leader + 1) % len(r.committee)
+	r.leaders[r.leader] = true
+	return true
 }
 
 // New creates a new dRand object
@@ -71,9 +143,6 @@ func New(host p2p.Host, ShardID uint32, peers []p2p.Peer, leader p2p.Peer, confi
 		dRand.ConfirmedBlockChannel = confirmedBlockChannel
 	}
 
-	dRand.PRndChannel = make(chan []byte)
-	dRand.RndChannel = make(chan [64]byte)
-
 	selfPeer := host.GetSelfPeer()
 	if leader.Port == selfPeer.Port && leader.IP == selfPeer.IP {
 		dRand.IsLeader = true
@@ -87,9 +156,6 @@ func New(host p2p.Host, ShardID uint32, peers []p2p.Peer, leader p2p.Peer, confi
 		dRand.CommitteePublicKeys[peer.ConsensusPubKey.SerializeToHexStr()] = true
 	}
 
-	dRand.vrfs = &map[string][]byte{}
-
-	// Initialize cosign bitmap
 	allPublicKeys := make([]*bls.PublicKey, 0)
 	for _, validatorPeer := range peers {
 		allPublicKeys = append(allPublicKeys, validatorPeer.ConsensusPubKey)
@@ -98,12 +164,6 @@ func New(host p2p.Host, ShardID uint32, peers []p2p.Peer, leader p2p.Peer, confi
 
 	dRand.PublicKeys = allPublicKeys
 
-	bitmap, _ := bls_cosi.NewMask(dRand.PublicKeys, dRand.leader.ConsensusPubKey)
-	dRand.bitmap = bitmap
-
-	dRand.pRand = nil
-	dRand.rand = nil
-
 	// For now use socket address as ID
 	dRand.SelfAddress = selfPeer.ConsensusPubKey.SerializeToHexStr()
 
@@ -112,11 +172,6 @@ func New(host p2p.Host, ShardID uint32, peers []p2p.Peer, leader p2p.Peer, confi
 		dRand.priKey = blsPriKey
 		dRand.pubKey = blsPriKey.GetPublicKey()
 	}
-
-	// VRF keys
-	priKey, pubKey := p256.GenerateKey()
-	dRand.vrfPriKey = &priKey
-	dRand.vrfPubKey = &pubKey
 	dRand.ShardID = ShardID
 
 	return &dRand
@@ -151,11 +206,6 @@ func (dRand *DRand) signAndMarshalDRandMessage(message *msg_pb.Message) ([]byte,
 	return marshaledMessage, nil
 }
 
-func (dRand *DRand) vrf(blockHash [32]byte) (rand [32]byte, proof []byte) {
-	rand, proof = (*dRand.vrfPriKey).Evaluate(blockHash[:])
-	return
-}
-
 // Verify the signature of the message are valid from the signer's public key.
 func verifyMessageSig(signerPubKey *bls.PublicKey, message *msg_pb.Message) error {
 	signature := message.Signature
@@ -185,12 +235,9 @@ func (dRand *DRand) IsValidatorInCommittee(validatorBlsPubKey string) bool {
 
 // ResetState resets the state of the randomness protocol
 func (dRand *DRand) ResetState() {
-	dRand.vrfs = &map[string][]byte{}
-
-	bitmap, _ := bls_cosi.NewMask(dRand.PublicKeys, dRand.leader.ConsensusPubKey)
-	dRand.bitmap = bitmap
-	dRand.pRand = nil
-	dRand.rand = nil
+	dRand.mutex.Lock()
+	defer dRand.mutex.Unlock()
+	dRand.round = nil
 }
 
 // SetLeaderPubKey deserialize the public key of drand leader
@@ -1,61 +1,38 @@
 package drand
 
 import (
-	"bytes"
+	"math/big"
 	"time"
 
-	"github.com/harmony-one/harmony/crypto/bls"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/harmony-one/bls/ffi/go/bls"
 
-	protobuf "github.com/golang/protobuf/proto"
-	msg_pb "github.com/harmony-one/harmony/api/proto/message"
 	"github.com/harmony-one/harmony/core"
 	"github.com/harmony-one/harmony/core/types"
-	"github.com/harmony-one/harmony/crypto/vdf"
-	"github.com/harmony-one/harmony/crypto/vrf/p256"
+	"github.com/harmony-one/harmony/crypto/hash"
 	"github.com/harmony-one/harmony/internal/utils"
-	"github.com/harmony-one/harmony/p2p"
-	"github.com/harmony-one/harmony/p2p/host"
 )
 
 const (
-	vdfDifficulty = 5000000 // This takes about 20s to finish the vdf
+	// dealTimeout is how long the leader waits for the deals of the whole
+	// committee before requesting signatures from a threshold of deals.
+	dealTimeout = 30 * time.Second
+	// requestTimeout is how long the members wait for the signature request
+	// of the leader, after the deal timeout, before the next member of the
+	// committee takes over.
+	requestTimeout = 20 * time.Second
 )
 
-// WaitForEpochBlock waits for the first epoch block to run DRG on
+// WaitForEpochBlock waits for the first block of each epoch to run DRG on
 func (dRand *DRand) WaitForEpochBlock(blockChannel chan *types.Block, stopChan chan struct{}, stoppedChan chan struct{}) {
 	go func() {
 		defer close(stoppedChan)
 		for {
 			select {
-			default:
-				// keep waiting for epoch block
-				newBlock := <-blockChannel
-				if core.IsEpochLastBlock(newBlock) {
+			case newBlock := <-blockChannel:
+				if core.IsEpochBlock(newBlock) {
 					dRand.init(newBlock)
 				}
-				// TODO: use real vrf
-				pRnd := [32]byte{} //newBlock.Header().Vrf
-				zeros := [32]byte{}
-				if core.IsEpochBlock(newBlock) && !bytes.Equal(pRnd[:], zeros[:]) {
-					// The epoch block should contain the randomness preimage pRnd
-					go func() {
-						vdf := vdf.New(vdfDifficulty, pRnd)
-						outputChannel := vdf.GetOutputChannel()
-						start := time.Now()
-						vdf.Execute()
-						duration := time.Now().Sub(start)
-						utils.Logger().Info().Dur("duration", duration).Msg("VDF computation finished")
-						output := <-outputChannel
-
-						rndBytes := [64]byte{} // The first 32 bytes are the randomness and the last 32 bytes are the hash of the block where the corresponding pRnd was generated
-						copy(rndBytes[:32], output[:])
-
-						blockHash := newBlock.Hash()
-						copy(rndBytes[32:], blockHash[:])
-
-						dRand.RndChannel <- rndBytes
-					}()
-				}
 			case <-stopChan:
 				return
 			}
@@ -63,140 +40,245 @@ func (dRand *DRand) WaitForEpochBlock(blockChannel chan *types.Block, stopChan c
 	}()
 }
 
-func (dRand *DRand) init(epochBlock *types.Block) {
-	utils.Logger().Debug().Msg("INITING DRAND")
-	dRand.ResetState()
-	// Copy over block hash and block header data
-	blockHash := epochBlock.Hash()
-	copy(dRand.blockHash[:], blockHash[:])
-
-	msgToSend := dRand.constructInitMessage()
-
-	// Leader commit vrf itself
-	rand, proof := dRand.vrf(dRand.blockHash)
+// roundFor returns the randomness generation of the given epoch, starting a
+// new one for an epoch later than the latest.  It returns nil for past
+// epochs, and for epochs too far ahead of the chain to trust their
+// committee.
+func (dRand *DRand) roundFor(epoch uint64) *round {
+	if dRand.round != nil {
+		switch {
+		case epoch == dRand.round.epoch:
+			return dRand.round
+		case epoch < dRand.round.epoch:
+			return nil
+		}
+	}
+	if dRand.Chain != nil && epoch > dRand.Chain.CurrentHeader().Epoch().Uint64()+1 {
+		return nil
+	}
+	committee := core.GetPublicKeys(new(big.Int).SetUint64(epoch), dRand.ShardID)
+	if len(committee) == 0 {
+		return nil
+	}
+	leader := int(epoch % uint64(len(committee)))
+	r := &round{
+		epoch:           epoch,
+		committee:       committee,
+		index:           -1,
+		leader:          leader,
+		leaders:         map[int]bool{leader: true},
+		pendingDeals:    map[uint32]*deal{},
+		deals:           map[uint32]*deal{},
+		complaints:      map[uint32]map[uint32]bool{},
+		disqualified:    map[uint32]bool{},
+		justified:       map[uint32]*bls.SecretKey{},
+		pendingPartials: map[uint32]*bls.Sign{},
+		partials:        map[uint32]*bls.Sign{},
+	}
+	for i, pubKey := range committee {
+		if dRand.pubKey != nil && pubKey.IsEqual(dRand.pubKey) {
+			r.index = i
+		}
+	}
+	dRand.round = r
+	return r
+}
 
-	(*dRand.vrfs)[dRand.SelfAddress] = append(rand[:], proof...)
+// init starts the randomness generation of the epoch of the given block, its
+// first one: it deals this node's shares to the committee.
+func (dRand *DRand) init(epochBlock *types.Block) {
+	dRand.mutex.Lock()
+	defer dRand.mutex.Unlock()
 
+	r := dRand.roundFor(epochBlock.Epoch().Uint64())
+	if r == nil || r.started() {
+		return
+	}
+	r.seed = epochBlock.Hash()
+	dRand.IsLeader = r.index >= 0 && r.index == r.leader
 	utils.Logger().Info().
-		Hex("msg", msgToSend).
-		Str("leader.PubKey", dRand.leader.ConsensusPubKey.SerializeToHexStr()).
-		Msg("[DRG] sent init")
-	dRand.host.SendMessageToGroups([]p2p.GroupID{p2p.NewGroupIDByShardID(p2p.ShardID(dRand.ShardID))}, host.ConstructP2pMessage(byte(17), msgToSend))
-}
+		Uint64("epoch", r.epoch).
+		Int("index", r.index).
+		Int("leader", r.leader).
+		Int("committeeSize", len(r.committee)).
+		Msg("[DRG] Started randomness generation")
 
-// ProcessMessageLeader dispatches messages for the leader to corresponding processors.
-func (dRand *DRand) ProcessMessageLeader(payload []byte) {
-	message := &msg_pb.Message{}
-	err := protobuf.Unmarshal(payload, message)
+	for _, d := range r.pendingDeals {
+		dRand.addDeal(r, d)
+	}
+	r.pendingDeals = map[uint32]*deal{}
+	if r.request != nil && r.requestSeed != r.seed {
+		r.request = nil
+	}
 
-	if err != nil {
-		utils.Logger().Error().Err(err).Interface("dRand", dRand).Msg("Failed to unmarshal message payload")
+	if r.index >= 0 {
+		d, err := newDeal(dRand.priKey, uint32(r.index), r.committee, r.epoch, r.seed)
+		if err != nil {
+			utils.Logger().Error().Err(err).Uint64("epoch", r.epoch).Msg("[DRG] Failed to deal shares")
+		} else {
+			dRand.addDeal(r, d)
+			dRand.broadcast(dRand.constructCommitMessage(r.seed, dealPayload, d))
+		}
 	}
+	time.AfterFunc(dealTimeout, func() {
+		dRand.mutex.Lock()
+		defer dRand.mutex.Unlock()
+		r.dealTimedOut = true
+		dRand.progress(r)
+	})
+	dRand.scheduleLeaderChange(r, dealTimeout+requestTimeout)
+	dRand.progress(r)
+}
 
-	if message.GetDrand().ShardId != dRand.ShardID {
+// scheduleLeaderChange hands the signature request over to the next member
+// of the committee if none arrived after the given time, e.g. because the
+// leader is offline, and so on until every member had its turn.
+func (dRand *DRand) scheduleLeaderChange(r *round, after time.Duration) {
+	time.AfterFunc(after, func() {
+		dRand.mutex.Lock()
+		defer dRand.mutex.Unlock()
+		if dRand.round != r || r.request != nil || r.done || !r.nextLeader() {
+			return
+		}
+		dRand.IsLeader = r.index >= 0 && r.index == r.leader
 		utils.Logger().Warn().
-			Uint32("myShardId", dRand.ShardID).
-			Uint32("receivedShardId", message.GetDrand().ShardId).
-			Msg("Received drand message from different shard")
+			Uint64("epoch", r.epoch).
+			Int("leader", r.leader).
+			Msg("[DRG] No signature request from the leader, switching leader")
+		dRand.progress(r)
+		dRand.scheduleLeaderChange(r, requestTimeout)
+	})
+}
+
+// progress moves the randomness generation forward as far as the received
+// messages allow.
+func (dRand *DRand) progress(r *round) {
+	if !r.started() || r.done {
 		return
 	}
-
-	switch message.Type {
-	case msg_pb.MessageType_DRAND_COMMIT:
-		dRand.processCommitMessage(message)
-	default:
-		utils.Logger().Error().
-			Uint32("msgType", uint32(message.Type)).
-			Interface("dRand", dRand).
-			Msg("Unexpected message type")
+	if r.request == nil && r.index >= 0 && r.index == r.leader {
+		dRand.requestSignatures(r)
 	}
-}
-
-// ProcessMessageValidator dispatches validator's consensus message.
-func (dRand *DRand) processCommitMessage(message *msg_pb.Message) {
-	utils.Logger().Info().Msg("[DRG] Leader received commit")
-	if message.Type != msg_pb.MessageType_DRAND_COMMIT {
-		utils.Logger().Error().
-			Uint32("expected", uint32(msg_pb.MessageType_DRAND_COMMIT)).
-			Uint32("got", uint32(message.Type)).
-			Msg("Wrong message type received")
+	if r.request == nil || !dRand.hasQualifiedDeals(r) {
 		return
 	}
+	if r.commitments == nil {
+		deals := dRand.qualifiedDeals(r)
+		commitments, err := groupCommitments(deals)
+		if err != nil {
+			utils.Logger().Error().Err(err).Uint64("epoch", r.epoch).Msg("[DRG] Invalid qualified deals")
+			return
+		}
+		r.commitments = commitments
+	}
+	if !r.signed && r.index >= 0 {
+		r.signed = true
+		dRand.sign(r)
+	}
+	for index, sig := range r.pendingPartials {
+		dRand.addPartial(r, index, sig)
+	}
+	r.pendingPartials = map[uint32]*bls.Sign{}
+	if len(r.partials) >= Threshold(len(r.committee)) {
+		dRand.recover(r)
+	}
+}
 
-	dRand.mutex.Lock()
-	defer dRand.mutex.Unlock()
-
-	drandMsg := message.GetDrand()
-
-	senderPubKey, err := bls.BytesToBlsPublicKey(drandMsg.SenderPubkey)
-	if err != nil {
-		utils.Logger().Debug().Err(err).Msg("Failed to deserialize BLS public key")
+// requestSignatures picks the qualified dealers once every member dealt with
+// no complaint left unanswered, or a threshold did when the deal timeout
+// expired, and asks the committee to sign with the resulting group key.
+func (dRand *DRand) requestSignatures(r *round) {
+	dealers := r.qualifiedDealers()
+	if len(dealers) < len(r.committee) &&
+		(!r.dealTimedOut || len(dealers) < Threshold(len(r.committee))) {
 		return
 	}
-	validatorAddress := senderPubKey.SerializeToHexStr()
+	request := &signRequest{Epoch: r.epoch, Dealers: dealers}
+	for _, index := range request.Dealers {
+		request.DealHashes = append(request.DealHashes, hash.FromRLP(r.deals[index]))
+	}
+	utils.Logger().Info().
+		Uint64("epoch", r.epoch).
+		Int("numDealers", len(request.Dealers)).
+		Msg("[DRG] Leader requesting signatures")
+	r.request = request
+	r.requestSeed = r.seed
+	dRand.broadcast(dRand.constructInitMessage(r.seed, request))
+}
 
-	if !dRand.IsValidatorInCommittee(validatorAddress) {
-		utils.Logger().Error().Str("validatorAddress", validatorAddress).Msg("Invalid validator")
-		return
+// hasQualifiedDeals returns whether this node received the deals of all the
+// qualified dealers.
+func (dRand *DRand) hasQualifiedDeals(r *round) bool {
+	for i, index := range r.request.Dealers {
+		d, ok := r.deals[index]
+		if !ok || hash.FromRLP(d) != r.request.DealHashes[i] {
+			return false
+		}
 	}
+	return true
+}
 
-	vrfs := dRand.vrfs
-	if len((*vrfs)) >= ((len(dRand.PublicKeys))/3 + 1) {
-		utils.Logger().Debug().
-			Str("validatorAddress", validatorAddress).Msg("Received additional randomness commit message")
-		return
+// qualifiedDeals returns the deals of the qualified dealers, in the order of
+// the request.
+func (dRand *DRand) qualifiedDeals(r *round) []*deal {
+	deals := make([]*deal, 0, len(r.request.Dealers))
+	for _, index := range r.request.Dealers {
+		deals = append(deals, r.deals[index])
 	}
+	return deals
+}
 
-	// Verify message signature
-	err = verifyMessageSig(senderPubKey, message)
-	if err != nil {
-		utils.Logger().Debug().
-			Err(err).Str("PubKey", senderPubKey.SerializeToHexStr()).Msg("[DRAND] failed to verify the message signature")
+// recover recovers the threshold signature from the verified signature
+// shares, and keeps the randomness for the beacon chain to commit.
+func (dRand *DRand) recover(r *round) {
+	sigs := make([]bls.Sign, 0, len(r.partials))
+	ids := make([]bls.ID, 0, len(r.partials))
+	for index, sig := range r.partials {
+		id, err := memberID(int(index))
+		if err != nil {
+			return
+		}
+		sigs = append(sigs, *sig)
+		ids = append(ids, *id)
+	}
+	var sig bls.Sign
+	if err := sig.Recover(sigs, ids); err != nil {
+		utils.Logger().Error().Err(err).Uint64("epoch", r.epoch).Msg("[DRG] Failed to recover the threshold signature")
 		return
 	}
-
-	rand := drandMsg.Payload[:32]
-	proof := drandMsg.Payload[32 : len(drandMsg.Payload)-64]
-	pubKeyBytes := drandMsg.Payload[len(drandMsg.Payload)-64:]
-	_, vrfPubKey := p256.GenerateKey()
-	vrfPubKey.Deserialize(pubKeyBytes)
-
-	expectedRand, err := vrfPubKey.ProofToHash(dRand.blockHash[:], proof)
-
-	if err != nil || !bytes.Equal(expectedRand[:], rand) {
-		utils.Logger().Error().
-			Err(err).
-			Str("validatorAddress", validatorAddress).
-			Hex("expectedRand", expectedRand[:]).
-			Hex("receivedRand", rand[:]).
-			Msg("[DRAND] Failed to verify the VRF")
+	randomness := &types.BeaconRandomness{
+		Epoch:     new(big.Int).SetUint64(r.epoch),
+		Seed:      r.seed,
+		Signature: sig.Serialize(),
+	}
+	for _, d := range dRand.qualifiedDeals(r) {
+		randomness.Dealers = append(randomness.Dealers, d.dealer())
+	}
+	if err := VerifyRandomness(randomness, r.committee); err != nil {
+		utils.Logger().Error().Err(err).Uint64("epoch", r.epoch).Msg("[DRG] Recovered invalid randomness")
 		return
 	}
+	r.done = true
+	r.output = randomness
+	utils.Logger().Info().
+		Uint64("epoch", r.epoch).
+		Str("randomness", randomness.Randomness().Hex()).
+		Msg("[DRG] Generated epoch randomness")
+}
 
-	utils.Logger().Debug().
-		Int("numReceivedSoFar", len((*vrfs))).
-		Str("validatorAddress", validatorAddress).
-		Int("PublicKeys", len(dRand.PublicKeys)).
-		Msg("Received new VRF commit")
-
-	(*vrfs)[validatorAddress] = drandMsg.Payload
-	dRand.bitmap.SetKey(senderPubKey, true) // Set the bitmap indicating that this validator signed.
-
-	if len((*vrfs)) >= ((len(dRand.PublicKeys))/3 + 1) {
-		// Construct pRand and initiate consensus on it
-		utils.Logger().Debug().
-			Int("numReceivedSoFar", len((*vrfs))).
-			Str("validatorAddress", validatorAddress).
-			Int("PublicKeys", len(dRand.PublicKeys)).
-			Msg("[DRAND] {BINGO} Received enough randomness commit")
-
-		pRnd := [32]byte{}
-		// Bitwise XOR on all the submitted vrfs
-		for _, vrf := range *vrfs {
-			for i := 0; i < len(pRnd); i++ {
-				pRnd[i] = pRnd[i] ^ vrf[i]
-			}
-		}
-		dRand.PRndChannel <- append(pRnd[:], dRand.bitmap.Bitmap...)
+// Randomness returns the randomness of the given epoch if this node
+// generated it, for the leader of the beacon chain to commit in a block.
+func (dRand *DRand) Randomness(epoch uint64) *types.BeaconRandomness {
+	dRand.mutex.Lock()
+	defer dRand.mutex.Unlock()
+	if dRand.round == nil || dRand.round.epoch != epoch {
+		return nil
 	}
+	return dRand.round.output
+}
+
+// sameSeed returns whether the message seed matches the one of the round,
+// which is unknown until the round starts.
+func sameSeed(r *round, seed []byte) bool {
+	return !r.started() || common.BytesToHash(seed) == r.seed
 }
@@ -1,13 +1,16 @@
 package drand
 
 import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+
 	"github.com/harmony-one/harmony/api/proto"
 	msg_pb "github.com/harmony-one/harmony/api/proto/message"
 	"github.com/harmony-one/harmony/internal/utils"
 )
 
-// Constructs the init message
-func (dRand *DRand) constructInitMessage() []byte {
+// Constructs the init message, carrying the signature request of the leader
+func (dRand *DRand) constructInitMessage(seed common.Hash, request *signRequest) []byte {
 	message := &msg_pb.Message{
 		ServiceType: msg_pb.ServiceType_DRAND,
 		Type:        msg_pb.MessageType_DRAND_INIT,
@@ -18,12 +21,18 @@ func (dRand *DRand) constructInitMessage() []byte {
 
 	drandMsg := message.GetDrand()
 	drandMsg.SenderPubkey = dRand.pubKey.Serialize()
-	drandMsg.BlockHash = dRand.blockHash[:]
+	drandMsg.BlockHash = seed[:]
 	drandMsg.ShardId = dRand.ShardID
-	// Don't need the payload in init message
+	payload, err := rlp.EncodeToBytes(request)
+	if err != nil {
+		utils.Logger().Error().Err(err).Msg("Failed to encode the signature request")
+		return nil
+	}
+	drandMsg.Payload = payload
 	marshaledMessage, err := dRand.signAndMarshalDRandMessage(message)
 	if err != nil {
 		utils.Logger().Error().Err(err).Msg("Failed to sign and marshal the init message")
+		return nil
 	}
 	return proto.ConstructDRandMessage(marshaledMessage)
 }
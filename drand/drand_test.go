@@ -1,18 +1,15 @@
 package drand
 
 import (
-	"math/big"
 	"strings"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/harmony-one/bls/ffi/go/bls"
 
-	blockfactory "github.com/harmony-one/harmony/block/factory"
 	bls2 "github.com/harmony-one/harmony/crypto/bls"
 
 	msg_pb "github.com/harmony-one/harmony/api/proto/message"
-	"github.com/harmony-one/harmony/core/types"
 	"github.com/harmony-one/harmony/internal/utils"
 	"github.com/harmony-one/harmony/p2p"
 	"github.com/harmony-one/harmony/p2p/p2pimpl"
@@ -107,7 +104,7 @@ func TestVerifyMessageSig(test *testing.T) {
 	}
 	drandMsg := message.GetDrand()
 	drandMsg.SenderPubkey = dRand.pubKey.Serialize()
-	drandMsg.BlockHash = dRand.blockHash[:]
+	drandMsg.BlockHash = common.Hash{}.Bytes()
 
 	dRand.signDRandMessage(message)
 
@@ -116,20 +113,72 @@ func TestVerifyMessageSig(test *testing.T) {
 	}
 }
 
-func TestVrf(test *testing.T) {
-	leader := p2p.Peer{IP: "127.0.0.1", Port: "9902"}
-	validator := p2p.Peer{IP: "127.0.0.1", Port: "9905"}
-	priKey, _, _ := utils.GenKeyP2P("127.0.0.1", "9902")
-	host, err := p2pimpl.NewHost(&leader, priKey)
-	if err != nil {
-		test.Fatalf("newhost failure: %v", err)
+// newTestRound returns a started round of the given committee, observed by
+// a node outside of it, with the deals of all its members.
+func newTestRound(t *testing.T, priKeys []*bls.SecretKey, committee []*bls.PublicKey) *round {
+	dealers := []int{}
+	for i := range committee {
+		dealers = append(dealers, i)
 	}
-	dRand := New(host, 0, []p2p.Peer{leader, validator}, leader, nil, bls2.RandPrivateKey())
-	tx1 := types.NewTransaction(1, common.BytesToAddress([]byte{0x11}), 0, big.NewInt(111), 1111, big.NewInt(11111), []byte{0x11, 0x11, 0x11})
-	txs := []*types.Transaction{tx1}
+	r := &round{
+		epoch:        1,
+		seed:         common.Hash{8},
+		committee:    committee,
+		index:        -1,
+		leaders:      map[int]bool{1: true},
+		leader:       1,
+		deals:        map[uint32]*deal{},
+		complaints:   map[uint32]map[uint32]bool{},
+		disqualified: map[uint32]bool{},
+		justified:    map[uint32]*bls.SecretKey{},
+	}
+	for _, d := range dealAll(t, priKeys, committee, r.epoch, r.seed, dealers) {
+		r.deals[d.Index] = d
+	}
+	return r
+}
+
+func TestComplaints(test *testing.T) {
+	priKeys, committee := newTestCommittee(4)
+	r := newTestRound(test, priKeys, committee)
+	dRand := &DRand{}
 
-	block := types.NewBlock(blockfactory.NewTestHeader().With().Number(big.NewInt(314)).Header(), txs, nil, nil, nil)
-	blockHash := block.Hash()
+	// a complaint leaves the dealer out until it answers
+	dRand.addComplaint(r, &complaint{Epoch: 1, Dealer: 0, Member: 1})
+	dRand.addComplaint(r, &complaint{Epoch: 1, Dealer: 2, Member: 1})
+	if dealers := r.qualifiedDealers(); len(dealers) != 2 || dealers[0] != 1 || dealers[1] != 3 {
+		test.Errorf("unexpected qualified dealers %v", dealers)
+	}
 
-	dRand.vrf(blockHash)
+	share, err := r.deals[0].revealShare(priKeys[0], 1, committee)
+	if err != nil {
+		test.Fatalf("cannot reveal share: %v", err)
+	}
+	dRand.addJustification(r, &justification{Epoch: 1, Dealer: 0, Member: 1, Share: share.Serialize()})
+	// a share which does not match the commitments disqualifies the dealer
+	dRand.addJustification(r, &justification{Epoch: 1, Dealer: 2, Member: 1, Share: share.Serialize()})
+	if dealers := r.qualifiedDealers(); len(dealers) != 3 || dealers[0] != 0 || dealers[1] != 1 || dealers[2] != 3 {
+		test.Errorf("unexpected qualified dealers %v", dealers)
+	}
+	if !r.disqualified[2] {
+		test.Error("dealer revealing a wrong share is not disqualified")
+	}
+	// complaining again does not reopen an answered complaint
+	dRand.addComplaint(r, &complaint{Epoch: 1, Dealer: 0, Member: 1})
+	if dealers := r.qualifiedDealers(); len(dealers) != 3 {
+		test.Errorf("unexpected qualified dealers %v", dealers)
+	}
+}
+
+func TestNextLeader(test *testing.T) {
+	priKeys, committee := newTestCommittee(3)
+	r := newTestRound(test, priKeys, committee)
+	for _, expected := range []int{2, 0} {
+		if !r.nextLeader() || r.leader != expected || !r.leaders[expected] {
+			test.Errorf("expected leader %d, got %d", expected, r.leader)
+		}
+	}
+	if r.nextLeader() {
+		test.Error("leader changed after every member had its turn")
+	}
 }
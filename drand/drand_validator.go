@@ -1,63 +1,357 @@
 package drand
 
 import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
 	protobuf "github.com/golang/protobuf/proto"
+	"github.com/harmony-one/bls/ffi/go/bls"
+
 	msg_pb "github.com/harmony-one/harmony/api/proto/message"
 	"github.com/harmony-one/harmony/internal/utils"
 	"github.com/harmony-one/harmony/p2p"
 	"github.com/harmony-one/harmony/p2p/host"
 )
 
-// ProcessMessageValidator dispatches messages for the validator to corresponding processors.
-func (dRand *DRand) ProcessMessageValidator(payload []byte) {
+// ProcessMessage dispatches the randomness messages of the leader and the
+// committee members to the corresponding processors.
+func (dRand *DRand) ProcessMessage(payload []byte) {
 	message := &msg_pb.Message{}
 	err := protobuf.Unmarshal(payload, message)
 
 	if err != nil {
-		utils.Logger().Error().Interface("dRand", dRand).Err(err).Msg("Failed to unmarshal message payload")
+		utils.Logger().Error().Err(err).Msg("Failed to unmarshal message payload")
+		return
+	}
+
+	drandMsg := message.GetDrand()
+	if drandMsg == nil {
+		utils.Logger().Warn().Msg("[DRG] Received message without drand request")
+		return
+	}
+	if drandMsg.ShardId != dRand.ShardID {
+		utils.Logger().Warn().
+			Uint32("myShardId", dRand.ShardID).
+			Uint32("receivedShardId", drandMsg.ShardId).
+			Msg("Received drand message from different shard")
+		return
 	}
 
 	switch message.Type {
 	case msg_pb.MessageType_DRAND_INIT:
 		dRand.processInitMessage(message)
 	case msg_pb.MessageType_DRAND_COMMIT:
-		// do nothing on the COMMIT message, as it is intended to send to leader
+		dRand.processCommitMessage(message)
 	default:
 		utils.Logger().Error().
-			Interface("dRand", dRand).
 			Uint32("msgType", uint32(message.Type)).
 			Msg("Unexpected message type")
 	}
 }
 
-// ProcessMessageValidator dispatches validator's consensus message.
+// verifySender checks that the sender of the message is the member of the
+// committee of the round at the given index, and that it signed the
+// message.
+func verifySender(r *round, index int, message *msg_pb.Message) bool {
+	if index < 0 || index >= len(r.committee) {
+		return false
+	}
+	senderPubKey := &bls.PublicKey{}
+	if err := senderPubKey.Deserialize(message.GetDrand().SenderPubkey); err != nil {
+		return false
+	}
+	if !senderPubKey.IsEqual(r.committee[index]) {
+		return false
+	}
+	if err := verifyMessageSig(senderPubKey, message); err != nil {
+		utils.Logger().Warn().Err(err).Int("index", index).Msg("[DRG] Failed to verify the message signature")
+		return false
+	}
+	return true
+}
+
+// processInitMessage processes the signature request of the leader.
 func (dRand *DRand) processInitMessage(message *msg_pb.Message) {
-	if message.Type != msg_pb.MessageType_DRAND_INIT {
-		utils.Logger().Error().
-			Uint32("expected", uint32(msg_pb.MessageType_DRAND_INIT)).
-			Uint32("got", uint32(message.Type)).
-			Msg("Wrong message type received")
+	drandMsg := message.GetDrand()
+	request := &signRequest{}
+	if err := rlp.DecodeBytes(drandMsg.Payload, request); err != nil {
+		utils.Logger().Warn().Err(err).Msg("[DRG] Failed to decode signature request")
+		return
+	}
+
+	dRand.mutex.Lock()
+	defer dRand.mutex.Unlock()
+
+	r := dRand.roundFor(request.Epoch)
+	if r == nil || r.request != nil || !sameSeed(r, drandMsg.BlockHash) {
 		return
 	}
+	if !dRand.verifyLeader(r, message) {
+		return
+	}
+	if len(request.Dealers) < Threshold(len(r.committee)) ||
+		len(request.Dealers) != len(request.DealHashes) {
+		utils.Logger().Warn().Uint64("epoch", r.epoch).Msg("[DRG] Invalid signature request")
+		return
+	}
+	for i, index := range request.Dealers {
+		if int(index) >= len(r.committee) || (i > 0 && index <= request.Dealers[i-1]) {
+			utils.Logger().Warn().Uint64("epoch", r.epoch).Msg("[DRG] Invalid dealers in signature request")
+			return
+		}
+		if r.disqualified[index] {
+			utils.Logger().Warn().Uint64("epoch", r.epoch).Uint32("dealer", index).Msg("[DRG] Disqualified dealer in signature request")
+			return
+		}
+	}
+	utils.Logger().Info().
+		Uint64("epoch", r.epoch).
+		Int("numDealers", len(request.Dealers)).
+		Msg("[DRG] Received signature request")
+	r.request = request
+	r.requestSeed = common.BytesToHash(drandMsg.BlockHash)
+	dRand.progress(r)
+}
 
+// verifyLeader checks that the sender of the message is one of the leaders
+// of the round so far, the first one or a fallback.
+func (dRand *DRand) verifyLeader(r *round, message *msg_pb.Message) bool {
+	for index := range r.leaders {
+		if verifySender(r, index, message) {
+			return true
+		}
+	}
+	return false
+}
+
+// processCommitMessage processes the deals, the complaints, the
+// justifications and the signature shares of the committee members.
+func (dRand *DRand) processCommitMessage(message *msg_pb.Message) {
 	drandMsg := message.GetDrand()
+	if len(drandMsg.Payload) == 0 {
+		return
+	}
+
+	dRand.mutex.Lock()
+	defer dRand.mutex.Unlock()
+
+	switch drandMsg.Payload[0] {
+	case dealPayload:
+		d := &deal{}
+		if err := rlp.DecodeBytes(drandMsg.Payload[1:], d); err != nil {
+			utils.Logger().Warn().Err(err).Msg("[DRG] Failed to decode deal")
+			return
+		}
+		r := dRand.roundFor(d.Epoch)
+		if r == nil || !sameSeed(r, drandMsg.BlockHash) || !verifySender(r, int(d.Index), message) {
+			return
+		}
+		if !r.started() {
+			r.pendingDeals[d.Index] = d
+			return
+		}
+		dRand.addDeal(r, d)
+		dRand.progress(r)
+	case partialPayload:
+		partial := &partialSignature{}
+		if err := rlp.DecodeBytes(drandMsg.Payload[1:], partial); err != nil {
+			utils.Logger().Warn().Err(err).Msg("[DRG] Failed to decode signature share")
+			return
+		}
+		r := dRand.roundFor(partial.Epoch)
+		if r == nil || r.done || !sameSeed(r, drandMsg.BlockHash) || !verifySender(r, int(partial.Index), message) {
+			return
+		}
+		sig := &bls.Sign{}
+		if err := sig.Deserialize(partial.Signature); err != nil {
+			utils.Logger().Warn().Err(err).Uint32("index", partial.Index).Msg("[DRG] Invalid signature share")
+			return
+		}
+		if r.commitments == nil {
+			r.pendingPartials[partial.Index] = sig
+			return
+		}
+		dRand.addPartial(r, partial.Index, sig)
+		dRand.progress(r)
+	case complaintPayload:
+		c := &complaint{}
+		if err := rlp.DecodeBytes(drandMsg.Payload[1:], c); err != nil {
+			utils.Logger().Warn().Err(err).Msg("[DRG] Failed to decode complaint")
+			return
+		}
+		r := dRand.roundFor(c.Epoch)
+		if r == nil || r.done || !sameSeed(r, drandMsg.BlockHash) || !verifySender(r, int(c.Member), message) ||
+			int(c.Dealer) >= len(r.committee) {
+			return
+		}
+		dRand.addComplaint(r, c)
+		dRand.progress(r)
+	case justificationPayload:
+		j := &justification{}
+		if err := rlp.DecodeBytes(drandMsg.Payload[1:], j); err != nil {
+			utils.Logger().Warn().Err(err).Msg("[DRG] Failed to decode justification")
+			return
+		}
+		r := dRand.roundFor(j.Epoch)
+		if r == nil || r.done || !r.started() || !sameSeed(r, drandMsg.BlockHash) ||
+			!verifySender(r, int(j.Dealer), message) || int(j.Member) >= len(r.committee) {
+			return
+		}
+		dRand.addJustification(r, j)
+		dRand.progress(r)
+	}
+}
+
+// addDeal adds the deal of a member to the started round, after checking
+// its commitments.
+func (dRand *DRand) addDeal(r *round, d *deal) {
+	if _, ok := r.deals[d.Index]; ok {
+		return
+	}
+	if err := d.verify(r.committee, r.seed); err != nil {
+		utils.Logger().Warn().Err(err).Uint32("dealer", d.Index).Uint64("epoch", r.epoch).Msg("[DRG] Invalid deal")
+		return
+	}
+	r.deals[d.Index] = d
+	dRand.checkShare(r, d)
+}
+
+// checkShare checks this node's share of the deal, and complains to the
+// committee if it does not match the commitments of the deal.
+func (dRand *DRand) checkShare(r *round, d *deal) {
+	if r.index < 0 || int(d.Index) == r.index {
+		return
+	}
+	share, err := d.decryptShare(dRand.priKey, r.index, r.committee)
+	if err == nil {
+		var commitments []bls.PublicKey
+		if commitments, err = d.publicKeys(); err == nil {
+			err = verifyShare(share, commitments, r.index)
+		}
+	}
+	if err == nil {
+		return
+	}
+	utils.Logger().Warn().Err(err).Uint32("dealer", d.Index).Uint64("epoch", r.epoch).Msg("[DRG] Received invalid share, complaining")
+	c := &complaint{Epoch: r.epoch, Dealer: d.Index, Member: uint32(r.index)}
+	dRand.addComplaint(r, c)
+	dRand.broadcast(dRand.constructCommitMessage(r.seed, complaintPayload, c))
+}
 
-	// Verify message signature
-	err := verifyMessageSig(dRand.leader.ConsensusPubKey, message)
+// addComplaint records a complaint against a dealer until the dealer answers
+// it, which this node does at once for its own deal.
+func (dRand *DRand) addComplaint(r *round, c *complaint) {
+	if _, ok := r.complaints[c.Dealer][c.Member]; ok {
+		return
+	}
+	if r.complaints[c.Dealer] == nil {
+		r.complaints[c.Dealer] = map[uint32]bool{}
+	}
+	r.complaints[c.Dealer][c.Member] = true
+	if int(c.Dealer) != r.index {
+		return
+	}
+	d, ok := r.deals[c.Dealer]
+	if !ok {
+		return
+	}
+	share, err := d.revealShare(dRand.priKey, int(c.Member), r.committee)
 	if err != nil {
-		utils.Logger().Warn().Err(err).Msg("[DRG] Failed to verify the message signature")
+		utils.Logger().Error().Err(err).Uint32("member", c.Member).Msg("[DRG] Failed to reveal share")
 		return
 	}
-	utils.Logger().Debug().Msg("[DRG] verify the message signature Succeeded")
+	j := &justification{Epoch: r.epoch, Dealer: c.Dealer, Member: c.Member, Share: share.Serialize()}
+	dRand.addJustification(r, j)
+	dRand.broadcast(dRand.constructCommitMessage(r.seed, justificationPayload, j))
+}
 
-	// TODO: check the blockHash is the block hash of last block of last epoch.
-	blockHash := drandMsg.BlockHash
-	copy(dRand.blockHash[:], blockHash[:])
+// addJustification checks the share a dealer revealed against the
+// commitments of its deal: a valid one answers the complaint, and a wrong one
+// disqualifies the dealer.
+func (dRand *DRand) addJustification(r *round, j *justification) {
+	d, ok := r.deals[j.Dealer]
+	if !ok || r.disqualified[j.Dealer] {
+		return
+	}
+	share := &bls.SecretKey{}
+	err := share.Deserialize(j.Share)
+	if err == nil {
+		var commitments []bls.PublicKey
+		if commitments, err = d.publicKeys(); err == nil {
+			err = verifyShare(share, commitments, int(j.Member))
+		}
+	}
+	if err != nil {
+		utils.Logger().Warn().Err(err).Uint32("dealer", j.Dealer).Uint64("epoch", r.epoch).Msg("[DRG] Dealer revealed invalid share, disqualifying")
+		r.disqualified[j.Dealer] = true
+		return
+	}
+	if r.complaints[j.Dealer] == nil {
+		r.complaints[j.Dealer] = map[uint32]bool{}
+	}
+	r.complaints[j.Dealer][j.Member] = false
+	if int(j.Member) == r.index {
+		r.justified[j.Dealer] = share
+	}
+}
 
-	rand, proof := dRand.vrf(dRand.blockHash)
+// addPartial adds the signature share of a member after checking it against
+// the member's share of the group key.
+func (dRand *DRand) addPartial(r *round, index uint32, sig *bls.Sign) {
+	if _, ok := r.partials[index]; ok {
+		return
+	}
+	pubKey, err := memberPublicKey(r.commitments, int(index))
+	if err != nil {
+		return
+	}
+	if !sig.VerifyHash(pubKey, seedHash(r.epoch, r.seed)) {
+		utils.Logger().Warn().Uint32("index", index).Uint64("epoch", r.epoch).Msg("[DRG] Wrong signature share")
+		return
+	}
+	r.partials[index] = sig
+}
 
-	msgToSend := dRand.constructCommitMessage(rand, proof)
+// sign signs the seed with this node's share of the group secret, the sum of
+// its shares of the qualified deals, and sends the signature share to the
+// committee.
+func (dRand *DRand) sign(r *round) {
+	var secret *bls.SecretKey
+	for _, d := range dRand.qualifiedDeals(r) {
+		share, ok := r.justified[d.Index]
+		if !ok {
+			var err error
+			share, err = d.decryptShare(dRand.priKey, r.index, r.committee)
+			if err != nil {
+				utils.Logger().Warn().Err(err).Uint32("dealer", d.Index).Msg("[DRG] Failed to decrypt share")
+				return
+			}
+		}
+		if secret == nil {
+			secret = &bls.SecretKey{}
+			*secret = *share
+		} else {
+			secret.Add(share)
+		}
+	}
+	if err := verifyShare(secret, r.commitments, r.index); err != nil {
+		// a qualified dealer whose complaint this node missed the answer to
+		utils.Logger().Warn().Err(err).Uint64("epoch", r.epoch).Msg("[DRG] Invalid share of the group secret")
+		return
+	}
+	sig := secret.SignHash(seedHash(r.epoch, r.seed))
+	partial := &partialSignature{
+		Epoch:     r.epoch,
+		Index:     uint32(r.index),
+		Signature: sig.Serialize(),
+	}
+	r.partials[partial.Index] = sig
+	dRand.broadcast(dRand.constructCommitMessage(r.seed, partialPayload, partial))
+}
 
-	// Send the commit message back to leader
-	dRand.host.SendMessageToGroups([]p2p.GroupID{p2p.NewGroupIDByShardID(p2p.ShardID(dRand.ShardID))}, host.ConstructP2pMessage(byte(17), msgToSend))
+// broadcast sends the message to the shard.
+func (dRand *DRand) broadcast(msg []byte) {
+	if msg == nil {
+		return
+	}
+	dRand.host.SendMessageToGroups([]p2p.GroupID{p2p.NewGroupIDByShardID(p2p.ShardID(dRand.ShardID))}, host.ConstructP2pMessage(byte(17), msg))
 }
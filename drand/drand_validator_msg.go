@@ -1,13 +1,25 @@
 package drand
 
 import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+
 	"github.com/harmony-one/harmony/api/proto"
 	msg_pb "github.com/harmony-one/harmony/api/proto/message"
 	"github.com/harmony-one/harmony/internal/utils"
 )
 
-// Constructs the init message
-func (dRand *DRand) constructCommitMessage(vrf [32]byte, proof []byte) []byte {
+// The first byte of the payload of a commit message tells what it carries.
+const (
+	dealPayload          byte = iota // a deal of the sender
+	partialPayload                   // a signature share of the sender
+	complaintPayload                 // a complaint of the sender about its share of a deal
+	justificationPayload             // a share the sender dealt, answering a complaint
+)
+
+// Constructs the commit message, carrying a deal, a signature share, a
+// complaint or a justification of the committee member
+func (dRand *DRand) constructCommitMessage(seed common.Hash, kind byte, content interface{}) []byte {
 	message := &msg_pb.Message{
 		ServiceType: msg_pb.ServiceType_DRAND,
 		Type:        msg_pb.MessageType_DRAND_COMMIT,
@@ -18,15 +30,18 @@ func (dRand *DRand) constructCommitMessage(vrf [32]byte, proof []byte) []byte {
 
 	drandMsg := message.GetDrand()
 	drandMsg.SenderPubkey = dRand.pubKey.Serialize()
-	drandMsg.BlockHash = dRand.blockHash[:]
+	drandMsg.BlockHash = seed[:]
 	drandMsg.ShardId = dRand.ShardID
-	drandMsg.Payload = append(vrf[:], proof...)
-	// Adding the public key into payload so leader can verify the vrf
-	// TODO: change the curve to follow the same curve with consensus, so the public key doesn't need to be attached.
-	drandMsg.Payload = append(drandMsg.Payload, (*dRand.vrfPubKey).Serialize()...)
+	encoded, err := rlp.EncodeToBytes(content)
+	if err != nil {
+		utils.Logger().Error().Err(err).Msg("Failed to encode the commit payload")
+		return nil
+	}
+	drandMsg.Payload = append([]byte{kind}, encoded...)
 	marshaledMessage, err := dRand.signAndMarshalDRandMessage(message)
 	if err != nil {
 		utils.Logger().Error().Err(err).Msg("Failed to sign and marshal the commit message")
+		return nil
 	}
 	return proto.ConstructDRandMessage(marshaledMessage)
 }
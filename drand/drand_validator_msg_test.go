@@ -3,6 +3,8 @@ package drand
 import (
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common"
+
 	"github.com/harmony-one/harmony/crypto/bls"
 
 	protobuf "github.com/golang/protobuf/proto"
@@ -22,8 +24,7 @@ func TestConstructCommitMessage(test *testing.T) {
 		test.Fatalf("newhost failure: %v", err)
 	}
 	dRand := New(host, 0, []p2p.Peer{leader, validator}, leader, nil, bls.RandPrivateKey())
-	dRand.blockHash = [32]byte{}
-	msg := dRand.constructCommitMessage([32]byte{}, []byte{})
+	msg := dRand.constructCommitMessage(common.Hash{}, partialPayload, &partialSignature{})
 	msgPayload, _ := proto.GetDRandMessagePayload(msg)
 
 	message := &msg_pb.Message{}
@@ -43,8 +44,7 @@ func TestProcessInitMessage(test *testing.T) {
 		test.Fatalf("newhost failure: %v", err)
 	}
 	dRand := New(host, 0, []p2p.Peer{leader, validator}, leader, nil, bls.RandPrivateKey())
-	dRand.blockHash = [32]byte{}
-	msg := dRand.constructInitMessage()
+	msg := dRand.constructInitMessage(common.Hash{}, &signRequest{})
 
 	msgPayload, _ := proto.GetDRandMessagePayload(msg)
 
@@ -55,5 +55,5 @@ func TestProcessInitMessage(test *testing.T) {
 		test.Error("Error in extracting Init message from payload", err)
 	}
 
-	dRand.ProcessMessageValidator(msgPayload)
+	dRand.ProcessMessage(msgPayload)
 }
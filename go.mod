@@ -24,7 +24,6 @@ require (
 	github.com/harmony-ek/gencodec v0.0.0-20190215044613-e6740dbdd846
 	github.com/harmony-one/bls v0.0.5
 	github.com/harmony-one/taggedrlp v0.1.2
-	github.com/harmony-one/vdf v1.0.0
 	github.com/hashicorp/golang-lru v0.5.1
 	github.com/iancoleman/strcase v0.0.0-20190422225806-e506e3ef7365
 	github.com/ipfs/go-ds-badger v0.0.5
@@ -15,11 +15,14 @@ import (
 	"github.com/harmony-one/harmony/accounts"
 	"github.com/harmony-one/harmony/api/proto"
 	"github.com/harmony-one/harmony/block"
+	"github.com/harmony-one/harmony/consensus"
 	"github.com/harmony-one/harmony/core"
 	"github.com/harmony-one/harmony/core/state"
 	"github.com/harmony-one/harmony/core/types"
 	"github.com/harmony-one/harmony/core/vm"
+	shardingconfig "github.com/harmony-one/harmony/internal/configs/sharding"
 	"github.com/harmony-one/harmony/internal/params"
+	"github.com/harmony-one/harmony/shard"
 )
 
 // APIBackend An implementation of internal/hmyapi/Backend. Full client.
@@ -90,9 +93,7 @@ func (b *APIBackend) GetPoolNonce(ctx context.Context, addr common.Address) (uin
 
 // SendTx ...
 func (b *APIBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
-	// return b.hmy.txPool.Add(ctx, signedTx)
-	b.hmy.nodeAPI.AddPendingTransaction(signedTx)
-	return nil // TODO(ricl): AddPendingTransaction should return error
+	return b.hmy.nodeAPI.AddPendingTransaction(signedTx)
 }
 
 // ChainConfig ...
@@ -105,6 +106,11 @@ func (b *APIBackend) CurrentBlock() *types.Block {
 	return types.NewBlockWithHeader(b.hmy.blockchain.CurrentHeader())
 }
 
+// FinalizedBlock ...
+func (b *APIBackend) FinalizedBlock() *types.Block {
+	return b.hmy.blockchain.FinalizedBlock()
+}
+
 // AccountManager ...
 func (b *APIBackend) AccountManager() *accounts.Manager {
 	return b.hmy.accountManager
@@ -253,3 +259,132 @@ func (b *APIBackend) ResendCx(ctx context.Context, txID common.Hash) (uint64, bo
 	success := b.hmy.CxPool().Add(entry)
 	return blockNum, success
 }
+
+// GetDoubleSignEvidence returns the double sign evidence known to the node
+func (b *APIBackend) GetDoubleSignEvidence() []*consensus.DoubleSignEvidence {
+	return b.hmy.nodeAPI.DoubleSignEvidence()
+}
+
+// GetConsensusTrace returns the traces of the latest n consensus rounds
+func (b *APIBackend) GetConsensusTrace(n int) []*consensus.RoundTrace {
+	return b.hmy.nodeAPI.ConsensusTrace(n)
+}
+
+// GetValidatorUptime returns the number of blocks the given BLS key signed and
+// missed in the given epoch
+func (b *APIBackend) GetValidatorUptime(epoch *big.Int, key shard.BlsPublicKey) (*types.ValidatorUptime, error) {
+	return b.hmy.blockchain.ReadValidatorUptime(epoch, key)
+}
+
+// GetCommittee returns the committee of the shard in the given epoch
+func (b *APIBackend) GetCommittee(epoch *big.Int) (*shard.Committee, error) {
+	shardState, err := b.hmy.blockchain.ReadShardState(epoch)
+	if err != nil {
+		return nil, err
+	}
+	committee := shardState.FindCommitteeByID(b.GetShardID())
+	if committee == nil {
+		return nil, errors.New("cannot find shard in the shard state")
+	}
+	return committee, nil
+}
+
+// GetRecentTxsStats returns the number of transactions of each sender account
+// in the recent blocks counted against the transaction throttling limits
+func (b *APIBackend) GetRecentTxsStats() (types.RecentTxsStats, error) {
+	txsThrottleConfig := core.ShardingSchedule.TxsThrottleConfig()
+	return b.hmy.blockchain.RecentTxsStats(txsThrottleConfig.RecentTxDuration)
+}
+
+// GetTxThrottleStatus returns the throttling result of the transaction, or
+// nil if the node does not know it as throttled or pending
+func (b *APIBackend) GetTxThrottleStatus(txHash common.Hash) *shardingconfig.TxThrottleStatus {
+	return b.hmy.nodeAPI.TxThrottleStatus(txHash)
+}
+
+// GetPoolReplacement returns the hash of the transaction which replaced the
+// given one in the transaction pool, if it was replaced recently
+func (b *APIBackend) GetPoolReplacement(txHash common.Hash) (common.Hash, bool) {
+	return b.hmy.txPool.ReplacedBy(txHash)
+}
+
+// GetCXReceiptsProof returns the proof of the cross-shard receipts of the
+// given block towards the given shard, as sent to that shard, or nil if the
+// block has none or is not committed yet
+func (b *APIBackend) GetCXReceiptsProof(toShardID uint32, blockHash common.Hash) (*types.CXReceiptsProof, error) {
+	blockchain := b.hmy.blockchain
+	blk := blockchain.GetBlockByHash(blockHash)
+	if blk == nil {
+		return nil, nil
+	}
+	receipts, err := blockchain.ReadCXReceipts(toShardID, blk.NumberU64(), blockHash, false)
+	if err != nil || len(receipts) == 0 {
+		return nil, err
+	}
+	// the commit signature of the block is in the header of the next block
+	nextHeader := blockchain.GetHeaderByNumber(blk.NumberU64() + 1)
+	if nextHeader == nil {
+		return nil, nil
+	}
+	merkleProof, err := blockchain.CXMerkleProof(toShardID, blk)
+	if err != nil || merkleProof == nil {
+		return nil, err
+	}
+	sig := nextHeader.LastCommitSignature()
+	return &types.CXReceiptsProof{
+		Receipts:     receipts,
+		MerkleProof:  merkleProof,
+		Header:       blk.Header(),
+		CommitSig:    sig[:],
+		CommitBitmap: nextHeader.LastCommitBitmap(),
+	}, nil
+}
+
+// IsCXReceiptsProofSent returns whether this node sent the proof of the
+// cross-shard receipts of the given block to the given shard
+func (b *APIBackend) IsCXReceiptsProofSent(toShardID uint32, blockNum uint64, blockHash common.Hash) bool {
+	return b.hmy.blockchain.IsCXReceiptsProofSent(toShardID, blockNum, blockHash)
+}
+
+// IsCXReceiptsSpent returns whether the cross-shard receipts of the given
+// block of the source shard were spent on the destination shard, and whether
+// the node has the chain of the destination shard to tell
+func (b *APIBackend) IsCXReceiptsSpent(fromShardID uint32, blockNum uint64, toShardID uint32) (bool, bool) {
+	var blockchain *core.BlockChain
+	switch {
+	case toShardID == b.GetShardID():
+		blockchain = b.hmy.blockchain
+	case toShardID == 0:
+		blockchain = b.hmy.nodeAPI.Beaconchain()
+	default:
+		return false, false
+	}
+	cxp := &types.CXReceiptsProof{
+		MerkleProof: &types.CXMerkleProof{ShardID: fromShardID, BlockNum: new(big.Int).SetUint64(blockNum)},
+	}
+	return blockchain.IsSpent(cxp), true
+}
+
+// GetBlockRewards returns the payouts of the block reward credited by the
+// given block, or nil if it credits none
+func (b *APIBackend) GetBlockRewards(blockHash common.Hash, blockNum uint64) (*types.BlockRewards, error) {
+	return b.hmy.blockchain.ReadBlockRewards(blockHash, blockNum)
+}
+
+// GetBlockFees returns the transaction fees collected and burned in the given
+// block, or nil if none are recorded
+func (b *APIBackend) GetBlockFees(blockHash common.Hash, blockNum uint64) (*types.BlockFees, error) {
+	return b.hmy.blockchain.ReadBlockFees(blockHash, blockNum)
+}
+
+// GetShardSupply returns the supply of the shard as of the given block, or nil
+// if none is recorded
+func (b *APIBackend) GetShardSupply(blockHash common.Hash, blockNum uint64) (*types.ShardSupply, error) {
+	return b.hmy.blockchain.ReadShardSupply(blockHash, blockNum)
+}
+
+// GetBeaconRandomness returns the output of the distributed randomness beacon
+// for the given epoch, or nil if the beacon chain has none
+func (b *APIBackend) GetBeaconRandomness(epoch *big.Int) (*types.BeaconRandomness, error) {
+	return b.hmy.nodeAPI.Beaconchain().ReadBeaconRandomness(epoch)
+}
@@ -8,8 +8,10 @@ import (
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/harmony-one/harmony/accounts"
+	"github.com/harmony-one/harmony/consensus"
 	"github.com/harmony-one/harmony/core"
 	"github.com/harmony-one/harmony/core/types"
+	shardingconfig "github.com/harmony-one/harmony/internal/configs/sharding"
 	"github.com/harmony-one/harmony/internal/params"
 )
 
@@ -43,11 +45,15 @@ type Harmony struct {
 
 // NodeAPI is the list of functions from node used to call rpc apis.
 type NodeAPI interface {
-	AddPendingTransaction(newTx *types.Transaction)
+	AddPendingTransaction(newTx *types.Transaction) error
 	Blockchain() *core.BlockChain
+	Beaconchain() *core.BlockChain
 	AccountManager() *accounts.Manager
 	GetBalanceOfAddress(address common.Address) (*big.Int, error)
 	GetNonceOfAddress(address common.Address) uint64
+	DoubleSignEvidence() []*consensus.DoubleSignEvidence
+	ConsensusTrace(n int) []*consensus.RoundTrace
+	TxThrottleStatus(hash common.Hash) *shardingconfig.TxThrottleStatus
 }
 
 // New creates a new Harmony object (including the
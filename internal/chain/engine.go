@@ -2,27 +2,47 @@ package chain
 
 import (
 	"encoding/binary"
+	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/harmony-one/bls/ffi/go/bls"
-	"github.com/pkg/errors"
 	"golang.org/x/crypto/sha3"
 
 	"github.com/harmony-one/harmony/block"
 	"github.com/harmony-one/harmony/consensus/engine"
+	"github.com/harmony-one/harmony/consensus/quorum"
 	"github.com/harmony-one/harmony/core"
 	"github.com/harmony-one/harmony/core/state"
 	"github.com/harmony-one/harmony/core/types"
+	bls2 "github.com/harmony-one/harmony/crypto/bls"
 	"github.com/harmony-one/harmony/internal/ctxerror"
 	"github.com/harmony-one/harmony/internal/utils"
 )
 
-type engineImpl struct{}
+type engineImpl struct {
+	quorumDecider quorum.Decider
+}
 
 // Engine is an algorithm-agnostic consensus engine.
 var Engine = &engineImpl{}
 
+// SetQuorumDecider sets the quorum policy the seals and commit signatures of
+// verified blocks must meet, which should be the one consensus uses.  Without
+// one, 2f+1 of the committee keys must sign.
+func (e *engineImpl) SetQuorumDecider(quorumDecider quorum.Decider) {
+	e.quorumDecider = quorumDecider
+}
+
+// isQuorumAchieved returns whether the signers in the mask reach the quorum
+// on a block of the given epoch.
+func (e *engineImpl) isQuorumAchieved(epoch *big.Int, mask *bls2.Mask) bool {
+	if e.quorumDecider == nil {
+		return quorum.NewCountDecider().IsQuorumAchieved(epoch, mask)
+	}
+	return e.quorumDecider.IsQuorumAchieved(epoch, mask)
+}
+
 // SealHash returns the hash of a block prior to it being sealed.
 func (e *engineImpl) SealHash(header *block.Header) (hash common.Hash) {
 	hasher := sha3.NewLegacyKeccak256()
@@ -83,17 +103,24 @@ func (e *engineImpl) VerifyHeader(chain engine.ChainReader, header *block.Header
 // VerifyHeaders is similar to VerifyHeader, but verifies a batch of headers
 // concurrently. The method returns a quit channel to abort the operations and
 // a results channel to retrieve the async verifications.
+// A header's parent may be the header before it in the batch.  The seals are
+// verified in BLS batches across all CPUs, and results are delivered in order.
 func (e *engineImpl) VerifyHeaders(chain engine.ChainReader, headers []*block.Header, seals []bool) (chan<- struct{}, <-chan error) {
 	abort, results := make(chan struct{}), make(chan error, len(headers))
 
 	go func() {
-		for i, header := range headers {
-			err := e.VerifyHeader(chain, header, seals[i])
-
-			select {
-			case <-abort:
-				return
-			case results <- err:
+		window := verifyWindow()
+		for start := 0; start < len(headers); start += window {
+			end := start + window
+			if end > len(headers) {
+				end = len(headers)
+			}
+			for _, err := range e.verifyHeaderRange(chain, headers, seals, start, end) {
+				select {
+				case <-abort:
+					return
+				case results <- err:
+				}
 			}
 		}
 	}()
@@ -108,25 +135,12 @@ func ReadPublicKeysFromLastBlock(bc engine.ChainReader, header *block.Header) ([
 		return nil, ctxerror.New("cannot find parent block header in DB",
 			"parentHash", header.ParentHash())
 	}
-	parentShardState := core.GetShardState(parentHeader.Epoch())
-	parentCommittee := parentShardState.FindCommitteeByID(parentHeader.ShardID())
-	if parentCommittee == nil {
-		return nil, ctxerror.New("cannot find shard in the shard state",
-			"parentBlockNumber", parentHeader.Number(),
-			"shardID", parentHeader.ShardID(),
-		)
-	}
-	var committerKeys []*bls.PublicKey
-	for _, member := range parentCommittee.NodeList {
-		committerKey := new(bls.PublicKey)
-		err := member.BlsPublicKey.ToLibBLSPublicKey(committerKey)
-		if err != nil {
-			return nil, ctxerror.New("cannot convert BLS public key",
-				"blsPublicKey", member.BlsPublicKey).WithCause(err)
-		}
-		committerKeys = append(committerKeys, committerKey)
+	committerKeys, err := committeePublicKeys(parentHeader.Epoch(), parentHeader.ShardID())
+	if err != nil {
+		return nil, ctxerror.New("cannot find parent committee",
+			"parentBlockNumber", parentHeader.Number()).WithCause(err)
 	}
-	return committerKeys, nil
+	return append([]*bls.PublicKey{}, committerKeys...), nil
 }
 
 // VerifySeal implements Engine, checking whether the given block's parent block satisfies
@@ -136,36 +150,16 @@ func (e *engineImpl) VerifySeal(chain engine.ChainReader, header *block.Header)
 	if chain.CurrentHeader().Number().Uint64() <= uint64(1) {
 		return nil
 	}
-	publicKeys, err := ReadPublicKeysFromLastBlock(chain, header)
-	if err != nil {
-		return ctxerror.New("[VerifySeal] Cannot retrieve publickeys from last block").WithCause(err)
-	}
-	sig := header.LastCommitSignature()
-	payload := append(sig[:], header.LastCommitBitmap()...)
-	aggSig, mask, err := ReadSignatureBitmapByPublicKeys(payload, publicKeys)
-	if err != nil {
-		return ctxerror.New("[VerifySeal] Unable to deserialize the LastCommitSignature and LastCommitBitmap in Block Header").WithCause(err)
+	parentHeader := chain.GetHeader(header.ParentHash(), header.Number().Uint64()-1)
+	if parentHeader == nil {
+		return ctxerror.New("[VerifySeal] Cannot find parent block header in DB",
+			"parentHash", header.ParentHash())
 	}
-	parentHash := header.ParentHash()
-	parentHeader := chain.GetHeader(parentHash, header.Number().Uint64()-1)
-	parentQuorum, err := QuorumForBlock(parentHeader)
+	seal, err := e.readSeal(header, parentHeader)
 	if err != nil {
-		return errors.Wrapf(err,
-			"cannot calculate quorum for block %s", header.Number())
-	}
-	if count := utils.CountOneBits(mask.Bitmap); count < parentQuorum {
-		return ctxerror.New("[VerifySeal] Not enough signature in LastCommitSignature from Block Header",
-			"need", parentQuorum, "got", count)
-	}
-
-	blockNumHash := make([]byte, 8)
-	binary.LittleEndian.PutUint64(blockNumHash, header.Number().Uint64()-1)
-	lastCommitPayload := append(blockNumHash, parentHash[:]...)
-
-	if !aggSig.VerifyHash(mask.AggregatePublic, lastCommitPayload) {
-		return ctxerror.New("[VerifySeal] Unable to verify aggregated signature from last block", "lastBlockNum", header.Number().Uint64()-1, "lastBlockHash", parentHash)
+		return err
 	}
-	return nil
+	return seal.verify()
 }
 
 // Finalize implements Engine, accumulating the block rewards,
@@ -176,19 +170,22 @@ func (e *engineImpl) Finalize(chain engine.ChainReader, header *block.Header, st
 	if err := AccumulateRewards(chain, state, header); err != nil {
 		return nil, ctxerror.New("cannot pay block reward").WithCause(err)
 	}
+	// Release the stake which finished unbonding at the end of each epoch
+	if core.IsEpochLastBlockByHeader(header) {
+		if err := core.ReleaseUnbonded(state, header.Epoch()); err != nil {
+			return nil, ctxerror.New("cannot release unbonded stake").WithCause(err)
+		}
+	}
 	header.SetRoot(state.IntermediateRoot(chain.Config().IsS3(header.Epoch())))
 	return types.NewBlock(header, txs, receipts, outcxs, incxs), nil
 }
 
-// QuorumForBlock returns the quorum for the given block header.
-func QuorumForBlock(h *block.Header) (quorum int, err error) {
-	ss := core.GetShardState(h.Epoch())
-	c := ss.FindCommitteeByID(h.ShardID())
-	if c == nil {
-		return 0, errors.Errorf(
-			"cannot find shard %d in shard state", h.ShardID())
-	}
-	return (len(c.NodeList))*2/3 + 1, nil
+// BlockRewards returns the payouts of the block reward credited by the given
+// header, as Finalize credits them.  The stakes weighting the rewards are the
+// same in the state Finalize credits and in the final state of the block, as
+// neither the rewards nor the release of unbonded stake change them.
+func (e *engineImpl) BlockRewards(chain engine.ChainReader, header *block.Header, state *state.DB) (*types.BlockRewards, error) {
+	return BlockRewards(chain, header, state)
 }
 
 // Similiar to VerifyHeader, which is only for verifying the block headers of one's own chain, this verification
@@ -208,14 +205,9 @@ func (e *engineImpl) VerifyHeaderWithSignature(header *block.Header, commitSig [
 	}
 
 	hash := header.Hash()
-	quorum, err := QuorumForBlock(header)
-	if err != nil {
-		return errors.Wrapf(err,
-			"cannot calculate quorum for block %s", header.Number())
-	}
-	if count := utils.CountOneBits(mask.Bitmap); count < quorum {
+	if !e.isQuorumAchieved(header.Epoch(), mask) {
 		return ctxerror.New("[VerifyHeaderWithSignature] Not enough signature in commitSignature from Block Header",
-			"need", quorum, "got", count)
+			"got", mask.CountEnabled(), "total", mask.CountTotal())
 	}
 
 	blockNumHash := make([]byte, 8)
@@ -230,23 +222,10 @@ func (e *engineImpl) VerifyHeaderWithSignature(header *block.Header, commitSig [
 
 // GetPublicKeys finds the public keys of the committee that signed the block header
 func GetPublicKeys(header *block.Header) ([]*bls.PublicKey, error) {
-	shardState := core.GetShardState(header.Epoch())
-	committee := shardState.FindCommitteeByID(header.ShardID())
-	if committee == nil {
-		return nil, ctxerror.New("cannot find shard in the shard state",
-			"blockNumber", header.Number(),
-			"shardID", header.ShardID(),
-		)
-	}
-	var committerKeys []*bls.PublicKey
-	for _, member := range committee.NodeList {
-		committerKey := new(bls.PublicKey)
-		err := member.BlsPublicKey.ToLibBLSPublicKey(committerKey)
-		if err != nil {
-			return nil, ctxerror.New("cannot convert BLS public key",
-				"blsPublicKey", member.BlsPublicKey).WithCause(err)
-		}
-		committerKeys = append(committerKeys, committerKey)
+	committerKeys, err := committeePublicKeys(header.Epoch(), header.ShardID())
+	if err != nil {
+		return nil, ctxerror.New("cannot find block committee",
+			"blockNumber", header.Number()).WithCause(err)
 	}
-	return committerKeys, nil
+	return append([]*bls.PublicKey{}, committerKeys...), nil
 }
@@ -1,57 +1,191 @@
 package chain
 
 import (
+	"bytes"
 	"math/big"
+	"sort"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/harmony-one/bls/ffi/go/bls"
 
 	"github.com/harmony-one/harmony/block"
-	"github.com/harmony-one/harmony/common/denominations"
 	"github.com/harmony-one/harmony/consensus/engine"
+	"github.com/harmony-one/harmony/contracts/structs"
+	"github.com/harmony-one/harmony/core"
 	"github.com/harmony-one/harmony/core/state"
+	"github.com/harmony-one/harmony/core/types"
 	bls2 "github.com/harmony-one/harmony/crypto/bls"
 	common2 "github.com/harmony-one/harmony/internal/common"
 	"github.com/harmony-one/harmony/internal/ctxerror"
+	"github.com/harmony-one/harmony/internal/params"
 	"github.com/harmony-one/harmony/internal/utils"
+	"github.com/harmony-one/harmony/shard"
 )
 
-// BlockReward is the block reward, to be split evenly among block signers.
-var BlockReward = new(big.Int).Mul(big.NewInt(24), big.NewInt(denominations.One))
+// rewardShare is the weight of an account in the split of the signers' part
+// of a block reward.
+type rewardShare struct {
+	account common.Address
+	key     shard.BlsPublicKey
+	weight  *big.Int
+}
 
-// AccumulateRewards credits the coinbase of the given block with the mining
-// reward. The total reward consists of the static block reward and rewards for
-// included uncles. The coinbase of each uncle block is also rewarded.
-func AccumulateRewards(
-	bc engine.ChainReader, state *state.DB, header *block.Header,
-) error {
+// crossLinkReader reads the cross-links the beacon chain committed, as
+// core.BlockChain does.
+type crossLinkReader interface {
+	ReadCrossLink(shardID uint32, blockNum uint64, temp bool) (*types.CrossLink, error)
+}
+
+// BlockRewards returns the payouts of the reward for signing the parent of the
+// given block, which the block credits, or nil for a block crediting none.
+//
+// The block reward of the parent epoch comes from the reward configuration of
+// the sharding schedule, once it is active.  The leader who proposed the
+// parent block, its coinbase, gets the leader share of the reward if it is in
+// the committee, and the signers split the rest, either equally or in
+// proportion to the stake the staking registry of the given state holds on
+// their keys.  If the signers have no stake at all, e.g. genesis nodes, they
+// split it equally.  Before the configuration is active, the signers split
+// the legacy block reward equally.
+//
+// The staking registry is on the beacon chain, so from the beacon rewards
+// fork epoch on, the shards other than the beacon chain credit no reward: the beacon block
+// cross-linking a block of theirs credits the reward for signing its parent
+// instead, weighted by the registry and split among the delegators like the
+// reward of a beacon block.
+func BlockRewards(
+	bc engine.ChainReader, header *block.Header, db *state.DB,
+) (*types.BlockRewards, error) {
 	blockNum := header.Number().Uint64()
 	if blockNum == 0 {
 		// Epoch block has no parent to reward.
-		return nil
+		return nil, nil
 	}
 	// TODO ek – retrieving by parent number (blockNum - 1) doesn't work,
 	//  while it is okay with hash.  Sounds like DB inconsistency.
 	//  Figure out why.
 	parentHeader := bc.GetHeaderByHash(header.ParentHash())
 	if parentHeader == nil {
-		return ctxerror.New("cannot find parent block header in DB",
+		return nil, ctxerror.New("cannot find parent block header in DB",
 			"parentHash", header.ParentHash())
 	}
-	if parentHeader.Number().Cmp(common.Big0) == 0 {
-		// Parent is an epoch block,
-		// which is not signed in the usual manner therefore rewards nothing.
-		return nil
+	var rewards *types.BlockRewards
+	// Parent is an epoch block,
+	// which is not signed in the usual manner therefore rewards nothing.
+	if parentHeader.Number().Sign() != 0 && !settledOnBeacon(bc.Config(), parentHeader) {
+		var err error
+		if rewards, err = signerRewards(bc, parentHeader, header, db); err != nil {
+			return nil, err
+		}
+	}
+	if header.ShardID() != types.StakingShardID {
+		return rewards, nil
 	}
+	settled, err := crossLinkRewards(bc, header, db)
+	if err != nil {
+		return nil, err
+	}
+	for _, linked := range settled {
+		if rewards == nil {
+			rewards = &types.BlockRewards{BlockNum: header.Number(), Total: big.NewInt(0)}
+		}
+		rewards.Total = new(big.Int).Add(rewards.Total, linked.Total)
+		rewards.Payouts = append(rewards.Payouts, linked.Payouts...)
+	}
+	return rewards, nil
+}
+
+// settledOnBeacon returns whether the reward for signing the given block of a
+// shard other than the beacon chain is credited on the beacon chain, which
+// takes cross-links to learn about the block.
+func settledOnBeacon(config *params.ChainConfig, signed *block.Header) bool {
+	return signed.ShardID() != types.StakingShardID &&
+		config.IsCrossLink(signed.Epoch()) &&
+		config.IsBeaconRewards(signed.Epoch())
+}
+
+// crossLinkRewards returns the payouts of the rewards for signing the parents
+// of the blocks the given beacon block cross-links, in the order of the
+// cross-links.  The parent of a cross-linked block is the block cross-linked
+// before it, by the same beacon block or an earlier one.
+func crossLinkRewards(
+	bc engine.ChainReader, header *block.Header, db *state.DB,
+) ([]*types.BlockRewards, error) {
+	if len(header.CrossLinks()) == 0 {
+		return nil, nil
+	}
+	crossLinks := types.CrossLinks{}
+	if err := rlp.DecodeBytes(header.CrossLinks(), &crossLinks); err != nil {
+		return nil, ctxerror.New("cannot parse cross links").WithCause(err)
+	}
+	// the first cross-linked block of a shard has no cross-linked parent,
+	// whose reward its shard credited
+	firstCrossLinkBlock := core.EpochFirstBlock(bc.Config().CrossLinkEpoch)
+	parents := make(map[uint32]*block.Header)
+	var settled []*types.BlockRewards
+	for _, crossLink := range crossLinks {
+		linked := crossLink.Header()
+		parent := parents[linked.ShardID()]
+		parents[linked.ShardID()] = linked
+		// the parent is no later than the block, so neither is settled here
+		if !settledOnBeacon(bc.Config(), linked) {
+			continue
+		}
+		if linked.Number().Cmp(firstCrossLinkBlock) <= 0 {
+			continue
+		}
+		if parent == nil {
+			reader, ok := bc.(crossLinkReader)
+			if !ok {
+				return nil, ctxerror.New("cannot read cross links of the chain")
+			}
+			parentLink, err := reader.ReadCrossLink(linked.ShardID(), linked.Number().Uint64()-1, false)
+			if err != nil {
+				return nil, ctxerror.New("cannot find cross-linked parent block",
+					"shardID", linked.ShardID(),
+					"blockNum", linked.Number(),
+				).WithCause(err)
+			}
+			parent = parentLink.Header()
+		}
+		if parent.Hash() != linked.ParentHash() {
+			return nil, ctxerror.New("cross-linked block does not follow its parent",
+				"shardID", linked.ShardID(),
+				"blockNum", linked.Number(),
+			)
+		}
+		if !settledOnBeacon(bc.Config(), parent) {
+			continue
+		}
+		rewards, err := signerRewards(bc, parent, linked, db)
+		if err != nil {
+			return nil, ctxerror.New("cannot compute cross-linked block rewards",
+				"shardID", linked.ShardID(),
+				"blockNum", linked.Number(),
+			).WithCause(err)
+		}
+		settled = append(settled, rewards)
+	}
+	return settled, nil
+}
+
+// signerRewards returns the payouts of the reward for signing parentHeader,
+// whose signers the commit bitmap of header names, weighted by the staking
+// registry of the given state if the reward configuration says so.
+func signerRewards(
+	bc engine.ChainReader, parentHeader *block.Header, header *block.Header,
+	db *state.DB,
+) (*types.BlockRewards, error) {
 	parentShardState, err := bc.ReadShardState(parentHeader.Epoch())
 	if err != nil {
-		return ctxerror.New("cannot read shard state",
+		return nil, ctxerror.New("cannot read shard state",
 			"epoch", parentHeader.Epoch(),
 		).WithCause(err)
 	}
 	parentCommittee := parentShardState.FindCommitteeByID(parentHeader.ShardID())
 	if parentCommittee == nil {
-		return ctxerror.New("cannot find shard in the shard state",
+		return nil, ctxerror.New("cannot find shard in the shard state",
 			"parentBlockNumber", parentHeader.Number(),
 			"shardID", parentHeader.ShardID(),
 		)
@@ -61,45 +195,155 @@ func AccumulateRewards(
 		committerKey := new(bls.PublicKey)
 		err := member.BlsPublicKey.ToLibBLSPublicKey(committerKey)
 		if err != nil {
-			return ctxerror.New("cannot convert BLS public key",
+			return nil, ctxerror.New("cannot convert BLS public key",
 				"blsPublicKey", member.BlsPublicKey).WithCause(err)
 		}
 		committerKeys = append(committerKeys, committerKey)
 	}
 	mask, err := bls2.NewMask(committerKeys, nil)
 	if err != nil {
-		return ctxerror.New("cannot create group sig mask").WithCause(err)
+		return nil, ctxerror.New("cannot create group sig mask").WithCause(err)
 	}
 	if err := mask.SetMask(header.LastCommitBitmap()); err != nil {
-		return ctxerror.New("cannot set group sig mask bits").WithCause(err)
+		return nil, ctxerror.New("cannot set group sig mask bits").WithCause(err)
 	}
-	totalAmount := big.NewInt(0)
-	var accounts []common.Address
-	signers := []string{}
-	for idx, member := range parentCommittee.NodeList {
+
+	config := core.ShardingSchedule.RewardConfig()
+	rewards := &types.BlockRewards{
+		BlockNum: header.Number(),
+		Total:    config.BlockReward(parentHeader.Epoch()),
+	}
+	active := config.IsActive(parentHeader.Epoch())
+	rest := new(big.Int).Set(rewards.Total)
+	if active && config.LeaderSharePercent > 0 {
+		for _, member := range parentCommittee.NodeList {
+			if member.EcdsaAddress != parentHeader.Coinbase() {
+				continue
+			}
+			leaderShare := new(big.Int).SetUint64(config.LeaderSharePercent)
+			leaderShare.Mul(leaderShare, rewards.Total).Div(leaderShare, big.NewInt(100))
+			rewards.Payouts = append(rewards.Payouts, types.RewardPayout{
+				Account:        member.EcdsaAddress,
+				Kind:           types.LeaderReward,
+				BlsPublicKey:   member.BlsPublicKey,
+				Amount:         leaderShare,
+				ShardID:        parentHeader.ShardID(),
+				SignedBlockNum: parentHeader.Number(),
+			})
+			rest.Sub(rest, leaderShare)
+			break
+		}
+	}
+	var signers []int
+	for idx := range parentCommittee.NodeList {
 		if signed, err := mask.IndexEnabled(idx); err != nil {
-			return ctxerror.New("cannot check for committer bit",
+			return nil, ctxerror.New("cannot check for committer bit",
 				"committerIndex", idx,
 			).WithCause(err)
 		} else if signed {
-			accounts = append(accounts, member.EcdsaAddress)
-		}
-	}
-	numAccounts := big.NewInt(int64(len(accounts)))
-	last := new(big.Int)
-	for i, account := range accounts {
-		cur := new(big.Int)
-		cur.Mul(BlockReward, big.NewInt(int64(i+1))).Div(cur, numAccounts)
-		diff := new(big.Int).Sub(cur, last)
-		signers = append(signers, common2.MustAddressToBech32(account))
-		state.AddBalance(account, diff)
-		totalAmount = new(big.Int).Add(totalAmount, diff)
+			signers = append(signers, idx)
+		}
+	}
+	if len(signers) == 0 {
+		if !active {
+			// legacy blocks credited nothing then
+			rewards.Total = big.NewInt(0)
+			return rewards, nil
+		}
+		return nil, ctxerror.New("no signer in the commit bitmap",
+			"parentBlockNumber", parentHeader.Number())
+	}
+
+	var shares []rewardShare
+	if active && config.StakeWeighted && db != nil {
+		stakeInfo, err := core.ReadStakeInfo(db)
+		if err != nil {
+			return nil, ctxerror.New("cannot read stakes").WithCause(err)
+		}
+		stakes := make(map[shard.BlsPublicKey][]*structs.StakeInfo)
+		for _, info := range stakeInfo {
+			stakes[info.BlsPublicKey] = append(stakes[info.BlsPublicKey], info)
+		}
+		for _, idx := range signers {
+			key := parentCommittee.NodeList[idx].BlsPublicKey
+			// the registry map has no order; pay in account order for
+			// every node to credit the same rounding
+			infos := stakes[key]
+			sort.Slice(infos, func(i, j int) bool {
+				return bytes.Compare(infos[i].Account[:], infos[j].Account[:]) < 0
+			})
+			for _, info := range infos {
+				shares = append(shares, rewardShare{
+					account: info.Account,
+					key:     key,
+					weight:  info.Amount,
+				})
+			}
+		}
+	}
+	if len(shares) == 0 {
+		for _, idx := range signers {
+			shares = append(shares, rewardShare{
+				account: parentCommittee.NodeList[idx].EcdsaAddress,
+				key:     parentCommittee.NodeList[idx].BlsPublicKey,
+				weight:  common.Big1,
+			})
+		}
+	}
+	totalWeight := big.NewInt(0)
+	for _, share := range shares {
+		totalWeight.Add(totalWeight, share.weight)
+	}
+	// pay the difference of the cumulative amounts, so that the payouts add
+	// up to the reward exactly
+	weight, last := big.NewInt(0), big.NewInt(0)
+	for _, share := range shares {
+		weight.Add(weight, share.weight)
+		cur := new(big.Int).Mul(rest, weight)
+		cur.Div(cur, totalWeight)
+		if amount := new(big.Int).Sub(cur, last); amount.Sign() > 0 {
+			rewards.Payouts = append(rewards.Payouts, types.RewardPayout{
+				Account:        share.account,
+				Kind:           types.SignerReward,
+				BlsPublicKey:   share.key,
+				Amount:         amount,
+				ShardID:        parentHeader.ShardID(),
+				SignedBlockNum: parentHeader.Number(),
+			})
+		}
 		last = cur
 	}
+	return rewards, nil
+}
+
+// AccumulateRewards credits the accounts with their payouts of the reward for
+// signing the parent of the given block, as computed by BlockRewards.  The
+// payouts of validators of the staking registry are split among their
+// delegators by share in the registry, for them to collect.
+func AccumulateRewards(
+	bc engine.ChainReader, state *state.DB, header *block.Header,
+) error {
+	rewards, err := BlockRewards(bc, header, state)
+	if err != nil || rewards == nil {
+		return err
+	}
+	accounts := []string{}
+	for _, payout := range rewards.Payouts {
+		registered, err := core.AddValidatorRewards(state, payout.Account, payout.Amount)
+		if err != nil {
+			return ctxerror.New("cannot credit validator rewards",
+				"account", payout.Account,
+			).WithCause(err)
+		}
+		if !registered {
+			state.AddBalance(payout.Account, payout.Amount)
+		}
+		accounts = append(accounts, common2.MustAddressToBech32(payout.Account))
+	}
 	header.Logger(utils.Logger()).Debug().
-		Str("NumAccounts", numAccounts.String()).
-		Str("TotalAmount", totalAmount.String()).
-		Strs("Signers", signers).
+		Int("NumPayouts", len(rewards.Payouts)).
+		Str("TotalAmount", rewards.Total.String()).
+		Strs("Accounts", accounts).
 		Msg("[Block Reward] Successfully paid out block reward")
 	return nil
 }
@@ -0,0 +1,385 @@
+package chain
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/harmony-one/harmony/block"
+	blockfactory "github.com/harmony-one/harmony/block/factory"
+	"github.com/harmony-one/harmony/consensus/engine"
+	"github.com/harmony-one/harmony/core"
+	"github.com/harmony-one/harmony/core/state"
+	"github.com/harmony-one/harmony/core/types"
+	shardingconfig "github.com/harmony-one/harmony/internal/configs/sharding"
+	"github.com/harmony-one/harmony/internal/ctxerror"
+	"github.com/harmony-one/harmony/internal/params"
+	"github.com/harmony-one/harmony/shard"
+)
+
+// rewardSchedule overrides the reward configuration of a schedule.
+type rewardSchedule struct {
+	shardingconfig.Schedule
+	config *shardingconfig.RewardConfig
+}
+
+func (s rewardSchedule) RewardConfig() *shardingconfig.RewardConfig {
+	return s.config
+}
+
+// newStakedState returns a state whose staking registry holds a validator
+// for each given key with a stake of the given amount, owned by an account
+// derived from the amount.
+func newStakedState(t *testing.T, stakes map[shard.BlsPublicKey]int64) *state.DB {
+	db, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	for key, amount := range stakes {
+		account := common.BigToAddress(big.NewInt(1000 + amount))
+		db.AddBalance(account, big.NewInt(amount))
+		data, err := types.EncodeStakingMessage(&types.CreateValidator{BlsPublicKey: key})
+		if err != nil {
+			t.Fatalf("cannot encode staking message: %v", err)
+		}
+		if _, err := core.ApplyStakingMessage(db, common.Big0, account, big.NewInt(amount), data); err != nil {
+			t.Fatalf("cannot stake: %v", err)
+		}
+	}
+	return db
+}
+
+// setupRewards installs a reward configuration over the test committee
+// schedule, and returns the committee and a chain whose parent block was
+// proposed by the member of the given index and signed by the first
+// numSigners members.
+func setupRewards(
+	t *testing.T, config *shardingconfig.RewardConfig, leader int, numSigners int,
+) (shard.NodeIDList, *testChain, *block.Header, func()) {
+	_, restoreCommittee := setupCommittee(t)
+	core.ShardingSchedule = rewardSchedule{core.ShardingSchedule, config}
+	// the test stakes are below the minimum stake
+	minStake := core.MinValidatorStake
+	core.MinValidatorStake = big.NewInt(1)
+	restore := func() {
+		core.MinValidatorStake = minStake
+		restoreCommittee()
+	}
+	committee := core.GetShardState(big.NewInt(0)).FindCommitteeByID(0).NodeList
+
+	parent := blockfactory.NewTestHeader().With().
+		Number(big.NewInt(1)).
+		Coinbase(committee[leader].EcdsaAddress).
+		Header()
+	bitmap := make([]byte, (testCommitteeSize+7)/8)
+	for i := 0; i < numSigners; i++ {
+		bitmap[i>>3] |= byte(1) << uint(i&7)
+	}
+	header := blockfactory.NewTestHeader().With().
+		ParentHash(parent.Hash()).
+		Number(big.NewInt(2)).
+		LastCommitBitmap(bitmap).
+		Header()
+	c := &testChain{headers: map[common.Hash]*block.Header{parent.Hash(): parent}}
+	return committee, c, header, restore
+}
+
+func checkPayout(t *testing.T, payout types.RewardPayout, kind types.RewardPayoutKind, account common.Address, amount int64) {
+	if payout.Kind != kind || payout.Account != account || payout.Amount.Cmp(big.NewInt(amount)) != 0 {
+		t.Errorf("wrong payout %v %x %v, want %v %x %d",
+			payout.Kind, payout.Account, payout.Amount, kind, account, amount)
+	}
+}
+
+func TestBlockRewardsLeaderShare(t *testing.T) {
+	config := &shardingconfig.RewardConfig{
+		Epoch:              big.NewInt(0),
+		InitialBlockReward: big.NewInt(1000),
+		MinBlockReward:     big.NewInt(0),
+		LeaderSharePercent: 10,
+	}
+	committee, c, header, restore := setupRewards(t, config, 3, 10)
+	defer restore()
+
+	rewards, err := BlockRewards(c, header, nil)
+	if err != nil {
+		t.Fatalf("cannot compute block rewards: %v", err)
+	}
+	if len(rewards.Payouts) != 11 {
+		t.Fatalf("got %d payouts, want 11", len(rewards.Payouts))
+	}
+	checkPayout(t, rewards.Payouts[0], types.LeaderReward, committee[3].EcdsaAddress, 100)
+	for i, payout := range rewards.Payouts[1:] {
+		checkPayout(t, payout, types.SignerReward, committee[i].EcdsaAddress, 90)
+	}
+	if rewards.Total.Cmp(big.NewInt(1000)) != 0 || rewards.Paid().Cmp(rewards.Total) != 0 {
+		t.Errorf("paid %v out of %v, want 1000", rewards.Paid(), rewards.Total)
+	}
+
+	db, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	if err := AccumulateRewards(c, db, header); err != nil {
+		t.Fatalf("cannot accumulate rewards: %v", err)
+	}
+	if balance := db.GetBalance(committee[3].EcdsaAddress); balance.Cmp(big.NewInt(190)) != 0 {
+		t.Errorf("leader balance is %v, want 190", balance)
+	}
+	if balance := db.GetBalance(committee[10].EcdsaAddress); balance.Sign() != 0 {
+		t.Errorf("non-signer balance is %v, want 0", balance)
+	}
+}
+
+func TestBlockRewardsStakeWeighted(t *testing.T) {
+	config := &shardingconfig.RewardConfig{
+		Epoch:              big.NewInt(0),
+		InitialBlockReward: big.NewInt(900),
+		MinBlockReward:     big.NewInt(0),
+		StakeWeighted:      true,
+	}
+	committee, c, header, restore := setupRewards(t, config, 0, 10)
+	defer restore()
+
+	stakes := map[shard.BlsPublicKey]int64{}
+	for i, member := range committee[1:] {
+		stakes[member.BlsPublicKey] = int64(i + 1)
+	}
+	rewards, err := BlockRewards(c, header, newStakedState(t, stakes))
+	if err != nil {
+		t.Fatalf("cannot compute block rewards: %v", err)
+	}
+	// signers 1 to 9 staked 45 in total; signer 0 has no stake
+	if len(rewards.Payouts) != 9 {
+		t.Fatalf("got %d payouts, want 9", len(rewards.Payouts))
+	}
+	for i, payout := range rewards.Payouts {
+		checkPayout(t, payout, types.SignerReward, common.BigToAddress(big.NewInt(int64(1001+i))), int64(20*(i+1)))
+	}
+
+	// no stake at all falls back to an equal split
+	rewards, err = BlockRewards(c, header, newStakedState(t, nil))
+	if err != nil {
+		t.Fatalf("cannot compute block rewards: %v", err)
+	}
+	if len(rewards.Payouts) != 10 {
+		t.Fatalf("got %d payouts, want 10", len(rewards.Payouts))
+	}
+	for i, payout := range rewards.Payouts {
+		checkPayout(t, payout, types.SignerReward, committee[i].EcdsaAddress, 90)
+	}
+}
+
+func TestBlockRewardsWithoutSigners(t *testing.T) {
+	config := &shardingconfig.RewardConfig{
+		Epoch:              big.NewInt(0),
+		InitialBlockReward: big.NewInt(1000),
+		MinBlockReward:     big.NewInt(0),
+	}
+	_, c, header, restore := setupRewards(t, config, 0, 0)
+	defer restore()
+
+	if _, err := BlockRewards(c, header, nil); err == nil {
+		t.Errorf("block rewards paid without signers")
+	}
+}
+
+func TestBlockRewardsLegacy(t *testing.T) {
+	config := &shardingconfig.RewardConfig{
+		Epoch:              big.NewInt(1),
+		InitialBlockReward: big.NewInt(1000),
+		MinBlockReward:     big.NewInt(0),
+		LeaderSharePercent: 10,
+		StakeWeighted:      true,
+	}
+	committee, c, header, restore := setupRewards(t, config, 3, 10)
+	defer restore()
+
+	// before the configuration is active, the signers split the legacy
+	// reward equally, whatever their stake
+	stakes := map[shard.BlsPublicKey]int64{committee[0].BlsPublicKey: 100}
+	rewards, err := BlockRewards(c, header, newStakedState(t, stakes))
+	if err != nil {
+		t.Fatalf("cannot compute block rewards: %v", err)
+	}
+	if len(rewards.Payouts) != 10 {
+		t.Fatalf("got %d payouts, want 10", len(rewards.Payouts))
+	}
+	share := new(big.Int).Div(shardingconfig.LegacyBlockReward, big.NewInt(10))
+	for i, payout := range rewards.Payouts {
+		checkPayout(t, payout, types.SignerReward, committee[i].EcdsaAddress, share.Int64())
+	}
+
+	// and a block without signers credits nothing
+	_, c, header, restore = setupRewards(t, config, 3, 0)
+	defer restore()
+	rewards, err = BlockRewards(c, header, nil)
+	if err != nil {
+		t.Fatalf("cannot compute block rewards: %v", err)
+	}
+	if len(rewards.Payouts) != 0 || rewards.Total.Sign() != 0 {
+		t.Errorf("paid %v without signers", rewards.Paid())
+	}
+}
+
+// crossLinkChain is a beacon chain reader whose shard state has a copy of
+// the test committee for shard 1, and which has cross-linked the given
+// blocks before.
+type crossLinkChain struct {
+	*testChain
+	links map[uint64]*types.CrossLink
+}
+
+func (c *crossLinkChain) ReadShardState(epoch *big.Int) (shard.State, error) {
+	committee := core.GetShardState(epoch).FindCommitteeByID(0)
+	return shard.State{*committee, shard.Committee{ShardID: 1, NodeList: committee.NodeList}}, nil
+}
+
+func (c *crossLinkChain) ReadCrossLink(shardID uint32, blockNum uint64, temp bool) (*types.CrossLink, error) {
+	if link, ok := c.links[blockNum]; ok && shardID == 1 && !temp {
+		return link, nil
+	}
+	return nil, ctxerror.New("cross link not found")
+}
+
+func TestBlockRewardsCrossLinked(t *testing.T) {
+	config := &shardingconfig.RewardConfig{
+		Epoch:              big.NewInt(0),
+		InitialBlockReward: big.NewInt(1000),
+		MinBlockReward:     big.NewInt(0),
+		LeaderSharePercent: 10,
+		StakeWeighted:      true,
+	}
+	committee, c, header, restore := setupRewards(t, config, 3, 10)
+	defer restore()
+
+	// blocks 5 to 7 of shard 1, 6 and 7 signed by the first 10 members
+	shardParent := blockfactory.NewTestHeader().With().
+		ShardID(1).
+		Number(big.NewInt(5)).
+		Coinbase(committee[3].EcdsaAddress).
+		Header()
+	linked := []*block.Header{shardParent}
+	for i := 0; i < 2; i++ {
+		linked = append(linked, blockfactory.NewTestHeader().With().
+			ShardID(1).
+			ParentHash(linked[i].Hash()).
+			Number(big.NewInt(int64(6+i))).
+			Coinbase(committee[3].EcdsaAddress).
+			LastCommitBitmap(header.LastCommitBitmap()).
+			Header())
+	}
+
+	// the shard credits no reward of its own
+	shardChain := &testChain{headers: map[common.Hash]*block.Header{shardParent.Hash(): shardParent}}
+	rewards, err := BlockRewards(shardChain, linked[1], nil)
+	if err != nil || rewards != nil {
+		t.Fatalf("shard credited rewards %v, %v", rewards, err)
+	}
+
+	// the beacon chain credits them instead, weighted by its registry
+	stakes := map[shard.BlsPublicKey]int64{}
+	for i, member := range committee[1:] {
+		stakes[member.BlsPublicKey] = int64(i + 1)
+	}
+	crossLinks := func(headers ...*block.Header) *block.Header {
+		links := types.CrossLinks{}
+		for _, linkedHeader := range headers {
+			links = append(links, types.NewCrossLink(linkedHeader))
+		}
+		data, err := rlp.EncodeToBytes(links)
+		if err != nil {
+			t.Fatalf("cannot encode cross links: %v", err)
+		}
+		return types.CopyHeader(header).With().CrossLinks(data).Header()
+	}
+	parentLink := types.NewCrossLink(shardParent)
+	beacon := &crossLinkChain{testChain: c, links: map[uint64]*types.CrossLink{5: &parentLink}}
+	rewards, err = BlockRewards(beacon, crossLinks(linked[1], linked[2]), newStakedState(t, stakes))
+	if err != nil {
+		t.Fatalf("cannot compute block rewards: %v", err)
+	}
+	if rewards.Total.Cmp(big.NewInt(3000)) != 0 || rewards.Paid().Cmp(rewards.Total) != 0 {
+		t.Errorf("paid %v out of %v, want 3000", rewards.Paid(), rewards.Total)
+	}
+	// the leader and 9 staked signers of each signed block
+	if len(rewards.Payouts) != 30 {
+		t.Fatalf("got %d payouts, want 30", len(rewards.Payouts))
+	}
+	for i, payout := range rewards.Payouts {
+		shardID, signedBlockNum := uint32(0), int64(1)
+		if i >= 10 {
+			shardID, signedBlockNum = 1, int64(5+(i-10)/10)
+		}
+		if payout.ShardID != shardID || payout.SignedBlockNum.Cmp(big.NewInt(signedBlockNum)) != 0 {
+			t.Errorf("payout %d is for block %v of shard %d, want %d of shard %d",
+				i, payout.SignedBlockNum, payout.ShardID, signedBlockNum, shardID)
+		}
+	}
+	checkPayout(t, rewards.Payouts[10], types.LeaderReward, committee[3].EcdsaAddress, 100)
+	checkPayout(t, rewards.Payouts[11], types.SignerReward, common.BigToAddress(big.NewInt(1001)), 20)
+
+	// a cross-linked block must follow the one cross-linked before it
+	if _, err := BlockRewards(beacon, crossLinks(linked[2]), nil); err == nil {
+		t.Errorf("block rewards paid without the cross-linked parent")
+	}
+	if _, err := BlockRewards(beacon, crossLinks(linked[1], linked[1]), nil); err == nil {
+		t.Errorf("block rewards paid for a cross-link not following its parent")
+	}
+}
+
+// configChain overrides the chain configuration of a chain reader.
+type configChain struct {
+	engine.ChainReader
+	config *params.ChainConfig
+}
+
+func (c configChain) Config() *params.ChainConfig { return c.config }
+
+func TestBlockRewardsBeforeBeaconRewards(t *testing.T) {
+	config := &shardingconfig.RewardConfig{
+		Epoch:              big.NewInt(0),
+		InitialBlockReward: big.NewInt(1000),
+		MinBlockReward:     big.NewInt(0),
+		LeaderSharePercent: 10,
+	}
+	committee, c, header, restore := setupRewards(t, config, 3, 10)
+	defer restore()
+
+	// cross-links are on, but the beacon rewards fork is not
+	chainConfig := *params.TestChainConfig
+	chainConfig.BeaconRewardsEpoch = big.NewInt(1)
+
+	shardParent := blockfactory.NewTestHeader().With().
+		ShardID(1).
+		Number(big.NewInt(5)).
+		Header()
+	if settledOnBeacon(&chainConfig, shardParent) {
+		t.Errorf("shard block reward credited on the beacon chain before the fork")
+	}
+	if !settledOnBeacon(params.TestChainConfig, shardParent) {
+		t.Errorf("shard block reward not credited on the beacon chain after the fork")
+	}
+
+	// the beacon chain credits only the reward of its own parent, without
+	// looking up the cross-linked parents
+	linked := blockfactory.NewTestHeader().With().
+		ShardID(1).
+		ParentHash(shardParent.Hash()).
+		Number(big.NewInt(6)).
+		LastCommitBitmap(header.LastCommitBitmap()).
+		Header()
+	data, err := rlp.EncodeToBytes(types.CrossLinks{types.NewCrossLink(linked)})
+	if err != nil {
+		t.Fatalf("cannot encode cross links: %v", err)
+	}
+	beacon := configChain{c, &chainConfig}
+	rewards, err := BlockRewards(beacon, types.CopyHeader(header).With().CrossLinks(data).Header(), nil)
+	if err != nil {
+		t.Fatalf("cannot compute block rewards: %v", err)
+	}
+	if len(rewards.Payouts) != 11 {
+		t.Fatalf("got %d payouts, want 11", len(rewards.Payouts))
+	}
+	checkPayout(t, rewards.Payouts[0], types.LeaderReward, committee[3].EcdsaAddress, 100)
+	if rewards.Total.Cmp(big.NewInt(1000)) != 0 || rewards.Paid().Cmp(rewards.Total) != 0 {
+		t.Errorf("paid %v out of %v, want 1000", rewards.Paid(), rewards.Total)
+	}
+}
@@ -0,0 +1,186 @@
+package chain
+
+import (
+	"encoding/binary"
+	"math/big"
+	"runtime"
+	"sync"
+
+	"github.com/harmony-one/bls/ffi/go/bls"
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/harmony-one/harmony/block"
+	"github.com/harmony-one/harmony/consensus/engine"
+	"github.com/harmony-one/harmony/core"
+	bls2 "github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/internal/ctxerror"
+)
+
+const (
+	// committeeCacheSize is the number of (epoch, shard) committees whose
+	// public keys are kept deserialized.
+	committeeCacheSize = 64
+	// sealBatchSize is the number of header seals verified in one BLS batch.
+	sealBatchSize = 32
+)
+
+type committeeCacheKey struct {
+	epoch   uint64
+	shardID uint32
+}
+
+var committeeCache, _ = lru.New(committeeCacheSize)
+
+// PurgeCommitteeCache drops the cached committee public keys.  It must be
+// called after core.ShardingSchedule is replaced, e.g. in tests.
+func PurgeCommitteeCache() {
+	committeeCache.Purge()
+}
+
+// committeePublicKeys returns the public keys of the committee of the given
+// shard in the given epoch.  Building them from the shard state deserializes
+// every key, so they are cached per epoch.  The returned slice must not be
+// modified.
+func committeePublicKeys(epoch *big.Int, shardID uint32) ([]*bls.PublicKey, error) {
+	key := committeeCacheKey{epoch.Uint64(), shardID}
+	if keys, ok := committeeCache.Get(key); ok {
+		return keys.([]*bls.PublicKey), nil
+	}
+	committee := core.GetShardState(epoch).FindCommitteeByID(shardID)
+	if committee == nil {
+		return nil, ctxerror.New("cannot find shard in the shard state",
+			"epoch", epoch,
+			"shardID", shardID,
+		)
+	}
+	var committerKeys []*bls.PublicKey
+	for _, member := range committee.NodeList {
+		committerKey := new(bls.PublicKey)
+		err := member.BlsPublicKey.ToLibBLSPublicKey(committerKey)
+		if err != nil {
+			return nil, ctxerror.New("cannot convert BLS public key",
+				"blsPublicKey", member.BlsPublicKey).WithCause(err)
+		}
+		committerKeys = append(committerKeys, committerKey)
+	}
+	committeeCache.Add(key, committerKeys)
+	return committerKeys, nil
+}
+
+// headerSeal is the last commit signature of a header, ready to be verified.
+type headerSeal struct {
+	header    *block.Header
+	sig       *bls.Sign
+	publicKey *bls.PublicKey // aggregate public key of the signers
+	payload   []byte         // the signed block number and hash of the parent
+}
+
+// readSeal reads the last commit signature and bitmap of header and checks
+// that the signers reach the quorum of the parent's committee; the signature
+// itself is not verified.
+func (e *engineImpl) readSeal(header, parentHeader *block.Header) (*headerSeal, error) {
+	publicKeys, err := committeePublicKeys(parentHeader.Epoch(), parentHeader.ShardID())
+	if err != nil {
+		return nil, ctxerror.New("[VerifySeal] Cannot retrieve publickeys from last block").WithCause(err)
+	}
+	sig := header.LastCommitSignature()
+	payload := append(sig[:], header.LastCommitBitmap()...)
+	aggSig, mask, err := ReadSignatureBitmapByPublicKeys(payload, publicKeys)
+	if err != nil {
+		return nil, ctxerror.New("[VerifySeal] Unable to deserialize the LastCommitSignature and LastCommitBitmap in Block Header").WithCause(err)
+	}
+	if !e.isQuorumAchieved(parentHeader.Epoch(), mask) {
+		return nil, ctxerror.New("[VerifySeal] Not enough signature in LastCommitSignature from Block Header",
+			"got", mask.CountEnabled(), "total", mask.CountTotal())
+	}
+
+	parentHash := header.ParentHash()
+	blockNumHash := make([]byte, 8)
+	binary.LittleEndian.PutUint64(blockNumHash, header.Number().Uint64()-1)
+	lastCommitPayload := append(blockNumHash, parentHash[:]...)
+	return &headerSeal{
+		header:    header,
+		sig:       aggSig,
+		publicKey: mask.AggregatePublic,
+		payload:   lastCommitPayload,
+	}, nil
+}
+
+// verify verifies the seal on its own.
+func (s *headerSeal) verify() error {
+	if !s.sig.VerifyHash(s.publicKey, s.payload) {
+		return ctxerror.New("[VerifySeal] Unable to verify aggregated signature from last block",
+			"lastBlockNum", s.header.Number().Uint64()-1,
+			"lastBlockHash", s.header.ParentHash())
+	}
+	return nil
+}
+
+// verifyHeaderRange verifies headers[start:end] in batches of sealBatchSize,
+// one goroutine per batch, and returns the result of each header in order.
+func (e *engineImpl) verifyHeaderRange(chain engine.ChainReader, headers []*block.Header, seals []bool, start, end int) []error {
+	errs := make([]error, end-start)
+	var wg sync.WaitGroup
+	for from := start; from < end; from += sealBatchSize {
+		to := from + sealBatchSize
+		if to > end {
+			to = end
+		}
+		wg.Add(1)
+		go func(from, to int) {
+			defer wg.Done()
+			e.verifyHeaderBatch(chain, headers, seals, from, to, errs[from-start:to-start])
+		}(from, to)
+	}
+	wg.Wait()
+	return errs
+}
+
+// verifyHeaderBatch verifies headers[from:to] into errs, verifying all their
+// seals with one BLS batch verification.  Only if the batch fails are the
+// seals verified one by one to find the invalid ones.
+func (e *engineImpl) verifyHeaderBatch(chain engine.ChainReader, headers []*block.Header, seals []bool, from, to int, errs []error) {
+	checkSeals := chain.CurrentHeader().Number().Uint64() > uint64(1)
+	batch := bls2.NewBatchVerifier()
+	pending := []*headerSeal{}
+	pendingIndexes := []int{}
+	for i := from; i < to; i++ {
+		parentHeader := parentOf(chain, headers, i)
+		if parentHeader == nil {
+			errs[i-from] = engine.ErrUnknownAncestor
+			continue
+		}
+		if !seals[i] || !checkSeals {
+			continue
+		}
+		seal, err := e.readSeal(headers[i], parentHeader)
+		if err != nil {
+			errs[i-from] = err
+			continue
+		}
+		batch.Add(seal.sig, seal.publicKey, seal.payload)
+		pending = append(pending, seal)
+		pendingIndexes = append(pendingIndexes, i-from)
+	}
+	if batch.Verify() {
+		return
+	}
+	for j, seal := range pending {
+		errs[pendingIndexes[j]] = seal.verify()
+	}
+}
+
+// parentOf returns the parent of headers[i], which is either the previous
+// header of the batch or a header already in the chain.
+func parentOf(chain engine.ChainReader, headers []*block.Header, i int) *block.Header {
+	header := headers[i]
+	if i > 0 && headers[i-1].Hash() == header.ParentHash() {
+		return headers[i-1]
+	}
+	return chain.GetHeader(header.ParentHash(), header.Number().Uint64()-1)
+}
+
+// verifyWindow is the number of headers verified concurrently.
+func verifyWindow() int {
+	return sealBatchSize * runtime.NumCPU()
+}
@@ -0,0 +1,380 @@
+package chain
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/harmony-one/bls/ffi/go/bls"
+
+	"github.com/harmony-one/harmony/block"
+	blockfactory "github.com/harmony-one/harmony/block/factory"
+	"github.com/harmony-one/harmony/core"
+	"github.com/harmony-one/harmony/core/types"
+	bls2 "github.com/harmony-one/harmony/crypto/bls"
+	shardingconfig "github.com/harmony-one/harmony/internal/configs/sharding"
+	"github.com/harmony-one/harmony/internal/genesis"
+	"github.com/harmony-one/harmony/internal/params"
+	"github.com/harmony-one/harmony/shard"
+)
+
+const testCommitteeSize = 32
+
+// testChain is a chain reader over headers kept in memory.
+type testChain struct {
+	headers map[common.Hash]*block.Header
+	current *block.Header
+}
+
+func (c *testChain) Config() *params.ChainConfig { return params.TestChainConfig }
+
+func (c *testChain) CurrentHeader() *block.Header { return c.current }
+
+func (c *testChain) GetHeader(hash common.Hash, number uint64) *block.Header {
+	return c.headers[hash]
+}
+
+func (c *testChain) GetHeaderByNumber(number uint64) *block.Header {
+	for _, header := range c.headers {
+		if header.Number().Uint64() == number {
+			return header
+		}
+	}
+	return nil
+}
+
+func (c *testChain) GetHeaderByHash(hash common.Hash) *block.Header {
+	return c.headers[hash]
+}
+
+func (c *testChain) GetBlock(hash common.Hash, number uint64) *types.Block {
+	return nil
+}
+
+func (c *testChain) ReadShardState(epoch *big.Int) (shard.State, error) {
+	return core.GetShardState(epoch), nil
+}
+
+// setupCommittee installs a one shard schedule with a committee of new keys
+// and returns the sum of their secret keys, which signs for all of them, and
+// a function restoring the previous schedule.
+func setupCommittee(tb testing.TB) (*bls.SecretKey, func()) {
+	keys, restore := setupCommitteeKeys(tb)
+	aggKey := &bls.SecretKey{}
+	for _, key := range keys {
+		aggKey.Add(key)
+	}
+	return aggKey, restore
+}
+
+// setupCommitteeKeys installs a one shard schedule with a committee of new
+// keys and returns the keys, in committee order, and a function restoring the
+// previous schedule.
+func setupCommitteeKeys(tb testing.TB) ([]*bls.SecretKey, func()) {
+	accounts := []genesis.DeployAccount{}
+	keys := []*bls.SecretKey{}
+	for i := 0; i < testCommitteeSize; i++ {
+		key := bls2.RandPrivateKey()
+		keys = append(keys, key)
+		accounts = append(accounts, genesis.DeployAccount{
+			Index:        fmt.Sprintf("%d", i),
+			Address:      common.BigToAddress(big.NewInt(int64(i + 1))).Hex(),
+			BlsPublicKey: key.GetPublicKey().SerializeToHexStr(),
+		})
+	}
+	instance, err := shardingconfig.NewInstance(1, testCommitteeSize, testCommitteeSize, accounts, nil, nil)
+	if err != nil {
+		tb.Fatalf("cannot create sharding config: %v", err)
+	}
+	prevSchedule := core.ShardingSchedule
+	core.ShardingSchedule = shardingconfig.NewFixedSchedule(instance)
+	PurgeCommitteeCache()
+	return keys, func() {
+		core.ShardingSchedule = prevSchedule
+		PurgeCommitteeCache()
+	}
+}
+
+// makeHeaders returns a chain of n headers after the genesis header, each
+// sealed by the whole committee.  The seal of the header at badIndex, if
+// any, signs the wrong parent.
+func makeHeaders(key *bls.SecretKey, n int, badIndex int) (*block.Header, []*block.Header) {
+	bitmap := make([]byte, (testCommitteeSize+7)/8)
+	for i := range bitmap {
+		bitmap[i] = 0xff
+	}
+	genesisHeader := blockfactory.NewTestHeader().With().Number(big.NewInt(0)).Header()
+	parent := genesisHeader
+	headers := []*block.Header{}
+	for i := 0; i < n; i++ {
+		signedHash := parent.Hash()
+		if i == badIndex {
+			signedHash = common.Hash{}
+		}
+		payload := make([]byte, 8)
+		binary.LittleEndian.PutUint64(payload, parent.Number().Uint64())
+		payload = append(payload, signedHash[:]...)
+		var sig [96]byte
+		copy(sig[:], key.SignHash(payload).Serialize())
+		header := blockfactory.NewTestHeader().With().
+			ParentHash(parent.Hash()).
+			Number(new(big.Int).Add(parent.Number(), big.NewInt(1))).
+			LastCommitSignature(sig).
+			LastCommitBitmap(bitmap).
+			Header()
+		headers = append(headers, header)
+		parent = header
+	}
+	return genesisHeader, headers
+}
+
+// newTestChain returns a chain reader containing genesis and headers, whose
+// current header is high enough for seals to be checked.
+func newTestChain(genesisHeader *block.Header, headers []*block.Header) *testChain {
+	c := &testChain{headers: map[common.Hash]*block.Header{genesisHeader.Hash(): genesisHeader}}
+	for _, header := range headers {
+		c.headers[header.Hash()] = header
+	}
+	c.current = blockfactory.NewTestHeader().With().Number(big.NewInt(2)).Header()
+	return c
+}
+
+func collectResults(n int, results <-chan error) []error {
+	errs := []error{}
+	for i := 0; i < n; i++ {
+		errs = append(errs, <-results)
+	}
+	return errs
+}
+
+func TestVerifyHeaders(t *testing.T) {
+	key, restore := setupCommittee(t)
+	defer restore()
+	n := 2*sealBatchSize + 5
+	badIndex := sealBatchSize + 3
+	genesisHeader, headers := makeHeaders(key, n, badIndex)
+	// only genesis is in the chain; the other parents come from the batch
+	chain := newTestChain(genesisHeader, nil)
+	seals := make([]bool, n)
+	for i := range seals {
+		seals[i] = true
+	}
+	abort, results := Engine.VerifyHeaders(chain, headers, seals)
+	defer close(abort)
+	for i, err := range collectResults(n, results) {
+		if i == badIndex && err == nil {
+			t.Errorf("invalid seal of header %d verified", i)
+		}
+		if i != badIndex && err != nil {
+			t.Errorf("valid header %d not verified: %v", i, err)
+		}
+	}
+}
+
+func TestVerifyHeadersUnknownAncestor(t *testing.T) {
+	key, restore := setupCommittee(t)
+	defer restore()
+	genesisHeader, headers := makeHeaders(key, 3, -1)
+	chain := newTestChain(genesisHeader, nil)
+	// headers[1] is missing, so headers[2] has no known parent
+	abort, results := Engine.VerifyHeaders(chain, []*block.Header{headers[0], headers[2]}, []bool{true, true})
+	defer close(abort)
+	errs := collectResults(2, results)
+	if errs[0] != nil {
+		t.Errorf("header with known parent not verified: %v", errs[0])
+	}
+	if errs[1] == nil {
+		t.Error("header with unknown parent verified")
+	}
+}
+
+func TestVerifySeal(t *testing.T) {
+	key, restore := setupCommittee(t)
+	defer restore()
+	genesisHeader, headers := makeHeaders(key, 3, 1)
+	chain := newTestChain(genesisHeader, headers)
+	if err := Engine.VerifySeal(chain, headers[0]); err != nil {
+		t.Errorf("valid seal not verified: %v", err)
+	}
+	if err := Engine.VerifySeal(chain, headers[1]); err == nil {
+		t.Error("invalid seal verified")
+	}
+}
+
+// weightedQuorumDecider weights the committee keys by the stakes given by
+// serialized key; keys without a stake weigh 1.
+type weightedQuorumDecider map[string]int64
+
+func (d weightedQuorumDecider) weight(key *bls.PublicKey) int64 {
+	if stake, ok := d[key.SerializeToHexStr()]; ok {
+		return stake
+	}
+	return 1
+}
+
+func (d weightedQuorumDecider) IsQuorumAchieved(epoch *big.Int, mask *bls2.Mask) bool {
+	signed, total := int64(0), int64(0)
+	for _, key := range mask.GetPubKeyFromMask(true) {
+		signed += d.weight(key)
+		total += d.weight(key)
+	}
+	for _, key := range mask.GetPubKeyFromMask(false) {
+		total += d.weight(key)
+	}
+	return signed*3 > total*2
+}
+
+func (d weightedQuorumDecider) IsRewardThresholdAchieved(epoch *big.Int, mask *bls2.Mask) bool {
+	return d.IsQuorumAchieved(epoch, mask)
+}
+
+// sealBy returns a child of parent sealed by the keys whose indexes are
+// given.
+func sealBy(keys []*bls.SecretKey, parent *block.Header, signers ...int) *block.Header {
+	bitmap := make([]byte, (len(keys)+7)/8)
+	aggKey := &bls.SecretKey{}
+	for _, i := range signers {
+		bitmap[i>>3] |= byte(1) << uint(i&7)
+		aggKey.Add(keys[i])
+	}
+	parentHash := parent.Hash()
+	payload := make([]byte, 8)
+	binary.LittleEndian.PutUint64(payload, parent.Number().Uint64())
+	payload = append(payload, parentHash[:]...)
+	var sig [96]byte
+	copy(sig[:], aggKey.SignHash(payload).Serialize())
+	return blockfactory.NewTestHeader().With().
+		ParentHash(parentHash).
+		Number(new(big.Int).Add(parent.Number(), big.NewInt(1))).
+		LastCommitSignature(sig).
+		LastCommitBitmap(bitmap).
+		Header()
+}
+
+func TestVerifySealQuorumDecider(t *testing.T) {
+	keys, restore := setupCommitteeKeys(t)
+	defer restore()
+	defer Engine.SetQuorumDecider(nil)
+	genesisHeader := blockfactory.NewTestHeader().With().Number(big.NewInt(0)).Header()
+	// all keys but the first one: a quorum by count, not by stake
+	many := []int{}
+	for i := 1; i < len(keys); i++ {
+		many = append(many, i)
+	}
+	manyHeader := sealBy(keys, genesisHeader, many...)
+	// the first key only: a quorum by stake, not by count
+	richHeader := sealBy(keys, genesisHeader, 0)
+	chain := newTestChain(genesisHeader, []*block.Header{manyHeader, richHeader})
+
+	Engine.SetQuorumDecider(nil)
+	if err := Engine.VerifySeal(chain, manyHeader); err != nil {
+		t.Errorf("seal with a quorum by count not verified: %v", err)
+	}
+	if err := Engine.VerifySeal(chain, richHeader); err == nil {
+		t.Error("seal without a quorum by count verified")
+	}
+
+	richKey := keys[0].GetPublicKey().SerializeToHexStr()
+	Engine.SetQuorumDecider(weightedQuorumDecider{richKey: 1000})
+	if err := Engine.VerifySeal(chain, manyHeader); err == nil {
+		t.Error("seal without a quorum by stake verified")
+	}
+	if err := Engine.VerifySeal(chain, richHeader); err != nil {
+		t.Errorf("seal with a quorum by stake not verified: %v", err)
+	}
+	abort, results := Engine.VerifyHeaders(chain, []*block.Header{manyHeader, richHeader}, []bool{true, true})
+	defer close(abort)
+	errs := collectResults(2, results)
+	if errs[0] == nil || errs[1] != nil {
+		t.Errorf("batch verification disagrees with the quorum decider: %v", errs)
+	}
+}
+
+func TestVerifyHeaderWithSignature(t *testing.T) {
+	keys, restore := setupCommitteeKeys(t)
+	defer restore()
+	defer Engine.SetQuorumDecider(nil)
+	header := blockfactory.NewTestHeader().With().Number(big.NewInt(0)).Header()
+	many := []int{}
+	for i := 1; i < len(keys); i++ {
+		many = append(many, i)
+	}
+	// the commit proof of a header is the seal of its child
+	manyProof := sealBy(keys, header, many...)
+	richProof := sealBy(keys, header, 0)
+	verify := func(proof *block.Header) error {
+		sig := proof.LastCommitSignature()
+		return Engine.VerifyHeaderWithSignature(header, sig[:], proof.LastCommitBitmap())
+	}
+
+	Engine.SetQuorumDecider(nil)
+	if err := verify(manyProof); err != nil {
+		t.Errorf("proof with a quorum by count not verified: %v", err)
+	}
+	if err := verify(richProof); err == nil {
+		t.Error("proof without a quorum by count verified")
+	}
+	tampered := richProof.LastCommitSignature()
+	if err := Engine.VerifyHeaderWithSignature(header, tampered[:], manyProof.LastCommitBitmap()); err == nil {
+		t.Error("proof with a tampered signature verified")
+	}
+
+	richKey := keys[0].GetPublicKey().SerializeToHexStr()
+	Engine.SetQuorumDecider(weightedQuorumDecider{richKey: 1000})
+	if err := verify(manyProof); err == nil {
+		t.Error("proof without a quorum by stake verified")
+	}
+	if err := verify(richProof); err != nil {
+		t.Errorf("proof with a quorum by stake not verified: %v", err)
+	}
+}
+
+// The benchmarks verify b.N chained headers, so ns/op is the time per header.
+
+func BenchmarkVerifyHeaderUncached(b *testing.B) {
+	key, restore := setupCommittee(b)
+	defer restore()
+	genesisHeader, headers := makeHeaders(key, b.N, -1)
+	chain := newTestChain(genesisHeader, headers)
+	b.ResetTimer()
+	for _, header := range headers {
+		PurgeCommitteeCache()
+		if err := Engine.VerifyHeader(chain, header, true); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkVerifyHeader(b *testing.B) {
+	key, restore := setupCommittee(b)
+	defer restore()
+	genesisHeader, headers := makeHeaders(key, b.N, -1)
+	chain := newTestChain(genesisHeader, headers)
+	b.ResetTimer()
+	for _, header := range headers {
+		if err := Engine.VerifyHeader(chain, header, true); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkVerifyHeaders(b *testing.B) {
+	key, restore := setupCommittee(b)
+	defer restore()
+	genesisHeader, headers := makeHeaders(key, b.N, -1)
+	chain := newTestChain(genesisHeader, nil)
+	seals := make([]bool, len(headers))
+	for i := range seals {
+		seals[i] = true
+	}
+	b.ResetTimer()
+	abort, results := Engine.VerifyHeaders(chain, headers, seals)
+	defer close(abort)
+	for _, err := range collectResults(len(headers), results) {
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
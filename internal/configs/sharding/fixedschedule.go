@@ -92,6 +92,27 @@ func (s fixedSchedule) TxsThrottleConfig() *TxsThrottleConfig {
 	}
 }
 
+func (s fixedSchedule) BlockPeriodConfig() *BlockPeriodConfig {
+	return &BlockPeriodConfig{
+		MinBlockPeriod:       mainnetMinBlockPeriod,
+		MaxBlockPeriod:       mainnetMaxBlockPeriod,
+		FullBlockTxs:         mainnetMaxNumTxsPerBlockLimit,
+		MinPhaseTimeout:      mainnetMinPhaseTimeout,
+		MaxPhaseTimeout:      mainnetMaxPhaseTimeout,
+		MinViewChangeTimeout: mainnetMinViewChangeTimeout,
+		MaxViewChangeTimeout: mainnetMaxViewChangeTimeout,
+		TimeoutLatencyFactor: mainnetTimeoutLatencyFactor,
+	}
+}
+
+func (s fixedSchedule) RewardConfig() *RewardConfig {
+	return MainnetSchedule.RewardConfig()
+}
+
+func (s fixedSchedule) FeeConfig() *FeeConfig {
+	return MainnetSchedule.FeeConfig()
+}
+
 func (s fixedSchedule) GetNetworkID() NetworkID {
 	return DevNet
 }
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/harmony-one/harmony/common/denominations"
+	common2 "github.com/harmony-one/harmony/internal/common"
 	"github.com/harmony-one/harmony/internal/genesis"
 )
 
@@ -22,7 +23,7 @@ const (
 	localnetEpochBlock1 = 10
 	twoOne              = 5
 
-	localnetVdfDifficulty  = 5000 // This takes about 10s to finish the vdf
+	localnetVdfDifficulty  = 5000 // This takes about 0.07s to finish the vdf
 	localnetConsensusRatio = float64(0.1)
 
 	localnetRandomnessStartingEpoch = 0
@@ -33,6 +34,26 @@ const (
 	localnetMaxNumTxsPerBlockLimit         = 1000
 	localnetRecentTxDuration               = time.Hour
 	localnetEnableTxnThrottling            = true
+
+	localnetMinBlockPeriod       = time.Second
+	localnetMaxBlockPeriod       = 5 * time.Second
+	localnetMinPhaseTimeout      = 5 * time.Second
+	localnetMaxPhaseTimeout      = 60 * time.Second
+	localnetMinViewChangeTimeout = 5 * time.Second
+	localnetMaxViewChangeTimeout = 60 * time.Second
+	localnetTimeoutLatencyFactor = 4
+
+	localnetRewardEpoch         = localnetV2Epoch
+	localnetInitialBlockReward  = 24 // unit is One
+	localnetMinBlockReward      = 8  // unit is One
+	localnetRewardDecayEpochs   = 10
+	localnetRewardDecayPercent  = 10
+	localnetLeaderSharePercent  = 10
+	localnetStakeWeightedReward = true
+
+	localnetFeeEpoch  = localnetV2Epoch
+	localnetFeePolicy = FeeToTreasury
+	localnetTreasury  = "one1a50tun737ulcvwy0yvve0pvu5skq0kjargvhwe"
 )
 
 func (localnetSchedule) InstanceForEpoch(epoch *big.Int) Instance {
@@ -134,6 +155,39 @@ func (ls localnetSchedule) TxsThrottleConfig() *TxsThrottleConfig {
 	}
 }
 
+func (ls localnetSchedule) BlockPeriodConfig() *BlockPeriodConfig {
+	return &BlockPeriodConfig{
+		MinBlockPeriod:       localnetMinBlockPeriod,
+		MaxBlockPeriod:       localnetMaxBlockPeriod,
+		FullBlockTxs:         localnetMaxNumTxsPerBlockLimit,
+		MinPhaseTimeout:      localnetMinPhaseTimeout,
+		MaxPhaseTimeout:      localnetMaxPhaseTimeout,
+		MinViewChangeTimeout: localnetMinViewChangeTimeout,
+		MaxViewChangeTimeout: localnetMaxViewChangeTimeout,
+		TimeoutLatencyFactor: localnetTimeoutLatencyFactor,
+	}
+}
+
+func (ls localnetSchedule) RewardConfig() *RewardConfig {
+	return &RewardConfig{
+		Epoch:              big.NewInt(localnetRewardEpoch),
+		InitialBlockReward: new(big.Int).Mul(big.NewInt(localnetInitialBlockReward), big.NewInt(denominations.One)),
+		DecayEpochs:        localnetRewardDecayEpochs,
+		DecayPercent:       localnetRewardDecayPercent,
+		MinBlockReward:     new(big.Int).Mul(big.NewInt(localnetMinBlockReward), big.NewInt(denominations.One)),
+		LeaderSharePercent: localnetLeaderSharePercent,
+		StakeWeighted:      localnetStakeWeightedReward,
+	}
+}
+
+func (ls localnetSchedule) FeeConfig() *FeeConfig {
+	return &FeeConfig{
+		Epoch:    big.NewInt(localnetFeeEpoch),
+		Policy:   localnetFeePolicy,
+		Treasury: common2.MustBech32ToAddress(localnetTreasury),
+	}
+}
+
 func (ls localnetSchedule) GetNetworkID() NetworkID {
 	return LocalNet
 }
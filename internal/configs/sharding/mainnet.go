@@ -12,7 +12,7 @@ const (
 	mainnetEpochBlock1 = 344064 // 21 * 2^14
 	blocksPerShard     = 16384  // 2^14
 
-	mainnetVdfDifficulty  = 50000 // This takes about 100s to finish the vdf
+	mainnetVdfDifficulty  = 50000 // This takes about 0.7s to finish the vdf
 	mainnetConsensusRatio = float64(0.1)
 
 	// TODO: remove it after randomness feature turned on mainnet
@@ -33,6 +33,25 @@ const (
 	mainnetRecentTxDuration               = time.Hour
 	mainnetEnableTxnThrottling            = true
 
+	mainnetMinBlockPeriod       = 5 * time.Second
+	mainnetMaxBlockPeriod       = 8 * time.Second
+	mainnetMinPhaseTimeout      = 20 * time.Second
+	mainnetMaxPhaseTimeout      = 60 * time.Second
+	mainnetMinViewChangeTimeout = 20 * time.Second
+	mainnetMaxViewChangeTimeout = 60 * time.Second
+	mainnetTimeoutLatencyFactor = 4
+
+	// The reward schedule below is disabled, i.e. its RewardConfig has no
+	// Epoch, until the epoch it takes effect is decided for mainnet
+	mainnetInitialBlockReward  = 24 // unit is One
+	mainnetMinBlockReward      = 8  // unit is One
+	mainnetRewardDecayEpochs   = 240
+	mainnetRewardDecayPercent  = 10
+	mainnetLeaderSharePercent  = 0
+	mainnetStakeWeightedReward = false
+
+	mainnetFeePolicy = FeeToLeader
+
 	// MainNetHTTPPattern is the http pattern for mainnet.
 	MainNetHTTPPattern = "https://api.s%d.t.hmny.io"
 	// MainNetWSPattern is the websocket pattern for mainnet.
@@ -160,6 +179,36 @@ func (ms mainnetSchedule) TxsThrottleConfig() *TxsThrottleConfig {
 	}
 }
 
+func (ms mainnetSchedule) BlockPeriodConfig() *BlockPeriodConfig {
+	return &BlockPeriodConfig{
+		MinBlockPeriod:       mainnetMinBlockPeriod,
+		MaxBlockPeriod:       mainnetMaxBlockPeriod,
+		FullBlockTxs:         mainnetMaxNumTxsPerBlockLimit,
+		MinPhaseTimeout:      mainnetMinPhaseTimeout,
+		MaxPhaseTimeout:      mainnetMaxPhaseTimeout,
+		MinViewChangeTimeout: mainnetMinViewChangeTimeout,
+		MaxViewChangeTimeout: mainnetMaxViewChangeTimeout,
+		TimeoutLatencyFactor: mainnetTimeoutLatencyFactor,
+	}
+}
+
+func (ms mainnetSchedule) RewardConfig() *RewardConfig {
+	return &RewardConfig{
+		InitialBlockReward: new(big.Int).Mul(big.NewInt(mainnetInitialBlockReward), big.NewInt(denominations.One)),
+		DecayEpochs:        mainnetRewardDecayEpochs,
+		DecayPercent:       mainnetRewardDecayPercent,
+		MinBlockReward:     new(big.Int).Mul(big.NewInt(mainnetMinBlockReward), big.NewInt(denominations.One)),
+		LeaderSharePercent: mainnetLeaderSharePercent,
+		StakeWeighted:      mainnetStakeWeightedReward,
+	}
+}
+
+func (ms mainnetSchedule) FeeConfig() *FeeConfig {
+	return &FeeConfig{
+		Policy: mainnetFeePolicy,
+	}
+}
+
 func (ms mainnetSchedule) GetNetworkID() NetworkID {
 	return MainNet
 }
@@ -101,6 +101,27 @@ func (ps pangaeaSchedule) TxsThrottleConfig() *TxsThrottleConfig {
 	}
 }
 
+func (ps pangaeaSchedule) BlockPeriodConfig() *BlockPeriodConfig {
+	return &BlockPeriodConfig{
+		MinBlockPeriod:       mainnetMinBlockPeriod,
+		MaxBlockPeriod:       mainnetMaxBlockPeriod,
+		FullBlockTxs:         mainnetMaxNumTxsPerBlockLimit,
+		MinPhaseTimeout:      mainnetMinPhaseTimeout,
+		MaxPhaseTimeout:      mainnetMaxPhaseTimeout,
+		MinViewChangeTimeout: mainnetMinViewChangeTimeout,
+		MaxViewChangeTimeout: mainnetMaxViewChangeTimeout,
+		TimeoutLatencyFactor: mainnetTimeoutLatencyFactor,
+	}
+}
+
+func (ps pangaeaSchedule) RewardConfig() *RewardConfig {
+	return MainnetSchedule.RewardConfig()
+}
+
+func (ps pangaeaSchedule) FeeConfig() *FeeConfig {
+	return MainnetSchedule.FeeConfig()
+}
+
 func (pangaeaSchedule) GetNetworkID() NetworkID {
 	return Pangaea
 }
@@ -7,6 +7,9 @@ import (
 	"math/big"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/harmony-one/harmony/common/denominations"
 	"github.com/harmony-one/harmony/internal/genesis"
 )
 
@@ -58,6 +61,15 @@ type Schedule interface {
 	// configuration for throttling pending transactions
 	TxsThrottleConfig() *TxsThrottleConfig
 
+	// configuration for the adaptive block period and consensus timeouts
+	BlockPeriodConfig() *BlockPeriodConfig
+
+	// configuration for the block reward issuance and distribution
+	RewardConfig() *RewardConfig
+
+	// configuration for what happens to the transaction fees
+	FeeConfig() *FeeConfig
+
 	// GetNetworkID() return networkID type.
 	GetNetworkID() NetworkID
 
@@ -114,6 +126,15 @@ func (result TxThrottleFlag) String() string {
 	return "TxThrottleUnknown"
 }
 
+// TxThrottleStatus is the throttling result of a transaction considered for
+// a new block, with the reason it was not selected
+type TxThrottleStatus struct {
+	Flag   TxThrottleFlag
+	Reason string
+	// BlockNum is the number of the block the transaction was considered for
+	BlockNum uint64
+}
+
 // TxsThrottleConfig contains configuration for throttling pending transactions per node block
 type TxsThrottleConfig struct {
 	// Max amount limit for a valid transaction
@@ -135,6 +156,146 @@ type TxsThrottleConfig struct {
 	EnableTxnThrottling bool
 }
 
+// BlockPeriodConfig contains the limits of the block period of the leader and
+// of the consensus timeouts, which adapt to the observed round latency, the
+// pending transactions and the view changes
+type BlockPeriodConfig struct {
+	// Shortest time between two block proposals, when at least FullBlockTxs
+	// transactions are pending
+	MinBlockPeriod time.Duration
+
+	// Longest time between two block proposals, when no transaction is pending
+	MaxBlockPeriod time.Duration
+
+	// Number of pending transactions for which the leader proposes a block
+	// after MinBlockPeriod
+	FullBlockTxs int
+
+	// Range of the announce/prepare/commit timeout, which is
+	// TimeoutLatencyFactor times the average round latency, doubled after
+	// each view change until a block is committed
+	MinPhaseTimeout time.Duration
+	MaxPhaseTimeout time.Duration
+
+	// Range of the base view change timeout, which is TimeoutLatencyFactor
+	// times the average round latency
+	MinViewChangeTimeout time.Duration
+	MaxViewChangeTimeout time.Duration
+
+	// Multiple of the average round latency the timeouts allow for
+	TimeoutLatencyFactor int
+}
+
+// LegacyBlockReward is the reward of each block before the reward
+// configuration of the schedule takes effect, split equally among the signers
+var LegacyBlockReward = new(big.Int).Mul(big.NewInt(24), big.NewInt(denominations.One))
+
+// RewardConfig contains the block reward schedule and how the reward of a
+// block is split among the validators who signed it
+type RewardConfig struct {
+	// First epoch whose blocks are rewarded by this configuration; the
+	// blocks of earlier epochs are rewarded LegacyBlockReward
+	Epoch *big.Int
+
+	// Reward of each block in the first DecayEpochs epochs from Epoch
+	InitialBlockReward *big.Int
+
+	// The block reward decreases by DecayPercent percent every DecayEpochs
+	// epochs, down to MinBlockReward; no decay if DecayEpochs is zero
+	DecayEpochs    uint64
+	DecayPercent   uint64
+	MinBlockReward *big.Int
+
+	// Percentage of the block reward paid to the leader who proposed the
+	// block, on top of its share of the rest as a signer
+	LeaderSharePercent uint64
+
+	// Whether the signers share the rest of the block reward in proportion
+	// to the stake on their keys, instead of equally
+	StakeWeighted bool
+}
+
+// IsActive returns whether the configuration rewards the blocks of the given
+// epoch
+func (c *RewardConfig) IsActive(epoch *big.Int) bool {
+	return c.Epoch != nil && epoch.Cmp(c.Epoch) >= 0
+}
+
+// BlockReward returns the reward of each block of the given epoch
+func (c *RewardConfig) BlockReward(epoch *big.Int) *big.Int {
+	if !c.IsActive(epoch) {
+		return new(big.Int).Set(LegacyBlockReward)
+	}
+	reward := new(big.Int).Set(c.InitialBlockReward)
+	if c.DecayEpochs == 0 || c.DecayPercent == 0 {
+		return reward
+	}
+	keep := big.NewInt(0)
+	if c.DecayPercent < 100 {
+		keep.SetUint64(100 - c.DecayPercent)
+	}
+	// the reward strictly decreases every period until it reaches the
+	// minimum or zero, so this ends quickly even for far epochs
+	periods := new(big.Int).Sub(epoch, c.Epoch)
+	periods.Div(periods, new(big.Int).SetUint64(c.DecayEpochs))
+	for i := big.NewInt(0); i.Cmp(periods) < 0 && reward.Cmp(c.MinBlockReward) > 0; i.Add(i, big.NewInt(1)) {
+		reward.Mul(reward, keep).Div(reward, big.NewInt(100))
+	}
+	if reward.Cmp(c.MinBlockReward) < 0 {
+		reward.Set(c.MinBlockReward)
+	}
+	return reward
+}
+
+// FeePolicy tells who is credited the transaction fees of a block
+type FeePolicy int
+
+// FeePolicy values
+const (
+	// FeeToLeader credits the fees to the block coinbase, the leader
+	FeeToLeader FeePolicy = iota
+	// FeeBurn credits the fees to nobody, removing them from the supply
+	FeeBurn
+	// FeeToTreasury credits the fees to the treasury account
+	FeeToTreasury
+)
+
+func (p FeePolicy) String() string {
+	switch p {
+	case FeeToLeader:
+		return "leader"
+	case FeeBurn:
+		return "burn"
+	case FeeToTreasury:
+		return "treasury"
+	}
+	return "unknown"
+}
+
+// FeeConfig contains the policy for the gas fees of the transactions
+type FeeConfig struct {
+	// First epoch whose fees follow Policy; the fees of earlier epochs, or
+	// of all epochs if nil, are credited to the leader
+	Epoch  *big.Int
+	Policy FeePolicy
+
+	// Account credited the fees with the FeeToTreasury policy
+	Treasury common.Address
+}
+
+// PolicyAt returns the fee policy of the given epoch.  The FeeToTreasury
+// policy without a treasury account falls back to FeeToLeader, so that the
+// fees are never credited to the zero address.
+func (c *FeeConfig) PolicyAt(epoch *big.Int) FeePolicy {
+	if c.Epoch == nil || epoch == nil || epoch.Cmp(c.Epoch) < 0 {
+		return FeeToLeader
+	}
+	if c.Policy == FeeToTreasury && c.Treasury == (common.Address{}) {
+		return FeeToLeader
+	}
+	return c.Policy
+}
+
 // genShardingStructure return sharding structure, given shard number and its patterns.
 func genShardingStructure(shardNum, shardID int, httpPattern, wsPattern string) []map[string]interface{} {
 	res := []map[string]interface{}{}
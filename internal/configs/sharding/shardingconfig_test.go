@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"math/big"
 	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
 )
 
 func TestMainnetInstanceForEpoch(t *testing.T) {
@@ -106,3 +108,89 @@ func TestGetShardingStructure(t *testing.T) {
 		}
 	}
 }
+
+func TestRewardConfigBlockReward(t *testing.T) {
+	config := &RewardConfig{
+		Epoch:              big.NewInt(0),
+		InitialBlockReward: big.NewInt(100),
+		DecayEpochs:        10,
+		DecayPercent:       10,
+		MinBlockReward:     big.NewInt(50),
+	}
+	tests := []struct {
+		epoch  int64
+		reward int64
+	}{
+		{0, 100},
+		{9, 100},
+		{10, 90},
+		{25, 81},
+		{30, 72},
+		{60, 51},
+		{70, 50},
+		{1000000000, 50},
+	}
+	for _, test := range tests {
+		if reward := config.BlockReward(big.NewInt(test.epoch)); reward.Cmp(big.NewInt(test.reward)) != 0 {
+			t.Errorf("block reward of epoch %d is %v, want %d", test.epoch, reward, test.reward)
+		}
+	}
+
+	config.DecayEpochs = 0
+	if reward := config.BlockReward(big.NewInt(1000)); reward.Cmp(big.NewInt(100)) != 0 {
+		t.Errorf("block reward decayed without decay epochs: %v", reward)
+	}
+	config.DecayEpochs, config.DecayPercent = 10, 100
+	if reward := config.BlockReward(big.NewInt(10)); reward.Cmp(big.NewInt(50)) != 0 {
+		t.Errorf("block reward below minimum: %v", reward)
+	}
+
+	// the decay starts with the configuration, and earlier epochs are
+	// rewarded the legacy block reward
+	config.Epoch, config.DecayPercent = big.NewInt(100), 10
+	if reward := config.BlockReward(big.NewInt(99)); reward.Cmp(LegacyBlockReward) != 0 {
+		t.Errorf("block reward before the configuration is %v, want %v", reward, LegacyBlockReward)
+	}
+	if reward := config.BlockReward(big.NewInt(109)); reward.Cmp(big.NewInt(100)) != 0 {
+		t.Errorf("block reward of the first decay period is %v, want 100", reward)
+	}
+	if reward := config.BlockReward(big.NewInt(110)); reward.Cmp(big.NewInt(90)) != 0 {
+		t.Errorf("block reward of the second decay period is %v, want 90", reward)
+	}
+}
+
+func TestFeeConfigPolicyAt(t *testing.T) {
+	treasury := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	tests := []struct {
+		config *FeeConfig
+		epoch  int64
+		policy FeePolicy
+	}{
+		{&FeeConfig{Policy: FeeBurn}, 1000, FeeToLeader},
+		{&FeeConfig{Epoch: big.NewInt(10), Policy: FeeBurn}, 9, FeeToLeader},
+		{&FeeConfig{Epoch: big.NewInt(10), Policy: FeeBurn}, 10, FeeBurn},
+		{&FeeConfig{Epoch: big.NewInt(10), Policy: FeeToTreasury, Treasury: treasury}, 9, FeeToLeader},
+		{&FeeConfig{Epoch: big.NewInt(10), Policy: FeeToTreasury, Treasury: treasury}, 10, FeeToTreasury},
+		// never credit the fees to the zero address
+		{&FeeConfig{Epoch: big.NewInt(10), Policy: FeeToTreasury}, 10, FeeToLeader},
+	}
+	for i, test := range tests {
+		if policy := test.config.PolicyAt(big.NewInt(test.epoch)); policy != test.policy {
+			t.Errorf("test %d: policy of epoch %d is %v, want %v", i, test.epoch, policy, test.policy)
+		}
+	}
+}
+
+func TestFeeConfigTreasury(t *testing.T) {
+	schedules := map[string]Schedule{
+		"mainnet":  MainnetSchedule,
+		"testnet":  TestnetSchedule,
+		"localnet": LocalnetSchedule,
+	}
+	for name, schedule := range schedules {
+		config := schedule.FeeConfig()
+		if config.Policy == FeeToTreasury && config.Treasury == (common.Address{}) {
+			t.Errorf("%s fees go to the treasury but no treasury is configured", name)
+		}
+	}
+}
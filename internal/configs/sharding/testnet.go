@@ -21,7 +21,7 @@ const (
 	testnetEpochBlock1 = 78
 	threeOne           = 111
 
-	testnetVdfDifficulty = 10000 // This takes about 20s to finish the vdf
+	testnetVdfDifficulty = 10000 // This takes about 0.15s to finish the vdf
 
 	testnetMaxTxAmountLimit               = 1e3 // unit is in One
 	testnetMaxNumRecentTxsPerAccountLimit = 1e2
@@ -30,6 +30,27 @@ const (
 	testnetRecentTxDuration               = time.Hour
 	testnetEnableTxnThrottling            = true
 
+	testnetMinBlockPeriod       = 5 * time.Second
+	testnetMaxBlockPeriod       = 8 * time.Second
+	testnetMinPhaseTimeout      = 15 * time.Second
+	testnetMaxPhaseTimeout      = 60 * time.Second
+	testnetMinViewChangeTimeout = 15 * time.Second
+	testnetMaxViewChangeTimeout = 60 * time.Second
+	testnetTimeoutLatencyFactor = 4
+
+	// The reward schedule below is disabled, i.e. its RewardConfig has no
+	// Epoch, until the epoch it takes effect is decided for testnet
+	testnetInitialBlockReward  = 24 // unit is One
+	testnetMinBlockReward      = 8  // unit is One
+	testnetRewardDecayEpochs   = 100
+	testnetRewardDecayPercent  = 10
+	testnetLeaderSharePercent  = 10
+	testnetStakeWeightedReward = true
+
+	// Fee burning is disabled, i.e. its FeeConfig has no Epoch, until the
+	// epoch it takes effect is decided for testnet
+	testnetFeePolicy = FeeBurn
+
 	// TestNetHTTPPattern is the http pattern for testnet.
 	TestNetHTTPPattern = "https://api.s%d.b.hmny.io"
 	// TestNetWSPattern is the websocket pattern for testnet.
@@ -136,6 +157,36 @@ func (ts testnetSchedule) TxsThrottleConfig() *TxsThrottleConfig {
 	}
 }
 
+func (ts testnetSchedule) BlockPeriodConfig() *BlockPeriodConfig {
+	return &BlockPeriodConfig{
+		MinBlockPeriod:       testnetMinBlockPeriod,
+		MaxBlockPeriod:       testnetMaxBlockPeriod,
+		FullBlockTxs:         testnetMaxNumTxsPerBlockLimit,
+		MinPhaseTimeout:      testnetMinPhaseTimeout,
+		MaxPhaseTimeout:      testnetMaxPhaseTimeout,
+		MinViewChangeTimeout: testnetMinViewChangeTimeout,
+		MaxViewChangeTimeout: testnetMaxViewChangeTimeout,
+		TimeoutLatencyFactor: testnetTimeoutLatencyFactor,
+	}
+}
+
+func (ts testnetSchedule) RewardConfig() *RewardConfig {
+	return &RewardConfig{
+		InitialBlockReward: new(big.Int).Mul(big.NewInt(testnetInitialBlockReward), big.NewInt(denominations.One)),
+		DecayEpochs:        testnetRewardDecayEpochs,
+		DecayPercent:       testnetRewardDecayPercent,
+		MinBlockReward:     new(big.Int).Mul(big.NewInt(testnetMinBlockReward), big.NewInt(denominations.One)),
+		LeaderSharePercent: testnetLeaderSharePercent,
+		StakeWeighted:      testnetStakeWeightedReward,
+	}
+}
+
+func (ts testnetSchedule) FeeConfig() *FeeConfig {
+	return &FeeConfig{
+		Policy: testnetFeePolicy,
+	}
+}
+
 func (ts testnetSchedule) GetNetworkID() NetworkID {
 	return TestNet
 }
@@ -12,11 +12,14 @@ import (
 
 	"github.com/harmony-one/harmony/accounts"
 	"github.com/harmony-one/harmony/block"
+	"github.com/harmony-one/harmony/consensus"
 	"github.com/harmony-one/harmony/core"
 	"github.com/harmony-one/harmony/core/state"
 	"github.com/harmony-one/harmony/core/types"
 	"github.com/harmony-one/harmony/core/vm"
+	shardingconfig "github.com/harmony-one/harmony/internal/configs/sharding"
 	"github.com/harmony-one/harmony/internal/params"
+	"github.com/harmony-one/harmony/shard"
 )
 
 // Backend interface provides the common API services (that are provided by
@@ -61,12 +64,51 @@ type Backend interface {
 
 	ChainConfig() *params.ChainConfig
 	CurrentBlock() *types.Block
+	FinalizedBlock() *types.Block
 	// Get balance
 	GetBalance(address common.Address) (*hexutil.Big, error)
 	GetShardID() uint32
 
 	// retrieve the blockHash using txID and add blockHash to CxPool for resending
 	ResendCx(ctx context.Context, txID common.Hash) (uint64, bool)
+
+	// double sign evidence detected or received by the node
+	GetDoubleSignEvidence() []*consensus.DoubleSignEvidence
+
+	// traces of the latest consensus rounds
+	GetConsensusTrace(n int) []*consensus.RoundTrace
+
+	// signed and missed block counts of a BLS key in an epoch
+	GetValidatorUptime(epoch *big.Int, key shard.BlsPublicKey) (*types.ValidatorUptime, error)
+	// committee of the shard of the node in an epoch
+	GetCommittee(epoch *big.Int) (*shard.Committee, error)
+
+	// transactions of each sender in the recent blocks counted against the
+	// transaction throttling limits
+	GetRecentTxsStats() (types.RecentTxsStats, error)
+	// throttling result of a throttled or pending transaction
+	GetTxThrottleStatus(txHash common.Hash) *shardingconfig.TxThrottleStatus
+
+	// replacement of a transaction replaced in the pool
+	GetPoolReplacement(txHash common.Hash) (common.Hash, bool)
+	// proof of the cross-shard receipts of a block towards a shard
+	GetCXReceiptsProof(toShardID uint32, blockHash common.Hash) (*types.CXReceiptsProof, error)
+	// whether the node sent the proof of the cross-shard receipts of a block
+	// to a shard
+	IsCXReceiptsProofSent(toShardID uint32, blockNum uint64, blockHash common.Hash) bool
+	// whether the cross-shard receipts of a block were spent on the
+	// destination shard, if the node has its chain
+	IsCXReceiptsSpent(fromShardID uint32, blockNum uint64, toShardID uint32) (spent bool, known bool)
+
+	// output of the distributed randomness beacon for an epoch
+	GetBeaconRandomness(epoch *big.Int) (*types.BeaconRandomness, error)
+
+	// payouts of the block reward credited by a block
+	GetBlockRewards(blockHash common.Hash, blockNum uint64) (*types.BlockRewards, error)
+	// transaction fees collected and burned in a block
+	GetBlockFees(blockHash common.Hash, blockNum uint64) (*types.BlockFees, error)
+	// supply of the shard as of a block
+	GetShardSupply(blockHash common.Hash, blockNum uint64) (*types.ShardSupply, error)
 }
 
 // GetAPIs returns all the APIs.
@@ -2,7 +2,9 @@ package hmyapi
 
 import (
 	"context"
+	"encoding/hex"
 	"fmt"
+	"strings"
 
 	"github.com/harmony-one/harmony/common/denominations"
 
@@ -13,16 +15,19 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/harmony-one/harmony/consensus"
 	"github.com/harmony-one/harmony/core"
 	"github.com/harmony-one/harmony/core/types"
 	"github.com/harmony-one/harmony/core/vm"
 	internal_common "github.com/harmony-one/harmony/internal/common"
 	"github.com/harmony-one/harmony/internal/utils"
+	"github.com/harmony-one/harmony/shard"
 )
 
 const (
 	defaultGasPrice    = denominations.Nano
 	defaultFromAddress = "0x0000000000000000000000000000000000000000"
+	supplyQueryTimeout = 10 * time.Second
 )
 
 // PublicBlockChainAPI provides an API to access the Harmony blockchain.
@@ -38,11 +43,11 @@ func NewPublicBlockChainAPI(b Backend) *PublicBlockChainAPI {
 
 // GetBlockByNumber returns the requested block. When blockNr is -1 the chain head is returned. When fullTx is true all
 // transactions in the block are returned in full detail, otherwise only the transaction hash is returned.
-func (s *PublicBlockChainAPI) GetBlockByNumber(ctx context.Context, blockNr rpc.BlockNumber, fullTx bool) (map[string]interface{}, error) {
-	block, err := s.b.BlockByNumber(ctx, blockNr)
+func (s *PublicBlockChainAPI) GetBlockByNumber(ctx context.Context, blockNr BlockNumber, fullTx bool) (map[string]interface{}, error) {
+	block, err := s.b.BlockByNumber(ctx, blockNr.resolve(s.b))
 	if block != nil {
 		response, err := RPCMarshalBlock(block, true, fullTx)
-		if err == nil && blockNr == rpc.PendingBlockNumber {
+		if err == nil && rpc.BlockNumber(blockNr) == rpc.PendingBlockNumber {
 			// Pending blocks need to nil out a few fields
 			for _, field := range []string{"hash", "nonce", "miner"} {
 				response[field] = nil
@@ -74,9 +79,9 @@ func (s *PublicBlockChainAPI) GetShardingStructure(ctx context.Context) ([]map[s
 }
 
 // GetCode returns the code stored at the given address in the state for the given block number.
-func (s *PublicBlockChainAPI) GetCode(ctx context.Context, addr string, blockNr rpc.BlockNumber) (hexutil.Bytes, error) {
+func (s *PublicBlockChainAPI) GetCode(ctx context.Context, addr string, blockNr BlockNumber) (hexutil.Bytes, error) {
 	address := internal_common.ParseAddr(addr)
-	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr.resolve(s.b))
 	if state == nil || err != nil {
 		return nil, err
 	}
@@ -87,9 +92,9 @@ func (s *PublicBlockChainAPI) GetCode(ctx context.Context, addr string, blockNr
 // GetStorageAt returns the storage from the state at the given address, key and
 // block number. The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta block
 // numbers are also allowed.
-func (s *PublicBlockChainAPI) GetStorageAt(ctx context.Context, addr string, key string, blockNr rpc.BlockNumber) (hexutil.Bytes, error) {
+func (s *PublicBlockChainAPI) GetStorageAt(ctx context.Context, addr string, key string, blockNr BlockNumber) (hexutil.Bytes, error) {
 	address := internal_common.ParseAddr(addr)
-	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr.resolve(s.b))
 	if state == nil || err != nil {
 		return nil, err
 	}
@@ -100,7 +105,7 @@ func (s *PublicBlockChainAPI) GetStorageAt(ctx context.Context, addr string, key
 // GetBalance returns the amount of Nano for the given address in the state of the
 // given block number. The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta
 // block numbers are also allowed.
-func (s *PublicBlockChainAPI) GetBalance(ctx context.Context, address string, blockNr rpc.BlockNumber) (*hexutil.Big, error) {
+func (s *PublicBlockChainAPI) GetBalance(ctx context.Context, address string, blockNr BlockNumber) (*hexutil.Big, error) {
 	// TODO: currently only get latest balance. Will add complete logic later.
 	addr := internal_common.ParseAddr(address)
 	return s.b.GetBalance(addr)
@@ -112,6 +117,13 @@ func (s *PublicBlockChainAPI) BlockNumber() hexutil.Uint64 {
 	return hexutil.Uint64(header.Number().Uint64())
 }
 
+// FinalizedBlockNumber returns the number of the latest finalized block, whose
+// commit proof is on the chain and which therefore no reorganisation can
+// remove.  The "finalized" block tag stands for it.
+func (s *PublicBlockChainAPI) FinalizedBlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(s.b.FinalizedBlock().NumberU64())
+}
+
 // ResendCx requests that the egress receipt for the given cross-shard
 // transaction be sent to the destination shard for credit.  This is used for
 // unblocking a half-complete cross-shard transaction whose fund has been
@@ -122,10 +134,195 @@ func (s *PublicBlockChainAPI) ResendCx(ctx context.Context, txID common.Hash) (b
 	return success, nil
 }
 
+// GetDoubleSignEvidence returns the double sign evidence detected by the node
+// or, on the beacon chain, reported by the nodes of any shard.
+func (s *PublicBlockChainAPI) GetDoubleSignEvidence(ctx context.Context) []*RPCDoubleSignEvidence {
+	evidences := s.b.GetDoubleSignEvidence()
+	result := make([]*RPCDoubleSignEvidence, 0, len(evidences))
+	for _, evidence := range evidences {
+		result = append(result, newRPCDoubleSignEvidence(evidence))
+	}
+	return result
+}
+
+// GetConsensusTrace returns the traces of the latest count consensus rounds
+// seen by the node, oldest first, or of all the rounds it keeps if count is
+// zero.  Each trace has the time of every phase, the arrival time of every
+// vote at the leader, the validators missing from the aggregated signatures
+// and the view changes started during the round.
+func (s *PublicBlockChainAPI) GetConsensusTrace(ctx context.Context, count int) []*consensus.RoundTrace {
+	return s.b.GetConsensusTrace(count)
+}
+
+// GetValidatorUptime returns the number of blocks of this shard the given BLS
+// public key signed and missed in the given epoch, counted from the commit
+// bitmaps of the blocks.
+func (s *PublicBlockChainAPI) GetValidatorUptime(ctx context.Context, blsKey string, epoch hexutil.Uint64) (*RPCValidatorUptime, error) {
+	keyBytes, err := hex.DecodeString(strings.TrimPrefix(blsKey, "0x"))
+	if err != nil {
+		return nil, err
+	}
+	key := shard.BlsPublicKey{}
+	if len(keyBytes) != len(key) {
+		return nil, fmt.Errorf("invalid BLS public key length %d", len(keyBytes))
+	}
+	copy(key[:], keyBytes)
+	uptime, err := s.b.GetValidatorUptime(new(big.Int).SetUint64(uint64(epoch)), key)
+	if err != nil {
+		return nil, err
+	}
+	return newRPCValidatorUptime(key.Hex(), uint64(epoch), uptime), nil
+}
+
+// GetCommitteeUptime returns the number of blocks each member of the committee
+// of this shard signed and missed in the given epoch.
+func (s *PublicBlockChainAPI) GetCommitteeUptime(ctx context.Context, epoch hexutil.Uint64) ([]*RPCValidatorUptime, error) {
+	epochNum := new(big.Int).SetUint64(uint64(epoch))
+	committee, err := s.b.GetCommittee(epochNum)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*RPCValidatorUptime, 0, len(committee.NodeList))
+	for _, member := range committee.NodeList {
+		uptime, err := s.b.GetValidatorUptime(epochNum, member.BlsPublicKey)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, newRPCValidatorUptime(member.BlsPublicKey.Hex(), uint64(epoch), uptime))
+	}
+	return result, nil
+}
+
+// GetAccountThrottleStatus returns the number of transactions the given
+// address sent in the recent blocks, counted against the transaction
+// throttling limits.  Every node of the shard counts them from the chain the
+// same way.
+func (s *PublicBlockChainAPI) GetAccountThrottleStatus(ctx context.Context, address string) (*RPCAccountThrottleStatus, error) {
+	addr := internal_common.ParseAddr(address)
+	stats, err := s.b.GetRecentTxsStats()
+	if err != nil {
+		return nil, err
+	}
+	config := core.ShardingSchedule.TxsThrottleConfig()
+	recentTxs := stats.AccountTxsCount(addr)
+	return &RPCAccountThrottleStatus{
+		Address:          internal_common.MustAddressToBech32(addr),
+		Enabled:          config.EnableTxnThrottling,
+		RecentTxs:        hexutil.Uint64(recentTxs),
+		MaxRecentTxs:     hexutil.Uint64(config.MaxNumRecentTxsPerAccountLimit),
+		RecentTxDuration: hexutil.Uint64(config.RecentTxDuration / time.Second),
+		MaxTxAmount:      (*hexutil.Big)(config.MaxTxAmountLimit),
+		Throttled:        config.EnableTxnThrottling && recentTxs >= config.MaxNumRecentTxsPerAccountLimit,
+	}, nil
+}
+
+// GetBlockRewards returns who was paid how much of the reward for signing the
+// parent of the given block, which the block credits, or null if it credits
+// none.
+func (s *PublicBlockChainAPI) GetBlockRewards(ctx context.Context, blockNr BlockNumber) (*RPCBlockRewards, error) {
+	header, err := s.b.HeaderByNumber(ctx, blockNr.resolve(s.b))
+	if header == nil || err != nil {
+		return nil, err
+	}
+	rewards, err := s.b.GetBlockRewards(header.Hash(), header.Number().Uint64())
+	if err != nil || rewards == nil {
+		return nil, err
+	}
+	return newRPCBlockRewards(rewards), nil
+}
+
+// GetShardSupply returns the supply of this shard as of the given block, from
+// its genesis allocations, the block rewards, the burnt transaction fees and
+// the cross-shard transfers, with the fees of the block, or null if the node
+// did not record it.
+func (s *PublicBlockChainAPI) GetShardSupply(ctx context.Context, blockNr BlockNumber) (*RPCShardSupply, error) {
+	header, err := s.b.HeaderByNumber(ctx, blockNr.resolve(s.b))
+	if header == nil || err != nil {
+		return nil, err
+	}
+	supply, err := s.b.GetShardSupply(header.Hash(), header.Number().Uint64())
+	if err != nil || supply == nil {
+		return nil, err
+	}
+	fees, err := s.b.GetBlockFees(header.Hash(), header.Number().Uint64())
+	if err != nil {
+		return nil, err
+	}
+	return newRPCShardSupply(header, supply, fees), nil
+}
+
+// GetCirculatingSupply returns the supply of the network, adding up the
+// latest supplies of all the shards.  The supplies of the other shards are
+// queried from their RPC endpoints in the sharding structure; the shards that
+// cannot be reached are listed as missing.
+func (s *PublicBlockChainAPI) GetCirculatingSupply(ctx context.Context) (*RPCNetworkSupply, error) {
+	own, err := s.GetShardSupply(ctx, BlockNumber(rpc.LatestBlockNumber))
+	if err != nil {
+		return nil, err
+	}
+	if own == nil {
+		return nil, fmt.Errorf("no supply recorded for the current block")
+	}
+	header := s.b.CurrentBlock().Header()
+	numShard := core.ShardingSchedule.InstanceForEpoch(header.Epoch()).NumShards()
+	result := &RPCNetworkSupply{
+		Total:         (*hexutil.Big)(big.NewInt(0)),
+		Circulating:   (*hexutil.Big)(big.NewInt(0)),
+		Shards:        []*RPCShardSupply{},
+		MissingShards: []hexutil.Uint64{},
+	}
+	for _, structure := range core.ShardingSchedule.GetShardingStructure(int(numShard), int(s.b.GetShardID())) {
+		shardID := structure["shardID"].(int)
+		supply := own
+		if !structure["current"].(bool) {
+			supply, err = queryShardSupply(ctx, structure["http"].(string))
+			if err != nil {
+				utils.Logger().Warn().Err(err).Int("shardID", shardID).Msg("[GetCirculatingSupply] cannot query shard supply")
+				result.MissingShards = append(result.MissingShards, hexutil.Uint64(shardID))
+				continue
+			}
+		}
+		result.add(supply)
+	}
+	return result, nil
+}
+
+// queryShardSupply queries the latest supply of another shard from its RPC
+// endpoint.
+func queryShardSupply(ctx context.Context, endpoint string) (*RPCShardSupply, error) {
+	ctx, cancel := context.WithTimeout(ctx, supplyQueryTimeout)
+	defer cancel()
+	client, err := rpc.DialContext(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	var supply *RPCShardSupply
+	if err := client.CallContext(ctx, &supply, "hmy_getShardSupply", "latest"); err != nil {
+		return nil, err
+	}
+	if supply == nil {
+		return nil, fmt.Errorf("no supply recorded for the latest block")
+	}
+	return supply, nil
+}
+
+// GetEpochRandomness returns the random value the beacon committee generated
+// for the given epoch, with the threshold signature it is the hash of and
+// the dealers of the group key to verify it, or null if beacon chain nodes
+// have none.
+func (s *PublicBlockChainAPI) GetEpochRandomness(ctx context.Context, epoch hexutil.Uint64) (*RPCBeaconRandomness, error) {
+	randomness, err := s.b.GetBeaconRandomness(new(big.Int).SetUint64(uint64(epoch)))
+	if err != nil || randomness == nil {
+		return nil, err
+	}
+	return newRPCBeaconRandomness(randomness), nil
+}
+
 // Call executes the given transaction on the state for the given block number.
 // It doesn't make and changes in the state/blockchain and is useful to execute and retrieve values.
-func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber) (hexutil.Bytes, error) {
-	result, _, _, err := doCall(ctx, s.b, args, blockNr, vm.Config{}, 5*time.Second, s.b.RPCGasCap())
+func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNr BlockNumber) (hexutil.Bytes, error) {
+	result, _, _, err := doCall(ctx, s.b, args, blockNr.resolve(s.b), vm.Config{}, 5*time.Second, s.b.RPCGasCap())
 	return (hexutil.Bytes)(result), err
 }
 
@@ -0,0 +1,41 @@
+package hmyapi
+
+import (
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// FinalizedBlockNumber is the block number of the "finalized" tag, which
+// stands for the latest finalized block.
+const FinalizedBlockNumber = BlockNumber(-3)
+
+// BlockNumber is a block number argument of the RPC methods.  Besides the
+// numbers and tags of rpc.BlockNumber, it accepts the "finalized" tag, for
+// the latest block whose commit proof is on the chain, which no
+// reorganisation can remove.
+type BlockNumber rpc.BlockNumber
+
+// UnmarshalJSON parses the given JSON fragment into a BlockNumber.
+func (bn *BlockNumber) UnmarshalJSON(data []byte) error {
+	var tag string
+	if err := json.Unmarshal(data, &tag); err == nil && tag == "finalized" {
+		*bn = FinalizedBlockNumber
+		return nil
+	}
+	var number rpc.BlockNumber
+	if err := number.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	*bn = BlockNumber(number)
+	return nil
+}
+
+// resolve returns the block number to look up in the backend, that of the
+// latest finalized block for the "finalized" tag.
+func (bn BlockNumber) resolve(b Backend) rpc.BlockNumber {
+	if bn == FinalizedBlockNumber {
+		return rpc.BlockNumber(b.FinalizedBlock().NumberU64())
+	}
+	return rpc.BlockNumber(bn)
+}
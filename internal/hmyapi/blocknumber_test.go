@@ -0,0 +1,47 @@
+package hmyapi
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+
+	blockfactory "github.com/harmony-one/harmony/block/factory"
+	"github.com/harmony-one/harmony/core/types"
+)
+
+// finalizedBackend is a Backend knowing only its finalized block.
+type finalizedBackend struct {
+	Backend
+	finalized *types.Block
+}
+
+func (b *finalizedBackend) FinalizedBlock() *types.Block {
+	return b.finalized
+}
+
+func TestBlockNumber(t *testing.T) {
+	header := blockfactory.NewTestHeader().With().Number(big.NewInt(42)).Header()
+	b := &finalizedBackend{finalized: types.NewBlockWithHeader(header)}
+
+	tests := []struct {
+		arg  string
+		want rpc.BlockNumber
+	}{
+		{`"finalized"`, 42},
+		{`"latest"`, rpc.LatestBlockNumber},
+		{`"pending"`, rpc.PendingBlockNumber},
+		{`"earliest"`, 0},
+		{`"0x7"`, 7},
+	}
+	for _, test := range tests {
+		var bn BlockNumber
+		if assert.NoError(t, json.Unmarshal([]byte(test.arg), &bn), test.arg) {
+			assert.Equal(t, test.want, bn.resolve(b), test.arg)
+		}
+	}
+	var bn BlockNumber
+	assert.Error(t, json.Unmarshal([]byte(`"final"`), &bn))
+}
@@ -25,8 +25,8 @@ func NewPublicTransactionPoolAPI(b Backend, nonceLock *AddrLocker) *PublicTransa
 }
 
 // GetBlockTransactionCountByNumber returns the number of transactions in the block with the given block number.
-func (s *PublicTransactionPoolAPI) GetBlockTransactionCountByNumber(ctx context.Context, blockNr rpc.BlockNumber) *hexutil.Uint {
-	if block, _ := s.b.BlockByNumber(ctx, blockNr); block != nil {
+func (s *PublicTransactionPoolAPI) GetBlockTransactionCountByNumber(ctx context.Context, blockNr BlockNumber) *hexutil.Uint {
+	if block, _ := s.b.BlockByNumber(ctx, blockNr.resolve(s.b)); block != nil {
 		n := hexutil.Uint(len(block.Transactions()))
 		return &n
 	}
@@ -43,8 +43,8 @@ func (s *PublicTransactionPoolAPI) GetBlockTransactionCountByHash(ctx context.Co
 }
 
 // GetTransactionByBlockNumberAndIndex returns the transaction for the given block number and index.
-func (s *PublicTransactionPoolAPI) GetTransactionByBlockNumberAndIndex(ctx context.Context, blockNr rpc.BlockNumber, index hexutil.Uint) *RPCTransaction {
-	if block, _ := s.b.BlockByNumber(ctx, blockNr); block != nil {
+func (s *PublicTransactionPoolAPI) GetTransactionByBlockNumberAndIndex(ctx context.Context, blockNr BlockNumber, index hexutil.Uint) *RPCTransaction {
+	if block, _ := s.b.BlockByNumber(ctx, blockNr.resolve(s.b)); block != nil {
 		return newRPCTransactionFromBlockIndex(block, uint64(index))
 	}
 	return nil
@@ -72,11 +72,80 @@ func (s *PublicTransactionPoolAPI) GetTransactionByHash(ctx context.Context, has
 	return nil
 }
 
+// GetTransactionThrottleStatus returns why the transaction of the given hash
+// was not selected for a recent block because of throttling, or whether it
+// would be throttled if it is still pending.  The leader knows the throttled
+// transactions best.  It returns nil for an unknown transaction.
+func (s *PublicTransactionPoolAPI) GetTransactionThrottleStatus(ctx context.Context, hash common.Hash) *RPCTxThrottleStatus {
+	status := s.b.GetTxThrottleStatus(hash)
+	if status == nil {
+		return nil
+	}
+	return newRPCTxThrottleStatus(hash, status)
+}
+
+// GetCXTransactionStatus returns the lifecycle stage of the transaction of the
+// given hash: pending in the pool of the source shard, replaced by another
+// transaction, included in a block of the source shard, its receipt proof
+// sent to the destination shard, or its receipt spent on the destination
+// shard.  A transaction within a shard is done once included.
+func (s *PublicTransactionPoolAPI) GetCXTransactionStatus(ctx context.Context, hash common.Hash) (*RPCCXTransactionStatus, error) {
+	status := &RPCCXTransactionStatus{Hash: hash, Status: CXStatusUnknown}
+	if tx := s.b.GetPoolTransaction(hash); tx != nil {
+		status.Status = CXStatusPending
+		status.ShardID, status.ToShardID = tx.ShardID(), tx.ToShardID()
+		return status, nil
+	}
+	if replacement, ok := s.b.GetPoolReplacement(hash); ok {
+		status.Status = CXStatusReplaced
+		status.ReplacedBy = &replacement
+		return status, nil
+	}
+	tx, blockHash, blockNumber, _ := rawdb.ReadTransaction(s.b.ChainDb(), hash)
+	if tx == nil {
+		return status, nil
+	}
+	status.Status = CXStatusIncluded
+	status.ShardID, status.ToShardID = tx.ShardID(), tx.ToShardID()
+	status.BlockHash = &blockHash
+	number := hexutil.Uint64(blockNumber)
+	status.BlockNumber = &number
+	if tx.ShardID() == tx.ToShardID() {
+		return status, nil
+	}
+
+	proof, err := s.b.GetCXReceiptsProof(tx.ToShardID(), blockHash)
+	if err != nil {
+		return nil, err
+	}
+	if proof == nil {
+		return status, nil
+	}
+	status.CXReceiptHash = &proof.MerkleProof.CXReceiptHash
+
+	spent, known := s.b.IsCXReceiptsSpent(tx.ShardID(), blockNumber, tx.ToShardID())
+	status.SpentKnown = known
+	switch {
+	case spent:
+		status.Status = CXStatusSpent
+	case s.b.IsCXReceiptsProofSent(tx.ToShardID(), blockNumber, blockHash):
+		status.Status = CXStatusProofSent
+	}
+	return status, nil
+}
+
+// GetCXReceiptsSpent returns whether this shard spent the cross-shard
+// receipts of the given block of the given source shard.
+func (s *PublicTransactionPoolAPI) GetCXReceiptsSpent(ctx context.Context, fromShardID uint32, blockNumber hexutil.Uint64) bool {
+	spent, _ := s.b.IsCXReceiptsSpent(fromShardID, uint64(blockNumber), s.b.GetShardID())
+	return spent
+}
+
 // GetTransactionCount returns the number of transactions the given address has sent for the given block number
-func (s *PublicTransactionPoolAPI) GetTransactionCount(ctx context.Context, addr string, blockNr rpc.BlockNumber) (*hexutil.Uint64, error) {
+func (s *PublicTransactionPoolAPI) GetTransactionCount(ctx context.Context, addr string, blockNr BlockNumber) (*hexutil.Uint64, error) {
 	address := internal_common.ParseAddr(addr)
 	// Ask transaction pool for the nonce which includes pending transactions
-	if blockNr == rpc.PendingBlockNumber {
+	if rpc.BlockNumber(blockNr) == rpc.PendingBlockNumber {
 		nonce, err := s.b.GetPoolNonce(ctx, address)
 		if err != nil {
 			return nil, err
@@ -84,7 +153,7 @@ func (s *PublicTransactionPoolAPI) GetTransactionCount(ctx context.Context, addr
 		return (*hexutil.Uint64)(&nonce), nil
 	}
 	// Resolve block number and use its state to ask for the nonce
-	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr.resolve(s.b))
 	if state == nil || err != nil {
 		return nil, err
 	}
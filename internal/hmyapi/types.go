@@ -6,7 +6,13 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	msg_pb "github.com/harmony-one/harmony/api/proto/message"
+	"github.com/harmony-one/harmony/block"
+	"github.com/harmony-one/harmony/consensus"
+	"github.com/harmony-one/harmony/core"
 	"github.com/harmony-one/harmony/core/types"
+	internal_common "github.com/harmony-one/harmony/internal/common"
+	shardingconfig "github.com/harmony-one/harmony/internal/configs/sharding"
 )
 
 // RPCTransaction represents a transaction that will serialize to the RPC representation of a transaction
@@ -168,3 +174,296 @@ type CallArgs struct {
 	Value    *hexutil.Big    `json:"value"`
 	Data     *hexutil.Bytes  `json:"data"`
 }
+
+// RPCDoubleSignEvidence represents a double sign evidence that will serialize
+// to the RPC representation
+type RPCDoubleSignEvidence struct {
+	Hash          common.Hash    `json:"hash"`
+	ShardID       hexutil.Uint64 `json:"shardID"`
+	Epoch         hexutil.Uint64 `json:"epoch"`
+	MessageType   string         `json:"messageType"`
+	BlockNumber   hexutil.Uint64 `json:"blockNumber"`
+	ViewID        hexutil.Uint64 `json:"viewID"`
+	Signer        hexutil.Bytes  `json:"signer"`
+	FirstMessage  hexutil.Bytes  `json:"firstMessage"`
+	SecondMessage hexutil.Bytes  `json:"secondMessage"`
+}
+
+// newRPCDoubleSignEvidence returns a double sign evidence that will serialize
+// to the RPC representation
+func newRPCDoubleSignEvidence(e *consensus.DoubleSignEvidence) *RPCDoubleSignEvidence {
+	return &RPCDoubleSignEvidence{
+		Hash:          e.Hash(),
+		ShardID:       hexutil.Uint64(e.ShardID),
+		Epoch:         hexutil.Uint64(e.Epoch),
+		MessageType:   msg_pb.MessageType(e.MessageType).String(),
+		BlockNumber:   hexutil.Uint64(e.BlockNum),
+		ViewID:        hexutil.Uint64(e.ViewID),
+		Signer:        e.SignerPubKey,
+		FirstMessage:  e.FirstMessage,
+		SecondMessage: e.SecondMessage,
+	}
+}
+
+// RPCValidatorUptime represents the signed and missed block counts of a
+// validator key in an epoch that will serialize to the RPC representation
+type RPCValidatorUptime struct {
+	BlsPublicKey string         `json:"blsPublicKey"`
+	Epoch        hexutil.Uint64 `json:"epoch"`
+	Signed       hexutil.Uint64 `json:"signed"`
+	Missed       hexutil.Uint64 `json:"missed"`
+	// Uptime is the fraction of the blocks the key signed
+	Uptime float64 `json:"uptime"`
+}
+
+// newRPCValidatorUptime returns the uptime of a validator key that will
+// serialize to the RPC representation
+func newRPCValidatorUptime(key string, epoch uint64, uptime *types.ValidatorUptime) *RPCValidatorUptime {
+	return &RPCValidatorUptime{
+		BlsPublicKey: key,
+		Epoch:        hexutil.Uint64(epoch),
+		Signed:       hexutil.Uint64(uptime.Signed),
+		Missed:       hexutil.Uint64(uptime.Missed),
+		Uptime:       uptime.Ratio(),
+	}
+}
+
+// RPCRewardPayout represents the part of a block reward credited to an account
+// that will serialize to the RPC representation
+type RPCRewardPayout struct {
+	Address string `json:"address"`
+	// Kind is "leader" for the leader bonus, "signer" for a signer share
+	Kind         string       `json:"kind"`
+	BlsPublicKey string       `json:"blsPublicKey"`
+	Amount       *hexutil.Big `json:"amount"`
+	// ShardID and SignedBlockNumber identify the block whose signers are
+	// paid, which is of another shard for a cross-linked block
+	ShardID           hexutil.Uint64 `json:"shardID"`
+	SignedBlockNumber hexutil.Uint64 `json:"signedBlockNumber"`
+}
+
+// RPCBlockRewards represents the payouts of the block reward credited by a
+// block that will serialize to the RPC representation
+type RPCBlockRewards struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	// SignedBlockNumber is the number of the parent block, whose signers
+	// are paid along with those of the cross-linked blocks, if any
+	SignedBlockNumber hexutil.Uint64    `json:"signedBlockNumber"`
+	Total             *hexutil.Big      `json:"total"`
+	Payouts           []RPCRewardPayout `json:"payouts"`
+}
+
+// newRPCBlockRewards returns the payouts of a block reward that will serialize
+// to the RPC representation
+func newRPCBlockRewards(rewards *types.BlockRewards) *RPCBlockRewards {
+	blockNum := rewards.BlockNum.Uint64()
+	result := &RPCBlockRewards{
+		BlockNumber:       hexutil.Uint64(blockNum),
+		SignedBlockNumber: hexutil.Uint64(blockNum - 1),
+		Total:             (*hexutil.Big)(rewards.Total),
+		Payouts:           make([]RPCRewardPayout, 0, len(rewards.Payouts)),
+	}
+	for _, payout := range rewards.Payouts {
+		signedBlockNum := blockNum - 1
+		if payout.SignedBlockNum != nil {
+			signedBlockNum = payout.SignedBlockNum.Uint64()
+		}
+		result.Payouts = append(result.Payouts, RPCRewardPayout{
+			Address:           internal_common.MustAddressToBech32(payout.Account),
+			Kind:              payout.Kind.String(),
+			BlsPublicKey:      payout.BlsPublicKey.Hex(),
+			Amount:            (*hexutil.Big)(payout.Amount),
+			ShardID:           hexutil.Uint64(payout.ShardID),
+			SignedBlockNumber: hexutil.Uint64(signedBlockNum),
+		})
+	}
+	return result
+}
+
+// RPCShardSupply represents the supply of a shard as of a block that will
+// serialize to the RPC representation
+type RPCShardSupply struct {
+	ShardID       hexutil.Uint64 `json:"shardID"`
+	BlockNumber   hexutil.Uint64 `json:"blockNumber"`
+	BlockHash     common.Hash    `json:"blockHash"`
+	Genesis       *hexutil.Big   `json:"genesis"`
+	Issued        *hexutil.Big   `json:"issued"`
+	Burned        *hexutil.Big   `json:"burned"`
+	CrossShardOut *hexutil.Big   `json:"crossShardOut"`
+	CrossShardIn  *hexutil.Big   `json:"crossShardIn"`
+	// Minted is genesis + issued - burned, the shard's part of the network
+	// supply
+	Minted *hexutil.Big `json:"minted"`
+	// Circulating is minted + crossShardIn - crossShardOut, the amount held
+	// in the shard
+	Circulating *hexutil.Big `json:"circulating"`
+	// FeePolicy tells who is credited the transaction fees
+	FeePolicy          string       `json:"feePolicy"`
+	BlockFeesCollected *hexutil.Big `json:"blockFeesCollected"`
+	BlockFeesBurned    *hexutil.Big `json:"blockFeesBurned"`
+}
+
+// newRPCShardSupply returns the supply of a shard as of the given block that
+// will serialize to the RPC representation
+func newRPCShardSupply(header *block.Header, supply *types.ShardSupply, fees *types.BlockFees) *RPCShardSupply {
+	if fees == nil {
+		fees = &types.BlockFees{Collected: big.NewInt(0), Burned: big.NewInt(0)}
+	}
+	return &RPCShardSupply{
+		ShardID:            hexutil.Uint64(header.ShardID()),
+		BlockNumber:        hexutil.Uint64(header.Number().Uint64()),
+		BlockHash:          header.Hash(),
+		Genesis:            (*hexutil.Big)(supply.Genesis),
+		Issued:             (*hexutil.Big)(supply.Issued),
+		Burned:             (*hexutil.Big)(supply.Burned),
+		CrossShardOut:      (*hexutil.Big)(supply.CrossShardOut),
+		CrossShardIn:       (*hexutil.Big)(supply.CrossShardIn),
+		Minted:             (*hexutil.Big)(supply.Minted()),
+		Circulating:        (*hexutil.Big)(supply.Circulating()),
+		FeePolicy:          core.ShardingSchedule.FeeConfig().PolicyAt(header.Epoch()).String(),
+		BlockFeesCollected: (*hexutil.Big)(fees.Collected),
+		BlockFeesBurned:    (*hexutil.Big)(fees.Burned),
+	}
+}
+
+// RPCNetworkSupply represents the supply of the whole network that will
+// serialize to the RPC representation
+type RPCNetworkSupply struct {
+	// Total is the sum of the amounts minted by the shards
+	Total *hexutil.Big `json:"total"`
+	// Circulating is the sum of the amounts held in the shards, i.e. the
+	// total less the amounts in transit between shards
+	Circulating   *hexutil.Big      `json:"circulating"`
+	Shards        []*RPCShardSupply `json:"shards"`
+	MissingShards []hexutil.Uint64  `json:"missingShards"`
+}
+
+// add adds the supply of a shard to the network supply.
+func (s *RPCNetworkSupply) add(shardSupply *RPCShardSupply) {
+	s.Total.ToInt().Add(s.Total.ToInt(), shardSupply.Minted.ToInt())
+	s.Circulating.ToInt().Add(s.Circulating.ToInt(), shardSupply.Circulating.ToInt())
+	s.Shards = append(s.Shards, shardSupply)
+}
+
+// RPCBeaconDealer represents a qualified dealer of the group key of the
+// randomness beacon that will serialize to the RPC representation
+type RPCBeaconDealer struct {
+	// Index is the index of the dealer in the beacon committee
+	Index           hexutil.Uint64 `json:"index"`
+	Commitment      hexutil.Bytes  `json:"commitment"`
+	CommitmentsHash common.Hash    `json:"commitmentsHash"`
+	Signature       hexutil.Bytes  `json:"signature"`
+}
+
+// RPCBeaconRandomness represents the output of the randomness beacon for an
+// epoch that will serialize to the RPC representation
+type RPCBeaconRandomness struct {
+	Epoch      hexutil.Uint64 `json:"epoch"`
+	Randomness common.Hash    `json:"randomness"`
+	// Seed is the hash of the first beacon block of the epoch, signed by
+	// the committee
+	Seed      common.Hash       `json:"seed"`
+	Signature hexutil.Bytes     `json:"signature"`
+	Dealers   []RPCBeaconDealer `json:"dealers"`
+}
+
+// newRPCBeaconRandomness returns the output of the randomness beacon that will
+// serialize to the RPC representation
+func newRPCBeaconRandomness(randomness *types.BeaconRandomness) *RPCBeaconRandomness {
+	result := &RPCBeaconRandomness{
+		Epoch:      hexutil.Uint64(randomness.Epoch.Uint64()),
+		Randomness: randomness.Randomness(),
+		Seed:       randomness.Seed,
+		Signature:  randomness.Signature,
+		Dealers:    make([]RPCBeaconDealer, 0, len(randomness.Dealers)),
+	}
+	for _, dealer := range randomness.Dealers {
+		result.Dealers = append(result.Dealers, RPCBeaconDealer{
+			Index:           hexutil.Uint64(dealer.Index),
+			Commitment:      dealer.Commitment,
+			CommitmentsHash: dealer.CommitmentsHash,
+			Signature:       dealer.Signature,
+		})
+	}
+	return result
+}
+
+// RPCAccountThrottleStatus represents the recent transactions of an account
+// counted against the transaction throttling limits that will serialize to
+// the RPC representation
+type RPCAccountThrottleStatus struct {
+	Address string `json:"address"`
+	// Enabled is whether transactions are throttled at all
+	Enabled bool `json:"enabled"`
+	// RecentTxs is the number of transactions of the account in the blocks
+	// of the last RecentTxDuration seconds
+	RecentTxs        hexutil.Uint64 `json:"recentTxs"`
+	MaxRecentTxs     hexutil.Uint64 `json:"maxRecentTxs"`
+	RecentTxDuration hexutil.Uint64 `json:"recentTxDuration"`
+	MaxTxAmount      *hexutil.Big   `json:"maxTxAmount"`
+	// Throttled is whether the next transaction of the account would be left
+	// out of the next block
+	Throttled bool `json:"throttled"`
+}
+
+// RPCTxThrottleStatus represents the throttling result of a transaction that
+// will serialize to the RPC representation
+type RPCTxThrottleStatus struct {
+	Hash common.Hash `json:"hash"`
+	// Flag is TxSelect, TxUnselect (left for a later block) or TxInvalid
+	// (dropped)
+	Flag        string         `json:"flag"`
+	Reason      string         `json:"reason"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+}
+
+// newRPCTxThrottleStatus returns the throttling result of a transaction that
+// will serialize to the RPC representation
+func newRPCTxThrottleStatus(hash common.Hash, status *shardingconfig.TxThrottleStatus) *RPCTxThrottleStatus {
+	return &RPCTxThrottleStatus{
+		Hash:        hash,
+		Flag:        status.Flag.String(),
+		Reason:      status.Reason,
+		BlockNumber: hexutil.Uint64(status.BlockNum),
+	}
+}
+
+// Stages of the lifecycle of a cross-shard transaction
+const (
+	// CXStatusUnknown is for a transaction unknown to the node
+	CXStatusUnknown = "unknown"
+	// CXStatusPending is for a transaction in the pool of the source shard
+	CXStatusPending = "pending"
+	// CXStatusReplaced is for a transaction replaced in the pool by another
+	// one with the same nonce, which speeds it up or cancels it
+	CXStatusReplaced = "replaced"
+	// CXStatusIncluded is for a transaction included in a block of the
+	// source shard
+	CXStatusIncluded = "included"
+	// CXStatusProofSent is for a transaction whose receipt proof the node
+	// recorded sending to the destination shard
+	CXStatusProofSent = "proofSent"
+	// CXStatusSpent is for a transaction whose receipt was spent on the
+	// destination shard
+	CXStatusSpent = "spent"
+)
+
+// RPCCXTransactionStatus represents the lifecycle stage of a cross-shard
+// transaction that will serialize to the RPC representation
+type RPCCXTransactionStatus struct {
+	Hash      common.Hash `json:"hash"`
+	Status    string      `json:"status"`
+	ShardID   uint32      `json:"shardID"`
+	ToShardID uint32      `json:"toShardID"`
+	// ReplacedBy is the hash of the replacement of a replaced transaction
+	ReplacedBy  *common.Hash    `json:"replacedBy"`
+	BlockHash   *common.Hash    `json:"blockHash"`
+	BlockNumber *hexutil.Uint64 `json:"blockNumber"`
+	// CXReceiptHash is the root hash of the cross-shard receipts of the block
+	// proven to the destination shard
+	CXReceiptHash *common.Hash `json:"cxReceiptHash"`
+	// SpentKnown is whether the node has the chain of the destination shard
+	// to tell whether the receipt was spent; otherwise the destination shard
+	// must be asked with hmy_getCXReceiptsSpent
+	SpentKnown bool `json:"spentKnown"`
+}
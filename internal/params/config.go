@@ -15,6 +15,7 @@ var (
 		CrossLinkEpoch: big.NewInt(10000000), // Temporarily made very large until a exact number is decided.
 		EIP155Epoch:    big.NewInt(28),
 		S3Epoch:        big.NewInt(28),
+		// BeaconRewardsEpoch is left unset until an exact number is decided.
 	}
 
 	// TestnetChainConfig contains the chain parameters to run a node on the harmony test network.
@@ -24,6 +25,7 @@ var (
 		CrossLinkEpoch: big.NewInt(2),
 		EIP155Epoch:    big.NewInt(0),
 		S3Epoch:        big.NewInt(0),
+		// BeaconRewardsEpoch is left unset until an exact number is decided.
 	}
 
 	// AllProtocolChanges ...
@@ -35,6 +37,7 @@ var (
 		big.NewInt(0),   // CrossLinkEpoch
 		big.NewInt(0),   // EIP155Epoch
 		big.NewInt(0),   // S3Epoch
+		big.NewInt(0),   // BeaconRewardsEpoch
 	}
 
 	// TestChainConfig ...
@@ -46,6 +49,7 @@ var (
 		big.NewInt(0),  // CrossLinkEpoch
 		big.NewInt(0),  // EIP155Epoch
 		big.NewInt(0),  // S3Epoch
+		big.NewInt(0),  // BeaconRewardsEpoch
 	}
 
 	// TestRules ...
@@ -82,15 +86,21 @@ type ChainConfig struct {
 
 	EIP155Epoch *big.Int `json:"eip155Epoch,omitempty"` // EIP155 hard fork epoch (include EIP158 too)
 	S3Epoch     *big.Int `json:"s3Epoch,omitempty"`     // S3 epoch is the first epoch containing S3 mainnet and all ethereum update up to Constantinople
+
+	// BeaconRewardsEpoch is the epoch where the rewards for signing the blocks
+	// of the shards other than the beacon chain start being credited on the
+	// beacon chain, by the beacon blocks cross-linking them.
+	BeaconRewardsEpoch *big.Int `json:"beaconRewardsEpoch,omitempty"`
 }
 
 // String implements the fmt.Stringer interface.
 func (c *ChainConfig) String() string {
-	return fmt.Sprintf("{ChainID: %v EIP155: %v CrossTx: %v CrossLink: %v}",
+	return fmt.Sprintf("{ChainID: %v EIP155: %v CrossTx: %v CrossLink: %v BeaconRewards: %v}",
 		c.ChainID,
 		c.EIP155Epoch,
 		c.CrossTxEpoch,
 		c.CrossLinkEpoch,
+		c.BeaconRewardsEpoch,
 	)
 }
 
@@ -119,6 +129,12 @@ func (c *ChainConfig) IsCrossLink(epoch *big.Int) bool {
 	return isForked(c.CrossLinkEpoch, epoch)
 }
 
+// IsBeaconRewards returns whether epoch is either equal to the BeaconRewards
+// fork epoch or greater.
+func (c *ChainConfig) IsBeaconRewards(epoch *big.Int) bool {
+	return isForked(c.BeaconRewardsEpoch, epoch)
+}
+
 // IsS3 returns whether epoch is either equal to the S3 fork epoch or greater.
 func (c *ChainConfig) IsS3(epoch *big.Int) bool {
 	return isForked(c.S3Epoch, epoch)
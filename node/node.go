@@ -3,6 +3,7 @@ package node
 import (
 	"crypto/ecdsa"
 	"fmt"
+	"math/big"
 	"sync"
 	"time"
 
@@ -20,12 +21,12 @@ import (
 	"github.com/harmony-one/harmony/block"
 	"github.com/harmony-one/harmony/consensus"
 	"github.com/harmony-one/harmony/contracts"
-	"github.com/harmony-one/harmony/contracts/structs"
 	"github.com/harmony-one/harmony/core"
 	"github.com/harmony-one/harmony/core/types"
 	"github.com/harmony-one/harmony/drand"
 	"github.com/harmony-one/harmony/internal/chain"
 	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
+	shardingconfig "github.com/harmony-one/harmony/internal/configs/sharding"
 	"github.com/harmony-one/harmony/internal/ctxerror"
 	"github.com/harmony-one/harmony/internal/shardchain"
 	"github.com/harmony-one/harmony/internal/utils"
@@ -54,6 +55,9 @@ const (
 	TxPoolLimit = 20000
 	// NumTryBroadCast is the number of times trying to broadcast
 	NumTryBroadCast = 3
+	// confirmedBlockChanSize is the number of epoch blocks waiting for the
+	// randomness generation
+	confirmedBlockChanSize = 1
 )
 
 func (state State) String() string {
@@ -118,14 +122,10 @@ type Node struct {
 	// BeaconNeighbors store only neighbor nodes in the beacon chain shard
 	BeaconNeighbors sync.Map // All the neighbor nodes, key is the sha256 of Peer IP/Port, value is the p2p.Peer
 
-	TxPool *core.TxPool // TODO migrate to TxPool from pendingTransactions list below
+	TxPool *core.TxPool // All the transactions received but not yet processed for Consensus
 
 	CxPool *core.CxPool // pool for missing cross shard receipts resend
 
-	pendingTransactions types.Transactions // All the transactions received but not yet processed for Consensus
-	pendingTxMutex      sync.Mutex
-	recentTxsStats      types.RecentTxsStats
-
 	Worker       *worker.Worker
 	BeaconWorker *worker.Worker // worker for beacon chain
 
@@ -146,11 +146,6 @@ type Node struct {
 	// Service manager.
 	serviceManager *service.Manager
 
-	//Staked Accounts and Contract
-	CurrentStakes          map[common.Address]*structs.StakeInfo //This will save the latest information about staked nodes.
-	StakingContractAddress common.Address
-	WithdrawStakeFunc      []byte
-
 	// Demo account.
 	DemoContractAddress      common.Address
 	LotteryManagerPrivateKey *ecdsa.PrivateKey
@@ -241,17 +236,6 @@ func (node *Node) Beaconchain() *core.BlockChain {
 	return bc
 }
 
-func (node *Node) reducePendingTransactions() {
-	txPoolLimit := core.ShardingSchedule.MaxTxPoolSizeLimit()
-	curLen := len(node.pendingTransactions)
-
-	// If length of pendingTransactions is greater than TxPoolLimit then by greedy take the TxPoolLimit recent transactions.
-	if curLen > txPoolLimit+txPoolLimit {
-		node.pendingTransactions = append(types.Transactions(nil), node.pendingTransactions[curLen-txPoolLimit:]...)
-		utils.Logger().Info().Msg("mem stat reduce pending transaction")
-	}
-}
-
 func (node *Node) tryBroadcast(tx *types.Transaction) {
 	msg := proto_node.ConstructTransactionListMessageAccount(types.Transactions{tx})
 
@@ -267,25 +251,69 @@ func (node *Node) tryBroadcast(tx *types.Transaction) {
 	}
 }
 
-// Add new transactions to the pending transaction list.
+// Add new transactions received from the network to the transaction pool.
+// Transactions of other shards and transactions rejected by the pool are
+// dropped.
 func (node *Node) addPendingTransactions(newTxs types.Transactions) {
-	node.pendingTxMutex.Lock()
-	node.pendingTransactions = append(node.pendingTransactions, newTxs...)
-	node.reducePendingTransactions()
-	node.pendingTxMutex.Unlock()
-	utils.Logger().Info().Int("length of newTxs", len(newTxs)).Int("totalPending", len(node.pendingTransactions)).Msg("Got more transactions")
+	txs := types.Transactions{}
+	for _, tx := range newTxs {
+		if tx.ShardID() != node.NodeConfig.ShardID {
+			utils.Logger().Debug().
+				Str("hash", tx.Hash().Hex()).
+				Uint32("shardID", tx.ShardID()).
+				Msg("Dropping transaction of another shard")
+			continue
+		}
+		txs = append(txs, tx)
+	}
+	for i, err := range node.TxPool.AddRemotes(txs) {
+		if err != nil {
+			utils.Logger().Debug().
+				Err(err).
+				Str("hash", txs[i].Hash().Hex()).
+				Msg("Transaction rejected by the pool")
+		}
+	}
+	pending, queued := node.TxPool.Stats()
+	utils.Logger().Info().
+		Int("length of newTxs", len(newTxs)).
+		Int("totalPending", pending).
+		Int("totalQueued", queued).
+		Msg("Got more transactions")
 }
 
-// AddPendingTransaction adds one new transaction to the pending transaction list.
-// This is only called from SDK.
-func (node *Node) AddPendingTransaction(newTx *types.Transaction) {
-	if node.Consensus.IsLeader() && newTx.ShardID() == node.NodeConfig.ShardID {
-		node.addPendingTransactions(types.Transactions{newTx})
-	} else {
-		utils.Logger().Info().Str("Hash", newTx.Hash().Hex()).Msg("Broadcasting Tx")
-		node.tryBroadcast(newTx)
+// addLocalTransactions adds transactions created by the node itself to the
+// transaction pool, which exempts them from the pool's price limit and
+// eviction.
+func (node *Node) addLocalTransactions(newTxs types.Transactions) {
+	for i, err := range node.TxPool.AddLocals(newTxs) {
+		if err != nil {
+			utils.Logger().Warn().
+				Err(err).
+				Str("hash", newTxs[i].Hash().Hex()).
+				Msg("Local transaction rejected by the pool")
+		}
 	}
-	utils.Logger().Debug().Int("totalPending", len(node.pendingTransactions)).Msg("Got ONE more transaction")
+}
+
+// AddPendingTransaction adds one new transaction to the transaction pool if it
+// belongs to the shard of the node, and broadcasts it to its shard unless the
+// node is the leader of that shard.  It returns the error of the pool, if
+// any.  This is only called from SDK.
+func (node *Node) AddPendingTransaction(newTx *types.Transaction) error {
+	if newTx.ShardID() == node.NodeConfig.ShardID {
+		if err := node.TxPool.AddLocal(newTx); err != nil {
+			return ctxerror.New("cannot add transaction to the pool",
+				"hash", newTx.Hash().Hex(),
+			).WithCause(err)
+		}
+		if node.Consensus.IsLeader() {
+			return nil
+		}
+	}
+	utils.Logger().Info().Str("Hash", newTx.Hash().Hex()).Msg("Broadcasting Tx")
+	node.tryBroadcast(newTx)
+	return nil
 }
 
 // AddPendingReceipts adds one receipt message to pending list.
@@ -298,39 +326,78 @@ func (node *Node) AddPendingReceipts(receipts *types.CXReceiptsProof) {
 	}
 }
 
-// Take out a subset of valid transactions from the pending transaction list
-// Note the pending transaction list will then contain the rest of the txs
+// Take out a subset of valid transactions from the executable transactions of
+// the transaction pool.  The invalid transactions are removed from the pool;
+// the selected ones are removed when the block is added to the chain, and the
+// rest stay in the pool.
 func (node *Node) getTransactionsForNewBlock(coinbase common.Address) types.Transactions {
-	node.pendingTxMutex.Lock()
+	pendingByAccount, err := node.TxPool.Pending()
+	if err != nil {
+		utils.Logger().Error().Err(err).Msg("Failed to fetch pending transactions")
+		return types.Transactions{}
+	}
+	pending := types.Transactions{}
+	for _, txs := range pendingByAccount {
+		pending = append(pending, txs...)
+	}
 
 	txsThrottleConfig := core.ShardingSchedule.TxsThrottleConfig()
 
-	// the next block number to be added in consensus protocol, which is always one more than current chain header block
-	newBlockNum := node.Blockchain().CurrentBlock().NumberU64() + 1
+	// the next block number to be added in consensus protocol, which is one more than the block the worker builds on
+	parent := node.Worker.ParentBlock()
+	newBlockNum := parent.NumberU64() + 1
 
-	// remove old (> txsThrottleConfigRecentTxDuration) blockNum keys from recentTxsStats and initiailize for the new block
-	for blockNum := range node.recentTxsStats {
-		recentTxsBlockNumGap := uint64(txsThrottleConfig.RecentTxDuration / node.BlockPeriod)
-		if recentTxsBlockNumGap < newBlockNum-blockNum {
-			delete(node.recentTxsStats, blockNum)
+	// the transactions of the recent (<= txsThrottleConfig.RecentTxDuration) blocks, which every node counts the same way
+	recentTxsStats, err := node.Blockchain().RecentTxsStats(txsThrottleConfig.RecentTxDuration)
+	if err != nil {
+		utils.Logger().Error().Err(err).Msg("Failed to read recent transactions stats")
+		return types.Transactions{}
+	}
+	// a pipelined parent is not in the chain yet, but its transactions are recent too
+	if parent.Hash() != node.Blockchain().CurrentBlock().Hash() {
+		parentCounts, err := core.CountBlockTxs(node.Blockchain().Config(), parent)
+		if err != nil {
+			utils.Logger().Error().Err(err).Msg("Failed to count transactions of the pipelined parent")
+			return types.Transactions{}
 		}
+		recentTxsStats[parent.NumberU64()] = parentCounts
 	}
-	node.recentTxsStats[newBlockNum] = make(types.BlockTxsCounts)
+	recentTxsStats[newBlockNum] = make(types.BlockTxsCounts)
 
-	selected, unselected, invalid := node.Worker.SelectTransactionsForNewBlock(newBlockNum, node.pendingTransactions, node.recentTxsStats, txsThrottleConfig, coinbase)
+	selected, unselected, invalid := node.Worker.SelectTransactionsForNewBlock(newBlockNum, pending, recentTxsStats, txsThrottleConfig, coinbase)
 
-	node.pendingTransactions = unselected
-	node.reducePendingTransactions()
+	node.TxPool.RemoveTxs(invalid)
 	utils.Logger().Info().
-		Int("remainPending", len(node.pendingTransactions)).
+		Int("remainPending", len(unselected)).
 		Int("selected", len(selected)).
 		Int("invalidDiscarded", len(invalid)).
 		Msg("Selecting Transactions")
-	node.pendingTxMutex.Unlock()
 
 	return selected
 }
 
+// TxThrottleStatus returns the throttling result of the transaction when it
+// was last considered for a new block by this node, or else the result it
+// would get in the next block if it is in the transaction pool.  It returns
+// nil if the transaction is neither throttled nor pending.
+func (node *Node) TxThrottleStatus(hash common.Hash) *shardingconfig.TxThrottleStatus {
+	if status, ok := node.Worker.ThrottledTx(hash); ok {
+		return status
+	}
+	tx := node.TxPool.Get(hash)
+	if tx == nil {
+		return nil
+	}
+	txsThrottleConfig := core.ShardingSchedule.TxsThrottleConfig()
+	recentTxsStats, err := node.Blockchain().RecentTxsStats(txsThrottleConfig.RecentTxDuration)
+	if err != nil {
+		utils.Logger().Error().Err(err).Msg("Failed to read recent transactions stats")
+		return nil
+	}
+	newBlockNum := node.Blockchain().CurrentBlock().NumberU64() + 1
+	return node.Worker.ThrottleTx(newBlockNum, recentTxsStats, txsThrottleConfig, tx)
+}
+
 // StartServer starts a server and process the requests by a handler.
 func (node *Node) StartServer() {
 	select {}
@@ -368,17 +435,7 @@ func New(host p2p.Host, consensusObj *consensus.Consensus, chainDBFactory shardc
 		node.SelfPeer = host.GetSelfPeer()
 	}
 
-	chainConfig := *params.TestnetChainConfig
-	if node.NodeConfig.GetNetworkType() == nodeconfig.Mainnet {
-		chainConfig = *params.MainnetChainConfig
-	}
-
-	collection := shardchain.NewCollection(
-		chainDBFactory, &genesisInitializer{&node}, chain.Engine, &chainConfig)
-	if isArchival {
-		collection.DisableCache()
-	}
-	node.shardChains = collection
+	node.shardChains = newChainCollection(&node, chainDBFactory, isArchival)
 
 	if host != nil && consensusObj != nil {
 		// Consensus and associated channel to communicate blocks
@@ -389,10 +446,13 @@ func New(host p2p.Host, consensusObj *consensus.Consensus, chainDBFactory shardc
 		beaconChain := node.Beaconchain()
 
 		node.BlockChannel = make(chan *types.Block)
-		node.ConfirmedBlockChannel = make(chan *types.Block)
+		node.ConfirmedBlockChannel = make(chan *types.Block, confirmedBlockChanSize)
 		node.BeaconBlockChannel = make(chan *types.Block)
-		node.recentTxsStats = make(types.RecentTxsStats)
-		node.TxPool = core.NewTxPool(core.DefaultTxPoolConfig, node.Blockchain().Config(), blockchain)
+		txPoolConfig := core.DefaultTxPoolConfig
+		txPoolConfig.GlobalSlots = uint64(core.ShardingSchedule.MaxTxPoolSizeLimit())
+		node.TxPool = core.NewTxPool(txPoolConfig, node.Blockchain().Config(), blockchain)
+		// transactions are commonly free of charge, so do not require any gas price
+		node.TxPool.SetGasPrice(big.NewInt(0))
 		node.CxPool = core.NewCxPool(core.CxPoolSize)
 		node.Worker = worker.New(node.Blockchain().Config(), blockchain, chain.Engine, node.Consensus.ShardID)
 		if node.Blockchain().ShardID() != 0 {
@@ -403,6 +463,13 @@ func New(host p2p.Host, consensusObj *consensus.Consensus, chainDBFactory shardc
 		// the sequence number is the next block number to be added in consensus protocol, which is always one more than current chain header block
 		node.Consensus.SetBlockNum(blockchain.CurrentBlock().NumberU64() + 1)
 
+		// databases written before the supply records lack them
+		go func() {
+			if err := blockchain.BackfillShardSupply(); err != nil {
+				utils.Logger().Warn().Err(err).Msg("[Node] cannot backfill the shard supply")
+			}
+		}()
+
 		// Add Faucet contract to all shards, so that on testnet, we can demo wallet in explorer
 		// TODO (leo): we need to have support of cross-shard tx later so that the token can be transferred from beacon chain shard to other tx shards.
 		if node.NodeConfig.GetNetworkType() != nodeconfig.Mainnet {
@@ -413,17 +480,6 @@ func New(host p2p.Host, consensusObj *consensus.Consensus, chainDBFactory shardc
 				node.AddContractKeyAndAddress(scFaucet)
 			}
 
-			//if node.Consensus.ShardID == 0 {
-			//	// Contracts only exist in beacon chain
-			//	if node.isFirstTime {
-			//		// Setup one time smart contracts
-			//		node.CurrentStakes = make(map[common.Address]*structs.StakeInfo)
-			//		node.AddStakingContractToPendingTransactions() //This will save the latest information about staked nodes in current staked
-			//	} else {
-			//		node.AddContractKeyAndAddress(scStaking)
-			//	}
-			//}
-
 			node.ContractCaller = contracts.NewContractCaller(node.Blockchain(), node.Blockchain().Config())
 
 			// Create test keys.  Genesis will later need this.
@@ -460,6 +516,34 @@ func New(host p2p.Host, consensusObj *consensus.Consensus, chainDBFactory shardc
 	return &node
 }
 
+// newChainCollection returns the shard chains of the given node, which sets
+// up the genesis block of new chain databases.
+func newChainCollection(
+	node *Node, chainDBFactory shardchain.DBFactory, isArchival bool,
+) *shardchain.CollectionImpl {
+	chainConfig := *params.TestnetChainConfig
+	if node.NodeConfig.GetNetworkType() == nodeconfig.Mainnet {
+		chainConfig = *params.MainnetChainConfig
+	}
+
+	collection := shardchain.NewCollection(
+		chainDBFactory, &genesisInitializer{node}, chain.Engine, &chainConfig)
+	if isArchival {
+		collection.DisableCache()
+	}
+	return collection
+}
+
+// NewChainCollection returns the shard chains a node of the given
+// configuration would open, without starting the node, for offline tools
+// working on the chain databases.
+func NewChainCollection(
+	config *nodeconfig.ConfigType, chainDBFactory shardchain.DBFactory,
+	isArchival bool,
+) shardchain.Collection {
+	return newChainCollection(&Node{NodeConfig: config}, chainDBFactory, isArchival)
+}
+
 // GetInitShardState initialize shard state from latest epoch and update committee pub keys for consensus and drand
 func (node *Node) GetInitShardState() (err error) {
 	if node.Consensus == nil {
@@ -483,6 +567,9 @@ func (node *Node) GetInitShardState() (err error) {
 			"shardID", shardID,
 			"blockNum", blockNum)
 	}
+	if node.DRand != nil {
+		node.DRand.UpdatePublicKeys(pubKeys)
+	}
 
 	for _, key := range pubKeys {
 		if key.IsEqual(node.Consensus.PubKey) {
@@ -495,8 +582,6 @@ func (node *Node) GetInitShardState() (err error) {
 			return nil
 		}
 	}
-	// TODO: Disable drand. Currently drand isn't functioning but we want to compeletely turn it off for full protection.
-	// node.DRand.UpdatePublicKeys(pubKeys)
 	return nil
 }
 
@@ -565,6 +650,15 @@ func (node *Node) initNodeConfiguration() (service.NodeConfig, chan p2p.Peer) {
 	return nodeConfig, chanPeer
 }
 
+// ConsensusTrace returns the traces of the latest n consensus rounds, or of
+// all the rounds kept if n is not positive.
+func (node *Node) ConsensusTrace(n int) []*consensus.RoundTrace {
+	if node.Consensus == nil {
+		return nil
+	}
+	return node.Consensus.Tracer.Rounds(n)
+}
+
 // AccountManager ...
 func (node *Node) AccountManager() *accounts.Manager {
 	return node.accountManager
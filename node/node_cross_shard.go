@@ -69,7 +69,13 @@ func (node *Node) BroadcastCXReceiptsWithShardID(block *types.Block, commitSig [
 	utils.Logger().Info().Uint32("ToShardID", toShardID).Msg("[BroadcastCXReceiptsWithShardID] ReadCXReceipts and MerkleProof Found")
 
 	groupID := p2p.ShardID(toShardID)
-	go node.host.SendMessageToGroups([]p2p.GroupID{p2p.NewGroupIDByShardID(groupID)}, host.ConstructP2pMessage(byte(0), proto_node.ConstructCXReceiptsProof(cxReceipts, merkleProof, block.Header(), commitSig, commitBitmap)))
+	if err := node.host.SendMessageToGroups([]p2p.GroupID{p2p.NewGroupIDByShardID(groupID)}, host.ConstructP2pMessage(byte(0), proto_node.ConstructCXReceiptsProof(cxReceipts, merkleProof, block.Header(), commitSig, commitBitmap))); err != nil {
+		utils.Logger().Warn().Err(err).Uint32("ToShardID", toShardID).Msg("[BroadcastCXReceiptsWithShardID] Cannot send CXReceiptsProof")
+		return
+	}
+	if err := node.Blockchain().WriteCXReceiptsProofSent(toShardID, block.NumberU64(), block.Hash()); err != nil {
+		utils.Logger().Warn().Err(err).Uint32("ToShardID", toShardID).Msg("[BroadcastCXReceiptsWithShardID] Cannot record CXReceiptsProof as sent")
+	}
 }
 
 // BroadcastMissingCXReceipts broadcasts missing cross shard receipts per request
@@ -7,6 +7,7 @@ import (
 	"github.com/ethereum/go-ethereum/rlp"
 	protobuf "github.com/golang/protobuf/proto"
 	msg_pb "github.com/harmony-one/harmony/api/proto/message"
+	"github.com/harmony-one/harmony/api/service"
 	"github.com/harmony-one/harmony/api/service/explorer"
 	"github.com/harmony-one/harmony/consensus"
 	"github.com/harmony-one/harmony/core"
@@ -43,10 +44,10 @@ func (node *Node) ExplorerMessageHandler(payload []byte) {
 		}
 
 		// check has 2f+1 signatures
-		if count := utils.CountOneBits(mask.Bitmap); count < node.Consensus.Quorum() {
+		if !node.Consensus.IsQuorumAchieved(mask) {
 			utils.Logger().Error().
 				Int("need", node.Consensus.Quorum()).
-				Int("have", count).
+				Int("have", utils.CountOneBits(mask.Bitmap)).
 				Msg("[Explorer] not have enough signature")
 			return
 		}
@@ -117,9 +118,8 @@ func (node *Node) AddNewBlockForExplorer() {
 				}
 				// Clean up the blocks to avoid OOM.
 				node.Consensus.PbftLog.DeleteBlockByNumber(blocks[0].NumberU64())
-				// Do dump all blocks from state syncing for explorer one time
-				// TODO: some blocks can be dumped before state syncing finished.
-				// And they would be dumped again here. Please fix it.
+				// Do dump all blocks from state syncing for explorer one time.
+				// Blocks already dumped are skipped.
 				once.Do(func() {
 					utils.Logger().Info().Int64("starting height", int64(blocks[0].NumberU64())-1).
 						Msg("[Explorer] Populating explorer data from state synced blocks")
@@ -145,6 +145,7 @@ func (node *Node) commitBlockForExplorer(block *types.Block) {
 	// Dump new block into level db.
 	utils.Logger().Info().Uint64("blockNum", block.NumberU64()).Msg("[Explorer] Committing block into explorer DB")
 	explorer.GetStorageInstance(node.SelfPeer.IP, node.SelfPeer.Port, true).Dump(block, block.NumberU64())
+	node.notifyExplorer(map[string]interface{}{explorer.NotifyBlock: block})
 
 	curNum := block.NumberU64()
 	if curNum-100 > 0 {
@@ -157,6 +158,7 @@ func (node *Node) commitBlockForExplorer(block *types.Block) {
 func (node *Node) CommitCommittee() {
 	events := make(chan core.ChainEvent)
 	node.Blockchain().SubscribeChainEvent(events)
+	lastEpoch := int64(-1)
 	for event := range events {
 		curBlock := event.Block
 		if curBlock == nil {
@@ -174,7 +176,23 @@ func (node *Node) CommitCommittee() {
 				if err != nil {
 					utils.Logger().Warn().Err(err).Msgf("[Explorer] Error dumping committee for block %d", curBlock.NumberU64())
 				}
+				if epoch := curBlock.Epoch().Int64(); epoch != lastEpoch {
+					node.notifyExplorer(map[string]interface{}{
+						explorer.NotifyCommittee: explorer.NewCommitteeChange(uint64(epoch), &committee),
+					})
+					lastEpoch = epoch
+				}
 			}
 		}
 	}
 }
+
+// notifyExplorer passes the given parameters to the explorer service, which
+// pushes the events in them to its subscribers.
+func (node *Node) notifyExplorer(params map[string]interface{}) {
+	node.serviceManager.TakeAction(&service.Action{
+		Action:      service.Notify,
+		ServiceType: service.SupportExplorer,
+		Params:      params,
+	})
+}
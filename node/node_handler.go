@@ -22,8 +22,10 @@ import (
 	"github.com/harmony-one/harmony/api/proto/message"
 	proto_node "github.com/harmony-one/harmony/api/proto/node"
 	"github.com/harmony-one/harmony/block"
+	"github.com/harmony-one/harmony/consensus"
 	"github.com/harmony-one/harmony/core"
 	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/drand"
 	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
 	"github.com/harmony-one/harmony/internal/ctxerror"
 	"github.com/harmony-one/harmony/internal/utils"
@@ -132,11 +134,7 @@ func (node *Node) messageHandler(content []byte, sender libp2p_peer.ID) {
 	case proto.DRand:
 		msgPayload, _ := proto.GetDRandMessagePayload(content)
 		if node.DRand != nil {
-			if node.DRand.IsLeader {
-				node.DRand.ProcessMessageLeader(msgPayload)
-			} else {
-				node.DRand.ProcessMessageValidator(msgPayload)
-			}
+			node.DRand.ProcessMessage(msgPayload)
 		}
 	case proto.Staking:
 		utils.Logger().Debug().Msg("NET: Received staking message")
@@ -200,6 +198,14 @@ func (node *Node) messageHandler(content []byte, sender libp2p_peer.ID) {
 			if err := node.epochShardStateMessageHandler(msgPayload); err != nil {
 				ctxerror.Log15(utils.GetLogger().Warn, err)
 			}
+		case proto_node.DoubleSignEvidence:
+			// only beacon chain keeps the double sign evidence of all shards
+			if node.NodeConfig.ShardID != 0 {
+				return
+			}
+			if err := node.doubleSignEvidenceMessageHandler(msgPayload); err != nil {
+				utils.Logger().Warn().Err(err).Msg("[DoubleSign] Invalid evidence message")
+			}
 		}
 	default:
 		utils.Logger().Error().
@@ -295,6 +301,84 @@ func (node *Node) BroadcastCrossLinkHeader(newBlock *types.Block) {
 	node.host.SendMessageToGroups([]p2p.GroupID{node.NodeConfig.GetBeaconGroupID()}, host.ConstructP2pMessage(byte(0), proto_node.ConstructCrossLinkHeadersMessage(headers)))
 }
 
+// BroadcastDoubleSignEvidence is called by consensus when a double signing
+// is detected, to report the evidence to the beacon chain.
+func (node *Node) BroadcastDoubleSignEvidence(evidence *consensus.DoubleSignEvidence) {
+	data, err := consensus.EncodeDoubleSignEvidences([]*consensus.DoubleSignEvidence{evidence})
+	if err != nil {
+		utils.Logger().Warn().Err(err).Msg("[BroadcastDoubleSignEvidence] Cannot encode evidence")
+		return
+	}
+	utils.Logger().Info().
+		Uint64("blockNum", evidence.BlockNum).
+		Uint64("viewID", evidence.ViewID).
+		Msgf("[BroadcastDoubleSignEvidence] Broadcasting evidence to beacon chain groupID %s", node.NodeConfig.GetBeaconGroupID())
+	msg := host.ConstructP2pMessage(byte(0), proto_node.ConstructDoubleSignEvidenceMessage(data))
+	if err := node.host.SendMessageToGroups([]p2p.GroupID{node.NodeConfig.GetBeaconGroupID()}, msg); err != nil {
+		utils.Logger().Warn().Err(err).Msg("[BroadcastDoubleSignEvidence] Cannot broadcast evidence")
+	}
+}
+
+// doubleSignEvidenceMessageHandler verifies the double sign evidence reported
+// by other nodes and adds it to the evidence pool.
+func (node *Node) doubleSignEvidenceMessageHandler(msgPayload []byte) error {
+	evidences, err := consensus.DecodeDoubleSignEvidences(msgPayload)
+	if err != nil {
+		return err
+	}
+	for _, evidence := range evidences {
+		if err := evidence.Verify(); err != nil {
+			return ctxerror.New("cannot verify double sign evidence",
+				"shardID", evidence.ShardID,
+				"blockNum", evidence.BlockNum).WithCause(err)
+		}
+		signer, err := evidence.Signer()
+		if err != nil {
+			return err
+		}
+		// The signed messages do not carry their epoch and a shard's epoch
+		// cannot be computed from its block number, so the evidence names
+		// it; a shard cannot be ahead of the beacon chain.
+		epoch := new(big.Int).SetUint64(evidence.Epoch)
+		if beaconEpoch := node.Beaconchain().CurrentHeader().Epoch(); epoch.Cmp(beaconEpoch) > 0 {
+			return ctxerror.New("double sign evidence from a future epoch",
+				"epoch", epoch,
+				"beaconEpoch", beaconEpoch,
+				"shardID", evidence.ShardID)
+		}
+		inCommittee := false
+		for _, key := range core.GetPublicKeys(epoch, evidence.ShardID) {
+			if key.IsEqual(signer) {
+				inCommittee = true
+				break
+			}
+		}
+		if !inCommittee {
+			return ctxerror.New("double signer is not in the committee",
+				"signer", signer.SerializeToHexStr(),
+				"epoch", epoch,
+				"shardID", evidence.ShardID)
+		}
+		if node.Consensus.EvidencePool.Add(evidence) {
+			utils.Logger().Warn().
+				Str("signer", signer.SerializeToHexStr()).
+				Uint32("shardID", evidence.ShardID).
+				Uint64("blockNum", evidence.BlockNum).
+				Uint64("viewID", evidence.ViewID).
+				Msg("[DoubleSign] Received double sign evidence")
+		}
+	}
+	return nil
+}
+
+// DoubleSignEvidence returns the double sign evidence known to the node.
+func (node *Node) DoubleSignEvidence() []*consensus.DoubleSignEvidence {
+	if node.Consensus == nil {
+		return nil
+	}
+	return node.Consensus.EvidencePool.Evidences()
+}
+
 // VerifyNewBlock is called by consensus participants to verify the block (account model) they are running consensus on
 func (node *Node) VerifyNewBlock(newBlock *types.Block) error {
 	// TODO ek – where do we verify parent-child invariants,
@@ -325,6 +409,9 @@ func (node *Node) VerifyNewBlock(newBlock *types.Block) error {
 			utils.Logger().Debug().Err(err).Msg("ops2 VerifyBlockCrossLinks Failed")
 			return err
 		}
+		if err := node.verifyBeaconRandomness(newBlock); err != nil {
+			return ctxerror.New("[VerifyNewBlock] Cannot verify beacon randomness", "blockHash", newBlock.Hash()).WithCause(err)
+		}
 	}
 
 	// TODO: move into ValidateNewBlock
@@ -338,13 +425,47 @@ func (node *Node) VerifyNewBlock(newBlock *types.Block) error {
 	// _ = newBlock.Header().Vrf
 
 	// TODO: uncomment 4 lines after we finish staking mechanism
-	//err = node.validateNewShardState(newBlock, &node.CurrentStakes)
+	//err = node.validateNewShardState(newBlock)
 	//	if err != nil {
 	//		return ctxerror.New("failed to verify sharding state").WithCause(err)
 	//	}
 	return nil
 }
 
+// verifyBeaconRandomness checks the output of the randomness beacon the new
+// beacon block commits, if any: it must be the first one of the epoch of the
+// block, over the first canonical block of the epoch, and generated by the
+// committee of the epoch.
+func (node *Node) verifyBeaconRandomness(newBlock *types.Block) error {
+	randomness, err := newBlock.BeaconRandomness()
+	if err != nil {
+		return ctxerror.New("cannot decode beacon randomness").WithCause(err)
+	}
+	if randomness == nil {
+		return nil
+	}
+	if randomness.Epoch == nil || randomness.Epoch.Cmp(newBlock.Epoch()) != 0 {
+		return ctxerror.New("beacon randomness of another epoch",
+			"blockEpoch", newBlock.Epoch(),
+			"randomnessEpoch", randomness.Epoch)
+	}
+	committed, err := node.Blockchain().ReadBeaconRandomness(randomness.Epoch)
+	if err != nil {
+		return err
+	}
+	if committed != nil {
+		return ctxerror.New("beacon randomness already committed",
+			"epoch", randomness.Epoch)
+	}
+	seedHeader := node.Blockchain().GetHeaderByNumber(core.EpochFirstBlock(randomness.Epoch).Uint64())
+	if seedHeader == nil || seedHeader.Hash() != randomness.Seed {
+		return ctxerror.New("beacon randomness seed is not the first block of the epoch",
+			"epoch", randomness.Epoch,
+			"seed", randomness.Seed)
+	}
+	return drand.VerifyRandomness(randomness, core.GetPublicKeys(randomness.Epoch, 0))
+}
+
 // BigMaxUint64 is maximum possible uint64 value, that is, (1**64)-1.
 var BigMaxUint64 = new(big.Int).SetBytes([]byte{
 	255, 255, 255, 255, 255, 255, 255, 255,
@@ -392,6 +513,19 @@ func (node *Node) PostConsensusProcessing(newBlock *types.Block, commitSigAndBit
 
 	node.BroadcastMissingCXReceipts()
 
+	if node.DRand != nil && core.IsEpochBlock(newBlock) {
+		// ConfirmedBlockChannel is listened by drand, which generates the
+		// randomness of the epoch starting with this block; do not wait for
+		// it if it is stuck on an earlier epoch block
+		select {
+		case node.ConfirmedBlockChannel <- newBlock:
+		default:
+			utils.Logger().Warn().
+				Uint64("blockNum", newBlock.NumberU64()).
+				Msg("[DRG] Randomness generation busy, skipped epoch block")
+		}
+	}
+
 	// TODO chao: uncomment this after beacon syncing is stable
 	// node.Blockchain().UpdateCXReceiptsCheckpointsByBlock(newBlock)
 
@@ -411,22 +545,6 @@ func (node *Node) PostConsensusProcessing(newBlock *types.Block, commitSigAndBit
 			}
 		}
 
-		// TODO: Enable the following after v0
-		if node.Consensus.ShardID == 0 {
-			// TODO: enable drand only for beacon chain
-			// ConfirmedBlockChannel which is listened by drand leader who will initiate DRG if its a epoch block (first block of a epoch)
-			//if node.DRand != nil {
-			//	go func() {
-			//		node.ConfirmedBlockChannel <- newBlock
-			//	}()
-			//}
-
-			// TODO: enable staking
-			// TODO: update staking information once per epoch.
-			//node.UpdateStakingList(node.QueryStakeInfo())
-			//node.printStakingList()
-		}
-
 		// TODO: enable shard state update
 		//newBlockHeader := newBlock.Header()
 		//if newBlockHeader.ShardStateHash != (common.Hash{}) {
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
 
 	"github.com/harmony-one/harmony/core"
 	"github.com/harmony-one/harmony/core/types"
@@ -33,8 +34,8 @@ func (node *Node) WaitForConsensusReadyV2(readySignal chan struct{}, stopChan ch
 		// TODO: make local net start faster
 		time.Sleep(30 * time.Second) // Wait for other nodes to be ready (test-only)
 
-		// Set up the very first deadline.
-		deadline := time.Now().Add(node.BlockPeriod)
+		// Set up the very first proposal time.
+		lastProposed := time.Now()
 		for {
 			// keep waiting for Consensus ready
 			select {
@@ -45,7 +46,7 @@ func (node *Node) WaitForConsensusReadyV2(readySignal chan struct{}, stopChan ch
 			case <-readySignal:
 				for {
 					time.Sleep(PeriodicBlock)
-					if time.Now().Before(deadline) {
+					if time.Now().Before(lastProposed.Add(node.blockPeriod())) {
 						continue
 					}
 
@@ -62,8 +63,8 @@ func (node *Node) WaitForConsensusReadyV2(readySignal chan struct{}, stopChan ch
 							Int("crossShardReceipts", newBlock.IncomingReceipts().Len()).
 							Msg("=========Successfully Proposed New Block==========")
 
-						// Set deadline will be BlockPeriod from now at this place. Announce stage happens right after this.
-						deadline = time.Now().Add(node.BlockPeriod)
+						// The next block period starts from now at this place. Announce stage happens right after this.
+						lastProposed = time.Now()
 						// Send the new block to Consensus so it can be confirmed.
 						node.BlockChannel <- newBlock
 						break
@@ -77,10 +78,26 @@ func (node *Node) WaitForConsensusReadyV2(readySignal chan struct{}, stopChan ch
 	}()
 }
 
+// blockPeriod returns how long the leader waits after proposing a block
+// before proposing the next one, which is shorter with more transactions
+// pending in the pool if consensus adapts its timing.
+func (node *Node) blockPeriod() time.Duration {
+	pending, _ := node.TxPool.Stats()
+	return node.Consensus.BlockPeriod(pending, node.BlockPeriod)
+}
+
 func (node *Node) proposeNewBlock() (*types.Block, error) {
 	// Update worker's current header and state data in preparation to propose/process new transactions
 	coinbase := node.Consensus.SelfAddress
 
+	// With pipelining, build on the last block while consensus finalizes it
+	pipelined := node.Consensus.PipelinedParent()
+	if pipelined != nil {
+		node.Worker.SetPendingParent(pipelined.Block)
+	} else {
+		node.Worker.SetPendingParent(nil)
+	}
+
 	// Prepare transactions
 	selectedTxs := node.getTransactionsForNewBlock(coinbase)
 
@@ -108,21 +125,98 @@ func (node *Node) proposeNewBlock() (*types.Block, error) {
 		if localErr == nil {
 			crossLinks = crossLinksToPropose
 		}
+		if pipelined != nil {
+			crossLinks = crossLinksNotIn(pipelined.Block, crossLinks)
+		}
 	}
 
 	// Prepare shard state
 	shardState := node.Worker.ProposeShardStateWithoutBeaconSync()
 
 	// Prepare last commit signatures
-	sig, mask, err := node.Consensus.LastCommitSig()
-	if err != nil {
-		ctxerror.Log15(utils.GetLogger().Error,
-			ctxerror.New("Cannot get commit signatures from last block").
-				WithCause(err))
-		return nil, err
+	var newBlock *types.Block
+	var parent *types.Block
+	if pipelined != nil {
+		block, err := node.Worker.FinalizeNewBlock(pipelined.CommitSig, pipelined.CommitBitmap, pipelined.ViewID, coinbase, crossLinks, shardState)
+		if err != nil {
+			return nil, err
+		}
+		newBlock, parent = block, pipelined.Block
+	} else {
+		sig, mask, err := node.Consensus.LastCommitSig()
+		if err != nil {
+			ctxerror.Log15(utils.GetLogger().Error,
+				ctxerror.New("Cannot get commit signatures from last block").
+					WithCause(err))
+			return nil, err
+		}
+		block, err := node.Worker.FinalizeNewBlock(sig, mask, node.Consensus.GetViewID(), coinbase, crossLinks, shardState)
+		if err != nil {
+			return nil, err
+		}
+		newBlock = block
 	}
 
-	return node.Worker.FinalizeNewBlock(sig, mask, node.Consensus.GetViewID(), coinbase, crossLinks, shardState)
+	// Prepare the randomness of the epoch
+	if node.NodeConfig.ShardID == 0 {
+		node.proposeBeaconRandomness(newBlock, parent)
+	}
+	return newBlock, nil
+}
+
+// proposeBeaconRandomness commits the output of the randomness beacon for
+// the epoch of the block in it, once this node generated it, unless the
+// chain or the pipelined parent, if any, already committed it.
+func (node *Node) proposeBeaconRandomness(block *types.Block, parent *types.Block) {
+	if node.DRand == nil {
+		return
+	}
+	randomness := node.DRand.Randomness(block.Epoch().Uint64())
+	if randomness == nil {
+		return
+	}
+	if parent != nil {
+		if committed, _ := parent.BeaconRandomness(); committed != nil && committed.Epoch.Cmp(randomness.Epoch) == 0 {
+			return
+		}
+	}
+	if committed, err := node.Blockchain().ReadBeaconRandomness(randomness.Epoch); err != nil || committed != nil {
+		return
+	}
+	if err := block.AddBeaconRandomness(randomness); err != nil {
+		utils.Logger().Warn().Err(err).Uint64("epoch", randomness.Epoch.Uint64()).Msg("[proposeBeaconRandomness] Cannot add beacon randomness")
+		return
+	}
+	utils.Logger().Info().
+		Uint64("blockNum", block.NumberU64()).
+		Uint64("epoch", randomness.Epoch.Uint64()).
+		Msg("[proposeBeaconRandomness] Proposed the epoch randomness")
+}
+
+// crossLinksNotIn drops the cross links already proposed in parent, which is
+// not in the chain yet.
+func crossLinksNotIn(parent *types.Block, crossLinks types.CrossLinks) types.CrossLinks {
+	parentCrossLinks := types.CrossLinks{}
+	if data := parent.Header().CrossLinks(); len(data) > 0 {
+		if err := rlp.DecodeBytes(data, &parentCrossLinks); err != nil {
+			utils.Logger().Warn().Err(err).Msg("[proposeNewBlock] Cannot decode cross links of the parent block")
+			return types.CrossLinks{}
+		}
+	}
+	proposed := make(map[uint32]uint64)
+	for _, link := range parentCrossLinks {
+		if num := link.BlockNum().Uint64(); num >= proposed[link.ShardID()] {
+			proposed[link.ShardID()] = num
+		}
+	}
+	result := types.CrossLinks{}
+	for _, link := range crossLinks {
+		if last, ok := proposed[link.ShardID()]; ok && link.BlockNum().Uint64() <= last {
+			continue
+		}
+		result = append(result, link)
+	}
+	return result
 }
 
 func (node *Node) proposeShardStateWithoutBeaconSync(block *types.Block) shard.State {
@@ -151,8 +245,7 @@ func (node *Node) proposeBeaconShardState(block *types.Block) error {
 		return nil
 	}
 	nextEpoch := new(big.Int).Add(block.Header().Epoch(), common.Big1)
-	shardState, err := core.CalculateNewShardState(
-		node.Blockchain(), nextEpoch, &node.CurrentStakes)
+	shardState, err := core.CalculateNewShardState(node.Blockchain(), nextEpoch)
 	if err != nil {
 		return err
 	}
@@ -192,6 +285,16 @@ func (node *Node) proposeLocalShardState(block *types.Block) {
 	}
 }
 
+// spentByBlock returns whether the block includes the receipts of cxp.
+func spentByBlock(block *types.Block, cxp *types.CXReceiptsProof) bool {
+	for _, included := range block.IncomingReceipts() {
+		if included.MerkleProof.BlockHash == cxp.MerkleProof.BlockHash {
+			return true
+		}
+	}
+	return false
+}
+
 func (node *Node) proposeReceiptsProof() []*types.CXReceiptsProof {
 	if !node.Blockchain().Config().IsCrossTx(node.Worker.GetNewEpoch()) {
 		return []*types.CXReceiptsProof{}
@@ -215,8 +318,8 @@ Loop:
 			pendingReceiptsList = append(pendingReceiptsList, cxp)
 			continue
 		}
-		// check double spent
-		if node.Blockchain().IsSpent(cxp) {
+		// check double spent, including by the block being built on
+		if node.Blockchain().IsSpent(cxp) || spentByBlock(node.Worker.ParentBlock(), cxp) {
 			utils.Logger().Debug().Interface("cxp", cxp).Msg("[proposeReceiptsProof] CXReceipt is spent")
 			continue
 		}
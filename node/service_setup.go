@@ -10,6 +10,7 @@ import (
 	"github.com/harmony-one/harmony/api/service/explorer"
 	"github.com/harmony-one/harmony/api/service/metrics"
 	"github.com/harmony-one/harmony/api/service/networkinfo"
+	"github.com/harmony-one/harmony/api/service/randomness"
 	"github.com/harmony-one/harmony/api/service/staking"
 	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
 	"github.com/harmony-one/harmony/internal/utils"
@@ -28,17 +29,16 @@ func (node *Node) setupForValidator() {
 	// Register new block service.
 	node.serviceManager.RegisterService(service.BlockProposal, blockproposal.New(node.Consensus.ReadySignal, node.WaitForConsensusReadyV2))
 	// Register client support service.
-	node.serviceManager.RegisterService(service.ClientSupport, clientsupport.New(node.Blockchain().State, node.CallFaucetContract, node.getDeployedStakingContract, node.SelfPeer.IP, node.SelfPeer.Port))
+	node.serviceManager.RegisterService(service.ClientSupport, clientsupport.New(node.Blockchain().State, node.CallFaucetContract, node.getStakingAddress, node.SelfPeer.IP, node.SelfPeer.Port))
 	// Register new metrics service
 	if node.NodeConfig.GetMetricsFlag() {
 		node.serviceManager.RegisterService(service.Metrics, metrics.New(&node.SelfPeer, node.NodeConfig.ConsensusPubKey.SerializeToHexStr(), node.NodeConfig.GetPushgatewayIP(), node.NodeConfig.GetPushgatewayPort()))
 	}
 
-	// Register randomness service
-	// TODO: Disable drand. Currently drand isn't functioning but we want to compeletely turn it off for full protection.
-	// Enable it back after mainnet.
-	// Need Dynamically enable for beacon validators
-	// node.serviceManager.RegisterService(service.Randomness, randomness.New(node.DRand))
+	// Register randomness service, only set up for beacon chain validators
+	if node.DRand != nil {
+		node.serviceManager.RegisterService(service.Randomness, randomness.New(node.DRand))
+	}
 
 }
 
@@ -71,7 +71,7 @@ func (node *Node) setupForExplorerNode() {
 	// Register networkinfo service.
 	node.serviceManager.RegisterService(service.NetworkInfo, networkinfo.New(node.host, node.NodeConfig.GetShardGroupID(), chanPeer, nil))
 	// Register explorer service.
-	node.serviceManager.RegisterService(service.SupportExplorer, explorer.New(&node.SelfPeer, node.NodeConfig.GetShardID(), node.Consensus.GetNodeIDs, node.GetBalanceOfAddress))
+	node.serviceManager.RegisterService(service.SupportExplorer, explorer.New(&node.SelfPeer, node.NodeConfig.GetShardID(), node.Blockchain().Genesis().Hash(), node.Consensus.GetNodeIDs, node.GetBalanceOfAddress))
 	// Register explorer service.
 }
 
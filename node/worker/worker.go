@@ -3,6 +3,7 @@ package worker
 import (
 	"fmt"
 	"math/big"
+	"sort"
 	"time"
 
 	blockfactory "github.com/harmony-one/harmony/block/factory"
@@ -17,6 +18,7 @@ import (
 	"github.com/harmony-one/harmony/block"
 	consensus_engine "github.com/harmony-one/harmony/consensus/engine"
 	"github.com/harmony-one/harmony/core"
+	"github.com/harmony-one/harmony/core/rawdb"
 	"github.com/harmony-one/harmony/core/state"
 	"github.com/harmony-one/harmony/core/types"
 	"github.com/harmony-one/harmony/core/vm"
@@ -46,6 +48,11 @@ type Worker struct {
 	chain   *core.BlockChain
 	current *environment // An environment for current running cycle.
 
+	// A block not yet in the chain which new blocks are built on, and the
+	// state after it
+	pending      *types.Block
+	pendingState *state.DB
+
 	engine consensus_engine.Engine
 
 	gasFloor uint64
@@ -55,9 +62,10 @@ type Worker struct {
 }
 
 // Returns a tuple where the first value is the txs sender account address,
-// the second is the throttling result enum for the transaction of interest.
-// Throttling happens based on the amount, frequency, etc.
-func (w *Worker) throttleTxs(selected types.Transactions, recentTxsStats types.RecentTxsStats, txsThrottleConfig *shardingconfig.TxsThrottleConfig, tx *types.Transaction) (common.Address, shardingconfig.TxThrottleFlag) {
+// the second is the throttling result enum for the transaction of interest,
+// and the third is the reason of the result unless the transaction is
+// selected.  Throttling happens based on the amount, frequency, etc.
+func (w *Worker) throttleTxs(recentTxsStats types.RecentTxsStats, txsThrottleConfig *shardingconfig.TxsThrottleConfig, tx *types.Transaction) (common.Address, shardingconfig.TxThrottleFlag, string) {
 	var sender common.Address
 	msg, err := tx.AsMessage(types.MakeSigner(w.config, w.chain.CurrentBlock().Epoch()))
 	if err != nil {
@@ -66,37 +74,53 @@ func (w *Worker) throttleTxs(selected types.Transactions, recentTxsStats types.R
 		sender = msg.From()
 	}
 
-	// already selected max num txs
-	if len(selected) > txsThrottleConfig.MaxNumTxsPerBlockLimit {
-		utils.Logger().Info().Str("txId", tx.Hash().Hex()).Int("MaxNumTxsPerBlockLimit", txsThrottleConfig.MaxNumTxsPerBlockLimit).Msg("Throttling tx with max num txs per block limit")
-		return sender, shardingconfig.TxUnselect
-	}
-
 	// do not throttle transactions if disabled
 	if !txsThrottleConfig.EnableTxnThrottling {
-		return sender, shardingconfig.TxSelect
+		return sender, shardingconfig.TxSelect, ""
 	}
 
-	// throttle a single sender sending too many transactions in one block
+	// throttle too large transaction
 	if tx.Value().Cmp(txsThrottleConfig.MaxTxAmountLimit) > 0 {
 		utils.Logger().Info().Str("txId", tx.Hash().Hex()).Uint64("MaxTxAmountLimit", txsThrottleConfig.MaxTxAmountLimit.Uint64()).Uint64("txAmount", tx.Value().Uint64()).Msg("Throttling tx with max amount limit")
-		return sender, shardingconfig.TxInvalid
+		return sender, shardingconfig.TxInvalid, fmt.Sprintf("amount %v exceeds the limit %v", tx.Value(), txsThrottleConfig.MaxTxAmountLimit)
 	}
 
-	// throttle too large transaction
-	var numTxsPastHour uint64
-	for _, blockTxsCounts := range recentTxsStats {
-		numTxsPastHour += blockTxsCounts[sender]
-	}
-	if numTxsPastHour >= txsThrottleConfig.MaxNumRecentTxsPerAccountLimit {
-		utils.Logger().Info().Str("txId", tx.Hash().Hex()).Uint64("MaxNumRecentTxsPerAccountLimit", txsThrottleConfig.MaxNumRecentTxsPerAccountLimit).Msg("Throttling tx with max txs per account in a single block limit")
-		return sender, shardingconfig.TxInvalid
+	// throttle a single sender sending too many transactions recently; the
+	// transaction may be selected once the earlier ones leave the window
+	numTxsRecent := recentTxsStats.AccountTxsCount(sender)
+	if numTxsRecent >= txsThrottleConfig.MaxNumRecentTxsPerAccountLimit {
+		utils.Logger().Info().Str("txId", tx.Hash().Hex()).Uint64("MaxNumRecentTxsPerAccountLimit", txsThrottleConfig.MaxNumRecentTxsPerAccountLimit).Msg("Throttling tx with max recent txs per account limit")
+		return sender, shardingconfig.TxUnselect, fmt.Sprintf("sender sent %d transactions in the last %v, limit %d", numTxsRecent, txsThrottleConfig.RecentTxDuration, txsThrottleConfig.MaxNumRecentTxsPerAccountLimit)
 	}
 
-	return sender, shardingconfig.TxSelect
+	return sender, shardingconfig.TxSelect, ""
+}
+
+// ThrottleTx returns the throttling result the transaction would get if it
+// were considered for the new block of the given number, given the recent
+// transactions stats.
+func (w *Worker) ThrottleTx(newBlockNum uint64, recentTxsStats types.RecentTxsStats, txsThrottleConfig *shardingconfig.TxsThrottleConfig, tx *types.Transaction) *shardingconfig.TxThrottleStatus {
+	_, flag, reason := w.throttleTxs(recentTxsStats, txsThrottleConfig, tx)
+	return &shardingconfig.TxThrottleStatus{Flag: flag, Reason: reason, BlockNum: newBlockNum}
 }
 
-// SelectTransactionsForNewBlock selects transactions for new block.
+// ThrottledTx returns the throttling result of the transaction if it was not
+// selected for a new block because of throttling, and has not been selected
+// since.  The results are kept in the chain database across restarts.
+func (w *Worker) ThrottledTx(hash common.Hash) (*shardingconfig.TxThrottleStatus, bool) {
+	status, err := rawdb.ReadTxThrottleStatus(w.chain.ChainDb(), hash)
+	if err != nil {
+		utils.Logger().Warn().Err(err).Str("txId", hash.Hex()).Msg("Cannot read transaction throttle status")
+		return nil, false
+	}
+	return status, status != nil
+}
+
+// SelectTransactionsForNewBlock selects transactions for new block.  It picks
+// the transaction with the highest gas price among the next transactions of
+// every sender, in nonce order per sender, until the block gas limit is
+// reached.  The transactions which are neither selected nor invalid are
+// returned as unselected in their original order.
 func (w *Worker) SelectTransactionsForNewBlock(newBlockNum uint64, txs types.Transactions, recentTxsStats types.RecentTxsStats, txsThrottleConfig *shardingconfig.TxsThrottleConfig, coinbase common.Address) (types.Transactions, types.Transactions, types.Transactions) {
 	// Must update to the correct current state before processing potential txns
 	if err := w.UpdateCurrent(coinbase); err != nil {
@@ -111,44 +135,97 @@ func (w *Worker) SelectTransactionsForNewBlock(newBlockNum uint64, txs types.Tra
 	}
 
 	selected := types.Transactions{}
-	unselected := types.Transactions{}
 	invalid := types.Transactions{}
+	// selected or invalid transactions, to return the others as unselected
+	done := make(map[common.Hash]struct{})
+
+	// group the transactions by sender, in nonce order
+	signer := types.MakeSigner(w.config, w.current.header.Epoch())
+	bySender := make(map[common.Address]types.Transactions)
 	for _, tx := range txs {
 		if tx.ShardID() != w.shardID {
 			invalid = append(invalid, tx)
+			done[tx.Hash()] = struct{}{}
 			continue
 		}
-
-		sender, flag := w.throttleTxs(selected, recentTxsStats, txsThrottleConfig, tx)
-		switch flag {
-		case shardingconfig.TxUnselect:
-			unselected = append(unselected, tx)
-
-		case shardingconfig.TxInvalid:
+		sender, err := types.Sender(signer, tx)
+		if err != nil {
+			utils.Logger().Info().Err(err).Str("txId", tx.Hash().Hex()).Msg("Cannot recover transaction sender")
 			invalid = append(invalid, tx)
+			done[tx.Hash()] = struct{}{}
+			continue
+		}
+		bySender[sender] = append(bySender[sender], tx)
+	}
+	for _, senderTxs := range bySender {
+		sort.Sort(types.TxByNonce(senderTxs))
+	}
 
-		case shardingconfig.TxSelect:
-			snap := w.current.state.Snapshot()
-			_, err := w.commitTransaction(tx, coinbase)
-			if err != nil {
-				w.current.state.RevertToSnapshot(snap)
+	byPrice := types.NewTransactionsByPriceAndNonce(signer, bySender)
+	for {
+		// stop when the block cannot fit even a plain transfer
+		if w.current.gasPool.Gas() < params.TxGas {
+			utils.Logger().Info().Uint64("gasLeft", w.current.gasPool.Gas()).Msg("Block gas limit reached")
+			break
+		}
+		tx := byPrice.Peek()
+		if tx == nil {
+			break
+		}
+
+		sender, flag, reason := w.throttleTxs(recentTxsStats, txsThrottleConfig, tx)
+		if flag != shardingconfig.TxSelect {
+			// the later transactions of the sender cannot be executed before it
+			utils.Logger().Info().Str("txId", tx.Hash().Hex()).Str("txThrottleFlag", flag.String()).Str("reason", reason).Msg("Transaction Throttle flag")
+			status := &shardingconfig.TxThrottleStatus{Flag: flag, Reason: reason, BlockNum: newBlockNum}
+			if err := rawdb.WriteTxThrottleStatus(w.chain.ChainDb(), tx.Hash(), status); err != nil {
+				utils.Logger().Warn().Err(err).Str("txId", tx.Hash().Hex()).Msg("Cannot write transaction throttle status")
+			}
+			if flag == shardingconfig.TxInvalid {
 				invalid = append(invalid, tx)
-				utils.Logger().Error().Err(err).Str("txId", tx.Hash().Hex()).Msg("Commit transaction error")
-			} else {
-				selected = append(selected, tx)
-				// handle the case when msg was not able to extracted from tx
-				if len(sender.String()) > 0 {
-					recentTxsStats[newBlockNum][sender]++
-				}
+				done[tx.Hash()] = struct{}{}
 			}
+			byPrice.Pop()
+			continue
 		}
 
-		// log invalid or unselected txs
-		if flag == shardingconfig.TxUnselect || flag == shardingconfig.TxInvalid {
-			utils.Logger().Info().Str("txId", tx.Hash().Hex()).Str("txThrottleFlag", flag.String()).Msg("Transaction Throttle flag")
+		snap := w.current.state.Snapshot()
+		_, err := w.commitTransaction(tx, coinbase)
+		switch err {
+		case nil:
+			selected = append(selected, tx)
+			done[tx.Hash()] = struct{}{}
+			if err := rawdb.DeleteTxThrottleStatus(w.chain.ChainDb(), tx.Hash()); err != nil {
+				utils.Logger().Warn().Err(err).Str("txId", tx.Hash().Hex()).Msg("Cannot delete transaction throttle status")
+			}
+			// handle the case when msg was not able to extracted from tx
+			if len(sender.String()) > 0 {
+				recentTxsStats[newBlockNum][sender]++
+			}
+			byPrice.Shift()
+
+		case core.ErrGasLimitReached, core.ErrNonceTooHigh:
+			// keep the transactions of the sender for a later block
+			w.current.state.RevertToSnapshot(snap)
+			utils.Logger().Info().Err(err).Str("txId", tx.Hash().Hex()).Msg("Transaction left for a later block")
+			byPrice.Pop()
+
+		default:
+			w.current.state.RevertToSnapshot(snap)
+			invalid = append(invalid, tx)
+			done[tx.Hash()] = struct{}{}
+			utils.Logger().Error().Err(err).Str("txId", tx.Hash().Hex()).Msg("Commit transaction error")
+			byPrice.Shift()
 		}
 
-		utils.Logger().Info().Str("txId", tx.Hash().Hex()).Uint64("txGasLimit", tx.Gas()).Msg("Transaction gas limit info")
+		utils.Logger().Info().Str("txId", tx.Hash().Hex()).Uint64("txGasLimit", tx.Gas()).Uint64("txGasPrice", tx.GasPrice().Uint64()).Msg("Transaction gas limit info")
+	}
+
+	unselected := types.Transactions{}
+	for _, tx := range txs {
+		if _, ok := done[tx.Hash()]; !ok {
+			unselected = append(unselected, tx)
+		}
 	}
 
 	utils.Logger().Info().Uint64("newBlockNum", newBlockNum).Uint64("blockGasLimit", w.current.header.GasLimit()).Uint64("blockGasUsed", w.current.header.GasUsed()).Msg("Block gas limit and usage info")
@@ -229,9 +306,31 @@ func (w *Worker) CommitReceipts(receiptsList []*types.CXReceiptsProof) error {
 	return nil
 }
 
+// SetPendingParent makes the worker build new blocks on the given block,
+// which is the child of the current block but not in the chain yet, e.g. a
+// block still being finalized by consensus.  A nil block builds them on the
+// current block again.
+func (w *Worker) SetPendingParent(pending *types.Block) {
+	if pending != nil && w.pending != nil && pending.Hash() == w.pending.Hash() {
+		return
+	}
+	w.pending = pending
+	w.pendingState = nil
+}
+
+// ParentBlock returns the block new blocks are built on: the pending parent
+// while it is the child of the current block, or else the current block.
+func (w *Worker) ParentBlock() *types.Block {
+	current := w.chain.CurrentBlock()
+	if w.pending != nil && w.pending.ParentHash() == current.Hash() {
+		return w.pending
+	}
+	return current
+}
+
 // UpdateCurrent updates the current environment with the current state and header.
 func (w *Worker) UpdateCurrent(coinbase common.Address) error {
-	parent := w.chain.CurrentBlock()
+	parent := w.ParentBlock()
 	num := parent.Number()
 	timestamp := time.Now().Unix()
 
@@ -249,7 +348,7 @@ func (w *Worker) UpdateCurrent(coinbase common.Address) error {
 
 // makeCurrent creates a new environment for the current cycle.
 func (w *Worker) makeCurrent(parent *types.Block, header *block.Header) error {
-	state, err := w.chain.StateAt(parent.Root())
+	state, err := w.stateAfter(parent)
 	if err != nil {
 		return err
 	}
@@ -262,6 +361,31 @@ func (w *Worker) makeCurrent(parent *types.Block, header *block.Header) error {
 	return nil
 }
 
+// stateAfter returns the state after the parent block.  The state after the
+// pending parent is not in the database, so it is computed by processing the
+// pending parent on the state of the current block.
+func (w *Worker) stateAfter(parent *types.Block) (*state.DB, error) {
+	if parent != w.pending {
+		return w.chain.StateAt(parent.Root())
+	}
+	if w.pendingState == nil {
+		statedb, err := w.chain.StateAt(w.chain.CurrentBlock().Root())
+		if err != nil {
+			return nil, err
+		}
+		if _, _, _, _, err := w.chain.Processor().Process(parent, statedb, *w.chain.GetVMConfig()); err != nil {
+			return nil, ctxerror.New("cannot process pending parent",
+				"blockNum", parent.NumberU64()).WithCause(err)
+		}
+		if root := statedb.IntermediateRoot(w.config.IsS3(parent.Epoch())); root != parent.Root() {
+			return nil, ctxerror.New("state root of pending parent does not match",
+				"blockNum", parent.NumberU64(), "want", parent.Root(), "got", root)
+		}
+		w.pendingState = statedb
+	}
+	return w.pendingState.Copy(), nil
+}
+
 // GetCurrentState gets the current state.
 func (w *Worker) GetCurrentState() *state.DB {
 	return w.current.state
@@ -269,7 +393,7 @@ func (w *Worker) GetCurrentState() *state.DB {
 
 // GetNewEpoch gets the current epoch.
 func (w *Worker) GetNewEpoch() *big.Int {
-	parent := w.chain.CurrentBlock()
+	parent := w.ParentBlock()
 	epoch := new(big.Int).Set(parent.Header().Epoch())
 
 	// TODO: Don't depend on sharding state for epoch change.
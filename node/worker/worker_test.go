@@ -1,9 +1,11 @@
 package worker
 
 import (
+	"crypto/ecdsa"
 	"math/big"
 	"math/rand"
 	"testing"
+	"time"
 
 	blockfactory "github.com/harmony-one/harmony/block/factory"
 	chain2 "github.com/harmony-one/harmony/internal/chain"
@@ -14,6 +16,7 @@ import (
 	"github.com/harmony-one/harmony/core"
 	"github.com/harmony-one/harmony/core/types"
 	"github.com/harmony-one/harmony/core/vm"
+	shardingconfig "github.com/harmony-one/harmony/internal/configs/sharding"
 	"github.com/harmony-one/harmony/internal/params"
 )
 
@@ -88,3 +91,128 @@ func TestCommitTransactions(t *testing.T) {
 		t.Error("Transaction is not committed")
 	}
 }
+
+func TestSelectTransactionsForNewBlock(t *testing.T) {
+	otherKey, _ := crypto.GenerateKey()
+	otherAddress := crypto.PubkeyToAddress(otherKey.PublicKey)
+	var (
+		database = ethdb.NewMemDatabase()
+		gspec    = core.Genesis{
+			Config:  chainConfig,
+			Factory: blockFactory,
+			Alloc: core.GenesisAlloc{
+				testBankAddress: {Balance: testBankFunds},
+				otherAddress:    {Balance: testBankFunds},
+			},
+			ShardID: 0,
+		}
+	)
+
+	gspec.MustCommit(database)
+	chain, _ := core.NewBlockChain(database, nil, gspec.Config, chain2.Engine, vm.Config{}, nil)
+
+	worker := New(params.TestChainConfig, chain, chain2.Engine, 0)
+
+	signer := types.HomesteadSigner{}
+	newTx := func(key *ecdsa.PrivateKey, nonce uint64, gasPrice int64) *types.Transaction {
+		tx, _ := types.SignTx(types.NewTransaction(nonce, testBankAddress, 0, big.NewInt(1), params.TxGas, big.NewInt(gasPrice), nil), signer, key)
+		return tx
+	}
+	// the bank pays more, but its second transaction must follow its first
+	bank0 := newTx(testBankKey, 0, 10)
+	bank1 := newTx(testBankKey, 1, 1)
+	other0 := newTx(otherKey, 0, 5)
+	other1 := newTx(otherKey, 1, 5)
+	tooHigh := newTx(otherKey, 3, 100)
+	txs := types.Transactions{bank1, other1, tooHigh, other0, bank0}
+
+	throttleConfig := &shardingconfig.TxsThrottleConfig{EnableTxnThrottling: false}
+	recentTxsStats := types.RecentTxsStats{1: types.BlockTxsCounts{}}
+	selected, unselected, invalid := worker.SelectTransactionsForNewBlock(1, txs, recentTxsStats, throttleConfig, testBankAddress)
+
+	want := types.Transactions{bank0, other0, other1, bank1}
+	if len(selected) != len(want) {
+		t.Fatalf("selected %d transactions, want %d", len(selected), len(want))
+	}
+	for i, tx := range want {
+		if selected[i].Hash() != tx.Hash() {
+			t.Errorf("selected transaction %d has nonce %d and price %v, want nonce %d and price %v",
+				i, selected[i].Nonce(), selected[i].GasPrice(), tx.Nonce(), tx.GasPrice())
+		}
+	}
+	if len(unselected) != 1 || unselected[0].Hash() != tooHigh.Hash() {
+		t.Errorf("wrong unselected transactions %v", unselected)
+	}
+	if len(invalid) != 0 {
+		t.Errorf("wrong invalid transactions %v", invalid)
+	}
+	if recentTxsStats[1][otherAddress] != 2 {
+		t.Errorf("recent transactions of the sender are %d, want 2", recentTxsStats[1][otherAddress])
+	}
+}
+
+func TestSelectTransactionsThrottling(t *testing.T) {
+	otherKey, _ := crypto.GenerateKey()
+	otherAddress := crypto.PubkeyToAddress(otherKey.PublicKey)
+	var (
+		database = ethdb.NewMemDatabase()
+		gspec    = core.Genesis{
+			Config:  chainConfig,
+			Factory: blockFactory,
+			Alloc: core.GenesisAlloc{
+				testBankAddress: {Balance: testBankFunds},
+				otherAddress:    {Balance: testBankFunds},
+			},
+			ShardID: 0,
+		}
+	)
+
+	gspec.MustCommit(database)
+	chain, _ := core.NewBlockChain(database, nil, gspec.Config, chain2.Engine, vm.Config{}, nil)
+
+	worker := New(params.TestChainConfig, chain, chain2.Engine, 0)
+
+	signer := types.HomesteadSigner{}
+	bankTx, _ := types.SignTx(types.NewTransaction(0, otherAddress, 0, big.NewInt(1), params.TxGas, big.NewInt(1), nil), signer, testBankKey)
+	tooLarge, _ := types.SignTx(types.NewTransaction(0, testBankAddress, 0, big.NewInt(1000), params.TxGas, big.NewInt(1), nil), signer, otherKey)
+	txs := types.Transactions{bankTx, tooLarge}
+
+	throttleConfig := &shardingconfig.TxsThrottleConfig{
+		EnableTxnThrottling:            true,
+		MaxTxAmountLimit:               big.NewInt(100),
+		RecentTxDuration:               time.Hour,
+		MaxNumRecentTxsPerAccountLimit: 2,
+	}
+	// the bank already sent its allowance of recent transactions
+	recentTxsStats := types.RecentTxsStats{
+		0: types.BlockTxsCounts{testBankAddress: 2},
+		1: types.BlockTxsCounts{},
+	}
+	selected, unselected, invalid := worker.SelectTransactionsForNewBlock(1, txs, recentTxsStats, throttleConfig, testBankAddress)
+
+	if len(selected) != 0 {
+		t.Errorf("wrong selected transactions %v", selected)
+	}
+	if len(unselected) != 1 || unselected[0].Hash() != bankTx.Hash() {
+		t.Errorf("wrong unselected transactions %v", unselected)
+	}
+	if len(invalid) != 1 || invalid[0].Hash() != tooLarge.Hash() {
+		t.Errorf("wrong invalid transactions %v", invalid)
+	}
+	if status, ok := worker.ThrottledTx(bankTx.Hash()); !ok || status.Flag != shardingconfig.TxUnselect || status.BlockNum != 1 || status.Reason == "" {
+		t.Errorf("wrong throttling result of the frequent sender: %+v", status)
+	}
+	if status, ok := worker.ThrottledTx(tooLarge.Hash()); !ok || status.Flag != shardingconfig.TxInvalid {
+		t.Errorf("wrong throttling result of the large transaction: %+v", status)
+	}
+
+	// the transaction is selected once the earlier ones leave the window
+	delete(recentTxsStats, 0)
+	selected, _, _ = worker.SelectTransactionsForNewBlock(1, types.Transactions{bankTx}, recentTxsStats, throttleConfig, testBankAddress)
+	if len(selected) != 1 {
+		t.Fatalf("selected %d transactions, want 1", len(selected))
+	}
+	if _, ok := worker.ThrottledTx(bankTx.Hash()); ok {
+		t.Error("throttling result kept for a selected transaction")
+	}
+}
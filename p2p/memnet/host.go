@@ -0,0 +1,182 @@
+package memnet
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	libp2p_host "github.com/libp2p/go-libp2p-host"
+	libp2p_peer "github.com/libp2p/go-libp2p-peer"
+
+	"github.com/harmony-one/harmony/p2p"
+)
+
+// Host is a p2p.Host attached to an in-memory Network.
+type Host struct {
+	network   *Network
+	self      p2p.Peer
+	mutex     sync.Mutex
+	closed    bool
+	receivers map[p2p.GroupID][]*GroupReceiver
+}
+
+// GetSelfPeer returns the peer this host was created for.
+func (host *Host) GetSelfPeer() p2p.Peer {
+	return host.self
+}
+
+// Close detaches the host from the network and closes its receivers.
+func (host *Host) Close() error {
+	host.mutex.Lock()
+	if host.closed {
+		host.mutex.Unlock()
+		return nil
+	}
+	host.closed = true
+	receivers := host.receivers
+	host.receivers = make(map[p2p.GroupID][]*GroupReceiver)
+	host.mutex.Unlock()
+
+	host.network.detach(host.self.PeerID)
+	for _, groupReceivers := range receivers {
+		for _, receiver := range groupReceivers {
+			receiver.Close()
+		}
+	}
+	return nil
+}
+
+// AddPeer is a no-op; every host on the network can reach every other host.
+func (host *Host) AddPeer(*p2p.Peer) error {
+	return nil
+}
+
+// GetID returns the peer ID assigned by the network.
+func (host *Host) GetID() libp2p_peer.ID {
+	return host.self.PeerID
+}
+
+// GetP2PHost returns nil; there is no libp2p host behind an in-memory host.
+func (host *Host) GetP2PHost() libp2p_host.Host {
+	return nil
+}
+
+// GetPeerCount returns the number of other hosts on the network.
+func (host *Host) GetPeerCount() int {
+	return host.network.peerCount() - 1
+}
+
+// ConnectHostPeer is a no-op; every host on the network can reach every
+// other host.
+func (host *Host) ConnectHostPeer(p2p.Peer) {
+}
+
+// SendMessageToGroups sends a message to one or more multicast groups,
+// subject to the network rules.
+func (host *Host) SendMessageToGroups(groups []p2p.GroupID, msg []byte) error {
+	host.mutex.Lock()
+	closed := host.closed
+	host.mutex.Unlock()
+	if closed {
+		return fmt.Errorf("host %s has been closed", host.self.PeerID)
+	}
+	host.network.send(host.self.PeerID, groups, msg)
+	return nil
+}
+
+// GroupReceiver returns a new receiver of messages sent to the group.
+func (host *Host) GroupReceiver(group p2p.GroupID) (p2p.GroupReceiver, error) {
+	host.mutex.Lock()
+	defer host.mutex.Unlock()
+	if host.closed {
+		return nil, fmt.Errorf("host %s has been closed", host.self.PeerID)
+	}
+	receiver := &GroupReceiver{notify: make(chan struct{}, 1)}
+	host.receivers[group] = append(host.receivers[group], receiver)
+	return receiver, nil
+}
+
+// groupReceivers returns the open receivers of the group.
+func (host *Host) groupReceivers(group p2p.GroupID) []*GroupReceiver {
+	host.mutex.Lock()
+	defer host.mutex.Unlock()
+	return append([]*GroupReceiver(nil), host.receivers[group]...)
+}
+
+// received is a message queued in a GroupReceiver.
+type received struct {
+	msg    []byte
+	sender libp2p_peer.ID
+}
+
+// GroupReceiver is an unbounded queue of messages received from a group.
+type GroupReceiver struct {
+	mutex  sync.Mutex
+	closed bool
+	queue  []received
+	notify chan struct{}
+}
+
+// Close closes the receiver; pending and future messages are discarded.
+func (r *GroupReceiver) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.closed = true
+	r.queue = nil
+	r.signal()
+	return nil
+}
+
+// Receive returns the next message, blocking until one arrives, the receiver
+// is closed or the context is done.
+func (r *GroupReceiver) Receive(ctx context.Context) (
+	msg []byte, sender libp2p_peer.ID, err error,
+) {
+	for {
+		r.mutex.Lock()
+		if r.closed {
+			r.mutex.Unlock()
+			return nil, libp2p_peer.ID(""), fmt.Errorf("GroupReceiver has been closed")
+		}
+		if len(r.queue) > 0 {
+			next := r.queue[0]
+			r.queue = r.queue[1:]
+			r.mutex.Unlock()
+			return next.msg, next.sender, nil
+		}
+		r.mutex.Unlock()
+
+		select {
+		case <-r.notify:
+		case <-ctx.Done():
+			return nil, libp2p_peer.ID(""), ctx.Err()
+		}
+	}
+}
+
+// deliver queues msg after the given delay.
+func (r *GroupReceiver) deliver(sender libp2p_peer.ID, msg []byte, delay time.Duration) {
+	push := func() {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		if r.closed {
+			return
+		}
+		r.queue = append(r.queue, received{msg: msg, sender: sender})
+		r.signal()
+	}
+	if delay <= 0 {
+		push()
+		return
+	}
+	time.AfterFunc(delay, push)
+}
+
+// signal wakes up a pending Receive.  Caller must hold r.mutex.
+func (r *GroupReceiver) signal() {
+	select {
+	case r.notify <- struct{}{}:
+	default:
+	}
+}
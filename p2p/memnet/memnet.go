@@ -0,0 +1,153 @@
+package memnet
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	libp2p_peer "github.com/libp2p/go-libp2p-peer"
+
+	"github.com/harmony-one/harmony/p2p"
+)
+
+// Envelope is a message in flight from one host to another.
+type Envelope struct {
+	From  libp2p_peer.ID
+	To    libp2p_peer.ID
+	Group p2p.GroupID
+	Msg   []byte
+}
+
+// Verdict is what a rule decides to do with an envelope.
+type Verdict struct {
+	// Drop discards the envelope.
+	Drop bool
+	// Delay holds the envelope back before delivering it.
+	Delay time.Duration
+}
+
+// Rule inspects an envelope and decides whether, and how late, it is
+// delivered.  The random source belongs to the network and is seeded, so that
+// a scenario can be replayed.
+type Rule func(env *Envelope, rnd *rand.Rand) Verdict
+
+// Network is an in-memory multicast network connecting Hosts in one process.
+// Every message sent to a group is delivered to every receiver of the group,
+// including the sender's own receivers, as libp2p pubsub does.
+// Hosts and rules are kept in the order they were added, so that the random
+// source is consumed in the same order and a seeded scenario replays exactly.
+type Network struct {
+	mutex  sync.Mutex
+	rnd    *rand.Rand
+	hosts  []*Host
+	rules  []installedRule
+	nextID int
+}
+
+// installedRule is a rule with the ID removing it.
+type installedRule struct {
+	id   int
+	rule Rule
+}
+
+// New creates an empty network.  The seed drives every random rule.
+func New(seed int64) *Network {
+	return &Network{
+		rnd: rand.New(rand.NewSource(seed)),
+	}
+}
+
+// NewHost attaches a new host to the network.  A peer ID is assigned to the
+// host and written back to self.
+func (n *Network) NewHost(self *p2p.Peer) *Host {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.nextID++
+	self.PeerID = libp2p_peer.ID(fmt.Sprintf("memnet-%d", n.nextID))
+	host := &Host{
+		network:   n,
+		self:      *self,
+		receivers: make(map[p2p.GroupID][]*GroupReceiver),
+	}
+	n.hosts = append(n.hosts, host)
+	return host
+}
+
+// AddRule installs a rule on the network and returns a function removing it.
+// All rules are consulted for every envelope: any rule may drop it, and the
+// delays of all rules add up.
+func (n *Network) AddRule(rule Rule) (remove func()) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.nextID++
+	id := n.nextID
+	n.rules = append(n.rules, installedRule{id: id, rule: rule})
+	return func() {
+		n.mutex.Lock()
+		defer n.mutex.Unlock()
+		for i, installed := range n.rules {
+			if installed.id == id {
+				n.rules = append(n.rules[:i:i], n.rules[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// ClearRules removes every rule, healing the network.
+func (n *Network) ClearRules() {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.rules = nil
+}
+
+// send fans msg out to every receiver of the given groups.
+func (n *Network) send(from libp2p_peer.ID, groups []p2p.GroupID, msg []byte) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	for _, group := range groups {
+		for _, host := range n.hosts {
+			env := &Envelope{From: from, To: host.self.PeerID, Group: group, Msg: msg}
+			verdict := n.judge(env)
+			if verdict.Drop {
+				continue
+			}
+			for _, receiver := range host.groupReceivers(group) {
+				receiver.deliver(from, msg, verdict.Delay)
+			}
+		}
+	}
+}
+
+// judge applies all rules to env.  Caller must hold n.mutex.
+func (n *Network) judge(env *Envelope) Verdict {
+	verdict := Verdict{}
+	for _, installed := range n.rules {
+		v := installed.rule(env, n.rnd)
+		if v.Drop {
+			return Verdict{Drop: true}
+		}
+		verdict.Delay += v.Delay
+	}
+	return verdict
+}
+
+// detach removes a closed host from the network.
+func (n *Network) detach(id libp2p_peer.ID) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	for i, host := range n.hosts {
+		if host.self.PeerID == id {
+			n.hosts = append(n.hosts[:i:i], n.hosts[i+1:]...)
+			return
+		}
+	}
+}
+
+// peerCount returns the number of hosts attached to the network.
+func (n *Network) peerCount() int {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return len(n.hosts)
+}
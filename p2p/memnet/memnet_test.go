@@ -0,0 +1,151 @@
+package memnet
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	libp2p_peer "github.com/libp2p/go-libp2p-peer"
+
+	"github.com/harmony-one/harmony/p2p"
+)
+
+var _ p2p.Host = (*Host)(nil)
+
+const testGroup = p2p.GroupID("test")
+
+func newTestHosts(t *testing.T, network *Network, n int) ([]*Host, []p2p.GroupReceiver) {
+	hosts := []*Host{}
+	receivers := []p2p.GroupReceiver{}
+	for i := 0; i < n; i++ {
+		host := network.NewHost(&p2p.Peer{IP: "127.0.0.1"})
+		receiver, err := host.GroupReceiver(testGroup)
+		if err != nil {
+			t.Fatalf("cannot create group receiver: %v", err)
+		}
+		hosts = append(hosts, host)
+		receivers = append(receivers, receiver)
+	}
+	return hosts, receivers
+}
+
+func receiveWithin(receiver p2p.GroupReceiver, d time.Duration) ([]byte, libp2p_peer.ID, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	return receiver.Receive(ctx)
+}
+
+func TestDelivery(t *testing.T) {
+	network := New(1)
+	hosts, receivers := newTestHosts(t, network, 3)
+	if hosts[0].GetPeerCount() != 2 {
+		t.Errorf("expected 2 peers, got %d", hosts[0].GetPeerCount())
+	}
+	if err := hosts[0].SendMessageToGroups([]p2p.GroupID{testGroup}, []byte("hello")); err != nil {
+		t.Fatalf("cannot send: %v", err)
+	}
+	for i, receiver := range receivers {
+		msg, sender, err := receiveWithin(receiver, time.Second)
+		if err != nil {
+			t.Fatalf("host %d did not receive: %v", i, err)
+		}
+		if string(msg) != "hello" || sender != hosts[0].GetID() {
+			t.Errorf("host %d received %q from %s", i, msg, sender)
+		}
+	}
+}
+
+func TestPartitionAndHeal(t *testing.T) {
+	network := New(1)
+	hosts, receivers := newTestHosts(t, network, 3)
+	heal := network.AddRule(Partition(
+		[]libp2p_peer.ID{hosts[0].GetID(), hosts[1].GetID()},
+		[]libp2p_peer.ID{hosts[2].GetID()},
+	))
+	hosts[0].SendMessageToGroups([]p2p.GroupID{testGroup}, []byte("before"))
+	if _, _, err := receiveWithin(receivers[1], time.Second); err != nil {
+		t.Errorf("host on the same side did not receive: %v", err)
+	}
+	if _, _, err := receiveWithin(receivers[2], 50*time.Millisecond); err == nil {
+		t.Error("host on the other side received across the partition")
+	}
+
+	heal()
+	hosts[0].SendMessageToGroups([]p2p.GroupID{testGroup}, []byte("after"))
+	msg, _, err := receiveWithin(receivers[2], time.Second)
+	if err != nil || string(msg) != "after" {
+		t.Errorf("host did not receive after healing: %q, %v", msg, err)
+	}
+}
+
+func TestLatency(t *testing.T) {
+	network := New(1)
+	hosts, receivers := newTestHosts(t, network, 2)
+	network.AddRule(Latency(100 * time.Millisecond))
+	start := time.Now()
+	hosts[0].SendMessageToGroups([]p2p.GroupID{testGroup}, []byte("late"))
+	if _, _, err := receiveWithin(receivers[1], time.Second); err != nil {
+		t.Fatalf("cannot receive: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("message arrived after %v, expected at least 100ms", elapsed)
+	}
+}
+
+func TestDropAll(t *testing.T) {
+	network := New(1)
+	hosts, receivers := newTestHosts(t, network, 2)
+	network.AddRule(Drop(1))
+	hosts[0].SendMessageToGroups([]p2p.GroupID{testGroup}, []byte("lost"))
+	if _, _, err := receiveWithin(receivers[0], time.Second); err != nil {
+		t.Errorf("sender did not receive its own message: %v", err)
+	}
+	if _, _, err := receiveWithin(receivers[1], 50*time.Millisecond); err == nil {
+		t.Error("message received despite a drop rate of 1")
+	}
+}
+
+func TestClose(t *testing.T) {
+	network := New(1)
+	hosts, receivers := newTestHosts(t, network, 2)
+	hosts[1].Close()
+	if _, _, err := receiveWithin(receivers[1], time.Second); err == nil {
+		t.Error("closed receiver returned a message")
+	}
+	if err := hosts[1].SendMessageToGroups([]p2p.GroupID{testGroup}, []byte("x")); err == nil {
+		t.Error("closed host sent a message")
+	}
+	if hosts[0].GetPeerCount() != 0 {
+		t.Errorf("closed host still counted as a peer")
+	}
+}
+
+func TestReplay(t *testing.T) {
+	// run records which envelopes a seeded random rule drops
+	run := func() []string {
+		network := New(7)
+		hosts, _ := newTestHosts(t, network, 5)
+		record := []string{}
+		network.AddRule(Reorder(time.Millisecond))
+		network.AddRule(func(env *Envelope, rnd *rand.Rand) Verdict {
+			drop := rnd.Intn(2) == 0
+			record = append(record, fmt.Sprintf("%s->%s:%v", env.From, env.To, drop))
+			return Verdict{Drop: drop}
+		})
+		for _, host := range hosts {
+			host.SendMessageToGroups([]p2p.GroupID{testGroup}, []byte("replay"))
+		}
+		return record
+	}
+	first, second := run(), run()
+	if len(first) != 25 {
+		t.Fatalf("expected 25 judged envelopes, got %d", len(first))
+	}
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("runs with the same seed differ at envelope %d: %s, %s", i, first[i], second[i])
+		}
+	}
+}
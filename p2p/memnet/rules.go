@@ -0,0 +1,80 @@
+package memnet
+
+import (
+	"math/rand"
+	"time"
+
+	libp2p_peer "github.com/libp2p/go-libp2p-peer"
+)
+
+// Latency delays every envelope by d.
+func Latency(d time.Duration) Rule {
+	return func(env *Envelope, rnd *rand.Rand) Verdict {
+		return Verdict{Delay: d}
+	}
+}
+
+// Reorder delays every envelope by a random duration in [0, window), so that
+// envelopes sent less than window apart may arrive out of order.
+func Reorder(window time.Duration) Rule {
+	return func(env *Envelope, rnd *rand.Rand) Verdict {
+		if window <= 0 {
+			return Verdict{}
+		}
+		return Verdict{Delay: time.Duration(rnd.Int63n(int64(window)))}
+	}
+}
+
+// Drop discards every envelope with the given probability.  Envelopes a host
+// sends to itself are never dropped.
+func Drop(probability float64) Rule {
+	return func(env *Envelope, rnd *rand.Rand) Verdict {
+		if env.From == env.To {
+			return Verdict{}
+		}
+		return Verdict{Drop: rnd.Float64() < probability}
+	}
+}
+
+// Partition splits the network into the given sides.  Envelopes between hosts
+// on different sides are discarded; hosts on no side reach everyone.
+func Partition(sides ...[]libp2p_peer.ID) Rule {
+	sideOf := make(map[libp2p_peer.ID]int)
+	for i, side := range sides {
+		for _, id := range side {
+			sideOf[id] = i
+		}
+	}
+	return func(env *Envelope, rnd *rand.Rand) Verdict {
+		from, ok := sideOf[env.From]
+		if !ok {
+			return Verdict{}
+		}
+		to, ok := sideOf[env.To]
+		if !ok {
+			return Verdict{}
+		}
+		return Verdict{Drop: from != to}
+	}
+}
+
+// Isolate cuts the given hosts off from every other host, in both directions.
+func Isolate(ids ...libp2p_peer.ID) Rule {
+	isolated := make(map[libp2p_peer.ID]bool)
+	for _, id := range ids {
+		isolated[id] = true
+	}
+	return func(env *Envelope, rnd *rand.Rand) Verdict {
+		if env.From == env.To {
+			return Verdict{}
+		}
+		return Verdict{Drop: isolated[env.From] || isolated[env.To]}
+	}
+}
+
+// Filter discards every envelope for which drop returns true.
+func Filter(drop func(env *Envelope) bool) Rule {
+	return func(env *Envelope, rnd *rand.Rand) Verdict {
+		return Verdict{Drop: drop(env)}
+	}
+}